@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+func TestParseDeviceFilter(t *testing.T) {
+	f, err := parseDeviceFilter("state=active, compliant=false,user=users/123")
+	if err != nil {
+		t.Fatalf("parseDeviceFilter() unexpected error: %v", err)
+	}
+	if f.state != types.DeviceStateActive {
+		t.Errorf("state = %q, want %q", f.state, types.DeviceStateActive)
+	}
+	if f.policyCompliant == nil || *f.policyCompliant {
+		t.Errorf("policyCompliant = %v, want false", f.policyCompliant)
+	}
+	if f.userName != "users/123" {
+		t.Errorf("userName = %q, want users/123", f.userName)
+	}
+
+	for _, expr := range []string{"state", "color=red", "compliant=maybe"} {
+		if _, err := parseDeviceFilter(expr); err == nil {
+			t.Errorf("parseDeviceFilter(%q) expected error, got nil", expr)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"24h", 24 * time.Hour},
+		{"7d", 7 * 24 * time.Hour},
+		{"90m", 90 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		got, err := parseDuration(tt.value)
+		if err != nil {
+			t.Errorf("parseDuration(%q) unexpected error: %v", tt.value, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("parseDuration(%q) = %v, want %v", tt.value, got, tt.expected)
+		}
+	}
+
+	if _, err := parseDuration("xd"); err == nil {
+		t.Error("parseDuration(\"xd\") expected error, got nil")
+	}
+}
+
+func TestSetConfigValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "amapi.yaml")
+
+	if err := setConfigValue(path, "project-id", "my-project"); err != nil {
+		t.Fatalf("setConfigValue(project-id) unexpected error: %v", err)
+	}
+	if err := setConfigValue(path, "retry-attempts", "5"); err != nil {
+		t.Fatalf("setConfigValue(retry-attempts) unexpected error: %v", err)
+	}
+	if err := setConfigValue(path, "timeout", "60s"); err != nil {
+		t.Fatalf("setConfigValue(timeout) unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read config file: %v", err)
+	}
+	for _, want := range []string{"project_id: my-project", "retry_attempts: 5", "timeout: 1m0s"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("config file missing %q:\n%s", want, data)
+		}
+	}
+
+	if err := setConfigValue(path, "no-such-key", "1"); err == nil {
+		t.Error("setConfigValue(no-such-key) expected error, got nil")
+	}
+	if err := setConfigValue(path, "retry-attempts", "many"); err == nil {
+		t.Error("setConfigValue(retry-attempts, many) expected error, got nil")
+	}
+}
+
+func TestMigrationTokenStats(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tokens := []*androidmanagement.MigrationToken{
+		{Name: "a", Device: "enterprises/LC1/devices/d1"},
+		{Name: "b", ExpireTime: "2024-12-31T00:00:00Z"},
+		{Name: "c", ExpireTime: "2025-01-02T00:00:00Z"},
+		{Name: "d"},
+	}
+
+	stats := migrationTokenStats(tokens, now)
+	expected := map[string]int{"total": 4, "used": 1, "expired": 1, "active": 2}
+	for k, v := range expected {
+		if stats[k] != v {
+			t.Errorf("stats[%q] = %d, want %d", k, stats[k], v)
+		}
+	}
+}
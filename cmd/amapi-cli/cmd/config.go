@@ -0,0 +1,343 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"amapi-pkg/cmd/amapi-cli/internal/output"
+	"amapi-pkg/pkgs/amapi/config"
+)
+
+// defaultConfigFile is the file written by "config init" and "config set" when --config is not given.
+const defaultConfigFile = "./amapi.yaml"
+
+// sensitiveMask replaces secrets in "config show" output.
+const sensitiveMask = "******"
+
+func newConfigCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "配置管理",
+	}
+
+	cmd.AddCommand(
+		newConfigShowCommand(a),
+		newConfigSetCommand(a),
+		newConfigValidateCommand(a),
+		newConfigInitCommand(a),
+		newConfigEnvironmentCommand(a),
+	)
+
+	return cmd
+}
+
+func newConfigShowCommand(a *app) *cobra.Command {
+	var showSensitive bool
+
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "显示当前配置",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := a.loadConfig()
+			if err != nil {
+				return err
+			}
+			return a.print(configView(cfg, showSensitive), nil)
+		},
+	}
+
+	cmd.Flags().BoolVar(&showSensitive, "show-sensitive", false, "显示凭证和密码等敏感信息")
+
+	return cmd
+}
+
+func newConfigSetCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "set KEY VALUE",
+		Short: "修改配置文件中的字段，例如 config set timeout 60s",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := a.configPath
+			if path == "" {
+				path = defaultConfigFile
+			}
+
+			if err := setConfigValue(path, args[0], args[1]); err != nil {
+				return err
+			}
+			fmt.Fprintf(a.out, "已更新 %s: %s = %s\n", path, args[0], args[1])
+			return nil
+		},
+	}
+}
+
+func newConfigValidateCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "验证配置有效性",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := a.loadConfig()
+			if err != nil {
+				return err
+			}
+			if err := cfg.Validate(); err != nil {
+				return err
+			}
+			return a.print(map[string]any{"valid": true, "project_id": cfg.ProjectID}, nil)
+		},
+	}
+}
+
+func newConfigInitCommand(a *app) *cobra.Command {
+	var projectID, credentialsFile string
+	var interactive bool
+
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "初始化配置文件",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := a.configPath
+			if path == "" {
+				path = defaultConfigFile
+			}
+
+			if interactive {
+				reader := bufio.NewReader(a.in)
+				projectID = prompt(a, reader, "Project ID", projectID)
+				credentialsFile = prompt(a, reader, "Credentials file", credentialsFile)
+			}
+
+			if err := requireFlag("project-id", projectID); err != nil {
+				return err
+			}
+			if err := requireFlag("credentials-file", credentialsFile); err != nil {
+				return err
+			}
+
+			cfg := config.DefaultConfig()
+			cfg.ProjectID = projectID
+			cfg.CredentialsFile = credentialsFile
+
+			if err := cfg.SaveToFile(path); err != nil {
+				return err
+			}
+			fmt.Fprintf(a.out, "配置已写入 %s\n", path)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&projectID, "project-id", "", "Google Cloud 项目 ID")
+	cmd.Flags().StringVar(&credentialsFile, "credentials-file", "", "服务账号密钥文件路径")
+	cmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "交互式输入")
+
+	return cmd
+}
+
+func newConfigEnvironmentCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:     "environment",
+		Aliases: []string{"env"},
+		Short:   "显示支持的环境变量",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			type envVar struct {
+				Name        string `json:"name"`
+				Description string `json:"description"`
+				Set         bool   `json:"set"`
+			}
+
+			vars := []envVar{
+				{Name: config.EnvProjectID, Description: "Google Cloud 项目 ID"},
+				{Name: config.EnvCredentialsFile, Description: "服务账号密钥文件路径"},
+				{Name: config.EnvCredentialsJSON, Description: "服务账号密钥 JSON 内容"},
+				{Name: config.EnvServiceAccountEmail, Description: "服务账号邮箱"},
+				{Name: config.EnvScopes, Description: "OAuth2 权限范围（逗号分隔）"},
+				{Name: config.EnvTimeout, Description: "API 请求超时时间"},
+				{Name: config.EnvRetryAttempts, Description: "重试次数"},
+				{Name: config.EnvRetryDelay, Description: "重试基础延迟"},
+				{Name: config.EnvEnableRetry, Description: "是否启用重试"},
+				{Name: config.EnvCallbackURL, Description: "企业注册回调 URL"},
+				{Name: config.EnvEnableCache, Description: "是否启用缓存"},
+				{Name: config.EnvCacheTTL, Description: "缓存有效期"},
+				{Name: config.EnvCacheSize, Description: "内存缓存最大条目数"},
+				{Name: config.EnvPolicyHistory, Description: "策略历史存储方式（memory/dir/redis）"},
+				{Name: config.EnvPolicyHistoryDir, Description: "策略历史目录"},
+				{Name: config.EnvPolicyHistoryLimit, Description: "每个策略保留的历史版本数"},
+				{Name: config.EnvLogLevel, Description: "日志级别"},
+				{Name: config.EnvEnableDebugLogging, Description: "是否启用调试日志"},
+				{Name: config.EnvRateLimit, Description: "每分钟最大请求数"},
+				{Name: config.EnvRateBurst, Description: "突发请求数"},
+				{Name: "AMAPI_OUTPUT", Description: "amapi-cli 默认输出格式"},
+				{Name: "AMAPI_CONFIG", Description: "amapi-cli 配置文件路径"},
+			}
+
+			t := &output.Table{Headers: []string{"NAME", "SET", "DESCRIPTION"}}
+			for i := range vars {
+				vars[i].Set = os.Getenv(vars[i].Name) != ""
+				t.AddRow(vars[i].Name, strconv.FormatBool(vars[i].Set), vars[i].Description)
+			}
+			return a.print(vars, t)
+		},
+	}
+}
+
+// configView returns a printable view of cfg with durations as strings and secrets masked.
+func configView(cfg *config.Config, showSensitive bool) map[string]any {
+	mask := func(value string) string {
+		if value == "" || showSensitive {
+			return value
+		}
+		return sensitiveMask
+	}
+
+	return map[string]any{
+		"project_id":            cfg.ProjectID,
+		"credentials_file":      cfg.CredentialsFile,
+		"credentials_json":      mask(cfg.CredentialsJSON),
+		"service_account_email": cfg.ServiceAccountEmail,
+		"scopes":                cfg.Scopes,
+		"timeout":               cfg.Timeout.String(),
+		"retry_attempts":        cfg.RetryAttempts,
+		"retry_delay":           cfg.RetryDelay.String(),
+		"enable_retry":          cfg.EnableRetry,
+		"callback_url":          cfg.CallbackURL,
+		"enable_cache":          cfg.EnableCache,
+		"cache_ttl":             cfg.CacheTTL.String(),
+		"cache_size":            cfg.CacheSize,
+		"policy_history":        cfg.PolicyHistory,
+		"policy_history_dir":    cfg.PolicyHistoryDir,
+		"policy_history_limit":  cfg.PolicyHistoryLimit,
+		"log_level":             cfg.LogLevel,
+		"enable_debug_logging":  cfg.EnableDebugLogging,
+		"rate_limit":            cfg.RateLimit,
+		"rate_burst":            cfg.RateBurst,
+		"redis_address":         cfg.RedisAddress,
+		"redis_password":        mask(cfg.RedisPassword),
+		"redis_db":              cfg.RedisDB,
+		"redis_key_prefix":      cfg.RedisKeyPrefix,
+		"use_redis_rate_limit":  cfg.UseRedisRateLimit,
+		"use_redis_retry":       cfg.UseRedisRetry,
+		"use_redis_cache":       cfg.UseRedisCache,
+	}
+}
+
+// setConfigValue updates a single field in a YAML or JSON config file, creating the file if needed.
+// Keys may use dashes or underscores, e.g. "retry-attempts" or "retry_attempts".
+func setConfigValue(path, key, value string) error {
+	key = strings.ReplaceAll(strings.ToLower(key), "-", "_")
+
+	field, ok := configFieldByTag(key)
+	if !ok {
+		return fmt.Errorf("unknown config key: %s", key)
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+		return fmt.Errorf("unsupported config file format: %s (supported: .yaml, .yml, .json)", ext)
+	}
+
+	values := map[string]any{}
+	if data, err := os.ReadFile(path); err == nil {
+		if ext == ".json" {
+			err = json.Unmarshal(data, &values)
+		} else {
+			err = yaml.Unmarshal(data, &values)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse config file: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	typed, err := convertConfigValue(field.Type, value, ext == ".json")
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	values[key] = typed
+
+	var data []byte
+	if ext == ".json" {
+		data, err = json.MarshalIndent(values, "", "  ")
+	} else {
+		data, err = yaml.Marshal(values)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode config file: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// configFieldByTag finds the config.Config field with the given yaml tag.
+func configFieldByTag(tag string) (reflect.StructField, bool) {
+	t := reflect.TypeOf(config.Config{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if name, _, _ := strings.Cut(field.Tag.Get("yaml"), ","); name == tag {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// convertConfigValue converts a command line string to the type stored in the config file.
+// Durations are stored as strings in YAML and as nanoseconds in JSON, matching config.LoadFromFile.
+func convertConfigValue(t reflect.Type, value string, jsonFile bool) (any, error) {
+	if t == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, err
+		}
+		if jsonFile {
+			return int64(d), nil
+		}
+		return d.String(), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return strconv.ParseBool(value)
+	case reflect.Int, reflect.Int64:
+		return strconv.Atoi(value)
+	case reflect.Slice:
+		parts := strings.Split(value, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		return parts, nil
+	default:
+		return value, nil
+	}
+}
+
+// prompt reads a single value from the user, keeping the current value on empty input.
+func prompt(a *app, reader *bufio.Reader, label, current string) string {
+	if current != "" {
+		fmt.Fprintf(a.out, "%s [%s]: ", label, current)
+	} else {
+		fmt.Fprintf(a.out, "%s: ", label)
+	}
+
+	line, _ := reader.ReadString('\n')
+	if line = strings.TrimSpace(line); line != "" {
+		return line
+	}
+	return current
+}
@@ -0,0 +1,1088 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/cmd/amapi-cli/internal/output"
+	"amapi-pkg/pkgs/amapi/client"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+func newDeviceCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "device",
+		Aliases: []string{"devices"},
+		Short:   "设备管理",
+	}
+
+	cmd.AddCommand(
+		newDeviceListCommand(a),
+		newDeviceGetCommand(a),
+		newDeviceDeleteCommand(a),
+		newDeviceSimpleCommand(a, "lock", "锁定设备", func(c deviceCommandContext) (*androidmanagement.Operation, error) {
+			return c.devices.Lock(c.name, c.duration)
+		}, func(cmd *cobra.Command, c *deviceCommandContext) {
+			cmd.Flags().StringVar(&c.duration, "duration", "", "锁定时长，例如 600s")
+		}),
+		newDeviceSimpleCommand(a, "reboot", "重启设备", func(c deviceCommandContext) (*androidmanagement.Operation, error) {
+			return c.devices.Reboot(c.name)
+		}),
+		newDeviceSimpleCommand(a, "reset", "恢复出厂设置（危险操作）", func(c deviceCommandContext) (*androidmanagement.Operation, error) {
+			if len(c.wipeFlags) == 0 && c.reason == "" {
+				return c.devices.Reset(c.name)
+			}
+			opts := types.WipeOptions{Reason: c.reason}
+			for _, flag := range c.wipeFlags {
+				opts.Flags = append(opts.Flags, types.WipeDataFlag(strings.ToUpper(flag)))
+			}
+			return c.devices.ResetWithOptions(c.name, opts)
+		}, func(cmd *cobra.Command, c *deviceCommandContext) {
+			cmd.Flags().StringSliceVar(&c.wipeFlags, "wipe-flag", nil, "擦除选项，可重复：PRESERVE_RESET_PROTECTION_DATA、WIPE_EXTERNAL_STORAGE、WIPE_ESIMS")
+			cmd.Flags().StringVar(&c.reason, "reason", "", "显示给用户的擦除原因")
+		}),
+		newDeviceSimpleCommand(a, "remove-password", "移除设备密码", func(c deviceCommandContext) (*androidmanagement.Operation, error) {
+			return c.devices.RemovePassword(c.name)
+		}),
+		newDeviceSimpleCommand(a, "reset-password", "重置设备密码", func(c deviceCommandContext) (*androidmanagement.Operation, error) {
+			var flags []types.ResetPasswordFlag
+			for _, flag := range c.resetPasswordFlags {
+				flags = append(flags, types.ResetPasswordFlag(strings.ToUpper(flag)))
+			}
+			return c.devices.ResetPassword(c.name, c.password, flags...)
+		}, func(cmd *cobra.Command, c *deviceCommandContext) {
+			cmd.Flags().StringVar(&c.password, "new-password", "", "新密码，留空则移除密码")
+			cmd.Flags().StringSliceVar(&c.resetPasswordFlags, "flag", nil, "重置选项，可重复：REQUIRE_ENTRY、DO_NOT_ASK_CREDENTIALS_ON_BOOT、LOCK_NOW")
+		}),
+		newDeviceSimpleCommand(a, "relinquish-ownership", "移除工作资料并将公司设备转为个人使用", func(c deviceCommandContext) (*androidmanagement.Operation, error) {
+			return c.devices.RelinquishOwnership(c.name)
+		}),
+		newDeviceSimpleCommand(a, "request-info", "请求设备信息（例如 EID）", func(c deviceCommandContext) (*androidmanagement.Operation, error) {
+			return c.devices.RequestDeviceInfo(c.name, types.DeviceInfoType(strings.ToUpper(c.info)))
+		}, func(cmd *cobra.Command, c *deviceCommandContext) {
+			cmd.Flags().StringVar(&c.info, "info", string(types.DeviceInfoEID), "请求的信息类型")
+		}),
+		newDeviceEsimCommand(a),
+		newDeviceBulkCommand(a),
+		newDeviceClearDataCommand(a),
+		newDeviceDisableCommand(a),
+		newDeviceEnableCommand(a),
+		newDeviceAssignPolicyCommand(a),
+		newDeviceLostModeCommand(a),
+		newDeviceFilterCommand(a),
+		newDeviceQueryCommand(a),
+		newDeviceStaleCommand(a),
+		newDeviceComplianceCommand(a),
+		newDeviceExportCommand(a),
+		newDeviceAppsCommand(a),
+		newDeviceOperationsCommand(a),
+	)
+
+	return cmd
+}
+
+// deviceCommandContext carries the arguments and flag values of a device command.
+type deviceCommandContext struct {
+	devices  *client.DeviceService
+	name     string
+	duration string
+
+	password           string
+	resetPasswordFlags []string
+	wipeFlags          []string
+	reason             string
+	info               string
+	lostMode           types.LostModeOptions
+	activationCode     string
+	activationState    string
+	iccID              string
+}
+
+// newDeviceSimpleCommand builds a command that issues a single device command after confirmation.
+// flags register the command-specific flags into the context passed to issue.
+func newDeviceSimpleCommand(a *app, use, short string, issue func(deviceCommandContext) (*androidmanagement.Operation, error), flags ...func(*cobra.Command, *deviceCommandContext)) *cobra.Command {
+	var force bool
+	var dc deviceCommandContext
+	var wait waitFlags
+
+	cmd := &cobra.Command{
+		Use:   use + " DEVICE",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !a.confirm(force, "确定要对设备 %s 执行 %s 吗？", args[0], use) {
+				fmt.Fprintln(a.out, "已取消")
+				return nil
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			dc.devices = c.Devices()
+			dc.name = args[0]
+			op, err := issue(dc)
+			if err != nil {
+				return err
+			}
+			return a.printOperation(cmd.Context(), c, op, wait)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "跳过确认")
+	wait.register(cmd)
+	for _, register := range flags {
+		register(cmd, &dc)
+	}
+
+	return cmd
+}
+
+// waitFlags holds the --wait and --wait-timeout flags of commands that issue device commands.
+type waitFlags struct {
+	wait    bool
+	timeout time.Duration
+}
+
+func (w *waitFlags) register(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&w.wait, "wait", false, "等待设备执行命令，失败或取消时返回非零退出码")
+	cmd.Flags().DurationVar(&w.timeout, "wait-timeout", client.DefaultWaitTimeout, "--wait 的最长等待时间")
+}
+
+// printOperation prints op, or waits for it to finish and prints the command result when --wait is set.
+func (a *app) printOperation(ctx context.Context, c *client.Client, op *androidmanagement.Operation, w waitFlags) error {
+	if !w.wait {
+		return a.print(op, nil)
+	}
+	return a.waitOperation(ctx, c, op.Name, w.timeout)
+}
+
+// waitOperation waits for an operation and prints its result.
+// A failed or cancelled command is returned as an error.
+func (a *app) waitOperation(ctx context.Context, c *client.Client, operationName string, timeout time.Duration) error {
+	result, err := c.Devices().WaitForOperation(ctx, operationName, &client.WaitOptions{Timeout: timeout})
+	if err != nil {
+		return err
+	}
+
+	t := &output.Table{Headers: []string{"NAME", "STATUS", "ERROR"}}
+	errMsg := ""
+	if result.Error != nil {
+		errMsg = result.Error.Error()
+	}
+	t.AddRow(result.Name, string(result.Status), errMsg)
+	if err := a.print(result, t); err != nil {
+		return err
+	}
+	return result.Err()
+}
+
+func newDeviceListCommand(a *app) *cobra.Command {
+	var enterprise, pageToken, filter string
+	var all bool
+	var pageSize int
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "列出企业的设备",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("enterprise", enterprise); err != nil {
+				return err
+			}
+
+			f, err := parseDeviceFilter(filter)
+			if err != nil {
+				return err
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			var result *types.ListResult[*androidmanagement.Device]
+			if all {
+				result, err = c.Devices().ListAll(enterpriseName(enterprise), f.state, f.policyCompliant, f.userName)
+			} else {
+				result, err = c.Devices().List(enterpriseName(enterprise), pageSize, pageToken, f.state, f.policyCompliant, f.userName)
+			}
+			if err != nil {
+				return err
+			}
+			return a.print(result, deviceTable(result.Items))
+		},
+	}
+
+	cmd.Flags().StringVarP(&enterprise, "enterprise", "e", "", "企业 ID 或资源名称")
+	cmd.Flags().IntVar(&pageSize, "page-size", 0, "每页数量")
+	cmd.Flags().StringVar(&pageToken, "page-token", "", "分页令牌")
+	cmd.Flags().BoolVar(&all, "all", false, "自动翻页获取全部结果（忽略 --page-size 和 --page-token）")
+	cmd.Flags().StringVar(&filter, "filter", "", "过滤条件，例如 state=ACTIVE,compliant=true,user=users/123")
+
+	return cmd
+}
+
+func newDeviceGetCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get DEVICE",
+		Short: "获取设备详情",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			device, err := c.Devices().Get(args[0])
+			if err != nil {
+				return err
+			}
+			return a.print(device, nil)
+		},
+	}
+}
+
+func newDeviceDeleteCommand(a *app) *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "delete DEVICE",
+		Short: "删除设备（擦除并移出管理）",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !a.confirm(force, "确定要删除设备 %s 吗？设备将被擦除", args[0]) {
+				fmt.Fprintln(a.out, "已取消")
+				return nil
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			if err := c.Devices().Delete(args[0]); err != nil {
+				return err
+			}
+			return a.print(map[string]string{"deleted": args[0]}, nil)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "跳过确认")
+
+	return cmd
+}
+
+func newDeviceClearDataCommand(a *app) *cobra.Command {
+	var force bool
+	var packageNames []string
+	var wait waitFlags
+
+	cmd := &cobra.Command{
+		Use:   "clear-data DEVICE",
+		Short: "清除应用数据",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(packageNames) == 0 {
+				return requireFlag("package", "")
+			}
+			if !a.confirm(force, "确定要清除设备 %s 上 %s 的数据吗？", args[0], strings.Join(packageNames, ", ")) {
+				fmt.Fprintln(a.out, "已取消")
+				return nil
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			op, err := c.Devices().ClearAppData(args[0], packageNames...)
+			if err != nil {
+				return err
+			}
+			return a.printOperation(cmd.Context(), c, op, wait)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&packageNames, "package", nil, "应用包名，可重复或用逗号分隔")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "跳过确认")
+	wait.register(cmd)
+
+	return cmd
+}
+
+func newDeviceDisableCommand(a *app) *cobra.Command {
+	var force bool
+	var reason string
+
+	cmd := &cobra.Command{
+		Use:   "disable DEVICE",
+		Short: "停用设备",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !a.confirm(force, "确定要停用设备 %s 吗？", args[0]) {
+				fmt.Fprintln(a.out, "已取消")
+				return nil
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			device, err := c.Devices().Disable(args[0], reason)
+			if err != nil {
+				return err
+			}
+			return a.print(device, nil)
+		},
+	}
+
+	cmd.Flags().StringVar(&reason, "reason", "", "显示在设备上的停用原因")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "跳过确认")
+
+	return cmd
+}
+
+func newDeviceEnableCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "enable DEVICE",
+		Short: "重新启用已停用的设备",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			device, err := c.Devices().Enable(args[0])
+			if err != nil {
+				return err
+			}
+			return a.print(device, nil)
+		},
+	}
+}
+
+func newDeviceAssignPolicyCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "assign-policy DEVICE POLICY",
+		Short: "为设备分配策略（策略 ID 或完整名称）",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			device, err := c.Devices().AssignPolicy(args[0], args[1])
+			if err != nil {
+				return err
+			}
+			return a.print(device, nil)
+		},
+	}
+}
+
+func newDeviceLostModeCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lost-mode",
+		Short: "管理丢失模式",
+	}
+
+	cmd.AddCommand(
+		newDeviceSimpleCommand(a, "start", "启用丢失模式", func(c deviceCommandContext) (*androidmanagement.Operation, error) {
+			if c.lostMode == (types.LostModeOptions{}) {
+				return c.devices.StartLostMode(c.name)
+			}
+			return c.devices.StartLostModeWithOptions(c.name, c.lostMode)
+		}, func(cmd *cobra.Command, c *deviceCommandContext) {
+			cmd.Flags().StringVar(&c.lostMode.Message, "message", "", "显示在设备上的消息")
+			cmd.Flags().StringVar(&c.lostMode.PhoneNumber, "phone", "", "联系电话")
+			cmd.Flags().StringVar(&c.lostMode.EmailAddress, "email", "", "联系邮箱")
+			cmd.Flags().StringVar(&c.lostMode.Address, "address", "", "归还地址")
+			cmd.Flags().StringVar(&c.lostMode.Organization, "organization", "", "组织名称")
+		}),
+		newDeviceSimpleCommand(a, "stop", "停用丢失模式", func(c deviceCommandContext) (*androidmanagement.Operation, error) {
+			return c.devices.StopLostMode(c.name)
+		}),
+	)
+
+	return cmd
+}
+
+func newDeviceEsimCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "esim",
+		Short: "管理设备的 eSIM",
+	}
+
+	cmd.AddCommand(
+		newDeviceSimpleCommand(a, "add", "下载并安装 eSIM", func(c deviceCommandContext) (*androidmanagement.Operation, error) {
+			return c.devices.AddEsim(c.name, c.activationCode, types.EsimActivationState(strings.ToUpper(c.activationState)))
+		}, func(cmd *cobra.Command, c *deviceCommandContext) {
+			cmd.Flags().StringVar(&c.activationCode, "activation-code", "", "eSIM 激活码")
+			cmd.Flags().StringVar(&c.activationState, "activation-state", "", "ACTIVATED 或 NOT_ACTIVATED，默认由设备决定")
+		}),
+		newDeviceSimpleCommand(a, "remove", "移除 eSIM", func(c deviceCommandContext) (*androidmanagement.Operation, error) {
+			return c.devices.RemoveEsim(c.name, c.iccID)
+		}, func(cmd *cobra.Command, c *deviceCommandContext) {
+			cmd.Flags().StringVar(&c.iccID, "iccid", "", "要移除的 eSIM 的 ICCID")
+		}),
+	)
+
+	return cmd
+}
+
+func newDeviceBulkCommand(a *app) *cobra.Command {
+	var enterprise, filter, queryExpr, duration string
+	var concurrency int
+	var force, dryRun bool
+	var wait waitFlags
+
+	cmd := &cobra.Command{
+		Use:   "bulk COMMAND [DEVICE...]",
+		Short: "对多台设备批量执行命令（lock、reboot、reset、remove-password、stop-lost-mode、relinquish-ownership）",
+		Long: `对多台设备批量执行命令。
+
+目标设备可以作为参数列出，也可以通过 --enterprise 和 --filter、--query 从设备列表中选择。
+所有请求共享客户端的速率限制；任一设备失败时返回非零退出码。`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			command := &types.DeviceCommand{
+				Type:     strings.ToUpper(strings.ReplaceAll(args[0], "-", "_")),
+				Duration: duration,
+			}
+			if err := command.Validate(); err != nil {
+				return err
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			deviceNames := args[1:]
+			if enterprise != "" {
+				f, err := parseDeviceFilter(filter)
+				if err != nil {
+					return err
+				}
+				devices, err := c.Devices().BulkIssueCommandByFilter(cmd.Context(), enterpriseName(enterprise), client.DeviceListFilter{
+					State:           f.state,
+					PolicyCompliant: f.policyCompliant,
+					UserName:        f.userName,
+					Query:           queryExpr,
+				}, command, &client.BulkOptions{DryRun: true})
+				if err != nil {
+					return err
+				}
+				for _, result := range devices.Results {
+					deviceNames = append(deviceNames, result.DeviceName)
+				}
+			}
+			if len(deviceNames) == 0 {
+				return fmt.Errorf("no target devices: pass device names or --enterprise")
+			}
+
+			if !dryRun && !a.confirm(force, "确定要对 %d 台设备执行 %s 吗？", len(deviceNames), command.Type) {
+				fmt.Fprintln(a.out, "已取消")
+				return nil
+			}
+
+			report, err := c.Devices().BulkIssueCommand(cmd.Context(), deviceNames, command, &client.BulkOptions{
+				Concurrency: concurrency,
+				DryRun:      dryRun,
+				Wait:        wait.wait,
+				WaitOptions: &client.WaitOptions{Timeout: wait.timeout},
+				OnProgress: func(p client.BulkProgress) {
+					status := "ok"
+					if p.Result.Error != nil {
+						status = p.Result.Error.Error()
+					}
+					fmt.Fprintf(a.errOut, "[%d/%d] %s: %s\n", p.Completed, p.Total, p.Result.DeviceName, status)
+				},
+			})
+			if err != nil {
+				return err
+			}
+
+			t := &output.Table{Headers: []string{"DEVICE", "OPERATION", "STATUS", "ERROR"}}
+			for _, result := range report.Results {
+				operation, status, errMsg := "", "", ""
+				if result.Operation != nil {
+					operation = result.Operation.Name
+				}
+				if result.Result != nil {
+					status = string(result.Result.Status)
+				}
+				if result.Error != nil {
+					errMsg = result.Error.Error()
+				}
+				t.AddRow(result.DeviceName, operation, status, errMsg)
+			}
+			if err := a.print(report, t); err != nil {
+				return err
+			}
+
+			if report.Failed > 0 {
+				return fmt.Errorf("%d of %d devices failed", report.Failed, len(report.Results))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&enterprise, "enterprise", "e", "", "从该企业的设备列表中选择目标设备")
+	cmd.Flags().StringVar(&filter, "filter", "", "与 --enterprise 一起使用的过滤条件，例如 state=ACTIVE,compliant=false")
+	cmd.Flags().StringVar(&queryExpr, "query", "", "与 --enterprise 一起使用的查询表达式，语法同 device query")
+	cmd.Flags().StringVar(&duration, "duration", "", "命令有效期，例如 600s（lock 为锁定时长）")
+	cmd.Flags().IntVar(&concurrency, "concurrency", client.DefaultBulkConcurrency, "并发处理的设备数量")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "只校验并列出目标设备，不执行命令")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "跳过确认")
+	wait.register(cmd)
+
+	return cmd
+}
+
+func newDeviceQueryCommand(a *app) *cobra.Command {
+	var enterprise string
+
+	cmd := &cobra.Command{
+		Use:   "query EXPR",
+		Short: "按查询表达式筛选设备（遍历所有分页）",
+		Long: `按查询表达式筛选设备，字段路径是设备资源的 JSON 字段名，例如：
+
+  amapi-cli device query -e LC00abc "appliedPolicyName = 'kiosk' AND hardwareInfo.brand = 'samsung' AND lastStatusReportTime < -7d"
+
+支持 AND、OR、NOT、括号，比较运算 = != < <= > >= ~ !~ 和 IN (...)，
+以及字符串、数字、true、false、null 和相对时间（-7d、-12h、-30m）。`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("enterprise", enterprise); err != nil {
+				return err
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			result, err := c.Devices().WithContext(cmd.Context()).Query(enterpriseName(enterprise), args[0])
+			if err != nil {
+				return err
+			}
+			return a.print(result, deviceTable(result.Items))
+		},
+	}
+
+	cmd.Flags().StringVarP(&enterprise, "enterprise", "e", "", "企业 ID 或资源名称")
+
+	return cmd
+}
+
+func newDeviceStaleCommand(a *app) *cobra.Command {
+	var enterprise, threshold, nudge string
+	var concurrency int
+	var force, dryRun, all bool
+
+	cmd := &cobra.Command{
+		Use:   "stale",
+		Short: "查找长时间未上报或从未同步策略的设备",
+		Long: `按最后一次联系时间（状态上报、策略同步、合规报告中最近的一次）将设备分为
+HEALTHY、STALE 和 NEVER_SYNCED，默认只列出不健康的设备。
+
+--nudge reboot 会重启不健康的活跃设备。`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("enterprise", enterprise); err != nil {
+				return err
+			}
+			d, err := parseDuration(threshold)
+			if err != nil {
+				return err
+			}
+
+			opts := &client.StaleOptions{
+				Nudge: client.StaleNudge(strings.ToUpper(strings.ReplaceAll(nudge, "-", "_"))),
+				Bulk:  &client.BulkOptions{Concurrency: concurrency, DryRun: dryRun},
+			}
+			switch opts.Nudge {
+			case client.StaleNudgeNone, client.StaleNudgeReboot:
+			default:
+				return fmt.Errorf("unknown --nudge %q (supported: reboot)", nudge)
+			}
+			if opts.Nudge != client.StaleNudgeNone && !dryRun && !a.confirm(force, "确定要对不健康的活跃设备执行 %s 吗？", opts.Nudge) {
+				fmt.Fprintln(a.out, "已取消")
+				return nil
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			report, err := c.Devices().FindStaleWithOptions(cmd.Context(), enterpriseName(enterprise), d, opts)
+			if err != nil {
+				return err
+			}
+
+			nudged := make(map[string]*client.BulkResult)
+			if report.Nudge != nil {
+				for _, result := range report.Nudge.Results {
+					nudged[result.DeviceName] = result
+				}
+			}
+
+			t := &output.Table{Headers: []string{"NAME", "STATE", "HEALTH", "LAST SEEN", "SINCE", "NUDGE"}}
+			for _, device := range report.Devices {
+				if !all && device.Health == types.DeviceHealthHealthy {
+					continue
+				}
+				lastSeen, since := "", ""
+				if !device.LastSeen.IsZero() {
+					lastSeen = device.LastSeen.Format(time.RFC3339)
+					since = device.Since.Round(time.Minute).String()
+				}
+				nudgeStatus := ""
+				if result := nudged[device.Device.Name]; result != nil {
+					switch {
+					case result.Error != nil:
+						nudgeStatus = result.Error.Error()
+					case report.Nudge.DryRun:
+						nudgeStatus = "dry-run"
+					default:
+						nudgeStatus = "ok"
+					}
+				}
+				t.AddRow(device.Device.Name, device.Device.State, string(device.Health), lastSeen, since, nudgeStatus)
+			}
+			if err := a.print(report, t); err != nil {
+				return err
+			}
+			fmt.Fprintf(a.errOut, "healthy: %d, stale: %d, never synced: %d\n", report.Healthy, report.Stale, report.NeverSynced)
+
+			if report.Nudge != nil && report.Nudge.Failed > 0 {
+				return fmt.Errorf("%d of %d nudges failed", report.Nudge.Failed, len(report.Nudge.Results))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&enterprise, "enterprise", "e", "", "企业 ID 或资源名称")
+	cmd.Flags().StringVar(&threshold, "threshold", "7d", "超过该时长未联系即为 STALE，例如 7d、36h")
+	cmd.Flags().StringVar(&nudge, "nudge", "", "对不健康的活跃设备执行的操作：reboot")
+	cmd.Flags().IntVar(&concurrency, "concurrency", client.DefaultBulkConcurrency, "并发处理的设备数量")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "只列出会被唤醒的设备，不执行操作")
+	cmd.Flags().BoolVar(&all, "all", false, "同时列出 HEALTHY 设备")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "跳过确认")
+
+	return cmd
+}
+
+func newDeviceComplianceCommand(a *app) *cobra.Command {
+	var enterprise, setting, format string
+
+	cmd := &cobra.Command{
+		Use:   "compliance",
+		Short: "按设置和原因汇总设备不合规详情",
+		Long: `汇总企业所有设备的不合规详情（设置名称、原因、应用包名、具体原因）及受影响的设备数量。
+
+--setting 列出某个设置下每台不合规的设备；--format csv 每行输出一个问题和一台设备，便于导入表格软件。`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("enterprise", enterprise); err != nil {
+				return err
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			report, err := c.Devices().WithContext(cmd.Context()).ComplianceReport(enterpriseName(enterprise))
+			if err != nil {
+				return err
+			}
+
+			if setting != "" {
+				issues := report.Setting(setting)
+				t := &output.Table{Headers: []string{"DEVICE", "REASON", "PACKAGE", "SPECIFIC REASON"}}
+				for _, issue := range issues {
+					for _, device := range issue.Devices {
+						t.AddRow(device, issue.NonComplianceReason, issue.PackageName, issue.SpecificNonComplianceReason)
+					}
+				}
+				return a.print(issues, t)
+			}
+
+			if format != "" {
+				return report.Write(a.out, types.ReportFormat(strings.ToLower(format)))
+			}
+
+			t := &output.Table{Headers: []string{"SETTING", "REASON", "PACKAGE", "SPECIFIC REASON", "DEVICES"}}
+			for _, issue := range report.Issues {
+				t.AddRow(issue.SettingName, issue.NonComplianceReason, issue.PackageName, issue.SpecificNonComplianceReason, strconv.Itoa(issue.DeviceCount))
+			}
+			if err := a.print(report, t); err != nil {
+				return err
+			}
+			fmt.Fprintf(a.errOut, "devices: %d, compliant: %d, non-compliant: %d\n", report.TotalDevices, report.CompliantDevices, report.NonCompliantDevices)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&enterprise, "enterprise", "e", "", "企业 ID 或资源名称")
+	cmd.Flags().StringVar(&setting, "setting", "", "只列出该设置（例如 applications）下不合规的设备")
+	cmd.Flags().StringVar(&format, "format", "", "报告格式：json、csv 或 table（默认使用 --output）")
+
+	return cmd
+}
+
+func newDeviceExportCommand(a *app) *cobra.Command {
+	var enterprise, format, save string
+	var fields []string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "将企业的所有设备导出为扁平的 CSV 或 JSONL",
+		Long: `逐页读取企业的所有设备，将嵌套字段展开为列后写出（不受 --output 影响）。
+
+--field 是设备资源的 JSON 字段路径，例如 hardwareInfo.serialNumber、networkInfo.imei；
+未指定时导出状态、所有权、硬件、软件、网络和内存等常用字段。`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("enterprise", enterprise); err != nil {
+				return err
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			w := a.out
+			if save != "" {
+				f, err := os.Create(save)
+				if err != nil {
+					return fmt.Errorf("failed to create export file: %w", err)
+				}
+				defer f.Close()
+				w = f
+			}
+
+			n, err := c.Devices().Export(cmd.Context(), enterpriseName(enterprise), w, types.ReportFormat(strings.ToLower(format)), fields)
+			if err != nil {
+				return err
+			}
+			if save != "" {
+				fmt.Fprintf(a.errOut, "已导出 %d 台设备到 %s\n", n, save)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&enterprise, "enterprise", "e", "", "企业 ID 或资源名称")
+	cmd.Flags().StringVar(&format, "format", string(types.ReportFormatCSV), "导出格式：csv 或 jsonl")
+	cmd.Flags().StringSliceVar(&fields, "field", nil, "导出的字段路径，可重复或用逗号分隔")
+	cmd.Flags().StringVar(&save, "save", "", "写入文件而不是标准输出")
+
+	return cmd
+}
+
+func newDeviceAppsCommand(a *app) *cobra.Command {
+	var enterprise, packageName, format string
+	var belowVersion int64
+	var unmanaged bool
+
+	cmd := &cobra.Command{
+		Use:   "apps",
+		Short: "汇总企业设备上安装的应用",
+		Long: `根据设备的应用报告（策略需启用 applicationReportsEnabled）汇总已安装的应用。
+
+  --package P --below-version N  列出 P 的版本号低于 N 的设备
+  --unmanaged                    列出不在设备策略中且不是系统应用的已安装应用`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("enterprise", enterprise); err != nil {
+				return err
+			}
+			if belowVersion > 0 && packageName == "" {
+				return fmt.Errorf("--below-version requires --package")
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			inventory, err := c.Devices().WithContext(cmd.Context()).AppInventory(enterpriseName(enterprise))
+			if err != nil {
+				return err
+			}
+
+			var installations []*client.AppInstallation
+			switch {
+			case belowVersion > 0:
+				installations = inventory.BelowVersion(packageName, belowVersion)
+			case unmanaged:
+				installations = inventory.Unmanaged()
+			case packageName != "":
+				if app := inventory.App(packageName); app != nil {
+					installations = app.Installations
+				}
+			default:
+				if format != "" {
+					return inventory.Write(a.out, types.ReportFormat(strings.ToLower(format)))
+				}
+				t := &output.Table{Headers: []string{"PACKAGE", "NAME", "DEVICES", "MIN VERSION", "MAX VERSION"}}
+				for _, app := range inventory.Apps {
+					t.AddRow(app.PackageName, app.DisplayName, strconv.Itoa(app.DeviceCount),
+						strconv.FormatInt(app.MinVersionCode, 10), strconv.FormatInt(app.MaxVersionCode, 10))
+				}
+				return a.print(inventory, t)
+			}
+
+			t := &output.Table{Headers: []string{"DEVICE", "PACKAGE", "VERSION CODE", "VERSION", "STATE", "SOURCE", "MANAGED"}}
+			for _, i := range installations {
+				t.AddRow(i.DeviceName, i.PackageName, strconv.FormatInt(i.VersionCode, 10), i.VersionName,
+					i.State, i.ApplicationSource, strconv.FormatBool(i.Managed))
+			}
+			return a.print(installations, t)
+		},
+	}
+
+	cmd.Flags().StringVarP(&enterprise, "enterprise", "e", "", "企业 ID 或资源名称")
+	cmd.Flags().StringVar(&packageName, "package", "", "只列出该应用的安装记录")
+	cmd.Flags().Int64Var(&belowVersion, "below-version", 0, "与 --package 一起使用，列出版本号低于该值的设备")
+	cmd.Flags().BoolVar(&unmanaged, "unmanaged", false, "列出非托管应用")
+	cmd.Flags().StringVar(&format, "format", "", "清单格式：json、csv 或 table（默认使用 --output）")
+
+	return cmd
+}
+
+func newDeviceFilterCommand(a *app) *cobra.Command {
+	var enterprise string
+
+	cmd := &cobra.Command{
+		Use:   "filter",
+		Short: "按常用条件筛选设备",
+	}
+	cmd.PersistentFlags().StringVarP(&enterprise, "enterprise", "e", "", "企业 ID")
+
+	run := func(list func(enterpriseID string, args []string) (*types.ListResult[*androidmanagement.Device], error)) func(*cobra.Command, []string) error {
+		return func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("enterprise", enterprise); err != nil {
+				return err
+			}
+			result, err := list(strings.TrimPrefix(enterprise, "enterprises/"), args)
+			if err != nil {
+				return err
+			}
+			return a.print(result, deviceTable(result.Items))
+		}
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "active",
+			Short: "活跃设备",
+			Args:  cobra.NoArgs,
+			RunE: run(func(enterpriseID string, args []string) (*types.ListResult[*androidmanagement.Device], error) {
+				c, err := a.getClient()
+				if err != nil {
+					return nil, err
+				}
+				return c.Devices().GetActiveDevices(enterpriseID)
+			}),
+		},
+		&cobra.Command{
+			Use:   "compliant",
+			Short: "合规设备",
+			Args:  cobra.NoArgs,
+			RunE: run(func(enterpriseID string, args []string) (*types.ListResult[*androidmanagement.Device], error) {
+				c, err := a.getClient()
+				if err != nil {
+					return nil, err
+				}
+				return c.Devices().GetCompliantDevices(enterpriseID)
+			}),
+		},
+		&cobra.Command{
+			Use:   "non-compliant",
+			Short: "非合规设备",
+			Args:  cobra.NoArgs,
+			RunE: run(func(enterpriseID string, args []string) (*types.ListResult[*androidmanagement.Device], error) {
+				c, err := a.getClient()
+				if err != nil {
+					return nil, err
+				}
+				return c.Devices().GetNonCompliantDevices(enterpriseID)
+			}),
+		},
+		&cobra.Command{
+			Use:   "by-user USER",
+			Short: "按用户筛选",
+			Args:  cobra.ExactArgs(1),
+			RunE: run(func(enterpriseID string, args []string) (*types.ListResult[*androidmanagement.Device], error) {
+				c, err := a.getClient()
+				if err != nil {
+					return nil, err
+				}
+				return c.Devices().GetDevicesByUser(enterpriseID, args[0])
+			}),
+		},
+	)
+
+	return cmd
+}
+
+func newDeviceOperationsCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "operations",
+		Aliases: []string{"operation", "ops"},
+		Short:   "查看和取消设备命令操作",
+	}
+
+	list := &cobra.Command{
+		Use:   "list DEVICE",
+		Short: "列出设备的操作",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			ops, err := c.Devices().GetOperations(args[0])
+			if err != nil {
+				return err
+			}
+
+			t := &output.Table{Headers: []string{"NAME", "DONE", "ERROR"}}
+			for _, op := range ops {
+				errMsg := ""
+				if op.Error != nil {
+					errMsg = op.Error.Message
+				}
+				t.AddRow(op.Name, strconv.FormatBool(op.Done), errMsg)
+			}
+			return a.print(ops, t)
+		},
+	}
+
+	get := &cobra.Command{
+		Use:   "get OPERATION",
+		Short: "获取操作详情",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			op, err := c.Devices().GetOperation(args[0])
+			if err != nil {
+				return err
+			}
+			return a.print(op, nil)
+		},
+	}
+
+	cancel := &cobra.Command{
+		Use:   "cancel OPERATION",
+		Short: "取消操作",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			if err := c.Devices().CancelOperation(args[0]); err != nil {
+				return err
+			}
+			return a.print(map[string]string{"cancelled": args[0]}, nil)
+		},
+	}
+
+	var waitTimeout time.Duration
+	wait := &cobra.Command{
+		Use:   "wait OPERATION",
+		Short: "等待操作完成，失败或取消时返回非零退出码",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+			return a.waitOperation(cmd.Context(), c, args[0], waitTimeout)
+		},
+	}
+	wait.Flags().DurationVar(&waitTimeout, "timeout", client.DefaultWaitTimeout, "最长等待时间")
+
+	cmd.AddCommand(list, get, wait, cancel)
+	return cmd
+}
+
+// deviceFilter holds the parsed --filter expression of "device list".
+type deviceFilter struct {
+	state           types.DeviceState
+	policyCompliant *bool
+	userName        string
+}
+
+// parseDeviceFilter parses a comma-separated key=value filter, e.g. "state=ACTIVE,compliant=true".
+func parseDeviceFilter(expr string) (deviceFilter, error) {
+	var f deviceFilter
+	if strings.TrimSpace(expr) == "" {
+		return f, nil
+	}
+
+	for _, part := range strings.Split(expr, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return f, fmt.Errorf("invalid filter %q: expected key=value", part)
+		}
+
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "state":
+			f.state = types.DeviceState(strings.ToUpper(value))
+		case "compliant", "policycompliant":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return f, fmt.Errorf("invalid filter %q: %w", part, err)
+			}
+			f.policyCompliant = &b
+		case "user", "username":
+			f.userName = value
+		default:
+			return f, fmt.Errorf("unsupported filter key %q (supported: state, compliant, user)", key)
+		}
+	}
+
+	return f, nil
+}
+
+// deviceTable renders devices for --output table.
+func deviceTable(devices []*androidmanagement.Device) *output.Table {
+	t := &output.Table{Headers: []string{"NAME", "STATE", "COMPLIANT", "POLICY", "MODEL", "LAST REPORT"}}
+	for _, d := range devices {
+		model := ""
+		if d.HardwareInfo != nil {
+			model = strings.TrimSpace(d.HardwareInfo.Brand + " " + d.HardwareInfo.Model)
+		}
+		t.AddRow(d.Name, d.State, strconv.FormatBool(d.PolicyCompliant), d.AppliedPolicyName, model, d.LastStatusReportTime)
+	}
+	return t
+}
@@ -0,0 +1,372 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/cmd/amapi-cli/internal/output"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+func newEnrollmentCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "enrollment",
+		Aliases: []string{"enrollment-token", "enrollment-tokens"},
+		Short:   "注册令牌管理",
+	}
+
+	cmd.AddCommand(
+		newEnrollmentCreateCommand(a),
+		newEnrollmentQuickCommand(a),
+		newEnrollmentGetCommand(a),
+		newEnrollmentListCommand(a),
+		newEnrollmentQRCodeCommand(a),
+		newEnrollmentBulkCreateCommand(a),
+		newEnrollmentRevokeCommand(a),
+		newEnrollmentStatsCommand(a),
+	)
+
+	return cmd
+}
+
+func newEnrollmentCreateCommand(a *app) *cobra.Command {
+	var enterprise, policy, duration, userAccount string
+	var workProfile, oneTime bool
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "创建注册令牌",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("enterprise", enterprise); err != nil {
+				return err
+			}
+			if err := requireFlag("policy", policy); err != nil {
+				return err
+			}
+
+			d, err := parseDuration(duration)
+			if err != nil {
+				return err
+			}
+
+			var user *androidmanagement.User
+			if userAccount != "" {
+				user = &androidmanagement.User{AccountIdentifier: userAccount}
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			token, err := c.EnrollmentTokens().Create(enterpriseName(enterprise), policyName(enterprise, policy), d, workProfile, oneTime, user)
+			if err != nil {
+				return err
+			}
+			return a.print(token, nil)
+		},
+	}
+
+	cmd.Flags().StringVarP(&enterprise, "enterprise", "e", "", "企业 ID 或资源名称")
+	cmd.Flags().StringVarP(&policy, "policy", "p", "", "策略 ID 或资源名称")
+	cmd.Flags().StringVar(&duration, "duration", "", "有效期，例如 24h、7d（默认由 API 决定）")
+	cmd.Flags().BoolVar(&workProfile, "work-profile", false, "允许个人使用（工作资料）")
+	cmd.Flags().BoolVar(&oneTime, "one-time", false, "一次性令牌")
+	cmd.Flags().StringVar(&userAccount, "user", "", "关联的用户账号标识")
+
+	return cmd
+}
+
+func newEnrollmentQuickCommand(a *app) *cobra.Command {
+	var enterprise, policy string
+
+	cmd := &cobra.Command{
+		Use:   "quick",
+		Short: "快速创建注册令牌（24 小时有效）",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("enterprise", enterprise); err != nil {
+				return err
+			}
+			if err := requireFlag("policy", policy); err != nil {
+				return err
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			token, err := c.EnrollmentTokens().CreateQuick(strings.TrimPrefix(enterprise, "enterprises/"), policy)
+			if err != nil {
+				return err
+			}
+			return a.print(token, nil)
+		},
+	}
+
+	cmd.Flags().StringVarP(&enterprise, "enterprise", "e", "", "企业 ID")
+	cmd.Flags().StringVarP(&policy, "policy", "p", "", "策略 ID")
+
+	return cmd
+}
+
+func newEnrollmentGetCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get TOKEN",
+		Short: "获取注册令牌详情",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			token, err := c.EnrollmentTokens().Get(args[0])
+			if err != nil {
+				return err
+			}
+			return a.print(token, nil)
+		},
+	}
+}
+
+func newEnrollmentListCommand(a *app) *cobra.Command {
+	var enterprise, policy, pageToken string
+	var all bool
+	var pageSize int
+	var activeOnly bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "列出注册令牌",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("enterprise", enterprise); err != nil {
+				return err
+			}
+
+			filterPolicy := ""
+			if policy != "" {
+				filterPolicy = policyName(enterprise, policy)
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			var result *types.ListResult[*androidmanagement.EnrollmentToken]
+			if all {
+				result, err = c.EnrollmentTokens().ListAll(enterpriseName(enterprise), filterPolicy, !activeOnly)
+			} else {
+				result, err = c.EnrollmentTokens().List(enterpriseName(enterprise), pageSize, pageToken, filterPolicy, !activeOnly)
+			}
+			if err != nil {
+				return err
+			}
+
+			t := &output.Table{Headers: []string{"NAME", "POLICY", "EXPIRES", "ONE TIME", "EXPIRED"}}
+			for _, token := range result.Items {
+				t.AddRow(token.Name, token.PolicyName, token.ExpirationTimestamp,
+					strconv.FormatBool(token.OneTimeOnly), strconv.FormatBool(types.IsEnrollmentTokenExpired(token)))
+			}
+			return a.print(result, t)
+		},
+	}
+
+	cmd.Flags().StringVarP(&enterprise, "enterprise", "e", "", "企业 ID 或资源名称")
+	cmd.Flags().StringVarP(&policy, "policy", "p", "", "只显示指定策略的令牌")
+	cmd.Flags().BoolVar(&activeOnly, "active-only", false, "只显示未过期的令牌")
+	cmd.Flags().IntVar(&pageSize, "page-size", 0, "每页数量")
+	cmd.Flags().StringVar(&pageToken, "page-token", "", "分页令牌")
+	cmd.Flags().BoolVar(&all, "all", false, "自动翻页获取全部结果（忽略 --page-size 和 --page-token）")
+
+	return cmd
+}
+
+func newEnrollmentQRCodeCommand(a *app) *cobra.Command {
+	var opts types.QRCodeOptions
+	var save string
+
+	cmd := &cobra.Command{
+		Use:     "qrcode TOKEN",
+		Aliases: []string{"qr-code"},
+		Short:   "生成注册二维码数据",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			qr, err := c.EnrollmentTokens().GenerateQRCode(args[0], &opts)
+			if err != nil {
+				return err
+			}
+
+			if save != "" {
+				if err := os.WriteFile(save, []byte(qr.String()), 0600); err != nil {
+					return fmt.Errorf("failed to save QR code data: %w", err)
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "二维码数据已保存到 %s\n", save)
+			}
+			return a.print(qr, nil)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.WiFiSSID, "wifi-ssid", "", "Wi-Fi SSID")
+	cmd.Flags().StringVar(&opts.WiFiPassword, "wifi-password", "", "Wi-Fi 密码")
+	cmd.Flags().StringVar(&opts.WiFiSecurityType, "wifi-security", "", "Wi-Fi 安全类型：NONE|WPA|WEP")
+	cmd.Flags().BoolVar(&opts.WiFiHidden, "wifi-hidden", false, "隐藏网络")
+	cmd.Flags().StringVar(&opts.TimeZone, "time-zone", "", "时区，例如 Asia/Shanghai")
+	cmd.Flags().StringVar(&opts.Locale, "locale", "", "语言，例如 zh-CN")
+	cmd.Flags().BoolVar(&opts.SkipSetupWizard, "skip-setup", false, "跳过设置向导")
+	cmd.Flags().BoolVar(&opts.LeaveAllSystemAppsEnabled, "keep-system-apps", false, "保留所有系统应用")
+	cmd.Flags().StringVar(&save, "save", "", "将二维码 JSON 数据保存到文件")
+
+	return cmd
+}
+
+func newEnrollmentBulkCreateCommand(a *app) *cobra.Command {
+	var enterprise, policy, duration string
+	var count int
+
+	cmd := &cobra.Command{
+		Use:   "bulk-create",
+		Short: "批量创建注册令牌（最多 100 个）",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("enterprise", enterprise); err != nil {
+				return err
+			}
+			if err := requireFlag("policy", policy); err != nil {
+				return err
+			}
+
+			d, err := parseDuration(duration)
+			if err != nil {
+				return err
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			tokens, err := c.EnrollmentTokens().CreateBulkTokens(strings.TrimPrefix(enterprise, "enterprises/"), policy, count, d)
+			if err != nil && len(tokens) == 0 {
+				return err
+			}
+
+			t := &output.Table{Headers: []string{"NAME", "VALUE", "EXPIRES"}}
+			for _, token := range tokens {
+				t.AddRow(token.Name, token.Value, token.ExpirationTimestamp)
+			}
+			if printErr := a.print(tokens, t); printErr != nil {
+				return printErr
+			}
+
+			// 部分失败时仍输出已创建的令牌
+			if err != nil {
+				return fmt.Errorf("created %d of %d tokens: %w", len(tokens), count, err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&enterprise, "enterprise", "e", "", "企业 ID")
+	cmd.Flags().StringVarP(&policy, "policy", "p", "", "策略 ID")
+	cmd.Flags().IntVar(&count, "count", 10, "创建数量")
+	cmd.Flags().StringVar(&duration, "duration", "", "有效期，例如 24h、7d（默认由 API 决定）")
+
+	return cmd
+}
+
+func newEnrollmentRevokeCommand(a *app) *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "revoke TOKEN",
+		Short: "撤销注册令牌",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !a.confirm(force, "确定要撤销注册令牌 %s 吗？", args[0]) {
+				fmt.Fprintln(a.out, "已取消")
+				return nil
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			if err := c.EnrollmentTokens().RevokeToken(args[0]); err != nil {
+				return err
+			}
+			return a.print(map[string]string{"revoked": args[0]}, nil)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "跳过确认")
+
+	return cmd
+}
+
+func newEnrollmentStatsCommand(a *app) *cobra.Command {
+	var enterprise string
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "注册令牌统计",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("enterprise", enterprise); err != nil {
+				return err
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			stats, err := c.EnrollmentTokens().GetTokenStatistics(strings.TrimPrefix(enterprise, "enterprises/"))
+			if err != nil {
+				return err
+			}
+			return a.print(stats, nil)
+		},
+	}
+
+	cmd.Flags().StringVarP(&enterprise, "enterprise", "e", "", "企业 ID")
+
+	return cmd
+}
+
+// parseDuration parses a duration that may use a day suffix, e.g. "7d" or "36h".
+func parseDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %w", value, err)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: %w", value, err)
+	}
+	return d, nil
+}
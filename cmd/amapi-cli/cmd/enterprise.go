@@ -0,0 +1,353 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/cmd/amapi-cli/internal/output"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// defaultNotificationTypes are the notification types enabled by "enterprise notifications enable".
+var defaultNotificationTypes = []string{
+	types.NotificationTypeEnrollment,
+	types.NotificationTypeStatusReport,
+	types.NotificationTypeCommand,
+	types.NotificationTypeComplianceReport,
+}
+
+func newEnterpriseCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "enterprise",
+		Aliases: []string{"enterprises"},
+		Short:   "企业管理",
+	}
+
+	cmd.AddCommand(
+		newEnterpriseCreateCommand(a),
+		newEnterpriseGetCommand(a),
+		newEnterpriseListCommand(a),
+		newEnterpriseUpdateCommand(a),
+		newEnterpriseDeleteCommand(a),
+		newEnterpriseSignupURLCommand(a),
+		newEnterpriseNotificationsCommand(a),
+		newEnterprisePubSubCommand(a),
+		newEnterpriseApplicationsCommand(a),
+	)
+
+	return cmd
+}
+
+func newEnterpriseCreateCommand(a *app) *cobra.Command {
+	var projectID, signupURLName, enterpriseToken, contactEmail string
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "使用注册 URL 返回的令牌创建企业",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("signup-url-name", signupURLName); err != nil {
+				return err
+			}
+			if err := requireFlag("enterprise-token", enterpriseToken); err != nil {
+				return err
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			var contactInfo *androidmanagement.ContactInfo
+			if contactEmail != "" {
+				contactInfo = &androidmanagement.ContactInfo{ContactEmail: contactEmail}
+			}
+
+			enterprise, err := c.Enterprises().Create(signupURLName, projectID, enterpriseToken, contactInfo)
+			if err != nil {
+				return err
+			}
+			return a.print(enterprise, nil)
+		},
+	}
+
+	cmd.Flags().StringVar(&projectID, "project-id", "", "Google Cloud 项目 ID（默认使用配置中的值）")
+	cmd.Flags().StringVar(&signupURLName, "signup-url-name", "", "signup-url 命令返回的 signupUrls/... 名称")
+	cmd.Flags().StringVar(&enterpriseToken, "enterprise-token", "", "回调 URL 中返回的 enterpriseToken")
+	cmd.Flags().StringVar(&contactEmail, "contact-email", "", "企业联系人邮箱")
+
+	return cmd
+}
+
+func newEnterpriseGetCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get ENTERPRISE",
+		Short: "获取企业详情",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			enterprise, err := c.Enterprises().Get(enterpriseName(args[0]))
+			if err != nil {
+				return err
+			}
+			return a.print(enterprise, nil)
+		},
+	}
+}
+
+func newEnterpriseListCommand(a *app) *cobra.Command {
+	var all bool
+	var pageSize int
+	var pageToken string
+
+	cmd := &cobra.Command{
+		Use:   "list [PROJECT_ID]",
+		Short: "列出项目下的企业",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			projectID := ""
+			if len(args) == 1 {
+				projectID = args[0]
+			}
+
+			var result *types.ListResult[*androidmanagement.Enterprise]
+			if all {
+				result, err = c.Enterprises().ListAll(projectID)
+			} else {
+				result, err = c.Enterprises().List(projectID, pageSize, pageToken)
+			}
+			if err != nil {
+				return err
+			}
+
+			t := &output.Table{Headers: []string{"NAME", "DISPLAY NAME", "PUBSUB TOPIC", "NOTIFICATIONS"}}
+			for _, e := range result.Items {
+				t.AddRow(e.Name, e.EnterpriseDisplayName, e.PubsubTopic, fmt.Sprint(len(e.EnabledNotificationTypes)))
+			}
+			return a.print(result, t)
+		},
+	}
+
+	cmd.Flags().IntVar(&pageSize, "page-size", 0, "每页数量")
+	cmd.Flags().StringVar(&pageToken, "page-token", "", "分页令牌")
+	cmd.Flags().BoolVar(&all, "all", false, "自动翻页获取全部结果（忽略 --page-size 和 --page-token）")
+
+	return cmd
+}
+
+func newEnterpriseUpdateCommand(a *app) *cobra.Command {
+	var primaryColor string
+	var autoApproval bool
+
+	cmd := &cobra.Command{
+		Use:   "update ENTERPRISE",
+		Short: "更新企业设置",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var colorPtr *int64
+			if cmd.Flags().Changed("primary-color") {
+				color, err := strconv.ParseInt(primaryColor, 0, 64)
+				if err != nil {
+					return fmt.Errorf("invalid --primary-color: %w", err)
+				}
+				colorPtr = &color
+			}
+
+			var autoApprovalPtr *bool
+			if cmd.Flags().Changed("app-auto-approval") {
+				autoApprovalPtr = &autoApproval
+			}
+
+			if colorPtr == nil && autoApprovalPtr == nil {
+				return fmt.Errorf("nothing to update: specify --primary-color or --app-auto-approval")
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			enterprise, err := c.Enterprises().Update(enterpriseName(args[0]), colorPtr, nil, nil, nil, autoApprovalPtr, nil)
+			if err != nil {
+				return err
+			}
+			return a.print(enterprise, nil)
+		},
+	}
+
+	cmd.Flags().StringVar(&primaryColor, "primary-color", "", "主颜色，例如 0xFF0000")
+	cmd.Flags().BoolVar(&autoApproval, "app-auto-approval", false, "是否自动批准应用权限")
+
+	return cmd
+}
+
+func newEnterpriseDeleteCommand(a *app) *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "delete ENTERPRISE",
+		Short: "删除企业",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := enterpriseName(args[0])
+			if !a.confirm(force, "确定要删除企业 %s 吗？此操作不可恢复", name) {
+				fmt.Fprintln(a.out, "已取消")
+				return nil
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			if err := c.Enterprises().Delete(name); err != nil {
+				return err
+			}
+			return a.print(map[string]string{"deleted": name}, nil)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "跳过确认")
+
+	return cmd
+}
+
+func newEnterpriseSignupURLCommand(a *app) *cobra.Command {
+	var projectID, callbackURL, adminEmail string
+
+	cmd := &cobra.Command{
+		Use:   "signup-url",
+		Short: "生成企业注册 URL",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			signupURL, err := c.Enterprises().GenerateSignupURL(projectID, callbackURL, adminEmail, "", "")
+			if err != nil {
+				return err
+			}
+			return a.print(signupURL, nil)
+		},
+	}
+
+	cmd.Flags().StringVar(&projectID, "project-id", "", "Google Cloud 项目 ID（默认使用配置中的值）")
+	cmd.Flags().StringVar(&callbackURL, "callback", "", "注册完成后的回调 URL（默认使用配置中的值）")
+	cmd.Flags().StringVar(&adminEmail, "admin-email", "", "预填的管理员邮箱")
+
+	return cmd
+}
+
+func newEnterpriseNotificationsCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "notifications",
+		Short: "管理企业 Pub/Sub 通知类型",
+	}
+
+	var enableTypes, disableTypes []string
+
+	enable := &cobra.Command{
+		Use:   "enable ENTERPRISE",
+		Short: "启用通知类型",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			enterprise, err := c.Enterprises().EnableNotifications(enterpriseName(args[0]), enableTypes)
+			if err != nil {
+				return err
+			}
+			return a.print(enterprise, nil)
+		},
+	}
+	enable.Flags().StringSliceVar(&enableTypes, "types", defaultNotificationTypes, "通知类型")
+
+	disable := &cobra.Command{
+		Use:   "disable ENTERPRISE",
+		Short: "禁用通知类型",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			enterprise, err := c.Enterprises().DisableNotifications(enterpriseName(args[0]), disableTypes)
+			if err != nil {
+				return err
+			}
+			return a.print(enterprise, nil)
+		},
+	}
+	disable.Flags().StringSliceVar(&disableTypes, "types", defaultNotificationTypes, "通知类型")
+
+	cmd.AddCommand(enable, disable)
+	return cmd
+}
+
+func newEnterprisePubSubCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:     "set-pubsub ENTERPRISE TOPIC",
+		Aliases: []string{"pubsub"},
+		Short:   "设置企业通知的 Pub/Sub 主题（projects/{project}/topics/{topic}）",
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			enterprise, err := c.Enterprises().SetPubSubTopic(enterpriseName(args[0]), args[1])
+			if err != nil {
+				return err
+			}
+			return a.print(enterprise, nil)
+		},
+	}
+}
+
+func newEnterpriseApplicationsCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "applications",
+		Aliases: []string{"application", "apps"},
+		Short:   "查看企业中的应用信息",
+	}
+
+	get := &cobra.Command{
+		Use:   "get ENTERPRISE PACKAGE",
+		Short: "获取托管 Google Play 中的应用详情",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			app, err := c.Enterprises().GetApplication(enterpriseName(args[0]), args[1])
+			if err != nil {
+				return err
+			}
+			return a.print(app, nil)
+		},
+	}
+
+	cmd.AddCommand(get)
+	return cmd
+}
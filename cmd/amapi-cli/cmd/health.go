@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"amapi-pkg/cmd/amapi-cli/internal/output"
+)
+
+// healthCheck is the result of a single health check step.
+type healthCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Latency string `json:"latency,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Health check statuses.
+const (
+	healthOK   = "ok"
+	healthFail = "fail"
+	healthSkip = "skipped"
+)
+
+func newHealthCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "health",
+		Short: "健康检查",
+	}
+
+	var detailed bool
+	check := &cobra.Command{
+		Use:   "check",
+		Short: "完整健康检查（配置、认证、API 连接）",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			checks := []healthCheck{a.checkConfig()}
+			if checks[0].Status == healthOK {
+				checks = append(checks, a.checkClient(), a.checkConnection())
+			} else {
+				checks = append(checks,
+					healthCheck{Name: "client", Status: healthSkip},
+					healthCheck{Name: "connection", Status: healthSkip})
+			}
+
+			if !detailed {
+				for i := range checks {
+					checks[i].Latency = ""
+				}
+			}
+			return a.printHealth(checks)
+		},
+	}
+	check.Flags().BoolVar(&detailed, "detailed", false, "显示每项检查的耗时")
+
+	quick := &cobra.Command{
+		Use:   "quick",
+		Short: "快速检查（仅验证配置和客户端创建）",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			checks := []healthCheck{a.checkConfig()}
+			if checks[0].Status == healthOK {
+				checks = append(checks, a.checkClient())
+			}
+			return a.printHealth(checks)
+		},
+	}
+
+	connection := &cobra.Command{
+		Use:   "connection",
+		Short: "检查 API 连接",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.printHealth([]healthCheck{a.checkConnection()})
+		},
+	}
+
+	configCheck := &cobra.Command{
+		Use:   "config",
+		Short: "检查配置状态",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.printHealth([]healthCheck{a.checkConfig()})
+		},
+	}
+
+	cmd.AddCommand(check, quick, connection, configCheck)
+	return cmd
+}
+
+// checkConfig verifies that the configuration loads and validates.
+func (a *app) checkConfig() healthCheck {
+	start := time.Now()
+	cfg, err := a.loadConfig()
+	if err == nil {
+		err = cfg.Validate()
+	}
+	return newHealthCheck("config", start, err)
+}
+
+// checkClient verifies that credentials load and a client can be created.
+func (a *app) checkClient() healthCheck {
+	start := time.Now()
+	_, err := a.getClient()
+	return newHealthCheck("client", start, err)
+}
+
+// checkConnection calls the API through Client.Health.
+func (a *app) checkConnection() healthCheck {
+	start := time.Now()
+	c, err := a.getClient()
+	if err == nil {
+		err = c.Health()
+	}
+	return newHealthCheck("connection", start, err)
+}
+
+// newHealthCheck builds a check result from an error.
+func newHealthCheck(name string, start time.Time, err error) healthCheck {
+	hc := healthCheck{
+		Name:    name,
+		Status:  healthOK,
+		Latency: time.Since(start).Round(time.Millisecond).String(),
+	}
+	if err != nil {
+		hc.Status = healthFail
+		hc.Message = err.Error()
+	}
+	return hc
+}
+
+// printHealth prints the check results and returns an error if any check failed.
+func (a *app) printHealth(checks []healthCheck) error {
+	t := &output.Table{Headers: []string{"CHECK", "STATUS", "LATENCY", "MESSAGE"}}
+	failed := 0
+	for _, hc := range checks {
+		t.AddRow(hc.Name, hc.Status, hc.Latency, hc.Message)
+		if hc.Status == healthFail {
+			failed++
+		}
+	}
+
+	if err := a.print(checks, t); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d health check(s) failed", failed)
+	}
+	return nil
+}
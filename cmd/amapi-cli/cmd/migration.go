@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/cmd/amapi-cli/internal/output"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+func newMigrationCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "migration",
+		Aliases: []string{"migration-token", "migration-tokens"},
+		Short:   "迁移令牌管理",
+	}
+
+	var createEnterprise, createPolicy string
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "创建迁移令牌",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("enterprise", createEnterprise); err != nil {
+				return err
+			}
+			if err := requireFlag("policy", createPolicy); err != nil {
+				return err
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			token, err := c.MigrationTokens().Create(enterpriseName(createEnterprise), policyName(createEnterprise, createPolicy))
+			if err != nil {
+				return err
+			}
+			return a.print(token, nil)
+		},
+	}
+	create.Flags().StringVarP(&createEnterprise, "enterprise", "e", "", "企业 ID 或资源名称")
+	create.Flags().StringVarP(&createPolicy, "policy", "p", "", "策略 ID 或资源名称")
+
+	get := &cobra.Command{
+		Use:   "get TOKEN",
+		Short: "获取迁移令牌详情",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			token, err := c.MigrationTokens().Get(args[0])
+			if err != nil {
+				return err
+			}
+			return a.print(token, nil)
+		},
+	}
+
+	var listEnterprise, pageToken string
+	var listAll bool
+	var pageSize int
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "列出迁移令牌",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("enterprise", listEnterprise); err != nil {
+				return err
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			var result *types.ListResult[*androidmanagement.MigrationToken]
+			if listAll {
+				result, err = c.MigrationTokens().ListAll(enterpriseName(listEnterprise))
+			} else {
+				result, err = c.MigrationTokens().List(enterpriseName(listEnterprise), pageSize, pageToken)
+			}
+			if err != nil {
+				return err
+			}
+
+			t := &output.Table{Headers: []string{"NAME", "POLICY", "EXPIRES", "DEVICE"}}
+			for _, token := range result.Items {
+				t.AddRow(token.Name, token.Policy, token.ExpireTime, token.Device)
+			}
+			return a.print(result, t)
+		},
+	}
+	list.Flags().StringVarP(&listEnterprise, "enterprise", "e", "", "企业 ID 或资源名称")
+	list.Flags().IntVar(&pageSize, "page-size", 0, "每页数量")
+	list.Flags().StringVar(&pageToken, "page-token", "", "分页令牌")
+	list.Flags().BoolVar(&listAll, "all", false, "自动翻页获取全部结果（忽略 --page-size 和 --page-token）")
+
+	var statsEnterprise string
+	stats := &cobra.Command{
+		Use:   "stats",
+		Short: "统计迁移令牌使用情况",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("enterprise", statsEnterprise); err != nil {
+				return err
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			result, err := c.MigrationTokens().ListAll(enterpriseName(statsEnterprise))
+			if err != nil {
+				return err
+			}
+			return a.print(migrationTokenStats(result.Items, time.Now()), nil)
+		},
+	}
+	stats.Flags().StringVarP(&statsEnterprise, "enterprise", "e", "", "企业 ID 或资源名称")
+
+	cmd.AddCommand(create, get, list, stats)
+	return cmd
+}
+
+// migrationTokenStats counts migration tokens by state.
+// A token is "used" once a device has been migrated with it.
+func migrationTokenStats(tokens []*androidmanagement.MigrationToken, now time.Time) map[string]int {
+	stats := map[string]int{
+		"total":   len(tokens),
+		"active":  0,
+		"used":    0,
+		"expired": 0,
+	}
+
+	for _, token := range tokens {
+		switch {
+		case token.Device != "":
+			stats["used"]++
+		case token.ExpireTime != "" && isExpired(token.ExpireTime, now):
+			stats["expired"]++
+		default:
+			stats["active"]++
+		}
+	}
+
+	return stats
+}
+
+// isExpired reports whether an RFC 3339 timestamp is before now.
+func isExpired(timestamp string, now time.Time) bool {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	return err == nil && t.Before(now)
+}
@@ -0,0 +1,1013 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/api/androidmanagement/v1"
+	"gopkg.in/yaml.v3"
+
+	"amapi-pkg/cmd/amapi-cli/internal/output"
+	"amapi-pkg/pkgs/amapi/client"
+	"amapi-pkg/pkgs/amapi/policydiff"
+	"amapi-pkg/pkgs/amapi/policylint"
+	"amapi-pkg/pkgs/amapi/policysync"
+	"amapi-pkg/pkgs/amapi/policytemplate"
+	"amapi-pkg/pkgs/amapi/presets"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+func newPolicyCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "policy",
+		Aliases: []string{"policies"},
+		Short:   "策略管理",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := cmd.Root().PersistentPreRunE(cmd, args); err != nil {
+				return err
+			}
+			return loadPresetDirs(viper.GetStringSlice("preset_dir"))
+		},
+	}
+
+	// 自定义预设目录也可以通过环境变量 AMAPI_PRESET_DIR 设置
+	cmd.PersistentFlags().StringSlice("preset-dir", nil, "加载自定义预设的目录（可重复）")
+	_ = viper.BindPFlag("preset_dir", cmd.PersistentFlags().Lookup("preset-dir"))
+
+	cmd.AddCommand(
+		newPolicyCreateCommand(a),
+		newPolicyCloneCommand(a),
+		newPolicyGetCommand(a),
+		newPolicyListCommand(a),
+		newPolicyUpdateCommand(a),
+		newPolicyDiffCommand(a),
+		newPolicyLintCommand(a),
+		newPolicySyncCommand(a),
+		newPolicyHistoryCommand(a),
+		newPolicyRollbackCommand(a),
+		newPolicyDeleteCommand(a),
+		newPolicyPresetsCommand(a),
+		newPolicyTemplateCommand(a),
+		newPolicyApplyPresetCommand(a),
+		newPolicyAppsCommand(a),
+		newPolicyKioskCommand(a),
+		newPolicyModeCommand(a, "fully-managed", "将策略切换为完全托管模式", (*client.PolicyService).SetFullyManagedMode),
+		newPolicyModeCommand(a, "work-profile", "将策略切换为工作资料模式", (*client.PolicyService).SetWorkProfileMode),
+		newPolicyDevicesCommand(a),
+	)
+
+	return cmd
+}
+
+func newPolicyCreateCommand(a *app) *cobra.Command {
+	var enterprise, policyID, fromPreset, file string
+	var templates []string
+	var template policyTemplateFlags
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "创建策略（默认使用 presets.GetDefaultPolicy）",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("enterprise", enterprise); err != nil {
+				return err
+			}
+			if err := requireFlag("policy-id", policyID); err != nil {
+				return err
+			}
+			sources := 0
+			for _, set := range []bool{fromPreset != "", file != "", len(templates) > 0} {
+				if set {
+					sources++
+				}
+			}
+			if sources > 1 {
+				return fmt.Errorf("--from-preset, --file and --template are mutually exclusive")
+			}
+
+			var policy *androidmanagement.Policy
+			var err error
+			switch {
+			case fromPreset != "":
+				policy, err = presets.CreatePolicyFromPreset(fromPreset, nil)
+			case file != "":
+				policy, err = readPolicyFile(file)
+			case len(templates) > 0:
+				policy, err = template.render(templates)
+			default:
+				policy = presets.GetDefaultPolicy()
+			}
+			if err != nil {
+				return err
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			created, err := c.Policies().Create(enterpriseName(enterprise), policyID, policy)
+			if err != nil {
+				return err
+			}
+			return a.print(created, nil)
+		},
+	}
+
+	cmd.Flags().StringVarP(&enterprise, "enterprise", "e", "", "企业 ID 或资源名称")
+	cmd.Flags().StringVar(&policyID, "policy-id", "", "策略 ID")
+	cmd.Flags().StringVar(&fromPreset, "from-preset", "", "使用预设创建（见 policy presets）")
+	cmd.Flags().StringVar(&file, "file", "", "从 JSON/YAML 文件读取策略")
+	cmd.Flags().StringSliceVar(&templates, "template", nil, "从模板渲染策略，可重复，后面的模板覆盖前面的模板")
+	template.register(cmd)
+
+	return cmd
+}
+
+func newPolicyGetCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get POLICY",
+		Short: "获取策略详情",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			policy, err := c.Policies().Get(args[0])
+			if err != nil {
+				return err
+			}
+			return a.print(policy, nil)
+		},
+	}
+}
+
+func newPolicyListCommand(a *app) *cobra.Command {
+	var enterprise, pageToken string
+	var all bool
+	var pageSize int
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "列出企业的策略",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("enterprise", enterprise); err != nil {
+				return err
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			var result *types.ListResult[*androidmanagement.Policy]
+			if all {
+				result, err = c.Policies().ListAll(enterpriseName(enterprise))
+			} else {
+				result, err = c.Policies().List(enterpriseName(enterprise), pageSize, pageToken)
+			}
+			if err != nil {
+				return err
+			}
+
+			t := &output.Table{Headers: []string{"NAME", "VERSION", "APPLICATIONS"}}
+			for _, p := range result.Items {
+				t.AddRow(p.Name, fmt.Sprint(p.Version), fmt.Sprint(len(p.Applications)))
+			}
+			return a.print(result, t)
+		},
+	}
+
+	cmd.Flags().StringVarP(&enterprise, "enterprise", "e", "", "企业 ID 或资源名称")
+	cmd.Flags().IntVar(&pageSize, "page-size", 0, "每页数量")
+	cmd.Flags().StringVar(&pageToken, "page-token", "", "分页令牌")
+	cmd.Flags().BoolVar(&all, "all", false, "自动翻页获取全部结果（忽略 --page-size 和 --page-token）")
+
+	return cmd
+}
+
+func newPolicyUpdateCommand(a *app) *cobra.Command {
+	var cameraDisabled, bluetoothDisabled, screenCaptureDisabled, statusBarDisabled bool
+	var file string
+
+	cmd := &cobra.Command{
+		Use:   "update POLICY",
+		Short: "更新策略字段",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			if file != "" {
+				policy, err := readPolicyFile(file)
+				if err != nil {
+					return err
+				}
+				updated, err := c.Policies().Update(name, policy, nil)
+				if err != nil {
+					return err
+				}
+				return a.print(updated, nil)
+			}
+
+			policy, err := c.Policies().Get(name)
+			if err != nil {
+				return err
+			}
+
+			var updateMask []string
+			flags := cmd.Flags()
+			if flags.Changed("camera-disabled") {
+				policy.CameraDisabled = cameraDisabled
+				updateMask = append(updateMask, "cameraDisabled")
+			}
+			if flags.Changed("bluetooth-disabled") {
+				policy.BluetoothDisabled = bluetoothDisabled
+				updateMask = append(updateMask, "bluetoothDisabled")
+			}
+			if flags.Changed("screen-capture-disabled") {
+				policy.ScreenCaptureDisabled = screenCaptureDisabled
+				updateMask = append(updateMask, "screenCaptureDisabled")
+			}
+			if flags.Changed("status-bar-disabled") {
+				policy.StatusBarDisabled = statusBarDisabled
+				updateMask = append(updateMask, "statusBarDisabled")
+			}
+
+			if len(updateMask) == 0 {
+				return fmt.Errorf("nothing to update: specify at least one field flag or --file")
+			}
+
+			updated, err := c.Policies().Update(name, policy, updateMask)
+			if err != nil {
+				return err
+			}
+			return a.print(updated, nil)
+		},
+	}
+
+	cmd.Flags().BoolVar(&cameraDisabled, "camera-disabled", false, "禁用摄像头")
+	cmd.Flags().BoolVar(&bluetoothDisabled, "bluetooth-disabled", false, "禁用蓝牙")
+	cmd.Flags().BoolVar(&screenCaptureDisabled, "screen-capture-disabled", false, "禁用截屏")
+	cmd.Flags().BoolVar(&statusBarDisabled, "status-bar-disabled", false, "禁用状态栏")
+	cmd.Flags().StringVar(&file, "file", "", "使用 JSON/YAML 文件中的完整策略替换")
+
+	return cmd
+}
+
+func newPolicyDiffCommand(a *app) *cobra.Command {
+	var file string
+
+	cmd := &cobra.Command{
+		Use:   "diff POLICY",
+		Short: "比较线上策略与 JSON/YAML 文件中的策略",
+		Long: `逐字段比较线上策略与文件中的策略，applications 按包名匹配；忽略 name 和 version。
+
+表格输出为每行一个变更（+ 新增、- 删除、~ 修改），并在 stderr 打印更新所需的最小 updateMask。`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("file", file); err != nil {
+				return err
+			}
+
+			desired, err := readPolicyFile(file)
+			if err != nil {
+				return err
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			current, err := c.Policies().Get(args[0])
+			if err != nil {
+				return err
+			}
+
+			result, err := policydiff.Diff(current, desired)
+			if err != nil {
+				return err
+			}
+
+			if a.output != output.FormatTable {
+				return a.print(struct {
+					Changes    []policydiff.Change `json:"changes"`
+					UpdateMask []string            `json:"update_mask"`
+				}{result.Changes, result.UpdateMask()}, nil)
+			}
+
+			if result.Empty() {
+				fmt.Fprintln(a.out, "策略没有差异")
+				return nil
+			}
+			fmt.Fprint(a.out, result)
+			fmt.Fprintf(a.errOut, "updateMask: %s\n", strings.Join(result.UpdateMask(), ","))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&file, "file", "", "期望的策略文件（JSON/YAML）")
+
+	return cmd
+}
+
+func newPolicyLintCommand(a *app) *cobra.Command {
+	var file string
+	var listRules bool
+	var opts policylint.Options
+
+	cmd := &cobra.Command{
+		Use:   "lint [POLICY]",
+		Short: "检查线上策略或 JSON/YAML 文件中的常见配置错误",
+		Long: `按规则检查策略，例如缺少 KIOSK 应用的自助服务终端设置、超出范围的密码长度、
+同时被阻止和被依赖的应用、过长的系统更新冻结期以及完全托管与工作资料设置的冲突。
+
+存在 error 级别的结果时命令以非零状态退出。使用 --list-rules 查看所有规则。`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if listRules {
+				rules := policylint.Rules()
+				t := &output.Table{Headers: []string{"RULE", "DESCRIPTION"}}
+				for _, rule := range rules {
+					t.AddRow(rule.ID, rule.Description)
+				}
+				return a.print(rules, t)
+			}
+
+			if (len(args) == 1) == (file != "") {
+				return fmt.Errorf("specify either POLICY or --file")
+			}
+
+			var policy *androidmanagement.Policy
+			if file != "" {
+				p, err := readPolicyFile(file)
+				if err != nil {
+					return err
+				}
+				policy = p
+			} else {
+				c, err := a.getClient()
+				if err != nil {
+					return err
+				}
+				p, err := c.Policies().Get(args[0])
+				if err != nil {
+					return err
+				}
+				policy = p
+			}
+
+			report, err := policylint.Lint(policy, &opts)
+			if err != nil {
+				return err
+			}
+
+			if a.output != output.FormatTable {
+				if err := a.print(report, nil); err != nil {
+					return err
+				}
+			} else {
+				fmt.Fprint(a.out, report)
+			}
+			if report.HasErrors() {
+				return fmt.Errorf("policy has %d lint errors", report.Count(policylint.SeverityError))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&file, "file", "", "检查策略文件（JSON/YAML）而不是线上策略")
+	cmd.Flags().StringSliceVar(&opts.Rules, "rule", nil, "只运行这些规则，可重复或用逗号分隔")
+	cmd.Flags().StringSliceVar(&opts.Disable, "disable", nil, "跳过这些规则，可重复或用逗号分隔")
+	cmd.Flags().BoolVar(&listRules, "list-rules", false, "列出所有规则")
+
+	return cmd
+}
+
+func newPolicySyncCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "以目录中的策略文档为准同步企业策略",
+		Long: `目录中每个 .yaml、.yml 或 .json 文件描述一个策略，文件名是策略 ID。
+文件可以是原始策略，也可以使用 preset 和 policy 键基于预设构建。
+
+plan 显示需要创建、更新和删除的策略；apply 在确认后执行，任一操作失败时撤销已执行的操作。`,
+	}
+
+	cmd.AddCommand(newPolicySyncPlanCommand(a), newPolicySyncApplyCommand(a))
+	return cmd
+}
+
+// policySyncFlags holds the flags shared by policy sync plan and apply.
+type policySyncFlags struct {
+	enterprise   string
+	keepUnlisted bool
+}
+
+func (f *policySyncFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.enterprise, "enterprise", "e", "", "企业 ID 或资源名称")
+	cmd.Flags().BoolVar(&f.keepUnlisted, "keep-unlisted", false, "保留目录中没有对应文档的策略，不删除")
+}
+
+// plan loads the documents of dir and plans the sync.
+func (f *policySyncFlags) plan(cmd *cobra.Command, a *app, dir string) (*policysync.Syncer, *policysync.Plan, error) {
+	if err := requireFlag("enterprise", f.enterprise); err != nil {
+		return nil, nil, err
+	}
+
+	docs, err := policysync.LoadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c, err := a.getClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	syncer := policysync.New(c)
+	plan, err := syncer.Plan(cmd.Context(), enterpriseName(f.enterprise), docs, &policysync.PlanOptions{KeepUnlisted: f.keepUnlisted})
+	if err != nil {
+		return nil, nil, err
+	}
+	return syncer, plan, nil
+}
+
+func newPolicySyncPlanCommand(a *app) *cobra.Command {
+	var flags policySyncFlags
+
+	cmd := &cobra.Command{
+		Use:   "plan DIR",
+		Short: "显示同步目录中的策略文档需要执行的操作",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, plan, err := flags.plan(cmd, a, args[0])
+			if err != nil {
+				return err
+			}
+
+			if a.output != output.FormatTable {
+				return a.print(plan, nil)
+			}
+			fmt.Fprint(a.out, plan)
+			return nil
+		},
+	}
+
+	flags.register(cmd)
+	return cmd
+}
+
+func newPolicySyncApplyCommand(a *app) *cobra.Command {
+	var flags policySyncFlags
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "apply DIR",
+		Short: "确认后执行同步计划",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			syncer, plan, err := flags.plan(cmd, a, args[0])
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprint(a.errOut, plan)
+			if plan.Empty() {
+				return nil
+			}
+			if !a.confirm(force, "确定要执行以上 %d 个操作吗？", len(plan.Actions)) {
+				fmt.Fprintln(a.out, "已取消")
+				return nil
+			}
+
+			summary, err := syncer.Apply(cmd.Context(), plan)
+			if summary != nil {
+				if a.output != output.FormatTable {
+					if printErr := a.print(summary, nil); printErr != nil {
+						return printErr
+					}
+				} else {
+					fmt.Fprintln(a.out, summary)
+				}
+			}
+			return err
+		},
+	}
+
+	flags.register(cmd)
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "跳过确认")
+	return cmd
+}
+
+func newPolicyHistoryCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "history POLICY",
+		Short: "查看策略的历史版本（需要配置 policy_history）",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			revisions, err := c.Policies().History(args[0])
+			if err != nil {
+				return err
+			}
+
+			t := &output.Table{Headers: []string{"VERSION", "RECORDED AT", "OPERATION", "APPLICATIONS"}}
+			for _, revision := range revisions {
+				t.AddRow(strconv.FormatInt(revision.Version, 10), revision.RecordedAt.Local().Format(time.RFC3339),
+					revision.Operation, strconv.Itoa(len(revision.Policy.Applications)))
+			}
+			return a.print(revisions, t)
+		},
+	}
+}
+
+func newPolicyRollbackCommand(a *app) *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "rollback POLICY VERSION",
+		Short: "将策略恢复为历史版本",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			version, err := strconv.ParseInt(args[1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid VERSION %q: %w", args[1], err)
+			}
+
+			if !a.confirm(force, "确定要将策略 %s 恢复为版本 %d 吗？", args[0], version) {
+				fmt.Fprintln(a.out, "已取消")
+				return nil
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			policy, err := c.Policies().Rollback(args[0], version)
+			if err != nil {
+				return err
+			}
+			return a.print(policy, nil)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "跳过确认")
+
+	return cmd
+}
+
+func newPolicyDeleteCommand(a *app) *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "delete POLICY",
+		Short: "删除策略",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !a.confirm(force, "确定要删除策略 %s 吗？", args[0]) {
+				fmt.Fprintln(a.out, "已取消")
+				return nil
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			if err := c.Policies().Delete(args[0]); err != nil {
+				return err
+			}
+			return a.print(map[string]string{"deleted": args[0]}, nil)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "跳过确认")
+
+	return cmd
+}
+
+func newPolicyPresetsCommand(a *app) *cobra.Command {
+	var tags []string
+
+	cmd := &cobra.Command{
+		Use:   "presets",
+		Short: "列出可用的策略预设",
+		Long: `列出内置预设和 --preset-dir 目录中加载的自定义预设。
+
+--tag 只列出包含所有指定标签的预设（不区分大小写）。`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			all := presets.FindPresetsByTag(tags...)
+
+			t := &output.Table{Headers: []string{"NAME", "DISPLAY NAME", "VERSION", "TAGS", "REQUIRES", "DESCRIPTION"}}
+			for _, p := range all {
+				t.AddRow(p.Name, p.DisplayName, p.Version, strings.Join(p.Tags, ","), strings.Join(p.Requires, ","), p.Description)
+			}
+			return a.print(all, t)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&tags, "tag", nil, "按标签过滤（可重复）")
+
+	return cmd
+}
+
+// loadPresetDirs registers the presets of dirs in the default preset registry.
+func loadPresetDirs(dirs []string) error {
+	for _, dir := range dirs {
+		if _, err := presets.LoadDir(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newPolicyTemplateCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "template",
+		Short: "使用带变量的分层模板生成策略",
+		Long: `模板是包含 variables、values、policy（以及第一层中的 preset）的 JSON/YAML 文件。
+多个模板按顺序叠加：对象深度合并，null 删除字段，列表整体替换，
+applications 按 packageName 合并，"$remove: true" 删除应用。`,
+	}
+
+	cmd.AddCommand(newPolicyTemplateRenderCommand(a), newPolicyTemplateVarsCommand(a))
+	return cmd
+}
+
+// policyTemplateFlags holds the variable values of template commands.
+type policyTemplateFlags struct {
+	set        []string
+	valuesFile string
+}
+
+func (f *policyTemplateFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&f.set, "set", nil, "设置模板变量 NAME=VALUE，可重复")
+	cmd.Flags().StringVar(&f.valuesFile, "values", "", "从 JSON/YAML 文件读取模板变量")
+}
+
+// values returns the variable values of --values and --set; --set takes precedence.
+func (f *policyTemplateFlags) values() (map[string]any, error) {
+	values := make(map[string]any)
+	if f.valuesFile != "" {
+		data, err := os.ReadFile(f.valuesFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read values file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil, fmt.Errorf("failed to parse values file: %w", err)
+		}
+	}
+
+	for _, assignment := range f.set {
+		name, value, ok := strings.Cut(assignment, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --set %q (expected NAME=VALUE)", assignment)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// render loads the template files and renders the policy.
+func (f *policyTemplateFlags) render(paths []string) (*androidmanagement.Policy, error) {
+	values, err := f.values()
+	if err != nil {
+		return nil, err
+	}
+
+	layers, err := policytemplate.LoadFiles(paths...)
+	if err != nil {
+		return nil, err
+	}
+	return policytemplate.Render(layers, values)
+}
+
+func newPolicyTemplateRenderCommand(a *app) *cobra.Command {
+	var flags policyTemplateFlags
+
+	cmd := &cobra.Command{
+		Use:   "render TEMPLATE...",
+		Short: "渲染模板并输出策略",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			policy, err := flags.render(args)
+			if err != nil {
+				return err
+			}
+			return a.print(policy, nil)
+		},
+	}
+
+	flags.register(cmd)
+	return cmd
+}
+
+func newPolicyTemplateVarsCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "vars TEMPLATE...",
+		Short: "列出模板声明的变量",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			layers, err := policytemplate.LoadFiles(args...)
+			if err != nil {
+				return err
+			}
+			variables, err := policytemplate.Variables(layers)
+			if err != nil {
+				return err
+			}
+
+			names := make([]string, 0, len(variables))
+			for name := range variables {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			list := make([]*policytemplate.Variable, 0, len(names))
+			t := &output.Table{Headers: []string{"NAME", "TYPE", "REQUIRED", "DEFAULT", "DESCRIPTION"}}
+			for _, name := range names {
+				v := variables[name]
+				list = append(list, v)
+				defaultValue := ""
+				if v.Default != nil {
+					defaultValue = fmt.Sprint(v.Default)
+				}
+				t.AddRow(v.Name, string(v.Type), strconv.FormatBool(v.Required), defaultValue, v.Description)
+			}
+			return a.print(list, t)
+		},
+	}
+}
+
+func newPolicyApplyPresetCommand(a *app) *cobra.Command {
+	var enterprise, policyID, preset string
+
+	cmd := &cobra.Command{
+		Use:   "apply-preset",
+		Short: "将预设应用到策略（创建或覆盖）",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("enterprise", enterprise); err != nil {
+				return err
+			}
+			if err := requireFlag("policy-id", policyID); err != nil {
+				return err
+			}
+			if err := requireFlag("preset", preset); err != nil {
+				return err
+			}
+
+			policy, err := presets.CreatePolicyFromPreset(preset, nil)
+			if err != nil {
+				return err
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			created, err := c.Policies().Create(enterpriseName(enterprise), policyID, policy)
+			if err != nil {
+				return err
+			}
+			return a.print(created, nil)
+		},
+	}
+
+	cmd.Flags().StringVarP(&enterprise, "enterprise", "e", "", "企业 ID 或资源名称")
+	cmd.Flags().StringVar(&policyID, "policy-id", "", "策略 ID")
+	cmd.Flags().StringVar(&preset, "preset", "", "预设名称")
+
+	return cmd
+}
+
+func newPolicyCloneCommand(a *app) *cobra.Command {
+	var enterprise, policyID string
+
+	cmd := &cobra.Command{
+		Use:   "clone SOURCE_POLICY",
+		Short: "复制已有策略为新策略",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("policy-id", policyID); err != nil {
+				return err
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			// 未指定 --enterprise 时复制到源策略所在的企业
+			target := strings.TrimPrefix(enterprise, "enterprises/")
+			if target == "" {
+				parts := strings.Split(args[0], "/")
+				if len(parts) < 2 || parts[0] != "enterprises" {
+					return fmt.Errorf("--enterprise is required when SOURCE_POLICY is not a full resource name")
+				}
+				target = parts[1]
+			}
+
+			policy, err := c.Policies().Clone(args[0], target, policyID)
+			if err != nil {
+				return err
+			}
+			return a.print(policy, nil)
+		},
+	}
+
+	cmd.Flags().StringVarP(&enterprise, "enterprise", "e", "", "目标企业 ID（默认与源策略相同）")
+	cmd.Flags().StringVar(&policyID, "policy-id", "", "新策略 ID")
+
+	return cmd
+}
+
+func newPolicyAppsCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "apps",
+		Aliases: []string{"app"},
+		Short:   "管理策略中的应用",
+	}
+
+	cmd.AddCommand(
+		newPolicyAddAppCommand(a),
+		newPolicyRemoveAppCommand(a),
+	)
+
+	return cmd
+}
+
+func newPolicyKioskCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "kiosk POLICY PACKAGE",
+		Short: "启用 Kiosk 模式并锁定到指定应用",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			policy, err := c.Policies().SetKioskMode(args[0], args[1])
+			if err != nil {
+				return err
+			}
+			return a.print(policy, nil)
+		},
+	}
+}
+
+// newPolicyModeCommand builds a command that switches a policy to a management mode.
+func newPolicyModeCommand(a *app, use, short string, apply func(*client.PolicyService, string) (*androidmanagement.Policy, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   use + " POLICY",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			policy, err := apply(c.Policies(), args[0])
+			if err != nil {
+				return err
+			}
+			return a.print(policy, nil)
+		},
+	}
+}
+
+func newPolicyAddAppCommand(a *app) *cobra.Command {
+	var packageName, installType string
+
+	cmd := &cobra.Command{
+		Use:   "add POLICY",
+		Short: "向策略添加应用",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("package", packageName); err != nil {
+				return err
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			policy, err := c.Policies().SetApplicationInstallType(args[0], packageName, types.ApplicationInstallType(strings.ToUpper(installType)))
+			if err != nil {
+				return err
+			}
+			return a.print(policy, nil)
+		},
+	}
+
+	cmd.Flags().StringVar(&packageName, "package", "", "应用包名")
+	cmd.Flags().StringVar(&installType, "install-type", string(types.InstallTypeAvailable), "安装类型：REQUIRED|PREINSTALLED|AVAILABLE|BLOCKED|KIOSK|REQUIRED_FOR_SETUP")
+
+	return cmd
+}
+
+func newPolicyRemoveAppCommand(a *app) *cobra.Command {
+	var packageName string
+
+	cmd := &cobra.Command{
+		Use:   "remove POLICY",
+		Short: "从策略移除应用",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("package", packageName); err != nil {
+				return err
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			policy, err := c.Policies().RemoveApplication(args[0], packageName)
+			if err != nil {
+				return err
+			}
+			return a.print(policy, nil)
+		},
+	}
+
+	cmd.Flags().StringVar(&packageName, "package", "", "应用包名")
+
+	return cmd
+}
+
+func newPolicyDevicesCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "devices POLICY",
+		Short: "列出正在使用该策略的设备",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			result, err := c.Policies().GetDevicesUsingPolicy(args[0])
+			if err != nil {
+				return err
+			}
+			return a.print(result, deviceTable(result.Items))
+		},
+	}
+}
+
+// readPolicyFile reads a policy from a JSON or YAML file.
+func readPolicyFile(path string) (*androidmanagement.Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		// YAML 先解码为通用结构，再通过 JSON 转换为 Policy，以复用 JSON 字段名
+		var generic any
+		if err := yaml.Unmarshal(data, &generic); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML policy: %w", err)
+		}
+		if data, err = json.Marshal(generic); err != nil {
+			return nil, fmt.Errorf("failed to convert YAML policy: %w", err)
+		}
+	case ".json":
+	default:
+		return nil, fmt.Errorf("unsupported policy file format: %s (supported: .yaml, .yml, .json)", filepath.Ext(path))
+	}
+
+	var policy androidmanagement.Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+	return &policy, nil
+}
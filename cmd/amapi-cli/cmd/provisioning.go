@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/androidmanagement/v1"
+)
+
+func newProvisioningCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "provisioning",
+		Short: "设备配置信息",
+	}
+
+	get := &cobra.Command{
+		Use:   "get NAME",
+		Short: "获取配置信息（provisioningInfo/{id}）",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			info, err := c.ProvisioningInfo().Get(args[0])
+			if err != nil {
+				return err
+			}
+			return a.print(info, nil)
+		},
+	}
+
+	var deviceID, enterpriseID, id string
+	info := &cobra.Command{
+		Use:   "info",
+		Short: "按设备、企业或配置信息 ID 查询配置信息",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			ps := c.ProvisioningInfo()
+			var result *androidmanagement.ProvisioningInfo
+			switch {
+			case id != "":
+				result, err = ps.GetByID(id)
+			case deviceID != "":
+				result, err = ps.GetByDeviceID(deviceID)
+			case enterpriseID != "":
+				result, err = ps.GetByEnterpriseID(strings.TrimPrefix(enterpriseID, "enterprises/"))
+			default:
+				return fmt.Errorf("one of --id, --device or --enterprise is required")
+			}
+			if err != nil {
+				return err
+			}
+			return a.print(result, nil)
+		},
+	}
+	info.Flags().StringVar(&id, "id", "", "配置信息 ID")
+	info.Flags().StringVar(&deviceID, "device", "", "设备 ID")
+	info.Flags().StringVar(&enterpriseID, "enterprise", "", "企业 ID")
+	info.MarkFlagsMutuallyExclusive("id", "device", "enterprise")
+
+	cmd.AddCommand(get, info)
+	return cmd
+}
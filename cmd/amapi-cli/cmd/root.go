@@ -0,0 +1,220 @@
+// Package cmd 实现 amapi-cli 的 cobra 命令树。
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"amapi-pkg/cmd/amapi-cli/internal/output"
+	"amapi-pkg/pkgs/amapi/client"
+	"amapi-pkg/pkgs/amapi/config"
+)
+
+// app holds the state shared by all subcommands.
+type app struct {
+	// configPath is the explicit config file path (--config)
+	configPath string
+
+	// output is the output format (--output)
+	output string
+
+	// debug enables debug logging (--debug)
+	debug bool
+
+	// in, out and errOut are the command's input, output and error streams
+	in     io.Reader
+	out    io.Writer
+	errOut io.Writer
+
+	// cfg and client are created lazily on first use
+	cfg    *config.Config
+	client *client.Client
+}
+
+// BuildInfo describes the binary being run, injected by the main package.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+}
+
+// Execute runs the amapi-cli root command.
+func Execute(info BuildInfo) error {
+	return NewRootCommand(info).Execute()
+}
+
+// NewRootCommand builds the amapi-cli command tree.
+func NewRootCommand(info BuildInfo) *cobra.Command {
+	a := &app{}
+
+	root := &cobra.Command{
+		Use:           "amapi-cli",
+		Short:         "Android Management API 命令行工具",
+		Long:          "amapi-cli 用于管理 Android Management API 的企业、策略、设备和注册令牌。",
+		Version:       fmt.Sprintf("%s (commit %s, built %s)", info.Version, info.Commit, info.BuildDate),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			a.in = cmd.InOrStdin()
+			a.out = cmd.OutOrStdout()
+			a.errOut = cmd.ErrOrStderr()
+			a.output = strings.ToLower(viper.GetString("output"))
+			a.debug = viper.GetBool("debug")
+			a.configPath = viper.GetString("config")
+			return output.ValidateFormat(a.output)
+		},
+		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+			if a.client != nil {
+				return a.client.Close()
+			}
+			return nil
+		},
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringP("config", "c", "", "配置文件路径（默认自动搜索）")
+	flags.StringP("output", "o", output.FormatJSON, "输出格式：json|yaml|table")
+	flags.BoolP("debug", "d", false, "启用调试日志")
+
+	// 全局选项也可以通过环境变量设置，例如 AMAPI_OUTPUT=table
+	viper.SetEnvPrefix("AMAPI")
+	viper.AutomaticEnv()
+	_ = viper.BindPFlag("config", flags.Lookup("config"))
+	_ = viper.BindPFlag("output", flags.Lookup("output"))
+	_ = viper.BindPFlag("debug", flags.Lookup("debug"))
+
+	root.AddCommand(
+		newEnterpriseCommand(a),
+		newPolicyCommand(a),
+		newDeviceCommand(a),
+		newEnrollmentCommand(a),
+		newMigrationCommand(a),
+		newWebAppCommand(a),
+		newWebTokenCommand(a),
+		newProvisioningCommand(a),
+		newConfigCommand(a),
+		newHealthCommand(a),
+		newVersionCommand(a, info),
+	)
+
+	return root
+}
+
+// loadConfig loads the configuration from --config or the default search paths.
+func (a *app) loadConfig() (*config.Config, error) {
+	if a.cfg != nil {
+		return a.cfg, nil
+	}
+
+	var cfg *config.Config
+	var err error
+	if a.configPath != "" {
+		cfg, err = config.LoadConfig(a.configPath)
+	} else {
+		cfg, err = config.AutoLoadConfig()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if a.debug {
+		cfg.LogLevel = "debug"
+		cfg.EnableDebugLogging = true
+	}
+
+	a.cfg = cfg
+	return cfg, nil
+}
+
+// getClient returns the API client, creating it on first use.
+func (a *app) getClient() (*client.Client, error) {
+	if a.client != nil {
+		return a.client, nil
+	}
+
+	cfg, err := a.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := client.New(cfg, client.WithLogger(a.logger()))
+	if err != nil {
+		return nil, err
+	}
+
+	a.client = c
+	return c, nil
+}
+
+// logger returns the logger for API calls. Errors are already printed by the
+// commands, so API call logs are only written to stderr with --debug.
+func (a *app) logger() *slog.Logger {
+	if !a.debug {
+		return slog.New(slog.DiscardHandler)
+	}
+	return slog.New(slog.NewTextHandler(a.errOut, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+// confirm asks the user to confirm a dangerous operation unless force is set.
+func (a *app) confirm(force bool, format string, args ...any) bool {
+	if force {
+		return true
+	}
+
+	fmt.Fprintf(a.out, format+" [y/N]: ", args...)
+	answer, _ := bufio.NewReader(a.in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// newVersionCommand prints the build information.
+func newVersionCommand(a *app, info BuildInfo) *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "显示版本信息",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := struct {
+				BuildInfo
+				SDKVersion string `json:"sdk_version"`
+			}{info, client.ClientVersion}
+			return a.print(v, nil)
+		},
+	}
+}
+
+// print writes v in the selected output format.
+// If t is nil in table mode, the top-level fields of v are rendered as key/value rows.
+func (a *app) print(v any, t *output.Table) error {
+	return output.Write(a.out, a.output, v, t)
+}
+
+// enterpriseName accepts either an enterprise ID or a full resource name.
+func enterpriseName(enterprise string) string {
+	if strings.HasPrefix(enterprise, "enterprises/") {
+		return enterprise
+	}
+	return "enterprises/" + enterprise
+}
+
+// policyName accepts either a policy ID or a full resource name.
+func policyName(enterprise, policy string) string {
+	if strings.HasPrefix(policy, "enterprises/") {
+		return policy
+	}
+	return enterpriseName(enterprise) + "/policies/" + policy
+}
+
+// requireFlag returns an error if a required string flag is empty.
+func requireFlag(name, value string) error {
+	if value == "" {
+		return fmt.Errorf("--%s is required", name)
+	}
+	return nil
+}
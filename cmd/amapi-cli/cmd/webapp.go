@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/cmd/amapi-cli/internal/output"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+func newWebAppCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "webapp",
+		Aliases: []string{"webapps", "web-app"},
+		Short:   "Web 应用管理",
+	}
+
+	var createEnterprise, title, startURL, displayMode string
+	var versionCode int64
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "创建 Web 应用",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("enterprise", createEnterprise); err != nil {
+				return err
+			}
+			if err := requireFlag("start-url", startURL); err != nil {
+				return err
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			webApp, err := c.WebApps().Create(enterpriseName(createEnterprise), startURL, nil, versionCode)
+			if err != nil {
+				return err
+			}
+
+			// Create 只接受基本字段，标题和显示模式需要通过更新设置
+			var updateMask []string
+			if title != "" {
+				webApp.Title = title
+				updateMask = append(updateMask, "title")
+			}
+			if displayMode != "" {
+				webApp.DisplayMode = displayMode
+				updateMask = append(updateMask, "displayMode")
+			}
+			if len(updateMask) > 0 {
+				if webApp, err = c.WebApps().Update(webApp.Name, webApp, updateMask); err != nil {
+					return err
+				}
+			}
+			return a.print(webApp, nil)
+		},
+	}
+	create.Flags().StringVarP(&createEnterprise, "enterprise", "e", "", "企业 ID 或资源名称")
+	create.Flags().StringVar(&title, "title", "", "应用标题")
+	create.Flags().StringVar(&startURL, "start-url", "", "起始 URL")
+	create.Flags().StringVar(&displayMode, "display-mode", "", "显示模式：MINIMAL_UI|STANDALONE|FULL_SCREEN")
+	create.Flags().Int64Var(&versionCode, "version-code", 0, "版本号")
+
+	get := &cobra.Command{
+		Use:   "get WEBAPP",
+		Short: "获取 Web 应用详情",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			webApp, err := c.WebApps().Get(args[0])
+			if err != nil {
+				return err
+			}
+			return a.print(webApp, nil)
+		},
+	}
+
+	var listEnterprise, pageToken string
+	var listAll bool
+	var pageSize int
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "列出 Web 应用",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("enterprise", listEnterprise); err != nil {
+				return err
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			var result *types.ListResult[*androidmanagement.WebApp]
+			if listAll {
+				result, err = c.WebApps().ListAll(enterpriseName(listEnterprise))
+			} else {
+				result, err = c.WebApps().List(enterpriseName(listEnterprise), pageSize, pageToken)
+			}
+			if err != nil {
+				return err
+			}
+
+			t := &output.Table{Headers: []string{"NAME", "TITLE", "START URL", "VERSION"}}
+			for _, w := range result.Items {
+				t.AddRow(w.Name, w.Title, w.StartUrl, fmt.Sprint(w.VersionCode))
+			}
+			return a.print(result, t)
+		},
+	}
+	list.Flags().StringVarP(&listEnterprise, "enterprise", "e", "", "企业 ID 或资源名称")
+	list.Flags().IntVar(&pageSize, "page-size", 0, "每页数量")
+	list.Flags().StringVar(&pageToken, "page-token", "", "分页令牌")
+	list.Flags().BoolVar(&listAll, "all", false, "自动翻页获取全部结果（忽略 --page-size 和 --page-token）")
+
+	var updateTitle, updateStartURL string
+	update := &cobra.Command{
+		Use:   "update WEBAPP",
+		Short: "更新 Web 应用",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			webApp := &androidmanagement.WebApp{}
+			var updateMask []string
+			if cmd.Flags().Changed("title") {
+				webApp.Title = updateTitle
+				updateMask = append(updateMask, "title")
+			}
+			if cmd.Flags().Changed("start-url") {
+				webApp.StartUrl = updateStartURL
+				updateMask = append(updateMask, "startUrl")
+			}
+			if len(updateMask) == 0 {
+				return fmt.Errorf("nothing to update: specify --title or --start-url")
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			updated, err := c.WebApps().Update(args[0], webApp, updateMask)
+			if err != nil {
+				return err
+			}
+			return a.print(updated, nil)
+		},
+	}
+	update.Flags().StringVar(&updateTitle, "title", "", "应用标题")
+	update.Flags().StringVar(&updateStartURL, "start-url", "", "起始 URL")
+
+	var force bool
+	del := &cobra.Command{
+		Use:   "delete WEBAPP",
+		Short: "删除 Web 应用",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !a.confirm(force, "确定要删除 Web 应用 %s 吗？", args[0]) {
+				fmt.Fprintln(a.out, "已取消")
+				return nil
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			if err := c.WebApps().Delete(args[0]); err != nil {
+				return err
+			}
+			return a.print(map[string]string{"deleted": args[0]}, nil)
+		},
+	}
+	del.Flags().BoolVarP(&force, "force", "f", false, "跳过确认")
+
+	cmd.AddCommand(create, get, list, update, del)
+	return cmd
+}
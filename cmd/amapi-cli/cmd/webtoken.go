@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newWebTokenCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "webtoken",
+		Aliases: []string{"web-token", "web-tokens"},
+		Short:   "管理控制台 Web 令牌",
+	}
+
+	var enterprise, parentFrameURL string
+	var features []string
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "创建用于嵌入托管 Google Play 的 Web 令牌",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireFlag("enterprise", enterprise); err != nil {
+				return err
+			}
+
+			c, err := a.getClient()
+			if err != nil {
+				return err
+			}
+
+			token, err := c.WebTokens().Create(enterpriseName(enterprise), parentFrameURL, features)
+			if err != nil {
+				return err
+			}
+			return a.print(token, nil)
+		},
+	}
+	create.Flags().StringVarP(&enterprise, "enterprise", "e", "", "企业 ID 或资源名称")
+	create.Flags().StringVar(&parentFrameURL, "parent-frame-url", "", "嵌入 iframe 的父页面 URL")
+	create.Flags().StringSliceVar(&features, "features", nil, "启用的功能，例如 PLAY_SEARCH,PRIVATE_APPS")
+
+	cmd.AddCommand(create)
+	return cmd
+}
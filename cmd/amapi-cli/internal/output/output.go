@@ -0,0 +1,138 @@
+// Package output 负责 amapi-cli 的结果输出，支持 JSON、YAML 和表格三种格式。
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Supported output formats.
+const (
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+	FormatTable = "table"
+)
+
+// ValidateFormat checks the --output flag value.
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatJSON, FormatYAML, FormatTable:
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format: %s (supported: json, yaml, table)", format)
+	}
+}
+
+// Table describes how a value is rendered with --output table.
+type Table struct {
+	Headers []string
+	Rows    [][]string
+}
+
+// AddRow appends a row to the table.
+func (t *Table) AddRow(cells ...string) {
+	t.Rows = append(t.Rows, cells)
+}
+
+// Write renders v to w in the given format.
+// If t is nil in table mode, the top-level fields of v are rendered as key/value rows.
+func Write(w io.Writer, format string, v any, t *Table) error {
+	switch format {
+	case FormatYAML:
+		// 先转换为通用结构，以便 YAML 使用与 JSON 相同的字段名
+		generic, err := toGeneric(v)
+		if err != nil {
+			return err
+		}
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(generic); err != nil {
+			return fmt.Errorf("failed to encode YAML: %w", err)
+		}
+		return enc.Close()
+	case FormatTable:
+		if t == nil {
+			generic, err := toGeneric(v)
+			if err != nil {
+				return err
+			}
+			t = keyValueTable(generic)
+		}
+		return writeTable(w, t)
+	default:
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+}
+
+// writeTable renders a table with aligned columns.
+func writeTable(w io.Writer, t *Table) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if len(t.Headers) > 0 {
+		fmt.Fprintln(tw, strings.Join(t.Headers, "\t"))
+	}
+	for _, row := range t.Rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// toGeneric converts v to maps and slices using its JSON representation.
+func toGeneric(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode output: %w", err)
+	}
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, fmt.Errorf("failed to decode output: %w", err)
+	}
+	return generic, nil
+}
+
+// keyValueTable renders the top-level fields of a generic value as FIELD/VALUE rows.
+func keyValueTable(v any) *Table {
+	t := &Table{Headers: []string{"FIELD", "VALUE"}}
+
+	m, ok := v.(map[string]any)
+	if !ok {
+		t.AddRow("value", FormatCell(v))
+		return t
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		t.AddRow(k, FormatCell(m[k]))
+	}
+	return t
+}
+
+// FormatCell formats a generic value for a single table cell.
+func FormatCell(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case map[string]any, []any:
+		data, _ := json.Marshal(val)
+		return string(data)
+	default:
+		return fmt.Sprint(val)
+	}
+}
@@ -0,0 +1,32 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	value := map[string]any{"name": "enterprises/LC1", "count": 2}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatYAML, value, nil); err != nil {
+		t.Fatalf("Write(yaml) unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "name: enterprises/LC1") {
+		t.Errorf("Write(yaml) = %q, missing name field", buf.String())
+	}
+
+	buf.Reset()
+	if err := Write(&buf, FormatTable, value, nil); err != nil {
+		t.Fatalf("Write(table) unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "FIELD") {
+		t.Errorf("Write(table) = %q, want header and 2 rows", buf.String())
+	}
+
+	if err := ValidateFormat("xml"); err == nil {
+		t.Error("ValidateFormat(\"xml\") expected error, got nil")
+	}
+}
@@ -0,0 +1,34 @@
+// Command amapi-cli 是 Android Management API 的命令行工具。
+//
+// amapi-cli 封装了 client 包中的所有服务，通过 config.AutoLoadConfig
+// 加载配置，运维人员无需编写 Go 程序即可完成日常管理操作：
+//
+//	amapi-cli enterprise list
+//	amapi-cli policy list --enterprise LC12345678 --output table
+//	amapi-cli device lock enterprises/LC12345678/devices/device123
+//	amapi-cli health check --detailed
+//
+// 所有命令都支持 --output json|yaml|table。
+// 详细用法请参考 docs/CLI_USAGE.md。
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"amapi-pkg/cmd/amapi-cli/cmd"
+)
+
+// 构建信息，通过 -ldflags 注入（见 Makefile）。
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildDate = "unknown"
+)
+
+func main() {
+	if err := cmd.Execute(cmd.BuildInfo{Version: version, Commit: commit, BuildDate: buildDate}); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
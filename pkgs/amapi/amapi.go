@@ -222,7 +222,9 @@ type (
 //	}
 //	defer c.Close()
 //
+// 可选的 client.Option 用于覆盖 API 地址等，例如在测试中连接 amapitest 服务器。
+//
 // 如果配置无效或认证失败，将返回错误。
-func NewClient(cfg *Config) (*Client, error) {
-	return client.New(cfg)
+func NewClient(cfg *Config, opts ...client.Option) (*Client, error) {
+	return client.New(cfg, opts...)
 }
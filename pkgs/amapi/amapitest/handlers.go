@@ -0,0 +1,538 @@
+package amapitest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apiPrefix is the path prefix of every Android Management API v1 method.
+const apiPrefix = "/v1/"
+
+// commandMetadataType is the @type of the Command stored in an operation's metadata.
+const commandMetadataType = "type.googleapis.com/google.android.devicemanagement.v1.Command"
+
+var errInvalidPageToken = errors.New("invalid page token")
+
+// listKeys maps a collection to the field holding items in its list response.
+var listKeys = map[string]string{
+	"enterprises":      "enterprises",
+	"policies":         "policies",
+	"devices":          "devices",
+	"enrollmentTokens": "enrollmentTokens",
+	"webApps":          "webApps",
+	"migrationTokens":  "migrationTokens",
+	"operations":       "operations",
+}
+
+// apiError is an error response in the Google JSON error format.
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+func newAPIError(status int, format string, args ...any) *apiError {
+	return &apiError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+func notFound(name string) *apiError {
+	return newAPIError(http.StatusNotFound, "Requested entity was not found: %s", name)
+}
+
+// statusNames maps HTTP status codes to google.rpc.Code names.
+var statusNames = map[int]string{
+	http.StatusBadRequest:          "INVALID_ARGUMENT",
+	http.StatusUnauthorized:        "UNAUTHENTICATED",
+	http.StatusForbidden:           "PERMISSION_DENIED",
+	http.StatusNotFound:            "NOT_FOUND",
+	http.StatusConflict:            "ALREADY_EXISTS",
+	http.StatusTooManyRequests:     "RESOURCE_EXHAUSTED",
+	http.StatusInternalServerError: "INTERNAL",
+	http.StatusServiceUnavailable:  "UNAVAILABLE",
+	http.StatusGatewayTimeout:      "DEADLINE_EXCEEDED",
+}
+
+// serveHTTP records the request, applies injected failures and routes it.
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, newAPIError(http.StatusBadRequest, "failed to read request body: %v", err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, Request{
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Query:     r.URL.RawQuery,
+		Body:      string(body),
+		UserAgent: r.UserAgent(),
+	})
+
+	if len(s.failures) > 0 {
+		failure := s.failures[0]
+		s.failures = s.failures[1:]
+		writeError(w, &apiError{status: failure.status, message: failure.message})
+		return
+	}
+
+	result, err := s.route(r, body)
+	if err != nil {
+		var apiErr *apiError
+		if !errors.As(err, &apiErr) {
+			apiErr = newAPIError(http.StatusInternalServerError, "%v", err)
+		}
+		writeError(w, apiErr)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// route dispatches a request to the handler for its resource path.
+// The caller must hold s.mu.
+func (s *Server) route(r *http.Request, body []byte) (any, error) {
+	if !strings.HasPrefix(r.URL.Path, apiPrefix) {
+		return nil, newAPIError(http.StatusNotFound, "unknown path %s", r.URL.Path)
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, apiPrefix)
+	verb := ""
+	if i := strings.LastIndex(path, ":"); i >= 0 && !strings.Contains(path[i:], "/") {
+		path, verb = path[:i], path[i+1:]
+	}
+
+	segments := strings.Split(path, "/")
+	query := r.URL.Query()
+	method := r.Method
+
+	switch {
+	case path == "signupUrls" && method == http.MethodPost:
+		return s.createSignupURL(query)
+
+	case len(segments) == 2 && segments[0] == "provisioningInfo" && method == http.MethodGet:
+		return s.getResource(path)
+
+	case path == "enterprises":
+		switch method {
+		case http.MethodGet:
+			return s.listEnterprises(query)
+		case http.MethodPost:
+			return s.createEnterprise(query, body)
+		}
+
+	case segments[0] != "enterprises":
+		// not an Android Management API resource
+
+	case len(segments) == 2:
+		// enterprises/{enterprise}
+		switch method {
+		case http.MethodGet:
+			return s.getResource(path)
+		case http.MethodPatch:
+			return s.patchResource(path, query, body, false)
+		case http.MethodDelete:
+			return s.deleteResource(path)
+		}
+
+	case len(segments) == 3:
+		// enterprises/{enterprise}/{collection}
+		parent, collection := strings.Join(segments[:2], "/"), segments[2]
+		switch method {
+		case http.MethodGet:
+			return s.listResources(parent, collection, query)
+		case http.MethodPost:
+			return s.createChild(parent, collection, body)
+		}
+
+	case len(segments) == 4:
+		// enterprises/{enterprise}/{collection}/{id}
+		switch {
+		case verb == "issueCommand" && segments[2] == "devices" && method == http.MethodPost:
+			return s.issueCommand(path, body)
+		case verb != "":
+			// unknown custom method
+		case method == http.MethodGet:
+			return s.getResource(path)
+		case method == http.MethodPatch && isPatchable(segments[2]):
+			return s.patchResource(path, query, body, segments[2] == "policies")
+		case method == http.MethodDelete && isDeletable(segments[2]):
+			return s.deleteResource(path)
+		}
+
+	case len(segments) == 5 && segments[2] == "devices" && segments[4] == "operations":
+		if method == http.MethodGet {
+			return s.listResources(path[:strings.LastIndex(path, "/")], "operations", query)
+		}
+
+	case len(segments) == 6 && segments[2] == "devices" && segments[4] == "operations":
+		switch {
+		case verb == "cancel" && method == http.MethodPost:
+			return s.cancelOperation(path)
+		case verb != "":
+			// unknown custom method
+		case method == http.MethodGet:
+			return s.getResource(path)
+		case method == http.MethodDelete:
+			return s.deleteResource(path)
+		}
+	}
+
+	return nil, newAPIError(http.StatusNotFound, "no route for %s %s", r.Method, r.URL.Path)
+}
+
+// isDeletable reports whether resources in collection support DELETE.
+func isDeletable(collection string) bool {
+	switch collection {
+	case "policies", "devices", "webApps", "enrollmentTokens":
+		return true
+	}
+	return false
+}
+
+// isPatchable reports whether resources in collection support PATCH.
+func isPatchable(collection string) bool {
+	switch collection {
+	case "policies", "devices", "webApps":
+		return true
+	}
+	return false
+}
+
+func (s *Server) getResource(name string) (any, error) {
+	r, ok := s.store.get(name)
+	if !ok {
+		return nil, notFound(name)
+	}
+	return r, nil
+}
+
+func (s *Server) deleteResource(name string) (any, error) {
+	if !s.store.delete(name) {
+		return nil, notFound(name)
+	}
+	delete(s.projects, name)
+	return resource{}, nil
+}
+
+// patchResource applies a PATCH request. Policies are created if they do not exist.
+func (s *Server) patchResource(name string, query map[string][]string, body []byte, upsert bool) (any, error) {
+	patch, err := decodeBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	r, ok := s.store.get(name)
+	if !ok {
+		if !upsert {
+			return nil, notFound(name)
+		}
+		if err := s.requireParent(name); err != nil {
+			return nil, err
+		}
+		r = resource{}
+		s.store.put(name, r)
+	}
+
+	updateMask := ""
+	if values := query["updateMask"]; len(values) > 0 {
+		updateMask = values[0]
+	}
+	version, versionCode := int64Field(r, "version"), int64Field(r, "versionCode")
+	applyUpdateMask(r, patch, updateMask)
+
+	// Policies and web apps carry a server-managed version that increases on every update
+	switch {
+	case strings.Contains(name, "/policies/"):
+		r["version"] = strconv.FormatInt(version+1, 10)
+	case strings.Contains(name, "/webApps/"):
+		r["versionCode"] = strconv.FormatInt(versionCode+1, 10)
+	}
+
+	return r, nil
+}
+
+func (s *Server) listResources(parent, collection string, query map[string][]string) (any, error) {
+	key, ok := listKeys[collection]
+	if !ok {
+		return nil, newAPIError(http.StatusNotFound, "collection %s cannot be listed", collection)
+	}
+	if _, ok := s.store.get(parent); !ok {
+		return nil, notFound(parent)
+	}
+
+	return s.listPage(s.store.children(parent, collection), key, query)
+}
+
+func (s *Server) listEnterprises(query map[string][]string) (any, error) {
+	projectID := first(query, "projectId")
+	if projectID == "" {
+		return nil, newAPIError(http.StatusBadRequest, "projectId is required")
+	}
+
+	var items []resource
+	for _, r := range s.store.children("", "enterprises") {
+		if s.projects[r["name"].(string)] == projectID {
+			items = append(items, r)
+		}
+	}
+
+	return s.listPage(items, "enterprises", query)
+}
+
+// listPage builds a list response for one page of items.
+func (s *Server) listPage(items []resource, key string, query map[string][]string) (any, error) {
+	pageSize := s.maxPageSize
+	if value := first(query, "pageSize"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, newAPIError(http.StatusBadRequest, "invalid pageSize %q", value)
+		}
+		if n > 0 && n < pageSize {
+			pageSize = n
+		}
+	}
+
+	items, next, err := page(items, pageSize, first(query, "pageToken"))
+	if err != nil {
+		return nil, newAPIError(http.StatusBadRequest, "%v", err)
+	}
+
+	response := resource{}
+	if len(items) > 0 {
+		response[key] = items
+	}
+	if next != "" {
+		response["nextPageToken"] = next
+	}
+	return response, nil
+}
+
+func (s *Server) createSignupURL(query map[string][]string) (any, error) {
+	if first(query, "projectId") == "" {
+		return nil, newAPIError(http.StatusBadRequest, "projectId is required")
+	}
+
+	id := s.newID("C")
+	token := s.newID("token-")
+	url := s.URL + "/signup?token=" + token
+	if callback := first(query, "callbackUrl"); callback != "" {
+		url += "&callbackUrl=" + callback
+	}
+
+	r := resource{"url": url}
+	s.store.put("signupUrls/"+id, r)
+	return r, nil
+}
+
+func (s *Server) createEnterprise(query map[string][]string, body []byte) (any, error) {
+	projectID := first(query, "projectId")
+	if projectID == "" {
+		return nil, newAPIError(http.StatusBadRequest, "projectId is required")
+	}
+
+	r, err := decodeBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	name := "enterprises/" + s.newID("LC")
+	s.store.put(name, r)
+	s.projects[name] = projectID
+	return r, nil
+}
+
+// createChild handles POST on an enterprise collection.
+func (s *Server) createChild(parent, collection string, body []byte) (any, error) {
+	if _, ok := s.store.get(parent); !ok {
+		return nil, notFound(parent)
+	}
+
+	r, err := decodeBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+
+	switch collection {
+	case "enrollmentTokens":
+		duration := time.Hour
+		if value, ok := r["duration"].(string); ok && value != "" {
+			if duration, err = parseDuration(value); err != nil {
+				return nil, err
+			}
+		}
+		if _, ok := r["policyName"]; !ok {
+			r["policyName"] = parent + "/policies/default"
+		}
+		value := s.newID("ENROLL")
+		r["value"] = value
+		r["duration"] = formatDuration(duration)
+		r["expirationTimestamp"] = now.Add(duration).Format(time.RFC3339Nano)
+		r["qrCode"] = `{"android.app.extra.PROVISIONING_ADMIN_EXTRAS_BUNDLE":{"com.google.android.apps.work.clouddpc.EXTRA_ENROLLMENT_TOKEN":"` + value + `"}}`
+
+	case "webApps":
+		if int64Field(r, "versionCode") == 0 {
+			r["versionCode"] = "1"
+		}
+
+	case "migrationTokens":
+		r["value"] = s.newID("MIGRATE")
+		r["createTime"] = now.Format(time.RFC3339Nano)
+		if _, ok := r["expireTime"]; !ok {
+			r["expireTime"] = now.Add(time.Hour).Format(time.RFC3339Nano)
+		}
+
+	case "webTokens":
+		r["value"] = s.newID("WEB")
+
+	default:
+		return nil, newAPIError(http.StatusNotFound, "collection %s does not support create", collection)
+	}
+
+	s.store.put(parent+"/"+collection+"/"+s.newID(""), r)
+	return r, nil
+}
+
+// issueCommand creates a pending operation for a device command.
+func (s *Server) issueCommand(deviceName string, body []byte) (any, error) {
+	if _, ok := s.store.get(deviceName); !ok {
+		return nil, notFound(deviceName)
+	}
+
+	command, err := decodeBody(body)
+	if err != nil {
+		return nil, err
+	}
+	if commandType, _ := command["type"].(string); commandType == "" {
+		return nil, newAPIError(http.StatusBadRequest, "command type is required")
+	}
+
+	command["@type"] = commandMetadataType
+	if _, ok := command["createTime"]; !ok {
+		command["createTime"] = s.now().UTC().Format(time.RFC3339Nano)
+	}
+
+	op := resource{"metadata": command, "done": false}
+	s.store.put(deviceName+"/operations/"+s.newID(""), op)
+	return op, nil
+}
+
+// cancelOperation finishes a pending operation with a CANCELLED status.
+func (s *Server) cancelOperation(name string) (any, error) {
+	op, ok := s.store.get(name)
+	if !ok {
+		return nil, notFound(name)
+	}
+
+	if done, _ := op["done"].(bool); !done {
+		op["done"] = true
+		op["error"] = resource{"code": 1, "message": "Operation was cancelled"}
+	}
+	return resource{}, nil
+}
+
+// requireParent returns an error if the enterprise owning name does not exist.
+func (s *Server) requireParent(name string) error {
+	segments := strings.Split(name, "/")
+	if len(segments) < 2 {
+		return notFound(name)
+	}
+
+	parent := strings.Join(segments[:2], "/")
+	if _, ok := s.store.get(parent); !ok {
+		return notFound(parent)
+	}
+	return nil
+}
+
+// newID returns a unique identifier with the given prefix.
+// The caller must hold s.mu.
+func (s *Server) newID(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s%06d", prefix, s.nextID)
+}
+
+// writeError writes err in the Google JSON error format understood by googleapi.CheckResponse.
+func writeError(w http.ResponseWriter, err *apiError) {
+	status := statusNames[err.status]
+	if status == "" {
+		status = "UNKNOWN"
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(err.status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{
+			"code":    err.status,
+			"message": err.message,
+			"status":  status,
+		},
+	})
+}
+
+// decodeBody decodes a JSON request body into a resource.
+func decodeBody(body []byte) (resource, error) {
+	r := resource{}
+	if len(body) == 0 {
+		return r, nil
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, newAPIError(http.StatusBadRequest, "invalid JSON payload: %v", err)
+	}
+	return r, nil
+}
+
+// parseDuration parses a protobuf JSON Duration such as "86400s" or "1.5s".
+func parseDuration(value string) (time.Duration, error) {
+	seconds, ok := strings.CutSuffix(value, "s")
+	if !ok {
+		return 0, newAPIError(http.StatusBadRequest, "invalid duration %q: must end with 's'", value)
+	}
+
+	f, err := strconv.ParseFloat(seconds, 64)
+	if err != nil || f <= 0 {
+		return 0, newAPIError(http.StatusBadRequest, "invalid duration %q", value)
+	}
+	return time.Duration(f * float64(time.Second)), nil
+}
+
+// formatDuration formats d as a protobuf JSON Duration.
+func formatDuration(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Second), 10) + "s"
+}
+
+// int64Field reads an int64 field that may be encoded as a JSON string or number.
+func int64Field(r resource, key string) int64 {
+	switch v := r[key].(type) {
+	case string:
+		n, _ := strconv.ParseInt(v, 10, 64)
+		return n
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
+
+// first returns the first value of a query parameter.
+func first(query map[string][]string, key string) string {
+	if values := query[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
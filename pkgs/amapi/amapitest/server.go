@@ -0,0 +1,410 @@
+// Package amapitest 提供用于测试的内存版 Android Management API 服务器。
+//
+// Server 基于 net/http/httptest 实现了 SDK 使用的 REST 接口，在内存中保存企业、策略、
+// 设备、注册令牌、Web 应用、迁移令牌和设备操作，无需网络和 Google Cloud 凭证即可
+// 测试基于 client.Client 的代码：
+//
+//	srv := amapitest.NewServer()
+//	defer srv.Close()
+//
+//	enterprise := srv.AddEnterprise(&androidmanagement.Enterprise{EnterpriseDisplayName: "Test"})
+//	srv.AddDevice(&androidmanagement.Device{Name: enterprise.Name + "/devices/d1", State: "ACTIVE"})
+//
+//	c, err := srv.NewClient()
+//	if err != nil {
+//	    t.Fatal(err)
+//	}
+//	defer c.Close()
+//
+//	devices, err := c.Devices().ListAll(enterprise.Name, "", nil, "")
+//
+// 通过 issueCommand 创建的操作保持未完成状态，直到调用 CompleteOperation。
+// FailNext 可以让后续请求返回指定的 HTTP 错误，用于测试重试和错误处理。
+//
+// Server 可以在多个 goroutine 中并发使用。
+package amapitest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/client"
+	"amapi-pkg/pkgs/amapi/config"
+)
+
+// DefaultMaxPageSize is the page size used when a list request does not set pageSize
+// or asks for more items than the server allows.
+const DefaultMaxPageSize = 100
+
+// ProjectID is the Google Cloud project ID reported by Server.Config.
+const ProjectID = "amapitest-project"
+
+// Request records one request received by the server.
+type Request struct {
+	Method    string
+	Path      string
+	Query     string
+	Body      string
+	UserAgent string
+}
+
+// injectedError is an error queued by FailNext.
+type injectedError struct {
+	status  int
+	message string
+}
+
+// Server is an in-memory fake of the Android Management API.
+type Server struct {
+	// URL is the base URL of the fake, suitable for client.WithEndpoint
+	URL string
+
+	httpServer *httptest.Server
+
+	mu          sync.Mutex
+	store       *store
+	projects    map[string]string // enterprise name -> project ID
+	failures    []injectedError
+	requests    []Request
+	maxPageSize int
+	nextID      int
+	now         func() time.Time
+}
+
+// NewServer starts a new fake server. Call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		store:       newStore(),
+		projects:    make(map[string]string),
+		maxPageSize: DefaultMaxPageSize,
+		now:         time.Now,
+	}
+
+	s.httpServer = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.httpServer.URL
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.httpServer.Close()
+}
+
+// Client returns an HTTP client that talks to the server.
+func (s *Server) Client() *http.Client {
+	return s.httpServer.Client()
+}
+
+// Config returns a valid client configuration for the server.
+//
+// 配置中的凭证只用于通过 Validate，不会被使用。重试延迟很短，限流足够宽松，
+// 以免拖慢测试。
+func (s *Server) Config() *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.ProjectID = ProjectID
+	cfg.CredentialsJSON = `{"type":"service_account","project_id":"` + ProjectID + `"}`
+	cfg.Timeout = 10 * time.Second
+	cfg.RetryDelay = 10 * time.Millisecond
+	cfg.RateLimit = 10000
+	cfg.RateBurst = 10000
+	return cfg
+}
+
+// ClientOptions returns the options that point a client at the server.
+func (s *Server) ClientOptions() []client.Option {
+	return []client.Option{
+		client.WithEndpoint(s.URL),
+		client.WithHTTPClient(s.Client()),
+	}
+}
+
+// NewClient creates a client for the server using Config and ClientOptions.
+func (s *Server) NewClient() (*client.Client, error) {
+	return client.New(s.Config(), s.ClientOptions()...)
+}
+
+// SetMaxPageSize limits the number of items returned per list page.
+func (s *Server) SetMaxPageSize(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n <= 0 {
+		n = DefaultMaxPageSize
+	}
+	s.maxPageSize = n
+}
+
+// SetClock replaces the clock used for timestamps and token expiry.
+func (s *Server) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now == nil {
+		now = time.Now
+	}
+	s.now = now
+}
+
+// FailNext makes the next request fail with the given HTTP status and message.
+// Calls queue up: each request consumes one injected failure.
+func (s *Server) FailNext(status int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, injectedError{status: status, message: message})
+}
+
+// Requests returns the requests received so far, oldest first.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Reset removes all resources, queued failures and recorded requests.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store = newStore()
+	s.projects = make(map[string]string)
+	s.failures = nil
+	s.requests = nil
+}
+
+// AddEnterprise stores an enterprise and returns the stored copy.
+// A name is generated if e.Name is empty.
+func (s *Server) AddEnterprise(e *androidmanagement.Enterprise) *androidmanagement.Enterprise {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := e.Name
+	if name == "" {
+		name = "enterprises/" + s.newID("LC")
+	}
+	s.projects[name] = ProjectID
+
+	out := &androidmanagement.Enterprise{}
+	s.mustSeed(name, e, out)
+	return out
+}
+
+// AddPolicy stores a policy. p.Name must be a full policy resource name.
+func (s *Server) AddPolicy(p *androidmanagement.Policy) *androidmanagement.Policy {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := &androidmanagement.Policy{}
+	s.mustSeed(requireName(p.Name, "policy"), p, out)
+	return out
+}
+
+// AddDevice stores a device. d.Name must be a full device resource name.
+func (s *Server) AddDevice(d *androidmanagement.Device) *androidmanagement.Device {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := &androidmanagement.Device{}
+	s.mustSeed(requireName(d.Name, "device"), d, out)
+	return out
+}
+
+// AddEnrollmentToken stores an enrollment token. t.Name must be a full resource name.
+func (s *Server) AddEnrollmentToken(t *androidmanagement.EnrollmentToken) *androidmanagement.EnrollmentToken {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := &androidmanagement.EnrollmentToken{}
+	s.mustSeed(requireName(t.Name, "enrollment token"), t, out)
+	return out
+}
+
+// AddWebApp stores a web app. w.Name must be a full resource name.
+func (s *Server) AddWebApp(w *androidmanagement.WebApp) *androidmanagement.WebApp {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := &androidmanagement.WebApp{}
+	s.mustSeed(requireName(w.Name, "web app"), w, out)
+	return out
+}
+
+// AddMigrationToken stores a migration token. t.Name must be a full resource name.
+func (s *Server) AddMigrationToken(t *androidmanagement.MigrationToken) *androidmanagement.MigrationToken {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := &androidmanagement.MigrationToken{}
+	s.mustSeed(requireName(t.Name, "migration token"), t, out)
+	return out
+}
+
+// AddApplication stores an application returned by enterprises.applications.get.
+// a.Name must be a full resource name such as enterprises/e1/applications/com.example.app.
+func (s *Server) AddApplication(a *androidmanagement.Application) *androidmanagement.Application {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := &androidmanagement.Application{}
+	s.mustSeed(requireName(a.Name, "application"), a, out)
+	return out
+}
+
+// AddProvisioningInfo stores provisioning info. p.Name must be provisioningInfo/{id}.
+func (s *Server) AddProvisioningInfo(p *androidmanagement.ProvisioningInfo) *androidmanagement.ProvisioningInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := &androidmanagement.ProvisioningInfo{}
+	s.mustSeed(requireName(p.Name, "provisioning info"), p, out)
+	return out
+}
+
+// AddOperation stores a device operation. op.Name must be a full operation resource name.
+func (s *Server) AddOperation(op *androidmanagement.Operation) *androidmanagement.Operation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := &androidmanagement.Operation{}
+	s.mustSeed(requireName(op.Name, "operation"), op, out)
+	return out
+}
+
+// Enterprise returns the stored enterprise, or nil if it does not exist.
+func (s *Server) Enterprise(name string) *androidmanagement.Enterprise {
+	out := &androidmanagement.Enterprise{}
+	if !s.lookup(name, out) {
+		return nil
+	}
+	return out
+}
+
+// Policy returns the stored policy, or nil if it does not exist.
+func (s *Server) Policy(name string) *androidmanagement.Policy {
+	out := &androidmanagement.Policy{}
+	if !s.lookup(name, out) {
+		return nil
+	}
+	return out
+}
+
+// Device returns the stored device, or nil if it does not exist.
+func (s *Server) Device(name string) *androidmanagement.Device {
+	out := &androidmanagement.Device{}
+	if !s.lookup(name, out) {
+		return nil
+	}
+	return out
+}
+
+// EnrollmentToken returns the stored enrollment token, or nil if it does not exist.
+func (s *Server) EnrollmentToken(name string) *androidmanagement.EnrollmentToken {
+	out := &androidmanagement.EnrollmentToken{}
+	if !s.lookup(name, out) {
+		return nil
+	}
+	return out
+}
+
+// WebApp returns the stored web app, or nil if it does not exist.
+func (s *Server) WebApp(name string) *androidmanagement.WebApp {
+	out := &androidmanagement.WebApp{}
+	if !s.lookup(name, out) {
+		return nil
+	}
+	return out
+}
+
+// MigrationToken returns the stored migration token, or nil if it does not exist.
+func (s *Server) MigrationToken(name string) *androidmanagement.MigrationToken {
+	out := &androidmanagement.MigrationToken{}
+	if !s.lookup(name, out) {
+		return nil
+	}
+	return out
+}
+
+// Operation returns the stored operation, or nil if it does not exist.
+func (s *Server) Operation(name string) *androidmanagement.Operation {
+	out := &androidmanagement.Operation{}
+	if !s.lookup(name, out) {
+		return nil
+	}
+	return out
+}
+
+// Operations returns the operations of a device, sorted by name.
+func (s *Server) Operations(deviceName string) []*androidmanagement.Operation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ops []*androidmanagement.Operation
+	for _, r := range s.store.children(deviceName, "operations") {
+		op := &androidmanagement.Operation{}
+		if err := fromResource(r, op); err != nil {
+			panic("amapitest: " + err.Error())
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// CompleteOperation marks an operation as done. If status is not nil the
+// operation finishes with that error, otherwise it succeeds.
+// It returns false if the operation does not exist.
+func (s *Server) CompleteOperation(name string, status *androidmanagement.Status) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.store.get(name)
+	if !ok {
+		return false
+	}
+
+	r["done"] = true
+	if status != nil {
+		errResource, err := toResource(status)
+		if err != nil {
+			panic("amapitest: " + err.Error())
+		}
+		r["error"] = errResource
+	}
+	return true
+}
+
+// lookup copies the stored resource into out.
+func (s *Server) lookup(name string, out any) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.store.get(name)
+	if !ok {
+		return false
+	}
+	if err := fromResource(r, out); err != nil {
+		panic("amapitest: " + err.Error())
+	}
+	return true
+}
+
+// mustSeed stores v under name and copies the stored form into out.
+// The caller must hold s.mu.
+func (s *Server) mustSeed(name string, v, out any) {
+	r, err := toResource(v)
+	if err != nil {
+		panic("amapitest: " + err.Error())
+	}
+	s.store.put(name, r)
+
+	if err := fromResource(r, out); err != nil {
+		panic("amapitest: " + err.Error())
+	}
+}
+
+// requireName panics if a seeded resource has no name.
+func requireName(name, kind string) string {
+	if name == "" {
+		panic("amapitest: " + kind + " name is required")
+	}
+	return name
+}
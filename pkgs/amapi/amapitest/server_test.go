@@ -0,0 +1,297 @@
+package amapitest_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/amapitest"
+	"amapi-pkg/pkgs/amapi/client"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// newServerAndClient 启动假服务器并创建指向它的客户端
+func newServerAndClient(t *testing.T) (*amapitest.Server, *client.Client) {
+	t.Helper()
+
+	srv := amapitest.NewServer()
+	t.Cleanup(srv.Close)
+
+	c, err := srv.NewClient()
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	return srv, c
+}
+
+// 测试企业的创建、查询、更新和删除
+func TestEnterpriseLifecycle(t *testing.T) {
+	srv, c := newServerAndClient(t)
+
+	signup, err := c.Enterprises().GenerateSignupURL("", "https://example.com/callback", "", "", "")
+	if err != nil {
+		t.Fatalf("GenerateSignupURL() unexpected error: %v", err)
+	}
+	if signup.CompletionToken == "" {
+		t.Errorf("GenerateSignupURL() returned no completion token: %s", signup.URL)
+	}
+
+	created, err := c.Enterprises().Create("signupUrls/C1", "", "enterprise-token", nil)
+	if err != nil {
+		t.Fatalf("Create() unexpected error: %v", err)
+	}
+
+	updated, err := c.Enterprises().SetPubSubTopic(created.Name, "projects/p/topics/amapi")
+	if err != nil {
+		t.Fatalf("SetPubSubTopic() unexpected error: %v", err)
+	}
+	if updated.PubsubTopic != "projects/p/topics/amapi" {
+		t.Errorf("PubsubTopic = %q", updated.PubsubTopic)
+	}
+	if srv.Enterprise(created.Name).PubsubTopic != "projects/p/topics/amapi" {
+		t.Error("server did not store the update")
+	}
+
+	list, err := c.Enterprises().ListAll("")
+	if err != nil || len(list.Items) != 1 {
+		t.Fatalf("ListAll() = %v, %v, want 1 enterprise", list, err)
+	}
+
+	if err := c.Enterprises().Delete(created.Name); err != nil {
+		t.Fatalf("Delete() unexpected error: %v", err)
+	}
+
+	_, err = c.Enterprises().Get(created.Name)
+	var apiErr *types.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusNotFound {
+		t.Errorf("Get() after delete error = %v, want 404", err)
+	}
+}
+
+// 测试策略的创建、按 updateMask 更新和删除
+func TestPolicyLifecycle(t *testing.T) {
+	srv, c := newServerAndClient(t)
+	enterprise := srv.AddEnterprise(&androidmanagement.Enterprise{EnterpriseDisplayName: "Test"})
+
+	policy, err := c.Policies().Create(enterprise.Name, "default", &androidmanagement.Policy{
+		CameraDisabled:        true,
+		ScreenCaptureDisabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Create() unexpected error: %v", err)
+	}
+	if policy.Name != enterprise.Name+"/policies/default" || policy.Version != 1 {
+		t.Errorf("Create() = %s version %d", policy.Name, policy.Version)
+	}
+
+	policy, err = c.Policies().Update(policy.Name, &androidmanagement.Policy{CameraDisabled: false}, []string{"cameraDisabled"})
+	if err != nil {
+		t.Fatalf("Update() unexpected error: %v", err)
+	}
+	if policy.CameraDisabled || !policy.ScreenCaptureDisabled || policy.Version != 2 {
+		t.Errorf("Update() = %+v, want camera enabled, screen capture still disabled, version 2", policy)
+	}
+
+	if err := c.Policies().Delete(policy.Name); err != nil {
+		t.Fatalf("Delete() unexpected error: %v", err)
+	}
+	if srv.Policy(policy.Name) != nil {
+		t.Error("policy still stored after delete")
+	}
+}
+
+// 测试设备列表分页
+func TestDevicePagination(t *testing.T) {
+	srv, c := newServerAndClient(t)
+	enterprise := srv.AddEnterprise(&androidmanagement.Enterprise{})
+
+	for i := 0; i < 7; i++ {
+		state := "ACTIVE"
+		if i%3 == 0 {
+			state = "DISABLED"
+		}
+		srv.AddDevice(&androidmanagement.Device{
+			Name:  fmt.Sprintf("%s/devices/d%d", enterprise.Name, i),
+			State: state,
+		})
+	}
+	srv.SetMaxPageSize(3)
+
+	first, err := c.Devices().List(enterprise.Name, 0, "", "", nil, "")
+	if err != nil {
+		t.Fatalf("List() unexpected error: %v", err)
+	}
+	if len(first.Items) != 3 || first.NextPageToken == "" {
+		t.Errorf("List() returned %d items, token %q, want 3 items and a token", len(first.Items), first.NextPageToken)
+	}
+
+	all, err := c.Devices().ListAll(enterprise.Name, "", nil, "")
+	if err != nil {
+		t.Fatalf("ListAll() unexpected error: %v", err)
+	}
+	if len(all.Items) != 7 {
+		t.Errorf("ListAll() returned %d devices, want 7", len(all.Items))
+	}
+
+	active, err := c.Devices().ListAll(enterprise.Name, types.DeviceStateActive, nil, "")
+	if err != nil {
+		t.Fatalf("ListAll(ACTIVE) unexpected error: %v", err)
+	}
+	if len(active.Items) != 4 {
+		t.Errorf("ListAll(ACTIVE) returned %d devices, want 4", len(active.Items))
+	}
+}
+
+// 测试设备命令生成待完成的操作
+func TestIssueCommandOperations(t *testing.T) {
+	srv, c := newServerAndClient(t)
+	enterprise := srv.AddEnterprise(&androidmanagement.Enterprise{})
+	device := srv.AddDevice(&androidmanagement.Device{Name: enterprise.Name + "/devices/d1", State: "ACTIVE"})
+
+	op, err := c.Devices().Lock(device.Name, "600s")
+	if err != nil {
+		t.Fatalf("Lock() unexpected error: %v", err)
+	}
+	if op.Done || !strings.HasPrefix(op.Name, device.Name+"/operations/") {
+		t.Errorf("Lock() = %+v, want pending operation of the device", op)
+	}
+
+	if !srv.CompleteOperation(op.Name, nil) {
+		t.Fatal("CompleteOperation() returned false")
+	}
+	got, err := c.Devices().GetOperation(op.Name)
+	if err != nil || !got.Done || got.Error != nil {
+		t.Errorf("GetOperation() = %+v, %v, want successful operation", got, err)
+	}
+
+	reboot, err := c.Devices().Reboot(device.Name)
+	if err != nil {
+		t.Fatalf("Reboot() unexpected error: %v", err)
+	}
+	if err := c.Devices().CancelOperation(reboot.Name); err != nil {
+		t.Fatalf("CancelOperation() unexpected error: %v", err)
+	}
+	if canceled := srv.Operation(reboot.Name); !canceled.Done || canceled.Error == nil || canceled.Error.Code != 1 {
+		t.Errorf("canceled operation = %+v, want CANCELLED error", canceled)
+	}
+
+	ops, err := c.Devices().GetOperations(device.Name)
+	if err != nil || len(ops) != 2 {
+		t.Errorf("GetOperations() = %d operations, %v, want 2", len(ops), err)
+	}
+
+	if _, err := c.Devices().Reboot(enterprise.Name + "/devices/missing"); err == nil {
+		t.Error("Reboot(missing device) expected error, got nil")
+	}
+}
+
+// 测试注册令牌使用秒格式的有效期并计算过期时间
+func TestEnrollmentTokens(t *testing.T) {
+	srv, c := newServerAndClient(t)
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	srv.SetClock(func() time.Time { return now })
+
+	enterprise := srv.AddEnterprise(&androidmanagement.Enterprise{})
+	policyName := enterprise.Name + "/policies/default"
+	srv.AddPolicy(&androidmanagement.Policy{Name: policyName})
+
+	token, err := c.EnrollmentTokens().Create(enterprise.Name, policyName, 24*time.Hour, false, true, nil)
+	if err != nil {
+		t.Fatalf("Create() unexpected error: %v", err)
+	}
+	if token.Duration != "86400s" || token.Value == "" {
+		t.Errorf("Create() = duration %q value %q", token.Duration, token.Value)
+	}
+	if expected := now.Add(24 * time.Hour).Format(time.RFC3339Nano); token.ExpirationTimestamp != expected {
+		t.Errorf("ExpirationTimestamp = %q, want %q", token.ExpirationTimestamp, expected)
+	}
+
+	tokens, err := c.EnrollmentTokens().ListAll(enterprise.Name, policyName, true)
+	if err != nil || len(tokens.Items) != 1 {
+		t.Fatalf("ListAll() = %v, %v, want 1 token", tokens, err)
+	}
+
+	if err := c.EnrollmentTokens().Delete(token.Name); err != nil {
+		t.Fatalf("Delete() unexpected error: %v", err)
+	}
+	if srv.EnrollmentToken(token.Name) != nil {
+		t.Error("token still stored after delete")
+	}
+}
+
+// 测试 Web 应用、迁移令牌、Web 令牌和预配信息
+func TestOtherResources(t *testing.T) {
+	srv, c := newServerAndClient(t)
+	enterprise := srv.AddEnterprise(&androidmanagement.Enterprise{})
+
+	webApp, err := c.WebApps().Create(enterprise.Name, "https://example.com", nil, 0)
+	if err != nil {
+		t.Fatalf("WebApps().Create() unexpected error: %v", err)
+	}
+	webApp, err = c.WebApps().Update(webApp.Name, &androidmanagement.WebApp{Title: "Example"}, []string{"title"})
+	if err != nil || webApp.Title != "Example" || webApp.StartUrl != "https://example.com" || webApp.VersionCode != 2 {
+		t.Errorf("WebApps().Update() = %+v, %v", webApp, err)
+	}
+
+	migration, err := c.MigrationTokens().Create(enterprise.Name, enterprise.Name+"/policies/default")
+	if err != nil || migration.Value == "" {
+		t.Fatalf("MigrationTokens().Create() = %+v, %v", migration, err)
+	}
+	if got, err := c.MigrationTokens().Get(migration.Name); err != nil || got.Value != migration.Value {
+		t.Errorf("MigrationTokens().Get() = %+v, %v", got, err)
+	}
+
+	webToken, err := c.WebTokens().Create(enterprise.Name, "https://admin.example.com", nil)
+	if err != nil || webToken.Value == "" {
+		t.Errorf("WebTokens().Create() = %+v, %v", webToken, err)
+	}
+
+	srv.AddProvisioningInfo(&androidmanagement.ProvisioningInfo{Name: "provisioningInfo/abc", Model: "Pixel 8"})
+	info, err := c.ProvisioningInfo().Get("provisioningInfo/abc")
+	if err != nil || info.Model != "Pixel 8" {
+		t.Errorf("ProvisioningInfo().Get() = %+v, %v", info, err)
+	}
+}
+
+// 测试注入的错误：可重试错误会被客户端重试，其他错误直接返回
+func TestFailNext(t *testing.T) {
+	srv, c := newServerAndClient(t)
+	enterprise := srv.AddEnterprise(&androidmanagement.Enterprise{})
+
+	srv.FailNext(http.StatusServiceUnavailable, "backend unavailable")
+	if _, err := c.Enterprises().Get(enterprise.Name); err != nil {
+		t.Fatalf("Get() after one 503 unexpected error: %v", err)
+	}
+
+	gets := 0
+	for _, req := range srv.Requests() {
+		if req.Method == http.MethodGet && req.Path == "/v1/"+enterprise.Name {
+			gets++
+		}
+	}
+	if gets != 2 {
+		t.Errorf("server received %d GET requests, want 2", gets)
+	}
+
+	cfg := srv.Config()
+	cfg.EnableRetry = false
+	noRetry, err := client.New(cfg, srv.ClientOptions()...)
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+	defer noRetry.Close()
+
+	srv.FailNext(http.StatusForbidden, "caller lacks permission")
+	_, err = noRetry.Enterprises().Get(enterprise.Name)
+	var apiErr *types.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusForbidden || apiErr.Message != "caller lacks permission" {
+		t.Errorf("Get() error = %v, want 403", err)
+	}
+}
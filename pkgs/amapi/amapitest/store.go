@@ -0,0 +1,143 @@
+package amapitest
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// resource is a stored API resource in its JSON object form.
+type resource = map[string]any
+
+// store holds resources keyed by their full resource name.
+// It is not safe for concurrent use; the Server serialises access.
+type store struct {
+	resources map[string]resource
+}
+
+func newStore() *store {
+	return &store{resources: make(map[string]resource)}
+}
+
+func (s *store) get(name string) (resource, bool) {
+	r, ok := s.resources[name]
+	return r, ok
+}
+
+func (s *store) put(name string, r resource) {
+	r["name"] = name
+	s.resources[name] = r
+}
+
+// delete removes name and every resource nested under it.
+func (s *store) delete(name string) bool {
+	if _, ok := s.resources[name]; !ok {
+		return false
+	}
+
+	prefix := name + "/"
+	for key := range s.resources {
+		if key == name || strings.HasPrefix(key, prefix) {
+			delete(s.resources, key)
+		}
+	}
+	return true
+}
+
+// children returns the direct children of parent in collection, sorted by name.
+// For example children("enterprises/e1", "devices") returns enterprises/e1/devices/*.
+func (s *store) children(parent, collection string) []resource {
+	prefix := collection + "/"
+	if parent != "" {
+		prefix = parent + "/" + prefix
+	}
+
+	var names []string
+	for key := range s.resources {
+		if strings.HasPrefix(key, prefix) && !strings.Contains(key[len(prefix):], "/") {
+			names = append(names, key)
+		}
+	}
+	sort.Strings(names)
+
+	items := make([]resource, 0, len(names))
+	for _, name := range names {
+		items = append(items, s.resources[name])
+	}
+	return items
+}
+
+// page returns one page of items. The page token is the offset of the first item.
+func page(items []resource, pageSize int, pageToken string) ([]resource, string, error) {
+	offset := 0
+	if pageToken != "" {
+		n, err := strconv.Atoi(pageToken)
+		if err != nil || n < 0 || n > len(items) {
+			return nil, "", errInvalidPageToken
+		}
+		offset = n
+	}
+
+	end := offset + pageSize
+	if end >= len(items) {
+		return items[offset:], "", nil
+	}
+	return items[offset:end], strconv.Itoa(end), nil
+}
+
+// toResource converts an API struct into its JSON object form.
+func toResource(v any) (resource, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	r := resource{}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// fromResource converts a stored resource into the API struct v.
+func fromResource(r resource, v any) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// applyUpdateMask copies the fields named in updateMask from patch into r.
+// An empty mask replaces every field except the name. Nested paths such as
+// "hardwareInfo.model" update the whole top-level field.
+func applyUpdateMask(r, patch resource, updateMask string) {
+	if updateMask == "" {
+		name := r["name"]
+		for key := range r {
+			delete(r, key)
+		}
+		for key, value := range patch {
+			r[key] = value
+		}
+		r["name"] = name
+		return
+	}
+
+	for _, path := range strings.Split(updateMask, ",") {
+		field := strings.TrimSpace(path)
+		if i := strings.Index(field, "."); i >= 0 {
+			field = field[:i]
+		}
+		if field == "" || field == "name" {
+			continue
+		}
+
+		if value, ok := patch[field]; ok {
+			r[field] = value
+		} else {
+			delete(r, field)
+		}
+	}
+}
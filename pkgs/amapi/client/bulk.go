@@ -0,0 +1,311 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/query"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// DefaultBulkConcurrency is the number of workers used by BulkIssueCommand when BulkOptions.Concurrency is not set.
+const DefaultBulkConcurrency = 10
+
+// BulkOptions configures BulkIssueCommand.
+type BulkOptions struct {
+	// Concurrency is the number of devices processed in parallel. Default: DefaultBulkConcurrency
+	Concurrency int
+
+	// DryRun validates the command and device names without issuing any command
+	DryRun bool
+
+	// Wait waits for each operation to finish and records its CommandResult
+	Wait bool
+
+	// WaitOptions configures the wait; nil uses the WaitForOperation defaults
+	WaitOptions *WaitOptions
+
+	// OnProgress is called after each device is processed. Calls are serialized.
+	OnProgress func(BulkProgress)
+}
+
+// BulkResult is the outcome of a bulk command for one device.
+type BulkResult struct {
+	// DeviceName is the device resource name
+	DeviceName string `json:"device_name"`
+
+	// Operation is the operation created by the command; nil on dry run or if issuing failed
+	Operation *androidmanagement.Operation `json:"operation,omitempty"`
+
+	// Result is the final state of the operation when BulkOptions.Wait is set
+	Result *types.CommandResult `json:"result,omitempty"`
+
+	// Error is set if the command could not be issued, the wait failed, or the command failed or was cancelled
+	Error *types.Error `json:"error,omitempty"`
+}
+
+// BulkProgress reports the progress of a bulk command.
+type BulkProgress struct {
+	// Completed is the number of devices processed so far
+	Completed int
+
+	// Total is the number of target devices
+	Total int
+
+	// Failed is the number of devices with an error so far
+	Failed int
+
+	// Result is the result of the device that was just processed
+	Result *BulkResult
+}
+
+// BulkReport is the aggregated result of a bulk command.
+type BulkReport struct {
+	// Results holds one result per target device, in the order of the device names
+	Results []*BulkResult `json:"results"`
+
+	// Succeeded is the number of devices without an error
+	Succeeded int `json:"succeeded"`
+
+	// Failed is the number of devices with an error
+	Failed int `json:"failed"`
+
+	// DryRun is true if no command was issued
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// Failures returns the results with an error.
+func (r *BulkReport) Failures() []*BulkResult {
+	var failures []*BulkResult
+	for _, result := range r.Results {
+		if result.Error != nil {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// BulkIssueCommand issues the same command to many devices with bounded concurrency.
+//
+// 所有 worker 共享客户端的速率限制器和重试处理器（包括 Redis 分布式实现），
+// 因此并发数只决定同时进行的请求数量，不会突破配置的速率限制。
+// 单台设备失败不会中断其他设备，每台设备的结果记录在 BulkReport.Results 中：
+//
+//	command, _ := types.NewLockCommand("600s")
+//	report, err := client.Devices().BulkIssueCommand(ctx, deviceNames, command, &client.BulkOptions{
+//	    Concurrency: 20,
+//	    Wait:        true,
+//	    OnProgress: func(p client.BulkProgress) {
+//	        log.Printf("%d/%d (失败 %d)", p.Completed, p.Total, p.Failed)
+//	    },
+//	})
+//	if err != nil {
+//	    return err // 命令无效
+//	}
+//	for _, failure := range report.Failures() {
+//	    log.Printf("%s: %v", failure.DeviceName, failure.Error)
+//	}
+//
+// 在 WithIdempotencyKey 视图上调用时，每台设备的操作 ID 为 "操作名称:key:设备名称"。
+// 重复的设备名称只处理一次。只有命令本身无效时才返回 error；
+// ctx 取消后尚未处理的设备记录 ErrCodeTimeout 错误。
+func (ds *DeviceService) BulkIssueCommand(ctx context.Context, deviceNames []string, command *types.DeviceCommand, opts *BulkOptions) (*BulkReport, error) {
+	if err := command.Validate(); err != nil {
+		return nil, err
+	}
+
+	o := BulkOptions{}
+	if opts != nil {
+		o = *opts
+	}
+
+	service := ds.WithContext(ctx)
+	return runBulk(deviceNames, o, func(deviceName string) *BulkResult {
+		return service.forDevice(deviceName).issueBulkCommand(ctx, deviceName, command, o)
+	}), nil
+}
+
+// forDevice returns the view of the service used for one device of a bulk operation.
+//
+// 视图设置了幂等 key 时，每台设备使用 "key:设备名称"，否则所有 worker 共享同一个操作 ID，
+// 争用同一个重试锁和重试计数。
+func (ds *DeviceService) forDevice(deviceName string) *DeviceService {
+	key := ds.client.idempotencyKey
+	if key == "" {
+		return ds
+	}
+	return &DeviceService{client: ds.client.WithIdempotencyKey(key + ":" + deviceName)}
+}
+
+// runBulk calls process for every unique name with o.Concurrency workers and aggregates the results.
+func runBulk(names []string, o BulkOptions, process func(name string) *BulkResult) *BulkReport {
+	if o.Concurrency <= 0 {
+		o.Concurrency = DefaultBulkConcurrency
+	}
+
+	names = uniqueStrings(names)
+	report := &BulkReport{Results: make([]*BulkResult, len(names)), DryRun: o.DryRun}
+	if len(names) == 0 {
+		return report
+	}
+
+	indexes := make(chan int)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < min(o.Concurrency, len(names)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				result := process(names[index])
+
+				mu.Lock()
+				report.Results[index] = result
+				if result.Error != nil {
+					report.Failed++
+				} else {
+					report.Succeeded++
+				}
+				if o.OnProgress != nil {
+					o.OnProgress(BulkProgress{
+						Completed: report.Succeeded + report.Failed,
+						Total:     len(names),
+						Failed:    report.Failed,
+						Result:    result,
+					})
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for index := range names {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+
+	return report
+}
+
+// DeviceListFilter selects the target devices of BulkIssueCommandByFilter, like the List filters.
+type DeviceListFilter struct {
+	State           types.DeviceState
+	PolicyCompliant *bool
+	UserName        string
+
+	// Query is an optional filter expression evaluated like Query, in addition to the other filters
+	Query string
+}
+
+// BulkIssueCommandByFilter issues a command to every device of an enterprise matching filter.
+//
+// 目标设备通过 ListAll 获取，因此与 List 的过滤条件一致；设置 filter.Query 时还需满足该表达式。
+// 配合 DryRun 可以先预览目标设备。
+func (ds *DeviceService) BulkIssueCommandByFilter(ctx context.Context, enterpriseName string, filter DeviceListFilter, command *types.DeviceCommand, opts *BulkOptions) (*BulkReport, error) {
+	if err := command.Validate(); err != nil {
+		return nil, err
+	}
+
+	var matches *query.Filter
+	if filter.Query != "" {
+		var err error
+		if matches, err = query.Parse(filter.Query); err != nil {
+			return nil, err
+		}
+		if err := matches.ValidateFields(reflect.TypeOf(androidmanagement.Device{})); err != nil {
+			return nil, err
+		}
+	}
+
+	devices, err := ds.WithContext(ctx).ListAll(enterpriseName, filter.State, filter.PolicyCompliant, filter.UserName)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	deviceNames := make([]string, 0, len(devices.Items))
+	for _, device := range devices.Items {
+		if matches != nil {
+			ok, err := matches.MatchAt(device, now)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		deviceNames = append(deviceNames, device.Name)
+	}
+
+	return ds.BulkIssueCommand(ctx, deviceNames, command, opts)
+}
+
+// issueBulkCommand processes one device of a bulk command.
+func (ds *DeviceService) issueBulkCommand(ctx context.Context, deviceName string, command *types.DeviceCommand, o BulkOptions) *BulkResult {
+	result := &BulkResult{DeviceName: deviceName}
+
+	if err := ctx.Err(); err != nil {
+		result.Error = asError(contextError(err))
+		return result
+	}
+	if _, _, err := parseDeviceName(deviceName); err != nil {
+		result.Error = asError(err)
+		return result
+	}
+	if o.DryRun {
+		return result
+	}
+
+	op, err := ds.IssueDeviceCommand(deviceName, command)
+	if err != nil {
+		result.Error = asError(err)
+		return result
+	}
+	result.Operation = op
+
+	if !o.Wait {
+		return result
+	}
+
+	commandResult, err := ds.WaitForOperation(ctx, op.Name, o.WaitOptions)
+	result.Result = commandResult
+	if err != nil {
+		result.Error = asError(err)
+		return result
+	}
+	if opErr := commandResult.Err(); opErr != nil {
+		result.Error = types.NewErrorWithCause(types.ErrCodePreconditionFailed, "device command "+string(commandResult.Status), opErr)
+		result.Error.Details = opErr.Error()
+	}
+
+	return result
+}
+
+// asError returns err as a *types.Error, wrapping errors of other types.
+func asError(err error) *types.Error {
+	var apiErr *types.Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return types.WrapError(err, types.ErrCodeInternalServerError, err.Error())
+}
+
+// uniqueStrings returns values without duplicates, keeping the first occurrence.
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
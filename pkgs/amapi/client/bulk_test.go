@@ -0,0 +1,181 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/client"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// 测试批量命令的结果、进度回调和错误汇总
+func TestBulkIssueCommand(t *testing.T) {
+	srv, c, device := newDeviceTestClient(t)
+	enterpriseName := device.Name[:len(device.Name)-len("/devices/d1")]
+	for _, id := range []string{"d2", "d3", "d4"} {
+		srv.AddDevice(&androidmanagement.Device{Name: enterpriseName + "/devices/" + id, State: "ACTIVE"})
+	}
+
+	deviceNames := []string{
+		enterpriseName + "/devices/d1",
+		enterpriseName + "/devices/d2",
+		enterpriseName + "/devices/missing",
+		enterpriseName + "/devices/d3",
+		"not-a-device",
+		enterpriseName + "/devices/d4",
+		enterpriseName + "/devices/d1",
+	}
+
+	command, err := types.NewLockCommand("600s")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var progress []client.BulkProgress
+	report, err := c.Devices().BulkIssueCommand(context.Background(), deviceNames, command, &client.BulkOptions{
+		Concurrency: 3,
+		OnProgress: func(p client.BulkProgress) {
+			mu.Lock()
+			progress = append(progress, p)
+			mu.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatalf("BulkIssueCommand() unexpected error: %v", err)
+	}
+
+	if len(report.Results) != 6 || report.Succeeded != 4 || report.Failed != 2 {
+		t.Fatalf("report = %d results, %d succeeded, %d failed, want 6, 4, 2", len(report.Results), report.Succeeded, report.Failed)
+	}
+	for i, want := range deviceNames[:6] {
+		if report.Results[i].DeviceName != want {
+			t.Errorf("Results[%d].DeviceName = %q, want %q", i, report.Results[i].DeviceName, want)
+		}
+	}
+
+	missing := report.Results[2]
+	if missing.Error == nil || missing.Error.Code != types.ErrCodeNotFound || missing.Operation != nil {
+		t.Errorf("missing device result = %+v, want not found error", missing)
+	}
+	if invalid := report.Results[4]; invalid.Error == nil || invalid.Error.Code != types.ErrCodeInvalidInput {
+		t.Errorf("invalid device result = %+v, want invalid input error", invalid)
+	}
+	if ok := report.Results[0]; ok.Error != nil || ok.Operation == nil {
+		t.Errorf("d1 result = %+v, want operation", ok)
+	}
+	if len(srv.Operations(enterpriseName+"/devices/d1")) != 1 {
+		t.Error("duplicate device name issued the command twice")
+	}
+	if failures := report.Failures(); len(failures) != 2 {
+		t.Errorf("Failures() = %d results, want 2", len(failures))
+	}
+
+	if len(progress) != 6 || progress[5].Completed != 6 || progress[5].Total != 6 || progress[5].Failed != 2 {
+		t.Errorf("progress = %+v, want 6 calls ending at 6/6 with 2 failed", progress)
+	}
+
+	// 无效命令在处理任何设备之前返回错误
+	if _, err := c.Devices().BulkIssueCommand(context.Background(), deviceNames, &types.DeviceCommand{}, nil); err == nil {
+		t.Error("BulkIssueCommand() expected error for invalid command")
+	}
+}
+
+// 测试 dry run 不发出命令
+func TestBulkIssueCommandDryRun(t *testing.T) {
+	srv, c, device := newDeviceTestClient(t)
+
+	command, _ := types.NewRebootCommand()
+	report, err := c.Devices().BulkIssueCommand(context.Background(), []string{device.Name, "bad"}, command, &client.BulkOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("BulkIssueCommand() unexpected error: %v", err)
+	}
+	if !report.DryRun || report.Succeeded != 1 || report.Failed != 1 || report.Results[0].Operation != nil {
+		t.Errorf("report = %+v, want dry run with one valid and one invalid device", report)
+	}
+	if ops := srv.Operations(device.Name); len(ops) != 0 {
+		t.Errorf("dry run created %d operations, want 0", len(ops))
+	}
+}
+
+// 测试幂等 key 视图上的批量命令为每台设备使用独立的操作 ID
+func TestBulkIssueCommandIdempotencyKey(t *testing.T) {
+	var buf bytes.Buffer
+	srv, c := newLoggedClient(t, false, &buf)
+	enterprise := srv.AddEnterprise(&androidmanagement.Enterprise{})
+	var deviceNames []string
+	for _, id := range []string{"d1", "d2", "d3"} {
+		device := srv.AddDevice(&androidmanagement.Device{Name: enterprise.Name + "/devices/" + id, State: "ACTIVE"})
+		deviceNames = append(deviceNames, device.Name)
+	}
+
+	command, _ := types.NewRebootCommand()
+	report, err := c.WithIdempotencyKey("job-42").Devices().BulkIssueCommand(context.Background(), deviceNames, command, nil)
+	if err != nil || report.Failed != 0 {
+		t.Fatalf("BulkIssueCommand() = %+v, %v", report, err)
+	}
+
+	var got []string
+	for _, record := range logRecords(t, &buf, "API call completed") {
+		got = append(got, record["operation_id"].(string))
+	}
+	sort.Strings(got)
+	want := []string{
+		"issue_device_command:job-42:" + deviceNames[0],
+		"issue_device_command:job-42:" + deviceNames[1],
+		"issue_device_command:job-42:" + deviceNames[2],
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("operation IDs = %q, want %q", got, want)
+	}
+}
+
+// 测试等待完成以及按过滤条件选择目标设备
+func TestBulkIssueCommandByFilterWait(t *testing.T) {
+	srv, c, device := newDeviceTestClient(t)
+	enterpriseName := device.Name[:len(device.Name)-len("/devices/d1")]
+	srv.AddDevice(&androidmanagement.Device{Name: enterpriseName + "/devices/d2", State: "ACTIVE"})
+	srv.AddDevice(&androidmanagement.Device{Name: enterpriseName + "/devices/d3", State: "DISABLED"})
+
+	command, _ := types.NewRebootCommand()
+	opts := &client.BulkOptions{
+		Wait: true,
+		WaitOptions: fastWait(func(r *types.CommandResult) {
+			// 模拟设备执行命令：d2 失败，其他成功
+			if r.Status != types.CommandStatusPending {
+				return
+			}
+			var status *androidmanagement.Status
+			if strings.HasPrefix(r.Name, enterpriseName+"/devices/d2/") {
+				status = &androidmanagement.Status{Code: types.RPCCodeFailedPrecondition, Message: "device offline"}
+			}
+			srv.CompleteOperation(r.Name, status)
+		}),
+	}
+
+	report, err := c.Devices().BulkIssueCommandByFilter(context.Background(), enterpriseName,
+		client.DeviceListFilter{State: types.DeviceStateActive}, command, opts)
+	if err != nil {
+		t.Fatalf("BulkIssueCommandByFilter() unexpected error: %v", err)
+	}
+
+	if len(report.Results) != 2 || report.Succeeded != 1 || report.Failed != 1 {
+		t.Fatalf("report = %d results, %d succeeded, %d failed, want 2 active devices with 1 failure", len(report.Results), report.Succeeded, report.Failed)
+	}
+	for _, result := range report.Results {
+		if result.Result == nil || !result.Result.Status.IsTerminal() {
+			t.Errorf("%s: Result = %+v, want finished command", result.DeviceName, result.Result)
+		}
+		if result.DeviceName == enterpriseName+"/devices/d2" &&
+			(result.Error == nil || result.Error.Code != types.ErrCodePreconditionFailed || result.Result.Status != types.CommandStatusFailed) {
+			t.Errorf("d2 result = %+v, want failed command", result)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package client_test
+
+import (
+	"net/http"
+	"testing"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/amapitest"
+	"amapi-pkg/pkgs/amapi/client"
+)
+
+// newCachedClient 创建启用缓存、指向假服务器的客户端
+func newCachedClient(t *testing.T) (*amapitest.Server, *client.Client) {
+	t.Helper()
+
+	srv := amapitest.NewServer()
+	t.Cleanup(srv.Close)
+
+	cfg := srv.Config()
+	cfg.EnableCache = true
+
+	c, err := client.New(cfg, srv.ClientOptions()...)
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	return srv, c
+}
+
+// countGets 统计服务器收到的指定路径的 GET 请求数
+func countGets(srv *amapitest.Server, name string) int {
+	n := 0
+	for _, req := range srv.Requests() {
+		if req.Method == http.MethodGet && req.Path == "/v1/"+name {
+			n++
+		}
+	}
+	return n
+}
+
+// 测试 Get 和 List 的结果被缓存
+func TestCacheReadThrough(t *testing.T) {
+	srv, c := newCachedClient(t)
+	enterprise := srv.AddEnterprise(&androidmanagement.Enterprise{EnterpriseDisplayName: "Cached"})
+	device := srv.AddDevice(&androidmanagement.Device{Name: enterprise.Name + "/devices/d1", State: "ACTIVE"})
+	srv.AddApplication(&androidmanagement.Application{Name: enterprise.Name + "/applications/com.example.app", Title: "Example"})
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Enterprises().Get(enterprise.Name); err != nil {
+			t.Fatalf("Enterprises().Get() unexpected error: %v", err)
+		}
+		if _, err := c.Devices().Get(device.Name); err != nil {
+			t.Fatalf("Devices().Get() unexpected error: %v", err)
+		}
+		if _, err := c.Devices().List(enterprise.Name, 10, "", "", nil, ""); err != nil {
+			t.Fatalf("Devices().List() unexpected error: %v", err)
+		}
+		if _, err := c.Enterprises().GetApplication(enterprise.Name, "com.example.app"); err != nil {
+			t.Fatalf("GetApplication() unexpected error: %v", err)
+		}
+	}
+
+	for _, name := range []string{enterprise.Name, device.Name, enterprise.Name + "/devices", enterprise.Name + "/applications/com.example.app"} {
+		if n := countGets(srv, name); n != 1 {
+			t.Errorf("GET %s sent %d times, want 1", name, n)
+		}
+	}
+
+	// 返回的对象是副本，修改不会影响缓存
+	got, _ := c.Devices().Get(device.Name)
+	got.State = "DELETED"
+	if again, _ := c.Devices().Get(device.Name); again.State != "ACTIVE" {
+		t.Errorf("cached device was modified through a returned value: %s", again.State)
+	}
+
+	// WithoutCache 直接访问 API
+	if _, err := c.WithoutCache().Devices().Get(device.Name); err != nil {
+		t.Fatalf("WithoutCache().Get() unexpected error: %v", err)
+	}
+	if n := countGets(srv, device.Name); n != 2 {
+		t.Errorf("GET after WithoutCache sent %d times, want 2", n)
+	}
+}
+
+// 测试修改操作使缓存失效
+func TestCacheInvalidation(t *testing.T) {
+	srv, c := newCachedClient(t)
+	enterprise := srv.AddEnterprise(&androidmanagement.Enterprise{})
+
+	policy, err := c.Policies().Create(enterprise.Name, "default", &androidmanagement.Policy{CameraDisabled: true})
+	if err != nil {
+		t.Fatalf("Create() unexpected error: %v", err)
+	}
+
+	if list, err := c.Policies().List(enterprise.Name, 0, ""); err != nil || len(list.Items) != 1 {
+		t.Fatalf("List() = %v, %v, want 1 policy", list, err)
+	}
+	if _, err := c.Policies().Get(policy.Name); err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+
+	if _, err := c.Policies().Update(policy.Name, &androidmanagement.Policy{CameraDisabled: false}, []string{"cameraDisabled"}); err != nil {
+		t.Fatalf("Update() unexpected error: %v", err)
+	}
+
+	got, err := c.Policies().Get(policy.Name)
+	if err != nil || got.CameraDisabled || got.Version != 2 {
+		t.Errorf("Get() after Update = %+v, %v, want updated policy", got, err)
+	}
+
+	if err := c.Policies().Delete(policy.Name); err != nil {
+		t.Fatalf("Delete() unexpected error: %v", err)
+	}
+	if list, err := c.Policies().List(enterprise.Name, 0, ""); err != nil || len(list.Items) != 0 {
+		t.Errorf("List() after Delete = %v, %v, want no policies", list, err)
+	}
+}
+
+// 测试手动使缓存失效
+func TestInvalidateCache(t *testing.T) {
+	srv, c := newCachedClient(t)
+	enterprise := srv.AddEnterprise(&androidmanagement.Enterprise{EnterpriseDisplayName: "Before"})
+
+	if _, err := c.Enterprises().Get(enterprise.Name); err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+
+	// 资源在 SDK 之外被修改
+	srv.AddEnterprise(&androidmanagement.Enterprise{Name: enterprise.Name, EnterpriseDisplayName: "After"})
+
+	if got, _ := c.Enterprises().Get(enterprise.Name); got.EnterpriseDisplayName != "Before" {
+		t.Fatalf("Get() = %q, want cached value", got.EnterpriseDisplayName)
+	}
+
+	if err := c.InvalidateCache(enterprise.Name); err != nil {
+		t.Fatalf("InvalidateCache() unexpected error: %v", err)
+	}
+	if got, _ := c.Enterprises().Get(enterprise.Name); got.EnterpriseDisplayName != "After" {
+		t.Errorf("Get() after InvalidateCache = %q, want After", got.EnterpriseDisplayName)
+	}
+}
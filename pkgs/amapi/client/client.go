@@ -46,12 +46,47 @@
 //
 // 每个服务都有完整的 CRUD 操作方法。
 //
+// # 按调用设置 Context
+//
+// 默认情况下所有调用都使用创建客户端时传入的 context。
+// 需要为单次调用设置超时、取消或传递链路信息时，使用 WithContext：
+//
+//	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+//	defer cancel()
+//
+//	devices, err := client.WithContext(ctx).Devices().List(enterpriseName, 100, "", "", nil, "")
+//	// 或者
+//	devices, err := client.Devices().WithContext(ctx).List(enterpriseName, 100, "", "", nil, "")
+//
+// 该 context 同时作用于速率限制等待、重试等待和 HTTP 请求本身。
+//
+// # 日志
+//
+// 每次 API 调用都会通过 log/slog 记录一条摘要日志，默认按照 config.LogLevel 写到标准错误输出。
+// 成功的调用记录为 debug 级别，因此默认只输出重试和失败。
+// 使用 WithLogger 注入自己的 logger：
+//
+//	client, err := New(cfg, WithLogger(slog.New(slog.NewJSONHandler(os.Stdout, nil))))
+//
+// 启用 config.EnableDebugLogging 后，debug 级别的日志会包含请求体和响应体，
+// 令牌值和凭据字段会被隐藏。
+//
+// # 链路追踪和指标
+//
+// 客户端使用 OpenTelemetry 为每次逻辑调用创建 span（速率限制等待和每次重试是子 span），
+// 并记录调用次数、错误码、重试次数和等待时间等指标。
+// 默认使用 otel 的全局 provider，也可以通过 WithTelemetry 指定：
+//
+//	client, err := New(cfg, WithTelemetry(tracerProvider, meterProvider))
+//
 // 更多详细信息请参考各服务类型的文档。
 package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"strings"
@@ -131,6 +166,24 @@ type Client struct {
 	// redisClient is the Redis client (if using Redis for distributed rate limiting/retry)
 	redisClient *redis.Client
 
+	// cache stores Get/List responses (nil if caching is disabled)
+	cache utils.CacheInterface
+
+	// history stores policy version snapshots (nil if policy history is disabled)
+	history utils.HistoryStore
+
+	// logger receives API call logs
+	logger *slog.Logger
+
+	// telemetry creates spans and records metrics for API calls
+	telemetry *telemetry
+
+	// idempotencyKey overrides the derived retry operation ID for views created by WithIdempotencyKey
+	idempotencyKey string
+
+	// skipCacheReads bypasses cache lookups for views created by WithoutCache
+	skipCacheReads bool
+
 	// info contains client information
 	info *types.ClientInfo
 }
@@ -168,12 +221,14 @@ type Client struct {
 //	}
 //
 //	client, err := New(cfg)
-func New(cfg *config.Config) (*Client, error) {
-	return newClientWithContext(context.Background(), cfg)
+//
+// 可选的 Option 用于覆盖 API 地址或 HTTP 客户端，例如在测试中连接 amapitest 服务器。
+func New(cfg *config.Config, opts ...Option) (*Client, error) {
+	return newClientWithContext(context.Background(), cfg, opts...)
 }
 
 // newClientWithContext 是内部的客户端创建函数，支持自定义 context
-func newClientWithContext(ctx context.Context, cfg *config.Config) (*Client, error) {
+func newClientWithContext(ctx context.Context, cfg *config.Config, opts ...Option) (*Client, error) {
 	if cfg == nil {
 		return nil, types.NewError(types.ErrCodeConfiguration, "configuration is required")
 	}
@@ -182,14 +237,36 @@ func newClientWithContext(ctx context.Context, cfg *config.Config) (*Client, err
 		return nil, types.WrapError(err, types.ErrCodeConfiguration, "invalid configuration")
 	}
 
+	options := applyOptions(opts)
+
+	logger := options.logger
+	if logger == nil {
+		logger = newDefaultLogger(cfg)
+	}
+
 	// Create HTTP client with authentication
-	httpClient, err := createHTTPClient(ctx, cfg)
+	httpClient := options.httpClient
+	if httpClient == nil {
+		var err error
+		httpClient, err = createHTTPClient(ctx, cfg)
+		if err != nil {
+			return nil, types.WrapError(err, types.ErrCodeAuthentication, "failed to create HTTP client")
+		}
+	}
+	httpClient = newLoggingHTTPClient(httpClient, logger, cfg.EnableDebugLogging)
+
+	tel, err := newTelemetry(options.tracerProvider, options.meterProvider)
 	if err != nil {
-		return nil, types.WrapError(err, types.ErrCodeAuthentication, "failed to create HTTP client")
+		return nil, types.WrapError(err, types.ErrCodeConfiguration, "failed to create telemetry instruments")
 	}
 
 	// Create Android Management API service
-	service, err := androidmanagement.NewService(ctx, option.WithHTTPClient(httpClient))
+	serviceOptions := []option.ClientOption{option.WithHTTPClient(httpClient)}
+	if options.endpoint != "" {
+		serviceOptions = append(serviceOptions, option.WithEndpoint(strings.TrimSuffix(options.endpoint, "/")+"/"))
+	}
+
+	service, err := androidmanagement.NewService(ctx, serviceOptions...)
 	if err != nil {
 		return nil, types.WrapError(err, types.ErrCodeConfiguration, "failed to create Android Management service")
 	}
@@ -236,6 +313,37 @@ func newClientWithContext(ctx context.Context, cfg *config.Config) (*Client, err
 		rateLimiter = utils.NewRateLimiter(cfg.RateLimit, cfg.RateBurst)
 	}
 
+	// Create response cache (Redis or local) if enabled
+	var cache utils.CacheInterface
+	if cfg.EnableCache {
+		if redisClient != nil && cfg.UseRedisCache {
+			cache = utils.NewRedisCache(redisClient, cfg.RedisKeyPrefix)
+		} else {
+			cache = utils.NewLRUCache(cfg.CacheSize)
+		}
+	}
+
+	// Create policy history store if configured; WithPolicyHistory takes precedence
+	history := options.history
+	if history == nil {
+		switch cfg.PolicyHistory {
+		case "memory":
+			history = utils.NewMemoryHistoryStore(cfg.PolicyHistoryLimit)
+		case "dir":
+			history, err = utils.NewDirHistoryStore(cfg.PolicyHistoryDir, cfg.PolicyHistoryLimit)
+			if err != nil {
+				if redisClient != nil {
+					redisClient.Close()
+				}
+				return nil, types.WrapError(err, types.ErrCodeConfiguration, "failed to create policy history directory")
+			}
+		case "redis":
+			if redisClient != nil {
+				history = utils.NewRedisHistoryStore(redisClient, cfg.RedisKeyPrefix, cfg.PolicyHistoryLimit)
+			}
+		}
+	}
+
 	// Create client info
 	clientInfo := &types.ClientInfo{
 		Version:   ClientVersion,
@@ -259,6 +367,10 @@ func newClientWithContext(ctx context.Context, cfg *config.Config) (*Client, err
 		retryHandler: retryHandler,
 		rateLimiter:  rateLimiter,
 		redisClient:  redisClient,
+		cache:        cache,
+		history:      history,
+		logger:       logger,
+		telemetry:    tel,
 		info:         clientInfo,
 	}
 
@@ -276,8 +388,30 @@ func newClientWithContext(ctx context.Context, cfg *config.Config) (*Client, err
 //	defer cancel()
 //
 //	client, err := NewWithContext(ctx, cfg)
-func NewWithContext(ctx context.Context, cfg *config.Config) (*Client, error) {
-	return newClientWithContext(ctx, cfg)
+func NewWithContext(ctx context.Context, cfg *config.Config, opts ...Option) (*Client, error) {
+	return newClientWithContext(ctx, cfg, opts...)
+}
+
+// WithContext returns a view of the client whose API calls use ctx.
+//
+// 返回的客户端与原客户端共享底层连接、速率限制器和重试处理器，
+// 创建开销很小，可以为每个请求创建一次。ctx 会用于速率限制等待、
+// 重试等待以及 HTTP 请求，取消 ctx 会立即终止正在进行的调用。
+//
+// 注意：只应对原客户端调用 Close()，视图之间共享资源。
+func (c *Client) WithContext(ctx context.Context) *Client {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	view := *c
+	view.ctx = ctx
+	return &view
+}
+
+// Context returns the context used for API calls made by this client.
+func (c *Client) Context() context.Context {
+	return c.ctx
 }
 
 // createHTTPClient creates an authenticated HTTP client.
@@ -335,6 +469,20 @@ func (c *Client) Close() error {
 		}
 	}
 
+	// Close response cache
+	if c.cache != nil {
+		if err := c.cache.Close(); err != nil {
+			return err
+		}
+	}
+
+	// Close policy history store
+	if c.history != nil {
+		if err := c.history.Close(); err != nil {
+			return err
+		}
+	}
+
 	// Close retry handler
 	if c.retryHandler != nil {
 		if err := c.retryHandler.Close(); err != nil {
@@ -377,42 +525,70 @@ func (c *Client) Health() error {
 	return nil
 }
 
-// executeWithRetry executes a function with retry logic.
-func (c *Client) executeWithRetry(operation func() error) error {
-	if !c.config.EnableRetry {
-		return operation()
+// executeAPICall executes an API call with rate limiting and retry logic.
+//
+// req describes the call for logs, telemetry and retry coordination. call receives
+// the context to pass to the API request; it carries the per-call logging state.
+func (c *Client) executeAPICall(req apiRequest, call func(ctx context.Context) error) error {
+	// Fail fast if the caller has already given up
+	if err := c.ctx.Err(); err != nil {
+		return contextError(err)
 	}
 
-	// Generate operation ID for distributed retry coordination
-	operationID := fmt.Sprintf("%d", time.Now().UnixNano())
+	info := &callInfo{operation: req.operation, operationID: req.operationID(c.idempotencyKey)}
+	ctx, span := c.telemetry.startCall(context.WithValue(c.ctx, callInfoKey{}, info), info)
+	start := time.Now()
+
+	err := c.runAPICall(ctx, info, call)
+	latency := time.Since(start)
 
-	return c.retryHandler.Execute(c.ctx, operationID, operation)
+	c.telemetry.endCall(ctx, span, info, latency, err, c.errorCode(err, req.operation))
+	c.logCall(ctx, info, latency, err)
+	return err
 }
 
-// withRateLimit applies rate limiting to an operation.
-func (c *Client) withRateLimit(operation func() error) error {
-	if err := c.rateLimiter.Wait(c.ctx); err != nil {
+// runAPICall applies rate limiting and retries to call, recording attempts in info.
+func (c *Client) runAPICall(ctx context.Context, info *callInfo, call func(ctx context.Context) error) error {
+	// Apply rate limiting first
+	waitCtx, waitSpan := c.telemetry.startRateLimitWait(ctx)
+	waitStart := time.Now()
+	err := c.rateLimiter.Wait(waitCtx)
+	info.rateLimitWait = time.Since(waitStart)
+	c.telemetry.endRateLimitWait(ctx, waitSpan, info, err)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return contextError(ctxErr)
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return contextError(err)
+		}
 		return types.WrapError(err, types.ErrCodeTooManyRequests, "rate limit exceeded")
 	}
 
-	return operation()
-}
+	attempt := func() error {
+		info.attempt++
+		if info.attempt > 1 {
+			c.logger.LogAttrs(ctx, slog.LevelWarn, "retrying API call",
+				slog.String("operation", info.operation),
+				slog.String("resource", info.resource),
+				slog.Int("attempt", info.attempt),
+				slog.Int("previous_status", info.status))
+		}
 
-// executeAPICall executes an API call with rate limiting and retry logic.
-func (c *Client) executeAPICall(operation func() error) error {
-	// Apply rate limiting first
-	if err := c.rateLimiter.Wait(c.ctx); err != nil {
-		return types.WrapError(err, types.ErrCodeTooManyRequests, "rate limit exceeded")
+		attemptCtx, span := c.telemetry.startAttempt(ctx, info)
+		info.status = 0
+		err := call(attemptCtx)
+		c.telemetry.endAttempt(span, info, err)
+		return err
 	}
 
 	// Then apply retry logic
 	if !c.config.EnableRetry {
-		return operation()
+		return attempt()
 	}
 
-	// Generate operation ID for distributed retry coordination
-	operationID := fmt.Sprintf("%d", time.Now().UnixNano())
-	return c.retryHandler.Execute(c.ctx, operationID, operation)
+	// The operation ID is stable across processes for distributed retry coordination
+	return c.retryHandler.Execute(ctx, info.operationID, attempt)
 }
 
 // wrapAPIError wraps API errors with additional context.
@@ -426,6 +602,11 @@ func (c *Client) wrapAPIError(err error, operation string) error {
 		return apiErr
 	}
 
+	// Cancellation and deadlines come from the caller and must not be retried
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return contextError(err)
+	}
+
 	// Determine error code based on error type
 	code := types.ErrCodeInternalServerError
 	message := fmt.Sprintf("%s failed", operation)
@@ -439,6 +620,19 @@ func (c *Client) wrapAPIError(err error, operation string) error {
 	return types.NewErrorWithCause(code, message, err)
 }
 
+// contextError converts a context cancellation into a non-retryable timeout error.
+// The original error is kept as the cause so errors.Is(err, context.Canceled) still works.
+func contextError(err error) error {
+	message := "operation canceled"
+	if errors.Is(err, context.DeadlineExceeded) {
+		message = "operation deadline exceeded"
+	}
+
+	wrapped := types.WrapError(err, types.ErrCodeTimeout, message)
+	wrapped.Retryable = false
+	return wrapped
+}
+
 // Utility methods
 
 // validateResourceName 验证资源名称格式并返回解析后的组件
@@ -548,6 +742,14 @@ func buildEnterpriseName(enterpriseID string) string {
 	return buildResourceName("enterprises", enterpriseID)
 }
 
+// resolveEnterpriseName accepts an enterprise ID or a full enterprise resource name.
+func resolveEnterpriseName(enterprise string) string {
+	if strings.HasPrefix(enterprise, "enterprises/") {
+		return enterprise
+	}
+	return buildEnterpriseName(enterprise)
+}
+
 // buildDeviceName builds a device resource name.
 func buildDeviceName(enterpriseID, deviceID string) string {
 	return buildResourceName("enterprises", enterpriseID, "devices", deviceID)
@@ -2,12 +2,17 @@ package client
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"strings"
 	"testing"
 	"time"
 
 	"amapi-pkg/pkgs/amapi/config"
 	"amapi-pkg/pkgs/amapi/types"
+	"amapi-pkg/pkgs/amapi/utils"
 )
 
 // 测试常量是否正确定义
@@ -214,6 +219,226 @@ func TestNewWithContextThreadSafety(t *testing.T) {
 	}
 }
 
+// newTestClient 创建不依赖网络的客户端，用于测试调用路径
+func newTestClient(ctx context.Context) *Client {
+	tel, _ := newTelemetry(nil, nil)
+	return &Client{
+		config:      &config.Config{EnableRetry: true},
+		ctx:         ctx,
+		logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		telemetry:   tel,
+		rateLimiter: utils.NewRateLimiter(100, 10),
+		retryHandler: utils.NewRetryHandler(utils.RetryConfig{
+			MaxAttempts: 3,
+			BaseDelay:   time.Hour,
+			EnableRetry: true,
+		}),
+	}
+}
+
+// 测试 WithContext 返回的视图不影响原客户端
+func TestWithContext(t *testing.T) {
+	base := newTestClient(context.Background())
+
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	view := base.WithContext(ctx)
+
+	if view.Context() != ctx {
+		t.Error("WithContext() view does not use the given context")
+	}
+	if base.Context() != context.Background() {
+		t.Error("WithContext() modified the original client")
+	}
+	if view.rateLimiter != base.rateLimiter || view.retryHandler != base.retryHandler {
+		t.Error("WithContext() view should share rate limiter and retry handler")
+	}
+	if base.Devices().WithContext(ctx).client.Context() != ctx {
+		t.Error("DeviceService.WithContext() does not use the given context")
+	}
+}
+
+// 测试已取消的 context 会阻止 API 调用
+func TestExecuteAPICallCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	err := newTestClient(context.Background()).WithContext(ctx).executeAPICall(apiRequest{operation: "test"}, func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+
+	if called {
+		t.Error("executeAPICall() ran the operation with a canceled context")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("executeAPICall() error = %v, want context.Canceled", err)
+	}
+	if apiErr, ok := err.(*types.Error); !ok || apiErr.IsRetryable() {
+		t.Errorf("executeAPICall() error = %#v, want non-retryable *types.Error", err)
+	}
+}
+
+// 测试截止时间之前无法获得限流配额时返回超时错误而不是 429
+func TestExecuteAPICallRateLimitDeadline(t *testing.T) {
+	c := newTestClient(context.Background())
+	c.rateLimiter = utils.NewRateLimiter(1, 1)
+	if !c.rateLimiter.Allow(context.Background()) {
+		t.Fatal("Allow() = false, want the only token")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	called := false
+	err := c.WithContext(ctx).executeAPICall(apiRequest{operation: "test"}, func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+
+	var apiErr *types.Error
+	if called || !errors.As(err, &apiErr) || apiErr.Code != types.ErrCodeTimeout || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("executeAPICall() = %v, called = %v, want timeout without calling", err, called)
+	}
+}
+
+// 测试 context 取消后不再重试
+func TestExecuteAPICallStopsRetryOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	attempts := 0
+	done := make(chan error, 1)
+	go func() {
+		done <- newTestClient(ctx).executeAPICall(apiRequest{operation: "test"}, func(ctx context.Context) error {
+			attempts++
+			cancel()
+			return types.NewError(types.ErrCodeServiceUnavailable, "unavailable")
+		})
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("executeAPICall() expected error, got nil")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("executeAPICall() kept waiting after the context was canceled")
+	}
+
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1", attempts)
+	}
+}
+
+// 测试 context 错误被包装为不可重试的超时错误
+func TestWrapAPIErrorContext(t *testing.T) {
+	c := newTestClient(context.Background())
+
+	err := c.wrapAPIError(context.DeadlineExceeded, "list devices")
+	apiErr, ok := err.(*types.Error)
+	if !ok {
+		t.Fatalf("wrapAPIError() = %T, want *types.Error", err)
+	}
+	if apiErr.Code != types.ErrCodeTimeout || apiErr.IsRetryable() {
+		t.Errorf("wrapAPIError() = %+v, want non-retryable timeout", apiErr)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("wrapAPIError() lost the original context error")
+	}
+}
+
+// fakePages 返回按页面令牌索引的分页数据
+func fakePages(pages [][]int, calls *int) pageFetcher[int] {
+	return func(pageToken string) (*types.ListResult[int], error) {
+		*calls++
+		index := 0
+		if pageToken != "" {
+			fmt.Sscanf(pageToken, "page-%d", &index)
+		}
+
+		result := &types.ListResult[int]{Items: pages[index]}
+		if index+1 < len(pages) {
+			result.NextPageToken = fmt.Sprintf("page-%d", index+1)
+		}
+		return result, nil
+	}
+}
+
+// 测试分页迭代器会跟随 NextPageToken 读取所有页面
+func TestPaginate(t *testing.T) {
+	calls := 0
+	result, err := collect(paginate(fakePages([][]int{{1, 2}, {3}, {}, {4, 5}}, &calls)))
+	if err != nil {
+		t.Fatalf("collect() unexpected error: %v", err)
+	}
+
+	expected := []int{1, 2, 3, 4, 5}
+	if len(result.Items) != len(expected) || result.TotalCount != len(expected) {
+		t.Fatalf("collect() = %v, want %v", result.Items, expected)
+	}
+	for i, v := range expected {
+		if result.Items[i] != v {
+			t.Errorf("Items[%d] = %d, want %d", i, result.Items[i], v)
+		}
+	}
+	if calls != 4 {
+		t.Errorf("fetched %d pages, want 4", calls)
+	}
+}
+
+// 测试提前结束迭代时不会请求后续页面
+func TestPaginateEarlyBreak(t *testing.T) {
+	calls := 0
+	for v, err := range paginate(fakePages([][]int{{1, 2}, {3}}, &calls)) {
+		if err != nil {
+			t.Fatalf("paginate() unexpected error: %v", err)
+		}
+		if v == 2 {
+			break
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("fetched %d pages, want 1", calls)
+	}
+}
+
+// 测试分页出错时返回已收集的结果和错误
+func TestPaginateError(t *testing.T) {
+	fetchErr := types.NewError(types.ErrCodeServiceUnavailable, "unavailable")
+	fetch := func(pageToken string) (*types.ListResult[int], error) {
+		if pageToken == "" {
+			return &types.ListResult[int]{Items: []int{1}, NextPageToken: "next"}, nil
+		}
+		return nil, fetchErr
+	}
+
+	result, err := collect(paginate(fetch))
+	if err != fetchErr {
+		t.Errorf("collect() error = %v, want %v", err, fetchErr)
+	}
+	if len(result.Items) != 1 {
+		t.Errorf("collect() returned %d items, want 1", len(result.Items))
+	}
+}
+
+// 测试服务端重复返回同一页面令牌时迭代会终止
+func TestPaginateRepeatedToken(t *testing.T) {
+	calls := 0
+	fetch := func(pageToken string) (*types.ListResult[int], error) {
+		calls++
+		return &types.ListResult[int]{Items: []int{calls}, NextPageToken: "same"}, nil
+	}
+
+	if _, err := collect(paginate(fetch)); err != nil {
+		t.Fatalf("collect() unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("fetched %d pages, want 2", calls)
+	}
+}
+
 // 测试验证函数
 func TestValidationFunctions(t *testing.T) {
 	tests := []struct {
@@ -282,4 +507,4 @@ func BenchmarkParseDeviceName(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		_, _, _ = parseDeviceName(deviceName)
 	}
-}
\ No newline at end of file
+}
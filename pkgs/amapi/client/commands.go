@@ -0,0 +1,171 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"google.golang.org/api/androidmanagement/v1"
+	"google.golang.org/api/googleapi"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// IssueDeviceCommand validates and issues a typed command to a device.
+//
+// 命令先由 types.DeviceCommand.Validate 校验，再通过 DeviceCommand.APICommand 转换为
+// androidmanagement.Command，使用生成客户端发送（见 IssueCommand）。当前依赖的生成客户端
+// 不包含 REQUEST_DEVICE_INFO、WIPE、ADD_ESIM、REMOVE_ESIM 的参数，带有这些参数的命令
+// 以 types.DeviceCommand 的 JSON 形式直接发送到 enterprises.devices.issueCommand。
+// 两种方式都经过速率限制、重试、日志和链路追踪。
+//
+//	command, err := types.NewResetPasswordCommand("s3cret-pin", types.ResetPasswordFlagLockNow)
+//	if err != nil {
+//	    return err // 参数校验失败，命令不会发送
+//	}
+//	op, err := client.Devices().IssueDeviceCommand(deviceName, command)
+func (ds *DeviceService) IssueDeviceCommand(deviceName string, command *types.DeviceCommand) (*androidmanagement.Operation, error) {
+	if deviceName == "" {
+		return nil, types.NewError(types.ErrCodeInvalidInput, "device name is required")
+	}
+
+	if err := command.Validate(); err != nil {
+		return nil, err
+	}
+
+	if apiCommand, ok := command.APICommand(); ok {
+		return ds.IssueCommand(deviceName, apiCommand)
+	}
+
+	var result *androidmanagement.Operation
+	var err error
+
+	err = ds.client.executeAPICall(apiRequest{operation: "issue device command", resource: deviceName, body: command}, func(ctx context.Context) error {
+		result = &androidmanagement.Operation{}
+		return ds.client.postJSON(ctx, deviceName+":issueCommand", command, result)
+	})
+
+	if err != nil {
+		return nil, ds.client.wrapAPIError(err, "issue device command")
+	}
+
+	// Commands change device state, e.g. lock or lost mode
+	ds.client.invalidateCache(deviceName)
+
+	return result, nil
+}
+
+// ResetPassword resets the device password. An empty newPassword removes the password.
+func (ds *DeviceService) ResetPassword(deviceName, newPassword string, flags ...types.ResetPasswordFlag) (*androidmanagement.Operation, error) {
+	command, err := types.NewResetPasswordCommand(newPassword, flags...)
+	if err != nil {
+		return nil, err
+	}
+
+	return ds.IssueDeviceCommand(deviceName, command)
+}
+
+// ResetWithOptions factory resets a device with wipe data flags and a reason shown to the user.
+//
+// 使用 API 的 WIPE 命令；不需要这些选项时可以继续使用 Reset。
+func (ds *DeviceService) ResetWithOptions(deviceName string, opts types.WipeOptions) (*androidmanagement.Operation, error) {
+	command, err := types.NewWipeCommand(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return ds.IssueDeviceCommand(deviceName, command)
+}
+
+// RelinquishOwnership removes the work profile and all policies from a company-owned device.
+func (ds *DeviceService) RelinquishOwnership(deviceName string) (*androidmanagement.Operation, error) {
+	command, err := types.NewRelinquishOwnershipCommand()
+	if err != nil {
+		return nil, err
+	}
+
+	return ds.IssueDeviceCommand(deviceName, command)
+}
+
+// RequestDeviceInfo requests information from a device, e.g. its EIDs.
+//
+// 结果在操作完成后通过 CommandResult.Command.RequestDeviceInfoStatus 返回：
+//
+//	op, err := client.Devices().RequestDeviceInfo(deviceName, types.DeviceInfoEID)
+//	result, err := client.Devices().WaitForOperation(ctx, op.Name, nil)
+//	for _, eid := range result.Command.RequestDeviceInfoStatus.EidInfo.Eids {
+//	    fmt.Println(eid.Eid)
+//	}
+func (ds *DeviceService) RequestDeviceInfo(deviceName string, info types.DeviceInfoType) (*androidmanagement.Operation, error) {
+	command, err := types.NewRequestDeviceInfoCommand(info)
+	if err != nil {
+		return nil, err
+	}
+
+	return ds.IssueDeviceCommand(deviceName, command)
+}
+
+// AddEsim downloads and installs an eSIM profile on the device.
+func (ds *DeviceService) AddEsim(deviceName, activationCode string, activationState types.EsimActivationState) (*androidmanagement.Operation, error) {
+	command, err := types.NewAddEsimCommand(activationCode, activationState)
+	if err != nil {
+		return nil, err
+	}
+
+	return ds.IssueDeviceCommand(deviceName, command)
+}
+
+// RemoveEsim removes the eSIM with the given ICCID from the device.
+func (ds *DeviceService) RemoveEsim(deviceName, iccID string) (*androidmanagement.Operation, error) {
+	command, err := types.NewRemoveEsimCommand(iccID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ds.IssueDeviceCommand(deviceName, command)
+}
+
+// StartLostModeWithOptions starts lost mode with a message and contact details shown on the device.
+func (ds *DeviceService) StartLostModeWithOptions(deviceName string, opts types.LostModeOptions) (*androidmanagement.Operation, error) {
+	command, err := types.NewStartLostModeCommand(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return ds.IssueDeviceCommand(deviceName, command)
+}
+
+// postJSON sends body to the API method at path (relative to "v1/") and decodes the response into result.
+//
+// 只用于生成客户端尚未支持的请求字段，升级 google.golang.org/api 后应改用生成客户端。
+// 请求使用与 service 相同的 HTTP 客户端、endpoint 和 User-Agent，错误转换为
+// *googleapi.Error，因此 wrapAPIError 和重试逻辑与生成客户端的调用一致。
+func (c *Client) postJSON(ctx context.Context, path string, body, result any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.service.BasePath+"v1/"+path+"?alt=json&prettyPrint=false", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	userAgent := googleapi.UserAgent
+	if c.service.UserAgent != "" {
+		userAgent += " " + c.service.UserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := googleapi.CheckResponse(resp); err != nil {
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,217 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/androidmanagement/v1"
+	"google.golang.org/api/googleapi"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// 测试类型化命令的参数随请求发送并出现在操作元数据中
+func TestIssueDeviceCommands(t *testing.T) {
+	srv, c, device := newDeviceTestClient(t)
+	devices := c.Devices()
+
+	tests := []struct {
+		name  string
+		issue func() (string, error)
+		want  map[string]any
+	}{
+		{
+			name: "reset password",
+			issue: func() (string, error) {
+				op, err := devices.ResetPassword(device.Name, "correct-horse", types.ResetPasswordFlagRequireEntry, types.ResetPasswordFlagLockNow)
+				return opName(op), err
+			},
+			want: map[string]any{"type": "RESET_PASSWORD", "newPassword": "correct-horse", "resetPasswordFlags": []any{"REQUIRE_ENTRY", "LOCK_NOW"}},
+		},
+		{
+			name: "wipe",
+			issue: func() (string, error) {
+				op, err := devices.ResetWithOptions(device.Name, types.WipeOptions{
+					Flags:  []types.WipeDataFlag{types.WipeDataFlagWipeEsims},
+					Reason: "Device retired",
+				})
+				return opName(op), err
+			},
+			want: map[string]any{"type": "WIPE", "wipeParams": map[string]any{
+				"wipeDataFlags": []any{"WIPE_ESIMS"},
+				"wipeReason":    map[string]any{"defaultMessage": "Device retired"},
+			}},
+		},
+		{
+			name: "lost mode",
+			issue: func() (string, error) {
+				op, err := devices.StartLostModeWithOptions(device.Name, types.LostModeOptions{
+					Message:      "Please return this phone",
+					PhoneNumber:  "+1 555 0100",
+					Organization: "Example Corp",
+				})
+				return opName(op), err
+			},
+			want: map[string]any{"type": "START_LOST_MODE", "startLostModeParams": map[string]any{
+				"lostMessage":      map[string]any{"defaultMessage": "Please return this phone"},
+				"lostPhoneNumber":  map[string]any{"defaultMessage": "+1 555 0100"},
+				"lostOrganization": map[string]any{"defaultMessage": "Example Corp"},
+			}},
+		},
+		{
+			name: "request device info",
+			issue: func() (string, error) {
+				op, err := devices.RequestDeviceInfo(device.Name, types.DeviceInfoEID)
+				return opName(op), err
+			},
+			want: map[string]any{"type": "REQUEST_DEVICE_INFO", "requestDeviceInfoParams": map[string]any{"deviceInfo": "EID"}},
+		},
+		{
+			name: "add esim",
+			issue: func() (string, error) {
+				op, err := devices.AddEsim(device.Name, "LPA:1$smdp.example.com$CODE", types.EsimActivated)
+				return opName(op), err
+			},
+			want: map[string]any{"type": "ADD_ESIM", "addEsimParams": map[string]any{"activationCode": "LPA:1$smdp.example.com$CODE", "activationState": "ACTIVATED"}},
+		},
+		{
+			name: "remove esim",
+			issue: func() (string, error) {
+				op, err := devices.RemoveEsim(device.Name, "8901260123456789012")
+				return opName(op), err
+			},
+			want: map[string]any{"type": "REMOVE_ESIM", "removeEsimParams": map[string]any{"iccId": "8901260123456789012"}},
+		},
+		{
+			name: "clear app data",
+			issue: func() (string, error) {
+				op, err := devices.ClearAppData(device.Name, "com.example.mail", "com.example.chat")
+				return opName(op), err
+			},
+			want: map[string]any{"type": "CLEAR_APP_DATA", "clearAppsDataParams": map[string]any{"packageNames": []any{"com.example.mail", "com.example.chat"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, err := tt.issue()
+			if err != nil {
+				t.Fatalf("issue unexpected error: %v", err)
+			}
+
+			var metadata map[string]any
+			if err := json.Unmarshal(srv.Operation(name).Metadata, &metadata); err != nil {
+				t.Fatalf("decode metadata: %v", err)
+			}
+			for key, want := range tt.want {
+				if got, _ := json.Marshal(metadata[key]); string(got) != mustJSON(t, want) {
+					t.Errorf("metadata[%s] = %s, want %s", key, got, mustJSON(t, want))
+				}
+			}
+		})
+	}
+}
+
+// 测试生成客户端能够表示的命令通过生成客户端发送
+func TestIssueDeviceCommandGeneratedClient(t *testing.T) {
+	srv, c, device := newDeviceTestClient(t)
+
+	if _, err := c.Devices().Lock(device.Name, "600s"); err != nil {
+		t.Fatalf("Lock() unexpected error: %v", err)
+	}
+	if _, err := c.Devices().RequestDeviceInfo(device.Name, types.DeviceInfoEID); err != nil {
+		t.Fatalf("RequestDeviceInfo() unexpected error: %v", err)
+	}
+
+	requests := srv.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(requests))
+	}
+	for _, r := range requests {
+		if !strings.HasPrefix(r.UserAgent, googleapi.UserAgent) {
+			t.Errorf("%s User-Agent = %q, want the generated client's %q", r.Body, r.UserAgent, googleapi.UserAgent)
+		}
+	}
+	if body := strings.TrimSpace(requests[0].Body); body != `{"duration":"600s","type":"LOCK"}` {
+		t.Errorf("LOCK request body = %s", body)
+	}
+
+	// 校验在类型层完成，生成客户端的请求同样不会发送
+	if _, err := c.Devices().IssueDeviceCommand(device.Name, &types.DeviceCommand{Type: string(types.CommandTypeLock), Duration: "soon"}); err == nil {
+		t.Error("IssueDeviceCommand() expected error for invalid duration")
+	}
+	if len(srv.Requests()) != 2 {
+		t.Error("invalid command was sent")
+	}
+}
+
+// 测试参数校验失败时不发送请求
+func TestIssueDeviceCommandValidation(t *testing.T) {
+	srv, c, device := newDeviceTestClient(t)
+
+	_, err := c.Devices().ResetPassword(device.Name, "1234")
+	var apiErr *types.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != types.ErrCodeInvalidInput {
+		t.Errorf("ResetPassword(short numeric) error = %v, want invalid input", err)
+	}
+	if _, err := c.Devices().Lock(device.Name, "10 minutes"); err == nil {
+		t.Error("Lock() expected error for invalid duration")
+	}
+	if _, err := c.Devices().IssueDeviceCommand(device.Name, &types.DeviceCommand{
+		Type:       string(types.CommandTypeLock),
+		WipeParams: &types.WipeParams{},
+	}); err == nil {
+		t.Error("IssueDeviceCommand() expected error for parameters of another command type")
+	}
+
+	if ops := srv.Operations(device.Name); len(ops) != 0 {
+		t.Errorf("%d operations created by invalid commands, want 0", len(ops))
+	}
+}
+
+// 测试从完成的操作中读取 EID
+func TestRequestDeviceInfoResult(t *testing.T) {
+	srv, c, device := newDeviceTestClient(t)
+
+	op, err := c.Devices().RequestDeviceInfo(device.Name, types.DeviceInfoEID)
+	if err != nil {
+		t.Fatalf("RequestDeviceInfo() unexpected error: %v", err)
+	}
+
+	// 模拟设备上报结果
+	stored := srv.Operation(op.Name)
+	stored.Done = true
+	stored.Metadata = googleapi.RawMessage(`{"type":"REQUEST_DEVICE_INFO","requestDeviceInfoStatus":{"status":"SUCCEEDED","eidInfo":{"eids":[{"eid":"89049032000001000000012345678901"}]}}}`)
+	srv.AddOperation(stored)
+
+	result, err := c.Devices().WaitForOperation(context.Background(), op.Name, fastWait(nil))
+	if err != nil {
+		t.Fatalf("WaitForOperation() unexpected error: %v", err)
+	}
+	status := result.Command.RequestDeviceInfoStatus
+	if result.Status != types.CommandStatusSucceeded || status == nil || status.EidInfo == nil ||
+		len(status.EidInfo.Eids) != 1 || status.EidInfo.Eids[0].Eid != "89049032000001000000012345678901" {
+		t.Errorf("result = %+v, want succeeded with one EID", result)
+	}
+}
+
+// opName 返回操作名称，op 为 nil 时返回空字符串
+func opName(op *androidmanagement.Operation) string {
+	if op == nil {
+		return ""
+	}
+	return op.Name
+}
+
+// mustJSON 返回 v 的 JSON 编码
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
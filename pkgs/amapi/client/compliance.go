@@ -0,0 +1,186 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// ComplianceIssue aggregates one kind of non-compliance across the devices of an enterprise.
+type ComplianceIssue struct {
+	// SettingName is the policy setting that is not applied, e.g. "applications" or "passwordPolicies"
+	SettingName string `json:"setting_name"`
+
+	// NonComplianceReason is the reason the setting is not applied, e.g. "APP_NOT_INSTALLED"
+	NonComplianceReason string `json:"non_compliance_reason"`
+
+	// PackageName is the package the issue applies to, if any
+	PackageName string `json:"package_name,omitempty"`
+
+	// SpecificNonComplianceReason is the detailed reason, if reported
+	SpecificNonComplianceReason string `json:"specific_non_compliance_reason,omitempty"`
+
+	// DeviceCount is the number of devices with this issue
+	DeviceCount int `json:"device_count"`
+
+	// Devices holds the names of the devices with this issue, sorted
+	Devices []string `json:"devices"`
+}
+
+// ComplianceReport is the result of DeviceService.ComplianceReport.
+type ComplianceReport struct {
+	// Enterprise is the enterprise resource name
+	Enterprise string `json:"enterprise"`
+
+	// GeneratedAt is the time the report was created
+	GeneratedAt time.Time `json:"generated_at"`
+
+	// TotalDevices, CompliantDevices and NonCompliantDevices count the devices by Device.PolicyCompliant
+	TotalDevices        int `json:"total_devices"`
+	CompliantDevices    int `json:"compliant_devices"`
+	NonCompliantDevices int `json:"non_compliant_devices"`
+
+	// Issues holds one entry per distinct setting, reason, package and specific reason,
+	// sorted by device count (descending), then setting name
+	Issues []*ComplianceIssue `json:"issues"`
+}
+
+// Setting returns the issues of one policy setting, for drilling down into the devices that fail it.
+func (r *ComplianceReport) Setting(settingName string) []*ComplianceIssue {
+	var issues []*ComplianceIssue
+	for _, issue := range r.Issues {
+		if issue.SettingName == settingName {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// DevicesFailing returns the sorted names of the devices with any issue on the setting.
+func (r *ComplianceReport) DevicesFailing(settingName string) []string {
+	var devices []string
+	for _, issue := range r.Setting(settingName) {
+		devices = append(devices, issue.Devices...)
+	}
+	devices = uniqueStrings(devices)
+	sort.Strings(devices)
+	return devices
+}
+
+// Write writes the report in the given format.
+//
+// JSON 包含完整报告；CSV 每行是一个问题和一台设备，便于在表格软件中筛选；
+// 表格输出汇总信息和每个问题的设备数量。
+func (r *ComplianceReport) Write(w io.Writer, format types.ReportFormat) error {
+	switch format {
+	case types.ReportFormatJSON:
+		return writeJSON(w, r)
+
+	case types.ReportFormatCSV:
+		var rows [][]string
+		for _, issue := range r.Issues {
+			for _, device := range issue.Devices {
+				rows = append(rows, []string{issue.SettingName, issue.NonComplianceReason, issue.PackageName,
+					issue.SpecificNonComplianceReason, strconv.Itoa(issue.DeviceCount), device})
+			}
+		}
+		return writeCSV(w, []string{"setting_name", "non_compliance_reason", "package_name",
+			"specific_non_compliance_reason", "device_count", "device_name"}, rows)
+
+	case types.ReportFormatTable:
+		fmt.Fprintf(w, "%s: %d devices, %d compliant, %d non-compliant\n\n",
+			r.Enterprise, r.TotalDevices, r.CompliantDevices, r.NonCompliantDevices)
+		rows := make([][]string, 0, len(r.Issues))
+		for _, issue := range r.Issues {
+			rows = append(rows, []string{issue.SettingName, issue.NonComplianceReason, issue.PackageName,
+				issue.SpecificNonComplianceReason, strconv.Itoa(issue.DeviceCount)})
+		}
+		return writeTable(w, []string{"SETTING", "REASON", "PACKAGE", "SPECIFIC REASON", "DEVICES"}, rows)
+	}
+
+	return unsupportedFormat(format, types.ReportFormatJSON, types.ReportFormatCSV, types.ReportFormatTable)
+}
+
+// ComplianceReport aggregates Device.NonComplianceDetails across every device of an enterprise.
+//
+// enterpriseID 可以是企业 ID，也可以是完整的资源名称。问题按设置名称、原因、应用包名和
+// 具体原因分组，每个问题记录受影响的设备，可通过 Setting 和 DevicesFailing 深入查看：
+//
+//	report, err := client.Devices().ComplianceReport(enterpriseID)
+//	for _, issue := range report.Issues {
+//	    log.Printf("%s %s: %d 台设备", issue.SettingName, issue.NonComplianceReason, issue.DeviceCount)
+//	}
+//	report.Write(os.Stdout, types.ReportFormatCSV)
+func (ds *DeviceService) ComplianceReport(enterpriseID string) (*ComplianceReport, error) {
+	if err := validateEnterpriseID(enterpriseID); err != nil {
+		return nil, err
+	}
+
+	enterpriseName := resolveEnterpriseName(enterpriseID)
+	report := &ComplianceReport{Enterprise: enterpriseName, GeneratedAt: time.Now(), Issues: make([]*ComplianceIssue, 0)}
+	issues := make(map[complianceIssueKey]*ComplianceIssue)
+
+	for device, err := range ds.All(enterpriseName, "", nil, "") {
+		if err != nil {
+			return nil, err
+		}
+
+		report.TotalDevices++
+		if device.PolicyCompliant {
+			report.CompliantDevices++
+		} else {
+			report.NonCompliantDevices++
+		}
+
+		for _, detail := range device.NonComplianceDetails {
+			addComplianceIssue(report, issues, device, detail)
+		}
+	}
+
+	for _, issue := range report.Issues {
+		issue.Devices = uniqueStrings(issue.Devices)
+		issue.DeviceCount = len(issue.Devices)
+		sort.Strings(issue.Devices)
+	}
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		a, b := report.Issues[i], report.Issues[j]
+		if a.DeviceCount != b.DeviceCount {
+			return a.DeviceCount > b.DeviceCount
+		}
+		return a.SettingName < b.SettingName
+	})
+
+	return report, nil
+}
+
+// complianceIssueKey groups non-compliance details into issues.
+type complianceIssueKey struct {
+	settingName, reason, packageName, specificReason string
+}
+
+// addComplianceIssue records a non-compliance detail of a device.
+func addComplianceIssue(report *ComplianceReport, issues map[complianceIssueKey]*ComplianceIssue, device *androidmanagement.Device, detail *androidmanagement.NonComplianceDetail) {
+	if detail == nil {
+		return
+	}
+
+	key := complianceIssueKey{detail.SettingName, detail.NonComplianceReason, detail.PackageName, detail.SpecificNonComplianceReason}
+	issue, ok := issues[key]
+	if !ok {
+		issue = &ComplianceIssue{
+			SettingName:                 key.settingName,
+			NonComplianceReason:         key.reason,
+			PackageName:                 key.packageName,
+			SpecificNonComplianceReason: key.specificReason,
+		}
+		issues[key] = issue
+		report.Issues = append(report.Issues, issue)
+	}
+	issue.Devices = append(issue.Devices, device.Name)
+}
@@ -0,0 +1,95 @@
+package client_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/client"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// 测试按设置、原因和应用包名汇总不合规详情
+func TestComplianceReport(t *testing.T) {
+	srv, c, device := newDeviceTestClient(t)
+	enterpriseName := device.Name[:len(device.Name)-len("/devices/d1")]
+
+	appMissing := &androidmanagement.NonComplianceDetail{
+		SettingName: "applications", NonComplianceReason: "APP_NOT_INSTALLED", PackageName: "com.example.vpn",
+	}
+	password := &androidmanagement.NonComplianceDetail{
+		SettingName: "passwordPolicies", NonComplianceReason: "USER_ACTION",
+		SpecificNonComplianceReason: "PASSWORD_POLICIES_USER_CREDENTIALS_CONFIRMATION_REQUIRED",
+	}
+	srv.AddDevice(&androidmanagement.Device{Name: enterpriseName + "/devices/d2", NonComplianceDetails: []*androidmanagement.NonComplianceDetail{appMissing, password}})
+	srv.AddDevice(&androidmanagement.Device{Name: enterpriseName + "/devices/d3", NonComplianceDetails: []*androidmanagement.NonComplianceDetail{appMissing, appMissing}})
+	srv.AddDevice(&androidmanagement.Device{Name: enterpriseName + "/devices/d4", NonComplianceDetails: []*androidmanagement.NonComplianceDetail{
+		{SettingName: "applications", NonComplianceReason: "APP_INCOMPATIBLE", PackageName: "com.example.legacy"},
+	}})
+	srv.AddDevice(&androidmanagement.Device{Name: enterpriseName + "/devices/ok", PolicyCompliant: true})
+
+	report, err := c.Devices().ComplianceReport(enterpriseName[len("enterprises/"):])
+	if err != nil {
+		t.Fatalf("ComplianceReport() unexpected error: %v", err)
+	}
+
+	if report.TotalDevices != 5 || report.CompliantDevices != 1 || report.NonCompliantDevices != 4 {
+		t.Errorf("counts = %d/%d/%d, want 5 total, 1 compliant, 4 non-compliant", report.TotalDevices, report.CompliantDevices, report.NonCompliantDevices)
+	}
+	if len(report.Issues) != 3 {
+		t.Fatalf("Issues = %d, want 3", len(report.Issues))
+	}
+	top := report.Issues[0]
+	if top.PackageName != "com.example.vpn" || top.DeviceCount != 2 ||
+		strings.Join(top.Devices, ",") != enterpriseName+"/devices/d2,"+enterpriseName+"/devices/d3" {
+		t.Errorf("top issue = %+v, want com.example.vpn on d2 and d3", top)
+	}
+
+	if issues := report.Setting("applications"); len(issues) != 2 {
+		t.Errorf("Setting(applications) = %d issues, want 2", len(issues))
+	}
+	if devices := report.DevicesFailing("applications"); len(devices) != 3 {
+		t.Errorf("DevicesFailing(applications) = %v, want d2, d3 and d4", devices)
+	}
+	if devices := report.DevicesFailing("passwordPolicies"); len(devices) != 1 || devices[0] != enterpriseName+"/devices/d2" {
+		t.Errorf("DevicesFailing(passwordPolicies) = %v, want d2", devices)
+	}
+
+	// 导出格式
+	var buf bytes.Buffer
+	if err := report.Write(&buf, types.ReportFormatCSV); err != nil {
+		t.Fatalf("Write(csv) unexpected error: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(records) != 5 || records[0][0] != "setting_name" || records[1][5] != enterpriseName+"/devices/d2" {
+		t.Errorf("csv = %v, want header and one row per issue and device", records)
+	}
+
+	buf.Reset()
+	if err := report.Write(&buf, types.ReportFormatJSON); err != nil {
+		t.Fatalf("Write(json) unexpected error: %v", err)
+	}
+	var decoded client.ComplianceReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded.Issues) != 3 || decoded.Issues[0].DeviceCount != 2 {
+		t.Errorf("json = %s, want the full report", buf.String())
+	}
+
+	buf.Reset()
+	if err := report.Write(&buf, types.ReportFormatTable); err != nil {
+		t.Fatalf("Write(table) unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "4 non-compliant") || !strings.Contains(buf.String(), "APP_NOT_INSTALLED") {
+		t.Errorf("table = %s, want summary and issues", buf.String())
+	}
+
+	if err := report.Write(&buf, "xml"); err == nil {
+		t.Error("Write(xml) expected error")
+	}
+}
@@ -1,6 +1,11 @@
 package client
 
 import (
+	"context"
+	"fmt"
+	"iter"
+	"strings"
+
 	"google.golang.org/api/androidmanagement/v1"
 
 	"amapi-pkg/pkgs/amapi/types"
@@ -16,6 +21,11 @@ func (c *Client) Devices() *DeviceService {
 	return &DeviceService{client: c}
 }
 
+// WithContext returns a copy of the service whose API calls use ctx.
+func (ds *DeviceService) WithContext(ctx context.Context) *DeviceService {
+	return &DeviceService{client: ds.client.WithContext(ctx)}
+}
+
 // List lists devices for an enterprise.
 func (ds *DeviceService) List(enterpriseName string, pageSize int, pageToken string, state types.DeviceState, policyCompliant *bool, userName string) (*types.ListResult[*androidmanagement.Device], error) {
 	if enterpriseName == "" {
@@ -25,7 +35,9 @@ func (ds *DeviceService) List(enterpriseName string, pageSize int, pageToken str
 	var result *androidmanagement.ListDevicesResponse
 	var err error
 
-	err = ds.client.executeAPICall(func() error {
+	params := pageParams(pageSize, pageToken)
+
+	err = ds.client.cachedAPICall(apiRequest{operation: "list devices", resource: enterpriseName + "/devices", body: params}, &result, func(ctx context.Context) error {
 		call := ds.client.service.Enterprises.Devices.List(enterpriseName)
 
 		if pageSize > 0 {
@@ -36,7 +48,7 @@ func (ds *DeviceService) List(enterpriseName string, pageSize int, pageToken str
 			call.PageToken(pageToken)
 		}
 
-		result, err = call.Context(ds.client.ctx).Do()
+		result, err = call.Context(ctx).Do()
 		return err
 	})
 
@@ -78,6 +90,18 @@ func (ds *DeviceService) List(enterpriseName string, pageSize int, pageToken str
 	}, nil
 }
 
+// All returns an iterator over all devices, following page tokens automatically.
+func (ds *DeviceService) All(enterpriseName string, state types.DeviceState, policyCompliant *bool, userName string) iter.Seq2[*androidmanagement.Device, error] {
+	return paginate(func(pageToken string) (*types.ListResult[*androidmanagement.Device], error) {
+		return ds.List(enterpriseName, DefaultIteratorPageSize, pageToken, state, policyCompliant, userName)
+	})
+}
+
+// ListAll returns all devices across every page.
+func (ds *DeviceService) ListAll(enterpriseName string, state types.DeviceState, policyCompliant *bool, userName string) (*types.ListResult[*androidmanagement.Device], error) {
+	return collect(ds.All(enterpriseName, state, policyCompliant, userName))
+}
+
 // ListByEnterpriseID lists devices for an enterprise by enterprise ID.
 func (ds *DeviceService) ListByEnterpriseID(enterpriseID string, pageSize int, pageToken string, state types.DeviceState, policyCompliant *bool, userName string) (*types.ListResult[*androidmanagement.Device], error) {
 	if err := validateEnterpriseID(enterpriseID); err != nil {
@@ -97,8 +121,8 @@ func (ds *DeviceService) Get(deviceName string) (*androidmanagement.Device, erro
 	var result *androidmanagement.Device
 	var err error
 
-	err = ds.client.executeAPICall(func() error {
-		result, err = ds.client.service.Enterprises.Devices.Get(deviceName).Context(ds.client.ctx).Do()
+	err = ds.client.cachedAPICall(apiRequest{operation: "get device", resource: deviceName}, &result, func(ctx context.Context) error {
+		result, err = ds.client.service.Enterprises.Devices.Get(deviceName).Context(ctx).Do()
 		return err
 	})
 
@@ -123,6 +147,92 @@ func (ds *DeviceService) GetByID(enterpriseID, deviceID string) (*androidmanagem
 	return ds.Get(deviceName)
 }
 
+// Patch updates a device. Only the fields listed in updateMask are changed;
+// an empty updateMask replaces every field the API allows to be updated.
+//
+// 可更新的字段包括 policyName、state、disabledReason 等，常用操作请使用
+// AssignPolicy、Disable 和 Enable。
+func (ds *DeviceService) Patch(deviceName string, device *androidmanagement.Device, updateMask []string) (*androidmanagement.Device, error) {
+	if deviceName == "" {
+		return nil, types.ErrInvalidDeviceID
+	}
+
+	if device == nil {
+		return nil, types.NewError(types.ErrCodeInvalidInput, "device is required")
+	}
+
+	var result *androidmanagement.Device
+	var err error
+
+	err = ds.client.executeAPICall(apiRequest{operation: "patch device", resource: deviceName, body: []any{device, updateMask}}, func(ctx context.Context) error {
+		call := ds.client.service.Enterprises.Devices.Patch(deviceName, device)
+
+		if len(updateMask) > 0 {
+			call.UpdateMask(strings.Join(updateMask, ","))
+		}
+
+		result, err = call.Context(ctx).Do()
+		return err
+	})
+
+	if err != nil {
+		return nil, ds.client.wrapAPIError(err, "patch device")
+	}
+
+	ds.client.invalidateCache(deviceName)
+
+	return result, nil
+}
+
+// AssignPolicy moves a device to another policy.
+//
+// policy 可以是完整的策略资源名称（enterprises/{enterpriseId}/policies/{policyId}），
+// 也可以只是策略 ID，此时使用设备所属的企业。
+func (ds *DeviceService) AssignPolicy(deviceName, policy string) (*androidmanagement.Device, error) {
+	enterpriseID, _, err := parseDeviceName(deviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	policyName := policy
+	if !strings.Contains(policy, "/") {
+		if err := validatePolicyID(policy); err != nil {
+			return nil, err
+		}
+		policyName = buildPolicyName(enterpriseID, policy)
+	} else {
+		policyEnterpriseID, _, err := parsePolicyName(policyName)
+		if err != nil {
+			return nil, err
+		}
+		if policyEnterpriseID != enterpriseID {
+			return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput,
+				"policy belongs to a different enterprise",
+				fmt.Sprintf("device enterprise: %s, policy: %s", enterpriseID, policyName))
+		}
+	}
+
+	return ds.Patch(deviceName, &androidmanagement.Device{PolicyName: policyName}, []string{"policyName"})
+}
+
+// Disable disables a device. The device stays enrolled but cannot be used until Enable is called.
+//
+// reason 会显示在设备上，告知用户设备被停用的原因，可以为空。
+func (ds *DeviceService) Disable(deviceName, reason string) (*androidmanagement.Device, error) {
+	device := &androidmanagement.Device{State: string(types.DeviceStateDisabled)}
+	if reason != "" {
+		device.DisabledReason = &androidmanagement.UserFacingMessage{DefaultMessage: reason}
+	}
+
+	return ds.Patch(deviceName, device, []string{"state", "disabledReason"})
+}
+
+// Enable re-enables a disabled device and clears its disabled reason.
+func (ds *DeviceService) Enable(deviceName string) (*androidmanagement.Device, error) {
+	device := &androidmanagement.Device{State: string(types.DeviceStateActive)}
+	return ds.Patch(deviceName, device, []string{"state", "disabledReason"})
+}
+
 // IssueCommand issues a command to a device.
 func (ds *DeviceService) IssueCommand(deviceName string, command *androidmanagement.Command) (*androidmanagement.Operation, error) {
 	if deviceName == "" {
@@ -136,8 +246,8 @@ func (ds *DeviceService) IssueCommand(deviceName string, command *androidmanagem
 	var result *androidmanagement.Operation
 	var err error
 
-	err = ds.client.executeAPICall(func() error {
-		result, err = ds.client.service.Enterprises.Devices.IssueCommand(deviceName, command).Context(ds.client.ctx).Do()
+	err = ds.client.executeAPICall(apiRequest{operation: "issue device command", resource: deviceName, body: command}, func(ctx context.Context) error {
+		result, err = ds.client.service.Enterprises.Devices.IssueCommand(deviceName, command).Context(ctx).Do()
 		return err
 	})
 
@@ -145,6 +255,9 @@ func (ds *DeviceService) IssueCommand(deviceName string, command *androidmanagem
 		return nil, ds.client.wrapAPIError(err, "issue device command")
 	}
 
+	// Commands change device state, e.g. lock or lost mode
+	ds.client.invalidateCache(deviceName)
+
 	return result, nil
 }
 
@@ -162,14 +275,14 @@ func (ds *DeviceService) IssueCommandByID(enterpriseID, deviceID string, command
 	return ds.IssueCommand(deviceName, command)
 }
 
-// Lock locks a device for the specified duration.
+// Lock locks a device for the specified duration, e.g. "600s".
 func (ds *DeviceService) Lock(deviceName string, duration string) (*androidmanagement.Operation, error) {
-	command := &androidmanagement.Command{
-		Type:     string(types.CommandTypeLock),
-		Duration: duration,
+	command, err := types.NewLockCommand(duration)
+	if err != nil {
+		return nil, err
 	}
 
-	return ds.IssueCommand(deviceName, command)
+	return ds.IssueDeviceCommand(deviceName, command)
 }
 
 // LockByID locks a device by enterprise ID and device ID.
@@ -255,15 +368,14 @@ func (ds *DeviceService) RemovePasswordByID(enterpriseID, deviceID string) (*and
 	return ds.RemovePassword(deviceName)
 }
 
-// ClearAppData clears data for a specific application on the device.
-func (ds *DeviceService) ClearAppData(deviceName, packageName string) (*androidmanagement.Operation, error) {
-	command := &androidmanagement.Command{
-		Type: string(types.CommandTypeClearAppData),
-		// Note: Package name would need to be included in the command
-		// The AMAPI Command structure would need to be extended for this
+// ClearAppData clears data for the given applications on the device.
+func (ds *DeviceService) ClearAppData(deviceName string, packageNames ...string) (*androidmanagement.Operation, error) {
+	command, err := types.NewClearAppDataCommand(packageNames...)
+	if err != nil {
+		return nil, err
 	}
 
-	return ds.IssueCommand(deviceName, command)
+	return ds.IssueDeviceCommand(deviceName, command)
 }
 
 // StartLostMode starts lost mode on a device.
@@ -290,9 +402,9 @@ func (ds *DeviceService) Delete(deviceName string) error {
 		return types.ErrInvalidDeviceID
 	}
 
-	err := ds.client.executeAPICall(func() error {
+	err := ds.client.executeAPICall(apiRequest{operation: "delete device", resource: deviceName}, func(ctx context.Context) error {
 		// Note: Delete returns *androidmanagement.Empty, not Operation
-		_, err := ds.client.service.Enterprises.Devices.Delete(deviceName).Context(ds.client.ctx).Do()
+		_, err := ds.client.service.Enterprises.Devices.Delete(deviceName).Context(ctx).Do()
 		return err
 	})
 
@@ -300,6 +412,8 @@ func (ds *DeviceService) Delete(deviceName string) error {
 		return ds.client.wrapAPIError(err, "delete device")
 	}
 
+	ds.client.invalidateCache(deviceName)
+
 	return nil
 }
 
@@ -326,8 +440,9 @@ func (ds *DeviceService) GetOperations(deviceName string) ([]*androidmanagement.
 	var result *androidmanagement.ListOperationsResponse
 	var err error
 
-	err = ds.client.executeAPICall(func() error {
-		result, err = ds.client.service.Enterprises.Devices.Operations.List(deviceName).Context(ds.client.ctx).Do()
+	err = ds.client.executeAPICall(apiRequest{operation: "get device operations", resource: deviceName + "/operations"}, func(ctx context.Context) error {
+		// The list method takes the operations collection name, not the device name
+		result, err = ds.client.service.Enterprises.Devices.Operations.List(deviceName + "/operations").Context(ctx).Do()
 		return err
 	})
 
@@ -347,8 +462,8 @@ func (ds *DeviceService) GetOperation(operationName string) (*androidmanagement.
 	var result *androidmanagement.Operation
 	var err error
 
-	err = ds.client.executeAPICall(func() error {
-		result, err = ds.client.service.Enterprises.Devices.Operations.Get(operationName).Context(ds.client.ctx).Do()
+	err = ds.client.executeAPICall(apiRequest{operation: "get device operation", resource: operationName}, func(ctx context.Context) error {
+		result, err = ds.client.service.Enterprises.Devices.Operations.Get(operationName).Context(ctx).Do()
 		return err
 	})
 
@@ -365,8 +480,8 @@ func (ds *DeviceService) CancelOperation(operationName string) error {
 		return types.NewError(types.ErrCodeInvalidInput, "operation name is required")
 	}
 
-	err := ds.client.executeAPICall(func() error {
-		_, err := ds.client.service.Enterprises.Devices.Operations.Cancel(operationName).Context(ds.client.ctx).Do()
+	err := ds.client.executeAPICall(apiRequest{operation: "cancel device operation", resource: operationName}, func(ctx context.Context) error {
+		_, err := ds.client.service.Enterprises.Devices.Operations.Cancel(operationName).Context(ctx).Do()
 		return err
 	})
 
@@ -382,25 +497,25 @@ func (ds *DeviceService) CancelOperation(operationName string) error {
 // GetActiveDevices returns all active devices for an enterprise.
 func (ds *DeviceService) GetActiveDevices(enterpriseID string) (*types.ListResult[*androidmanagement.Device], error) {
 	enterpriseName := buildEnterpriseName(enterpriseID)
-	return ds.List(enterpriseName, 0, "", types.DeviceStateActive, nil, "")
+	return ds.ListAll(enterpriseName, types.DeviceStateActive, nil, "")
 }
 
 // GetCompliantDevices returns all policy-compliant devices for an enterprise.
 func (ds *DeviceService) GetCompliantDevices(enterpriseID string) (*types.ListResult[*androidmanagement.Device], error) {
 	compliant := true
 	enterpriseName := buildEnterpriseName(enterpriseID)
-	return ds.List(enterpriseName, 0, "", "", &compliant, "")
+	return ds.ListAll(enterpriseName, "", &compliant, "")
 }
 
 // GetNonCompliantDevices returns all non-compliant devices for an enterprise.
 func (ds *DeviceService) GetNonCompliantDevices(enterpriseID string) (*types.ListResult[*androidmanagement.Device], error) {
 	compliant := false
 	enterpriseName := buildEnterpriseName(enterpriseID)
-	return ds.List(enterpriseName, 0, "", "", &compliant, "")
+	return ds.ListAll(enterpriseName, "", &compliant, "")
 }
 
 // GetDevicesByUser returns all devices for a specific user in an enterprise.
 func (ds *DeviceService) GetDevicesByUser(enterpriseID, userName string) (*types.ListResult[*androidmanagement.Device], error) {
 	enterpriseName := buildEnterpriseName(enterpriseID)
-	return ds.List(enterpriseName, 0, "", "", nil, userName)
+	return ds.ListAll(enterpriseName, "", nil, userName)
 }
@@ -0,0 +1,117 @@
+package client_test
+
+import (
+	"testing"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/amapitest"
+	"amapi-pkg/pkgs/amapi/client"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// newDeviceTestClient 创建假服务器和客户端，并预置一个企业、两个策略和一台设备
+func newDeviceTestClient(t *testing.T) (*amapitest.Server, *client.Client, *androidmanagement.Device) {
+	t.Helper()
+
+	srv := amapitest.NewServer()
+	t.Cleanup(srv.Close)
+
+	c, err := srv.NewClient()
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	enterprise := srv.AddEnterprise(&androidmanagement.Enterprise{})
+	srv.AddPolicy(&androidmanagement.Policy{Name: enterprise.Name + "/policies/default"})
+	srv.AddPolicy(&androidmanagement.Policy{Name: enterprise.Name + "/policies/kiosk"})
+	device := srv.AddDevice(&androidmanagement.Device{
+		Name:       enterprise.Name + "/devices/d1",
+		State:      string(types.DeviceStateActive),
+		PolicyName: enterprise.Name + "/policies/default",
+	})
+
+	return srv, c, device
+}
+
+// 测试按更新掩码修改设备
+func TestDevicePatch(t *testing.T) {
+	srv, c, device := newDeviceTestClient(t)
+
+	got, err := c.Devices().Patch(device.Name, &androidmanagement.Device{
+		State:      string(types.DeviceStateDisabled),
+		PolicyName: "ignored",
+	}, []string{"state"})
+	if err != nil {
+		t.Fatalf("Patch() unexpected error: %v", err)
+	}
+	if got.State != string(types.DeviceStateDisabled) || got.PolicyName != device.PolicyName {
+		t.Errorf("Patch() = state %q policy %q, want only state changed", got.State, got.PolicyName)
+	}
+	if stored := srv.Device(device.Name); stored.State != string(types.DeviceStateDisabled) {
+		t.Errorf("stored state = %q, want DISABLED", stored.State)
+	}
+
+	if _, err := c.Devices().Patch("", &androidmanagement.Device{}, nil); err == nil {
+		t.Error("Patch() expected error for empty device name")
+	}
+	if _, err := c.Devices().Patch(device.Name, nil, nil); err == nil {
+		t.Error("Patch() expected error for nil device")
+	}
+}
+
+// 测试停用和重新启用设备
+func TestDeviceDisableEnable(t *testing.T) {
+	srv, c, device := newDeviceTestClient(t)
+
+	got, err := c.Devices().Disable(device.Name, "Device reported lost")
+	if err != nil {
+		t.Fatalf("Disable() unexpected error: %v", err)
+	}
+	if got.State != string(types.DeviceStateDisabled) || got.DisabledReason == nil || got.DisabledReason.DefaultMessage != "Device reported lost" {
+		t.Errorf("Disable() = %+v, want DISABLED with reason", got)
+	}
+
+	got, err = c.Devices().Enable(device.Name)
+	if err != nil {
+		t.Fatalf("Enable() unexpected error: %v", err)
+	}
+	if got.State != string(types.DeviceStateActive) || got.DisabledReason != nil {
+		t.Errorf("Enable() = state %q reason %+v, want ACTIVE without reason", got.State, got.DisabledReason)
+	}
+	if stored := srv.Device(device.Name); stored.DisabledReason != nil {
+		t.Errorf("stored disabled reason = %+v, want cleared", stored.DisabledReason)
+	}
+}
+
+// 测试为设备分配策略
+func TestDeviceAssignPolicy(t *testing.T) {
+	_, c, device := newDeviceTestClient(t)
+	enterpriseName := device.Name[:len(device.Name)-len("/devices/d1")]
+
+	got, err := c.Devices().AssignPolicy(device.Name, "kiosk")
+	if err != nil {
+		t.Fatalf("AssignPolicy(kiosk) unexpected error: %v", err)
+	}
+	if got.PolicyName != enterpriseName+"/policies/kiosk" {
+		t.Errorf("PolicyName = %q, want kiosk policy", got.PolicyName)
+	}
+
+	got, err = c.Devices().AssignPolicy(device.Name, enterpriseName+"/policies/default")
+	if err != nil {
+		t.Fatalf("AssignPolicy(full name) unexpected error: %v", err)
+	}
+	if got.PolicyName != enterpriseName+"/policies/default" {
+		t.Errorf("PolicyName = %q, want default policy", got.PolicyName)
+	}
+
+	for _, policy := range []string{"", "enterprises/other/policies/default", "enterprises/x/devices/y"} {
+		if _, err := c.Devices().AssignPolicy(device.Name, policy); err == nil {
+			t.Errorf("AssignPolicy(%q) expected error", policy)
+		}
+	}
+	if _, err := c.Devices().AssignPolicy("not-a-device", "kiosk"); err == nil {
+		t.Error("AssignPolicy() expected error for invalid device name")
+	}
+}
@@ -1,6 +1,9 @@
 package client
 
 import (
+	"context"
+	"fmt"
+	"iter"
 	"time"
 
 	"google.golang.org/api/androidmanagement/v1"
@@ -18,6 +21,11 @@ func (c *Client) EnrollmentTokens() *EnrollmentService {
 	return &EnrollmentService{client: c}
 }
 
+// WithContext returns a copy of the service whose API calls use ctx.
+func (es *EnrollmentService) WithContext(ctx context.Context) *EnrollmentService {
+	return &EnrollmentService{client: es.client.WithContext(ctx)}
+}
+
 // Create creates a new enrollment token.
 func (es *EnrollmentService) Create(enterpriseName, policyName string, duration time.Duration, allowPersonalUsage, oneTimeOnly bool, user *androidmanagement.User) (*androidmanagement.EnrollmentToken, error) {
 	if enterpriseName == "" {
@@ -43,7 +51,8 @@ func (es *EnrollmentService) Create(enterpriseName, policyName string, duration
 
 	// Set duration
 	if duration > 0 {
-		token.Duration = duration.String()
+		// The API expects a protobuf Duration, e.g. "86400s"
+		token.Duration = fmt.Sprintf("%ds", int64(duration/time.Second))
 	}
 
 	// Set user information
@@ -54,8 +63,8 @@ func (es *EnrollmentService) Create(enterpriseName, policyName string, duration
 	var result *androidmanagement.EnrollmentToken
 	var err error
 
-	err = es.client.executeAPICall(func() error {
-		result, err = es.client.service.Enterprises.EnrollmentTokens.Create(enterpriseName, token).Context(es.client.ctx).Do()
+	err = es.client.executeAPICall(apiRequest{operation: "create enrollment token", resource: enterpriseName + "/enrollmentTokens", body: token}, func(ctx context.Context) error {
+		result, err = es.client.service.Enterprises.EnrollmentTokens.Create(enterpriseName, token).Context(ctx).Do()
 		return err
 	})
 
@@ -112,8 +121,8 @@ func (es *EnrollmentService) Get(tokenName string) (*androidmanagement.Enrollmen
 	var result *androidmanagement.EnrollmentToken
 	var err error
 
-	err = es.client.executeAPICall(func() error {
-		result, err = es.client.service.Enterprises.EnrollmentTokens.Get(tokenName).Context(es.client.ctx).Do()
+	err = es.client.executeAPICall(apiRequest{operation: "get enrollment token", resource: tokenName}, func(ctx context.Context) error {
+		result, err = es.client.service.Enterprises.EnrollmentTokens.Get(tokenName).Context(ctx).Do()
 		return err
 	})
 
@@ -147,7 +156,7 @@ func (es *EnrollmentService) List(enterpriseName string, pageSize int, pageToken
 	var result *androidmanagement.ListEnrollmentTokensResponse
 	var err error
 
-	err = es.client.executeAPICall(func() error {
+	err = es.client.executeAPICall(apiRequest{operation: "list enrollment tokens", resource: enterpriseName + "/enrollmentTokens", body: pageParams(pageSize, pageToken)}, func(ctx context.Context) error {
 		call := es.client.service.Enterprises.EnrollmentTokens.List(enterpriseName)
 
 		if pageSize > 0 {
@@ -158,7 +167,7 @@ func (es *EnrollmentService) List(enterpriseName string, pageSize int, pageToken
 			call.PageToken(pageToken)
 		}
 
-		result, err = call.Context(es.client.ctx).Do()
+		result, err = call.Context(ctx).Do()
 		return err
 	})
 
@@ -201,6 +210,18 @@ func (es *EnrollmentService) List(enterpriseName string, pageSize int, pageToken
 	}, nil
 }
 
+// All returns an iterator over all enrollment tokens, following page tokens automatically.
+func (es *EnrollmentService) All(enterpriseName string, policyName string, includeExpired bool) iter.Seq2[*androidmanagement.EnrollmentToken, error] {
+	return paginate(func(pageToken string) (*types.ListResult[*androidmanagement.EnrollmentToken], error) {
+		return es.List(enterpriseName, DefaultIteratorPageSize, pageToken, policyName, includeExpired)
+	})
+}
+
+// ListAll returns all enrollment tokens across every page.
+func (es *EnrollmentService) ListAll(enterpriseName string, policyName string, includeExpired bool) (*types.ListResult[*androidmanagement.EnrollmentToken], error) {
+	return collect(es.All(enterpriseName, policyName, includeExpired))
+}
+
 // ListByEnterpriseID lists enrollment tokens for an enterprise by enterprise ID.
 func (es *EnrollmentService) ListByEnterpriseID(enterpriseID string, pageSize int, pageToken string, policyName string, includeExpired bool) (*types.ListResult[*androidmanagement.EnrollmentToken], error) {
 	if err := validateEnterpriseID(enterpriseID); err != nil {
@@ -217,8 +238,8 @@ func (es *EnrollmentService) Delete(tokenName string) error {
 		return types.ErrInvalidTokenID
 	}
 
-	err := es.client.executeAPICall(func() error {
-		_, err := es.client.service.Enterprises.EnrollmentTokens.Delete(tokenName).Context(es.client.ctx).Do()
+	err := es.client.executeAPICall(apiRequest{operation: "delete enrollment token", resource: tokenName}, func(ctx context.Context) error {
+		_, err := es.client.service.Enterprises.EnrollmentTokens.Delete(tokenName).Context(ctx).Do()
 		return err
 	})
 
@@ -277,14 +298,14 @@ func (es *EnrollmentService) GenerateQRCodeByID(enterpriseID, tokenID string, op
 // GetActiveTokens returns all non-expired enrollment tokens for an enterprise.
 func (es *EnrollmentService) GetActiveTokens(enterpriseID string) (*types.ListResult[*androidmanagement.EnrollmentToken], error) {
 	enterpriseName := buildEnterpriseName(enterpriseID)
-	return es.List(enterpriseName, 0, "", "", false)
+	return es.ListAll(enterpriseName, "", false)
 }
 
 // GetTokensForPolicy returns all enrollment tokens for a specific policy.
 func (es *EnrollmentService) GetTokensForPolicy(enterpriseID, policyID string) (*types.ListResult[*androidmanagement.EnrollmentToken], error) {
 	enterpriseName := buildEnterpriseName(enterpriseID)
 	policyName := buildPolicyName(enterpriseID, policyID)
-	return es.List(enterpriseName, 0, "", policyName, false)
+	return es.ListAll(enterpriseName, policyName, false)
 }
 
 // RevokeToken revokes an enrollment token by deleting it.
@@ -371,7 +392,11 @@ func (es *EnrollmentService) ExtendTokenExpiration(tokenName string, newDuration
 
 // GetTokenStatistics returns statistics about enrollment tokens for an enterprise.
 func (es *EnrollmentService) GetTokenStatistics(enterpriseID string) (map[string]int, error) {
-	tokens, err := es.ListByEnterpriseID(enterpriseID, 0, "", "", true)
+	if err := validateEnterpriseID(enterpriseID); err != nil {
+		return nil, err
+	}
+
+	tokens, err := es.ListAll(buildEnterpriseName(enterpriseID), "", true)
 	if err != nil {
 		return nil, err
 	}
@@ -1,7 +1,9 @@
 package client
 
 import (
+	"context"
 	"fmt"
+	"iter"
 	"net/url"
 	"time"
 
@@ -20,6 +22,11 @@ func (c *Client) Enterprises() *EnterpriseService {
 	return &EnterpriseService{client: c}
 }
 
+// WithContext returns a copy of the service whose API calls use ctx.
+func (es *EnterpriseService) WithContext(ctx context.Context) *EnterpriseService {
+	return &EnterpriseService{client: es.client.WithContext(ctx)}
+}
+
 // GenerateSignupURL generates a signup URL for enterprise creation.
 func (es *EnterpriseService) GenerateSignupURL(projectID, callbackURL, adminEmail, enterpriseDisplayName, locale string) (*types.EnterpriseSignupURL, error) {
 	if projectID == "" {
@@ -33,7 +40,7 @@ func (es *EnterpriseService) GenerateSignupURL(projectID, callbackURL, adminEmai
 	var result *androidmanagement.SignupUrl
 	var err error
 
-	err = es.client.executeAPICall(func() error {
+	err = es.client.executeAPICall(apiRequest{operation: "generate signup URL", resource: "signupUrls", body: []string{projectID, callbackURL, adminEmail}}, func(ctx context.Context) error {
 		call := es.client.service.SignupUrls.Create()
 		call.ProjectId(projectID)
 
@@ -48,7 +55,7 @@ func (es *EnterpriseService) GenerateSignupURL(projectID, callbackURL, adminEmai
 		// Note: EnterpriseDisplayName and Locale are not available in the API
 		// They are accepted as parameters but not used
 
-		result, err = call.Context(es.client.ctx).Do()
+		result, err = call.Context(ctx).Do()
 		return err
 	})
 
@@ -92,7 +99,7 @@ func (es *EnterpriseService) Create(signupToken, projectID, enterpriseToken stri
 	var result *androidmanagement.Enterprise
 	var err error
 
-	err = es.client.executeAPICall(func() error {
+	err = es.client.executeAPICall(apiRequest{operation: "create enterprise", resource: "enterprises", body: []any{projectID, signupToken, enterpriseToken, enterprise}}, func(ctx context.Context) error {
 		call := es.client.service.Enterprises.Create(enterprise)
 		call.ProjectId(projectID)
 		call.SignupUrlName(signupToken)
@@ -101,7 +108,7 @@ func (es *EnterpriseService) Create(signupToken, projectID, enterpriseToken stri
 			call.EnterpriseToken(enterpriseToken)
 		}
 
-		result, err = call.Context(es.client.ctx).Do()
+		result, err = call.Context(ctx).Do()
 		return err
 	})
 
@@ -109,6 +116,8 @@ func (es *EnterpriseService) Create(signupToken, projectID, enterpriseToken stri
 		return nil, es.client.wrapAPIError(err, "create enterprise")
 	}
 
+	es.client.invalidateCache(result.Name)
+
 	return result, nil
 }
 
@@ -121,8 +130,8 @@ func (es *EnterpriseService) Get(enterpriseName string) (*androidmanagement.Ente
 	var result *androidmanagement.Enterprise
 	var err error
 
-	err = es.client.executeAPICall(func() error {
-		result, err = es.client.service.Enterprises.Get(enterpriseName).Context(es.client.ctx).Do()
+	err = es.client.cachedAPICall(apiRequest{operation: "get enterprise", resource: enterpriseName}, &result, func(ctx context.Context) error {
+		result, err = es.client.service.Enterprises.Get(enterpriseName).Context(ctx).Do()
 		return err
 	})
 
@@ -150,7 +159,7 @@ func (es *EnterpriseService) Update(enterpriseName string, primaryColor *int64,
 	}
 
 	// Get current enterprise
-	current, err := es.Get(enterpriseName)
+	current, err := es.client.WithoutCache().Enterprises().Get(enterpriseName)
 	if err != nil {
 		return nil, err
 	}
@@ -182,8 +191,8 @@ func (es *EnterpriseService) Update(enterpriseName string, primaryColor *int64,
 
 	var result *androidmanagement.Enterprise
 
-	err = es.client.executeAPICall(func() error {
-		result, err = es.client.service.Enterprises.Patch(enterpriseName, current).Context(es.client.ctx).Do()
+	err = es.client.executeAPICall(apiRequest{operation: "update enterprise", resource: enterpriseName, body: current}, func(ctx context.Context) error {
+		result, err = es.client.service.Enterprises.Patch(enterpriseName, current).Context(ctx).Do()
 		return err
 	})
 
@@ -191,6 +200,8 @@ func (es *EnterpriseService) Update(enterpriseName string, primaryColor *int64,
 		return nil, es.client.wrapAPIError(err, "update enterprise")
 	}
 
+	es.client.invalidateCache(enterpriseName)
+
 	return result, nil
 }
 
@@ -203,7 +214,10 @@ func (es *EnterpriseService) List(projectID string, pageSize int, pageToken stri
 	var result *androidmanagement.ListEnterprisesResponse
 	var err error
 
-	err = es.client.executeAPICall(func() error {
+	params := pageParams(pageSize, pageToken)
+	params.Set("projectId", projectID)
+
+	err = es.client.cachedAPICall(apiRequest{operation: "list enterprises", resource: "enterprises", body: params}, &result, func(ctx context.Context) error {
 		call := es.client.service.Enterprises.List()
 		call.ProjectId(projectID)
 
@@ -215,7 +229,7 @@ func (es *EnterpriseService) List(projectID string, pageSize int, pageToken stri
 			call.PageToken(pageToken)
 		}
 
-		result, err = call.Context(es.client.ctx).Do()
+		result, err = call.Context(ctx).Do()
 		return err
 	})
 
@@ -233,14 +247,26 @@ func (es *EnterpriseService) List(projectID string, pageSize int, pageToken stri
 	}, nil
 }
 
+// All returns an iterator over all enterprises in the project, following page tokens automatically.
+func (es *EnterpriseService) All(projectID string) iter.Seq2[*androidmanagement.Enterprise, error] {
+	return paginate(func(pageToken string) (*types.ListResult[*androidmanagement.Enterprise], error) {
+		return es.List(projectID, DefaultIteratorPageSize, pageToken)
+	})
+}
+
+// ListAll returns all enterprises in the project across every page.
+func (es *EnterpriseService) ListAll(projectID string) (*types.ListResult[*androidmanagement.Enterprise], error) {
+	return collect(es.All(projectID))
+}
+
 // Delete deletes an enterprise.
 func (es *EnterpriseService) Delete(enterpriseName string) error {
 	if enterpriseName == "" {
 		return types.ErrInvalidEnterpriseID
 	}
 
-	err := es.client.executeAPICall(func() error {
-		_, err := es.client.service.Enterprises.Delete(enterpriseName).Context(es.client.ctx).Do()
+	err := es.client.executeAPICall(apiRequest{operation: "delete enterprise", resource: enterpriseName}, func(ctx context.Context) error {
+		_, err := es.client.service.Enterprises.Delete(enterpriseName).Context(ctx).Do()
 		return err
 	})
 
@@ -248,6 +274,8 @@ func (es *EnterpriseService) Delete(enterpriseName string) error {
 		return es.client.wrapAPIError(err, "delete enterprise")
 	}
 
+	es.client.invalidateCache(enterpriseName)
+
 	return nil
 }
 
@@ -281,7 +309,7 @@ func (es *EnterpriseService) EnableNotifications(enterpriseName string, notifica
 	}
 
 	// Get current enterprise to merge notification types
-	current, err := es.Get(enterpriseName)
+	current, err := es.client.WithoutCache().Enterprises().Get(enterpriseName)
 	if err != nil {
 		return nil, err
 	}
@@ -315,7 +343,7 @@ func (es *EnterpriseService) DisableNotifications(enterpriseName string, notific
 	}
 
 	// Get current enterprise
-	current, err := es.Get(enterpriseName)
+	current, err := es.client.WithoutCache().Enterprises().Get(enterpriseName)
 	if err != nil {
 		return nil, err
 	}
@@ -347,7 +375,7 @@ func (es *EnterpriseService) SetPubSubTopic(enterpriseName, topicName string) (*
 	}
 
 	// Get current enterprise
-	current, err := es.Get(enterpriseName)
+	current, err := es.client.WithoutCache().Enterprises().Get(enterpriseName)
 	if err != nil {
 		return nil, err
 	}
@@ -357,8 +385,8 @@ func (es *EnterpriseService) SetPubSubTopic(enterpriseName, topicName string) (*
 
 	var result *androidmanagement.Enterprise
 
-	err = es.client.executeAPICall(func() error {
-		result, err = es.client.service.Enterprises.Patch(enterpriseName, current).Context(es.client.ctx).Do()
+	err = es.client.executeAPICall(apiRequest{operation: "set pub/sub topic", resource: enterpriseName, body: current}, func(ctx context.Context) error {
+		result, err = es.client.service.Enterprises.Patch(enterpriseName, current).Context(ctx).Do()
 		return err
 	})
 
@@ -366,6 +394,8 @@ func (es *EnterpriseService) SetPubSubTopic(enterpriseName, topicName string) (*
 		return nil, es.client.wrapAPIError(err, "set pub/sub topic")
 	}
 
+	es.client.invalidateCache(enterpriseName)
+
 	return result, nil
 }
 
@@ -382,10 +412,11 @@ func (es *EnterpriseService) GetApplication(enterpriseName string, packageName s
 	var result *androidmanagement.Application
 	var err error
 
-	err = es.client.executeAPICall(func() error {
-		// Build the application name: enterprises/{enterprise}/applications/{package}
-		appName := fmt.Sprintf("%s/applications/%s", enterpriseName, packageName)
-		result, err = es.client.service.Enterprises.Applications.Get(appName).Context(es.client.ctx).Do()
+	// Build the application name: enterprises/{enterprise}/applications/{package}
+	appName := fmt.Sprintf("%s/applications/%s", enterpriseName, packageName)
+
+	err = es.client.cachedAPICall(apiRequest{operation: "get application", resource: appName}, &result, func(ctx context.Context) error {
+		result, err = es.client.service.Enterprises.Applications.Get(appName).Context(ctx).Do()
 		return err
 	})
 
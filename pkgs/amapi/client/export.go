@@ -0,0 +1,189 @@
+package client
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/query"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// DefaultExportFields are the columns written by Export when no fields are given.
+var DefaultExportFields = []string{
+	"name",
+	"state",
+	"appliedState",
+	"ownership",
+	"managementMode",
+	"userName",
+	"enrollmentTime",
+	"lastStatusReportTime",
+	"lastPolicySyncTime",
+	"policyName",
+	"appliedPolicyName",
+	"appliedPolicyVersion",
+	"policyCompliant",
+	"hardwareInfo.brand",
+	"hardwareInfo.manufacturer",
+	"hardwareInfo.model",
+	"hardwareInfo.serialNumber",
+	"softwareInfo.androidVersion",
+	"softwareInfo.securityPatchLevel",
+	"softwareInfo.androidBuildNumber",
+	"networkInfo.imei",
+	"networkInfo.meid",
+	"networkInfo.wifiMacAddress",
+	"networkInfo.networkOperatorName",
+	"memoryInfo.totalRam",
+	"memoryInfo.totalInternalStorage",
+}
+
+// Export streams every device of an enterprise to w as flat records, one per device.
+//
+// fields 是 androidmanagement.Device 的 JSON 字段路径（例如 hardwareInfo.model、networkInfo.imei），
+// 每个字段是一列；为空时使用 DefaultExportFields。设备逐页读取并立即写出，不会在内存中保存整个设备列表。
+//
+//   - types.ReportFormatCSV：第一行是字段路径；数组中的多个值用 ";" 连接，对象编码为 JSON
+//   - types.ReportFormatJSONL：每行一个以字段路径为键的 JSON 对象；经过数组的路径有多个值时为 JSON 数组，缺失的字符串和对象字段为 null
+//
+// 返回写出的设备数量；出错时已写出的记录保留在 w 中。
+//
+//	f, _ := os.Create("devices.csv")
+//	defer f.Close()
+//	n, err := client.Devices().Export(ctx, enterpriseID, f, types.ReportFormatCSV, nil)
+func (ds *DeviceService) Export(ctx context.Context, enterpriseID string, w io.Writer, format types.ReportFormat, fields []string) (int, error) {
+	if err := validateEnterpriseID(enterpriseID); err != nil {
+		return 0, err
+	}
+	if format != types.ReportFormatCSV && format != types.ReportFormatJSONL {
+		return 0, unsupportedFormat(format, types.ReportFormatCSV, types.ReportFormatJSONL)
+	}
+
+	if len(fields) == 0 {
+		fields = DefaultExportFields
+	}
+	// API 的 JSON 表示省略零值，缺失的布尔和数字字段按字段类型输出零值
+	zeros := make([]any, len(fields))
+	for i, field := range fields {
+		t, ok := query.FieldType(reflect.TypeOf(androidmanagement.Device{}), field)
+		if !ok {
+			return 0, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "unknown export field", field)
+		}
+		switch t.Kind() {
+		case reflect.Bool:
+			zeros[i] = false
+		case reflect.Int64:
+			// int64 字段在 JSON 中编码为字符串
+			zeros[i] = "0"
+		case reflect.Int, reflect.Int32, reflect.Float64:
+			zeros[i] = float64(0)
+		}
+	}
+
+	var cw *csv.Writer
+	var encoder *json.Encoder
+	if format == types.ReportFormatCSV {
+		cw = csv.NewWriter(w)
+		if err := cw.Write(fields); err != nil {
+			return 0, err
+		}
+	} else {
+		encoder = json.NewEncoder(w)
+	}
+
+	count := 0
+	for device, err := range ds.WithContext(ctx).All(resolveEnterpriseName(enterpriseID), "", nil, "") {
+		if err != nil {
+			if cw != nil {
+				cw.Flush()
+			}
+			return count, err
+		}
+
+		doc, err := toJSONValue(device)
+		if err != nil {
+			return count, err
+		}
+
+		if cw != nil {
+			record := make([]string, len(fields))
+			for i, field := range fields {
+				record[i] = exportCell(query.Lookup(doc, field), zeros[i])
+			}
+			if err := cw.Write(record); err != nil {
+				return count, err
+			}
+		} else {
+			record := make(map[string]any, len(fields))
+			for i, field := range fields {
+				record[field] = exportValue(query.Lookup(doc, field), zeros[i])
+			}
+			if err := encoder.Encode(record); err != nil {
+				return count, err
+			}
+		}
+		count++
+	}
+
+	if cw != nil {
+		cw.Flush()
+		return count, cw.Error()
+	}
+	return count, nil
+}
+
+// toJSONValue converts v to its generic JSON representation.
+func toJSONValue(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInternalServerError, "failed to encode device")
+	}
+
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInternalServerError, "failed to decode device")
+	}
+	return doc, nil
+}
+
+// exportCell formats the values of a field as one CSV cell.
+func exportCell(values []any, zero any) string {
+	if len(values) == 0 && zero != nil {
+		values = []any{zero}
+	}
+	cells := make([]string, 0, len(values))
+	for _, value := range values {
+		switch v := value.(type) {
+		case nil:
+			continue
+		case string:
+			cells = append(cells, v)
+		case float64:
+			cells = append(cells, strconv.FormatFloat(v, 'f', -1, 64))
+		case bool:
+			cells = append(cells, strconv.FormatBool(v))
+		default:
+			data, _ := json.Marshal(v)
+			cells = append(cells, string(data))
+		}
+	}
+	return strings.Join(cells, ";")
+}
+
+// exportValue returns the JSONL value of a field: null, a single value, or an array for several values.
+func exportValue(values []any, zero any) any {
+	switch {
+	case len(values) == 0:
+		return zero
+	case len(values) == 1:
+		return values[0]
+	}
+	return values
+}
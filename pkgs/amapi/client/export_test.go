@@ -0,0 +1,110 @@
+package client_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/client"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// 测试将嵌套字段展开为 CSV 列并遍历所有分页
+func TestDeviceExportCSV(t *testing.T) {
+	srv, c, device := newDeviceTestClient(t)
+	enterpriseName := device.Name[:len(device.Name)-len("/devices/d1")]
+	srv.SetMaxPageSize(2)
+	for i := 2; i <= 5; i++ {
+		srv.AddDevice(&androidmanagement.Device{
+			Name:                 fmt.Sprintf("%s/devices/d%d", enterpriseName, i),
+			State:                "ACTIVE",
+			Ownership:            "COMPANY_OWNED",
+			PolicyCompliant:      i%2 == 0,
+			AppliedPolicyVersion: int64(i),
+			HardwareInfo:         &androidmanagement.HardwareInfo{Brand: "google", Model: "Pixel 8", SerialNumber: fmt.Sprintf("SN%d", i)},
+			NetworkInfo: &androidmanagement.NetworkInfo{
+				Imei:           fmt.Sprintf("35000000000000%d", i),
+				WifiMacAddress: "aa:bb:cc:dd:ee:0" + fmt.Sprint(i),
+				TelephonyInfos: []*androidmanagement.TelephonyInfo{{CarrierName: "A"}, {CarrierName: "B"}},
+			},
+			MemoryInfo: &androidmanagement.MemoryInfo{TotalRam: 8 << 30},
+		})
+	}
+
+	var buf bytes.Buffer
+	fields := []string{"name", "ownership", "policyCompliant", "appliedPolicyVersion", "hardwareInfo.serialNumber",
+		"networkInfo.imei", "networkInfo.wifiMacAddress", "networkInfo.telephonyInfos.carrierName", "memoryInfo.totalRam"}
+	n, err := c.Devices().Export(context.Background(), enterpriseName, &buf, types.ReportFormatCSV, fields)
+	if err != nil {
+		t.Fatalf("Export() unexpected error: %v", err)
+	}
+	if n != 5 {
+		t.Errorf("Export() = %d devices, want 5", n)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(records) != 6 || len(records[0]) != len(fields) {
+		t.Fatalf("csv = %d rows of %d columns, want 6 rows of %d", len(records), len(records[0]), len(fields))
+	}
+
+	want := map[string][]string{
+		enterpriseName + "/devices/d1": {enterpriseName + "/devices/d1", "", "false", "0", "", "", "", "", "0"},
+		enterpriseName + "/devices/d2": {enterpriseName + "/devices/d2", "COMPANY_OWNED", "true", "2", "SN2",
+			"350000000000002", "aa:bb:cc:dd:ee:02", "A;B", "8589934592"},
+	}
+	for _, record := range records[1:] {
+		if w, ok := want[record[0]]; ok && fmt.Sprint(record) != fmt.Sprint(w) {
+			t.Errorf("row = %q, want %q", record, w)
+		}
+	}
+}
+
+// 测试 JSONL 格式、默认字段和参数校验
+func TestDeviceExportJSONL(t *testing.T) {
+	srv, c, device := newDeviceTestClient(t)
+	enterpriseName := device.Name[:len(device.Name)-len("/devices/d1")]
+	srv.AddDevice(&androidmanagement.Device{
+		Name:         enterpriseName + "/devices/d2",
+		SoftwareInfo: &androidmanagement.SoftwareInfo{AndroidVersion: "14"},
+		NetworkInfo:  &androidmanagement.NetworkInfo{Meid: "A0000000000002"},
+	})
+
+	var buf bytes.Buffer
+	n, err := c.Devices().Export(context.Background(), enterpriseName[len("enterprises/"):], &buf, types.ReportFormatJSONL, nil)
+	if err != nil || n != 2 {
+		t.Fatalf("Export() = %d, %v, want 2 devices", n, err)
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	var lines []map[string]any
+	for scanner.Scan() {
+		var record map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("decode line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, record)
+	}
+	if len(lines) != 2 || len(lines[1]) != len(client.DefaultExportFields) {
+		t.Fatalf("lines = %v, want 2 records with the default fields", lines)
+	}
+	if lines[1]["softwareInfo.androidVersion"] != "14" || lines[1]["networkInfo.meid"] != "A0000000000002" ||
+		lines[1]["policyCompliant"] != false || lines[1]["hardwareInfo.model"] != nil {
+		t.Errorf("d2 record = %v, want flattened fields", lines[1])
+	}
+
+	if _, err := c.Devices().Export(context.Background(), enterpriseName, &buf, types.ReportFormatCSV, []string{"hardwareInfo.imei"}); err == nil {
+		t.Error("Export() expected error for unknown field")
+	}
+	if _, err := c.Devices().Export(context.Background(), enterpriseName, &buf, "parquet", nil); err == nil {
+		t.Error("Export() expected error for unsupported format")
+	}
+}
@@ -0,0 +1,189 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/types"
+	"amapi-pkg/pkgs/amapi/utils"
+)
+
+// PolicyRevision is a recorded version of a policy.
+type PolicyRevision struct {
+	// Version is the Policy.Version of the snapshot
+	Version int64 `json:"version"`
+
+	// RecordedAt is the time the snapshot was recorded
+	RecordedAt time.Time `json:"recorded_at"`
+
+	// Operation is the operation that produced the version: "create policy", "update policy",
+	// "rollback policy", or "snapshot" for a version recorded just before it was replaced
+	Operation string `json:"operation,omitempty"`
+
+	// Policy is the policy as returned by the API
+	Policy *androidmanagement.Policy `json:"policy"`
+}
+
+// History returns the recorded versions of a policy, oldest first.
+//
+// 需要通过 config.PolicyHistory 或 WithPolicyHistory 启用策略历史，否则返回配置错误。
+// 只包含启用历史之后由本客户端（或共享同一存储的其他客户端）记录的版本。
+func (ps *PolicyService) History(policyName string) ([]*PolicyRevision, error) {
+	if policyName == "" {
+		return nil, types.ErrInvalidPolicyID
+	}
+
+	store, err := ps.historyStore()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := store.List(ps.client.ctx, policyName)
+	if err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInternalServerError, "failed to read policy history")
+	}
+
+	revisions := make([]*PolicyRevision, 0, len(entries))
+	for _, entry := range entries {
+		revision, err := newPolicyRevision(entry)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, nil
+}
+
+// Rollback restores a recorded version of a policy.
+//
+// 快照会整体替换当前策略（不使用 updateMask），策略的 Version 会继续递增，
+// 回滚后的新版本同样会记录到历史中，因此回滚本身也可以再次回滚。
+// 版本不存在时返回 ErrCodeNotFound 错误。
+func (ps *PolicyService) Rollback(policyName string, version int64) (*androidmanagement.Policy, error) {
+	if policyName == "" {
+		return nil, types.ErrInvalidPolicyID
+	}
+
+	store, err := ps.historyStore()
+	if err != nil {
+		return nil, err
+	}
+
+	entry, ok, err := store.Get(ps.client.ctx, policyName, version)
+	if err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInternalServerError, "failed to read policy history")
+	}
+	if !ok {
+		return nil, types.NewErrorWithDetails(types.ErrCodeNotFound, "policy version not found in history", policyName)
+	}
+
+	revision, err := newPolicyRevision(*entry)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := revision.Policy
+	policy.Name = ""
+	policy.Version = 0
+	return ps.update(policyName, policy, nil, "rollback policy")
+}
+
+// historyStore returns the client's history store or a configuration error if history is disabled.
+func (ps *PolicyService) historyStore() (utils.HistoryStore, error) {
+	if ps.client.history == nil {
+		return nil, types.NewError(types.ErrCodeConfiguration, "policy history is not enabled")
+	}
+	return ps.client.history, nil
+}
+
+// snapshotCurrent records the current version of a policy before policy replaces it, unless it is already recorded.
+//
+// 为了不在每次更新前多读取一次策略：policy 带有版本（例如读取后修改的策略）且该版本已记录时跳过；
+// policy 不带版本时，只要历史中已有该策略的版本（上一次记录的写操作）就跳过。
+// 只有没有快照时才读取当前策略，因此在本客户端之外（例如控制台）产生的中间版本不会被补记。
+// 策略不存在或读取失败时跳过，不影响更新操作。
+func (ps *PolicyService) snapshotCurrent(policyName string, policy *androidmanagement.Policy) {
+	if ps.client.history == nil {
+		return
+	}
+
+	if policy.Version != 0 {
+		if ps.isRecorded(policyName, policy.Version) {
+			return
+		}
+	} else if entries, err := ps.client.history.List(ps.client.ctx, policyName); err == nil && len(entries) > 0 {
+		return
+	}
+
+	current, err := ps.client.WithoutCache().Policies().Get(policyName)
+	if err != nil {
+		var apiErr *types.Error
+		if !errors.As(err, &apiErr) || apiErr.Code != types.ErrCodeNotFound {
+			ps.client.logger.Warn("failed to read policy for history", "policy", policyName, "error", err)
+		}
+		return
+	}
+	ps.recordSnapshot(current)
+}
+
+// getForUpdate reads a policy for a read-modify-write helper, bypassing the cache.
+// With history enabled the policy is recorded as a snapshot, so the following Update does not read it again.
+func (ps *PolicyService) getForUpdate(policyName string) (*androidmanagement.Policy, error) {
+	policy, err := ps.client.WithoutCache().Policies().Get(policyName)
+	if err != nil {
+		return nil, err
+	}
+	ps.recordSnapshot(policy)
+	return policy, nil
+}
+
+// recordSnapshot records policy as a snapshot unless its version is already recorded.
+func (ps *PolicyService) recordSnapshot(policy *androidmanagement.Policy) {
+	if ps.client.history == nil || ps.isRecorded(policy.Name, policy.Version) {
+		return
+	}
+	ps.recordHistory(policy, "snapshot")
+}
+
+// isRecorded reports whether a version of a policy is in the history. Read errors count as not recorded.
+func (ps *PolicyService) isRecorded(policyName string, version int64) bool {
+	_, ok, err := ps.client.history.Get(ps.client.ctx, policyName, version)
+	return err == nil && ok
+}
+
+// recordHistory stores policy under its version. Failures are logged and do not fail the API call.
+func (ps *PolicyService) recordHistory(policy *androidmanagement.Policy, operation string) {
+	if ps.client.history == nil || policy == nil {
+		return
+	}
+
+	data, err := json.Marshal(policy)
+	if err == nil {
+		err = ps.client.history.Record(ps.client.ctx, policy.Name, utils.HistoryEntry{
+			Version:    policy.Version,
+			RecordedAt: time.Now().UTC(),
+			Operation:  operation,
+			Data:       data,
+		})
+	}
+	if err != nil {
+		ps.client.logger.Warn("failed to record policy history", "policy", policy.Name, "version", policy.Version, "error", err)
+	}
+}
+
+// newPolicyRevision decodes a history entry.
+func newPolicyRevision(entry utils.HistoryEntry) (*PolicyRevision, error) {
+	var policy androidmanagement.Policy
+	if err := json.Unmarshal(entry.Data, &policy); err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInvalidResponse, "failed to decode policy history")
+	}
+	return &PolicyRevision{
+		Version:    entry.Version,
+		RecordedAt: entry.RecordedAt,
+		Operation:  entry.Operation,
+		Policy:     &policy,
+	}, nil
+}
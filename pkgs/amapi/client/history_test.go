@@ -0,0 +1,170 @@
+package client_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/amapitest"
+	"amapi-pkg/pkgs/amapi/client"
+	"amapi-pkg/pkgs/amapi/types"
+	"amapi-pkg/pkgs/amapi/utils"
+)
+
+// 测试策略变更记录历史并回滚到之前的版本
+func TestPolicyHistoryAndRollback(t *testing.T) {
+	srv := amapitest.NewServer()
+	defer srv.Close()
+
+	c, err := client.New(srv.Config(), append(srv.ClientOptions(), client.WithPolicyHistory(utils.NewMemoryHistoryStore(0)))...)
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+	defer c.Close()
+
+	enterprise := srv.AddEnterprise(&androidmanagement.Enterprise{})
+	policyName := enterprise.Name + "/policies/default"
+	// 启用历史之前已经存在的策略，第一次更新前记录为快照
+	srv.AddPolicy(&androidmanagement.Policy{Name: policyName, Version: 1, CameraDisabled: true})
+
+	policies := c.Policies()
+	if _, err := policies.Update(policyName, &androidmanagement.Policy{ScreenCaptureDisabled: true}, []string{"screenCaptureDisabled"}); err != nil {
+		t.Fatalf("Update() unexpected error: %v", err)
+	}
+	if _, err := policies.AddApplication(policyName, &androidmanagement.ApplicationPolicy{PackageName: "com.example.app", InstallType: "FORCE_INSTALLED"}); err != nil {
+		t.Fatalf("AddApplication() unexpected error: %v", err)
+	}
+	// 错误的推送：相机重新启用
+	if _, err := policies.Update(policyName, &androidmanagement.Policy{}, []string{"cameraDisabled"}); err != nil {
+		t.Fatalf("Update() unexpected error: %v", err)
+	}
+
+	revisions, err := policies.History(policyName)
+	if err != nil {
+		t.Fatalf("History() unexpected error: %v", err)
+	}
+	var got []string
+	for _, revision := range revisions {
+		got = append(got, fmt.Sprintf("%d %s", revision.Version, revision.Operation))
+	}
+	if fmt.Sprint(got) != "[1 snapshot 2 update policy 3 update policy 4 update policy]" {
+		t.Fatalf("History() = %v", got)
+	}
+
+	policy, err := policies.Rollback(policyName, 3)
+	if err != nil {
+		t.Fatalf("Rollback() unexpected error: %v", err)
+	}
+	if policy.Version != 5 || !policy.CameraDisabled || !policy.ScreenCaptureDisabled || len(policy.Applications) != 1 {
+		t.Errorf("Rollback() = %+v, want version 3 restored as version 5", policy)
+	}
+	if current := srv.Policy(policyName); !current.CameraDisabled || len(current.Applications) != 1 {
+		t.Errorf("server policy = %+v, want the rolled back policy", current)
+	}
+	if revisions, _ := policies.History(policyName); len(revisions) != 5 || revisions[4].Operation != "rollback policy" {
+		t.Errorf("History() after rollback has %d revisions", len(revisions))
+	}
+
+	// 新建的策略同样记录
+	if _, err := policies.Create(enterprise.Name, "kiosk", &androidmanagement.Policy{StatusBarDisabled: true}); err != nil {
+		t.Fatalf("Create() unexpected error: %v", err)
+	}
+	if revisions, err := policies.History(enterprise.Name + "/policies/kiosk"); err != nil || len(revisions) != 1 || revisions[0].Operation != "create policy" {
+		t.Errorf("History(kiosk) = %v, %v", revisions, err)
+	}
+
+	var apiErr *types.Error
+	if _, err := policies.Rollback(policyName, 42); !errors.As(err, &apiErr) || apiErr.Code != types.ErrCodeNotFound {
+		t.Errorf("Rollback(missing version) error = %v, want not found", err)
+	}
+}
+
+// 测试启用历史后更新不会重复读取策略
+func TestPolicyHistoryRequests(t *testing.T) {
+	srv := amapitest.NewServer()
+	defer srv.Close()
+
+	c, err := client.New(srv.Config(), append(srv.ClientOptions(), client.WithPolicyHistory(utils.NewMemoryHistoryStore(0)))...)
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+	defer c.Close()
+
+	enterprise := srv.AddEnterprise(&androidmanagement.Enterprise{})
+	policyName := enterprise.Name + "/policies/default"
+	srv.AddPolicy(&androidmanagement.Policy{Name: policyName, Version: 1})
+
+	// countRequests 返回 call 发出的 GET 和 PATCH 请求数
+	countRequests := func(call func() error) (gets, patches int) {
+		t.Helper()
+		before := len(srv.Requests())
+		if err := call(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, r := range srv.Requests()[before:] {
+			switch r.Method {
+			case http.MethodGet:
+				gets++
+			case http.MethodPatch:
+				patches++
+			}
+		}
+		return gets, patches
+	}
+
+	// 读取后修改的辅助方法复用已读取的策略作为快照
+	gets, patches := countRequests(func() error {
+		_, err := c.Policies().AddApplication(policyName, &androidmanagement.ApplicationPolicy{PackageName: "com.example.app"})
+		return err
+	})
+	if gets != 1 || patches != 1 {
+		t.Errorf("AddApplication() sent %d GET and %d PATCH requests, want 1 and 1", gets, patches)
+	}
+
+	// 历史中已有上一次写入的版本，不带版本的更新不再读取
+	gets, patches = countRequests(func() error {
+		_, err := c.Policies().Update(policyName, &androidmanagement.Policy{CameraDisabled: true}, []string{"cameraDisabled"})
+		return err
+	})
+	if gets != 0 || patches != 1 {
+		t.Errorf("Update() sent %d GET and %d PATCH requests, want 0 and 1", gets, patches)
+	}
+
+	revisions, err := c.Policies().History(policyName)
+	if err != nil {
+		t.Fatalf("History() unexpected error: %v", err)
+	}
+	var got []string
+	for _, revision := range revisions {
+		got = append(got, fmt.Sprintf("%d %s", revision.Version, revision.Operation))
+	}
+	if fmt.Sprint(got) != "[1 snapshot 2 update policy 3 update policy]" {
+		t.Errorf("History() = %v", got)
+	}
+	if len(revisions) > 0 && len(revisions[0].Policy.Applications) != 0 {
+		t.Error("snapshot recorded the modified policy instead of the version read from the server")
+	}
+}
+
+// 测试未启用策略历史时返回配置错误
+func TestPolicyHistoryDisabled(t *testing.T) {
+	srv := amapitest.NewServer()
+	defer srv.Close()
+
+	c, err := srv.NewClient()
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+	defer c.Close()
+
+	var apiErr *types.Error
+	if _, err := c.Policies().History("enterprises/e1/policies/p1"); !errors.As(err, &apiErr) || apiErr.Code != types.ErrCodeConfiguration {
+		t.Errorf("History() error = %v, want configuration error", err)
+	}
+	if _, err := c.Policies().Rollback("enterprises/e1/policies/p1", 1); !errors.As(err, &apiErr) || apiErr.Code != types.ErrCodeConfiguration {
+		t.Errorf("Rollback() error = %v, want configuration error", err)
+	}
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// apiRequest describes a logical API call.
+//
+// operation 用于日志、链路追踪和错误信息；resource 和 body 用于生成重试协调使用的
+// 操作 ID（见 operationID），以及 cachedAPICall 的缓存 key。
+type apiRequest struct {
+	// operation names the call, e.g. "get device"
+	operation string
+
+	// resource is the resource name, or the parent collection for create and list calls
+	resource string
+
+	// body is the request body or the list parameters; nil for calls without one
+	body any
+}
+
+// operationID returns the ID used to coordinate retries of the request across processes.
+//
+// 默认由操作名称、资源名称和请求体的哈希确定性地生成，例如：
+//
+//	get_device:enterprises/LC00abc/devices/d1
+//	issue_device_command:enterprises/LC00abc/devices/d1:3f2a9c0b1d4e5f60
+//
+// 因此不同进程中的同一个逻辑操作会得到相同的 ID，RedisRetryHandler 的分布式锁
+// 和 GetRetryCount 才能在进程之间生效。idempotencyKey 非空时替代资源名称和请求哈希。
+func (r apiRequest) operationID(idempotencyKey string) string {
+	operation := strings.ReplaceAll(r.operation, " ", "_")
+	if idempotencyKey != "" {
+		return operation + ":" + idempotencyKey
+	}
+
+	id := operation + ":" + r.resource
+	if r.body != nil {
+		id += ":" + hashRequestBody(r.body)
+	}
+	return id
+}
+
+// cacheKey returns the response cache key of the request.
+// List calls are keyed by their collection and parameters, other calls by the resource name.
+func (r apiRequest) cacheKey() string {
+	if params, ok := r.body.(url.Values); ok {
+		return listCacheKey(r.resource, params)
+	}
+	return r.resource
+}
+
+// secretRequestFields lists the request body fields excluded from operation ID hashes (compared case-insensitively).
+//
+// 操作 ID 会出现在日志、span 属性和 Redis key 中，不能由它反推出密码、激活码等短小的秘密值。
+// 这里只列出请求中的秘密字段；sensitiveFields 中的 value 等通用字段还出现在托管配置等
+// 普通数据中，参与哈希才能区分不同的写操作。
+var secretRequestFields = map[string]bool{
+	"password":       true,
+	"newpassword":    true,
+	"passphrase":     true,
+	"activationcode": true,
+	"private_key":    true,
+	"client_secret":  true,
+	"access_token":   true,
+	"refresh_token":  true,
+}
+
+// hashRequestBody returns a short, stable hash of the JSON encoding of body.
+// encoding/json sorts map keys, so equal bodies always produce the same hash.
+//
+// 哈希前隐藏 secretRequestFields 中的字段，因此只有这些字段不同的请求会得到相同的 ID。
+func hashRequestBody(body any) string {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "unhashable"
+	}
+
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return "unhashable"
+	}
+	if data, err = json.Marshal(redactValue(decoded, secretRequestFields)); err != nil {
+		return "unhashable"
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// WithIdempotencyKey returns a view of the client whose API calls use key to coordinate retries.
+//
+// 默认的操作 ID 由操作、资源名称和请求内容生成，内容相同的请求会被视为同一个操作。
+// 当调用方有自己的业务 ID（例如任务 ID）时，可以用它区分或合并操作：
+//
+//	err := client.WithIdempotencyKey("job-42").Devices().IssueCommand(deviceName, command)
+//
+//	// 查询该操作的重试次数（仅 RedisRetryHandler）
+//	count, err := retryHandler.GetRetryCount(ctx, "issue_device_command:job-42")
+//
+// 操作名称始终是 ID 的一部分，因此同一个视图发出的不同操作（例如 Update 内部的
+// Get 和 Patch）不会相互冲突。key 为空时恢复默认行为。
+//
+// 注意：只应对原客户端调用 Close()，视图之间共享资源。
+func (c *Client) WithIdempotencyKey(key string) *Client {
+	view := *c
+	view.idempotencyKey = key
+	return &view
+}
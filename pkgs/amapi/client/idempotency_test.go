@@ -0,0 +1,90 @@
+package client
+
+import (
+	"net/url"
+	"testing"
+
+	"google.golang.org/api/androidmanagement/v1"
+)
+
+// 测试操作 ID 的确定性生成
+func TestOperationID(t *testing.T) {
+	command := &androidmanagement.Command{Type: "LOCK", Duration: "60s"}
+	device := "enterprises/e1/devices/d1"
+
+	get := apiRequest{operation: "get device", resource: device}
+	if id := get.operationID(""); id != "get_device:"+device {
+		t.Errorf("operationID() = %q, want get_device:%s", id, device)
+	}
+
+	issue := apiRequest{operation: "issue device command", resource: device, body: command}
+	same := apiRequest{operation: "issue device command", resource: device, body: &androidmanagement.Command{Type: "LOCK", Duration: "60s"}}
+	other := apiRequest{operation: "issue device command", resource: device, body: &androidmanagement.Command{Type: "REBOOT"}}
+
+	if issue.operationID("") != same.operationID("") {
+		t.Error("equal requests produced different operation IDs")
+	}
+	if issue.operationID("") == other.operationID("") {
+		t.Error("different request bodies produced the same operation ID")
+	}
+	if issue.operationID("") == (apiRequest{operation: "issue device command", resource: device + "2", body: command}).operationID("") {
+		t.Error("different resources produced the same operation ID")
+	}
+
+	// 只有秘密字段不同的请求得到相同的 ID，托管配置中的 value 等普通字段参与哈希
+	policy := func(pin, value string) apiRequest {
+		return apiRequest{operation: "patch policy", resource: "enterprises/e1/policies/p1", body: map[string]any{
+			"passwordPolicies": []any{map[string]any{"password": pin}},
+			"applications": []any{map[string]any{
+				"packageName":          "com.example.app",
+				"managedConfiguration": map[string]any{"key": "server", "value": value},
+			}},
+		}}
+	}
+	if policy("123456", "a").operationID("") != policy("654321", "a").operationID("") {
+		t.Error("requests differing only in a secret field produced different operation IDs")
+	}
+	if policy("123456", "a").operationID("") == policy("123456", "b").operationID("") {
+		t.Error("requests differing in a value field produced the same operation ID")
+	}
+
+	// 调用方提供的 key 替代资源和请求哈希，但保留操作名称
+	if id := issue.operationID("job-42"); id != "issue_device_command:job-42" {
+		t.Errorf("operationID(job-42) = %q", id)
+	}
+	if get.operationID("job-42") == issue.operationID("job-42") {
+		t.Error("different operations with the same idempotency key share an operation ID")
+	}
+
+	// 列表参数的顺序不影响 ID
+	a := url.Values{}
+	a.Set("pageSize", "10")
+	a.Set("pageToken", "abc")
+	b := url.Values{}
+	b.Set("pageToken", "abc")
+	b.Set("pageSize", "10")
+	listA := apiRequest{operation: "list devices", resource: "enterprises/e1/devices", body: a}
+	listB := apiRequest{operation: "list devices", resource: "enterprises/e1/devices", body: b}
+	if listA.operationID("") != listB.operationID("") {
+		t.Error("list parameters in a different order produced different operation IDs")
+	}
+	if listA.cacheKey() != "enterprises/e1/devices?pageSize=10&pageToken=abc" {
+		t.Errorf("cacheKey() = %q", listA.cacheKey())
+	}
+	if get.cacheKey() != device {
+		t.Errorf("cacheKey() = %q, want %q", get.cacheKey(), device)
+	}
+}
+
+// 测试 WithIdempotencyKey 返回的视图不影响原客户端
+func TestWithIdempotencyKey(t *testing.T) {
+	base := &Client{}
+	view := base.WithIdempotencyKey("job-42")
+
+	if view.idempotencyKey != "job-42" {
+		t.Errorf("view idempotencyKey = %q, want job-42", view.idempotencyKey)
+	}
+	if base.idempotencyKey != "" {
+		t.Error("WithIdempotencyKey() modified the original client")
+	}
+}
@@ -0,0 +1,291 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// Application sources and states reported in Device.ApplicationReports.
+const (
+	AppSourceSystemFactory = "SYSTEM_APP_FACTORY_VERSION"
+	AppSourceSystemUpdated = "SYSTEM_APP_UPDATED_VERSION"
+	AppSourcePlayStore     = "INSTALLED_FROM_PLAY_STORE"
+
+	AppStateInstalled = "INSTALLED"
+	AppStateRemoved   = "REMOVED"
+)
+
+// AppInstallation is one application report of one device.
+type AppInstallation struct {
+	DeviceName           string `json:"device_name"`
+	PackageName          string `json:"package_name"`
+	DisplayName          string `json:"display_name,omitempty"`
+	VersionCode          int64  `json:"version_code"`
+	VersionName          string `json:"version_name,omitempty"`
+	State                string `json:"state"`
+	ApplicationSource    string `json:"application_source,omitempty"`
+	InstallerPackageName string `json:"installer_package_name,omitempty"`
+
+	// Managed is true if the package is listed in the applications of the device's applied policy
+	Managed bool `json:"managed"`
+}
+
+// IsSystemApp reports whether the application is a system app.
+func (i *AppInstallation) IsSystemApp() bool {
+	return i.ApplicationSource == AppSourceSystemFactory || i.ApplicationSource == AppSourceSystemUpdated
+}
+
+// IsInstalled reports whether the application is currently installed.
+func (i *AppInstallation) IsInstalled() bool {
+	return i.State != AppStateRemoved
+}
+
+// InventoryApp aggregates the installations of one package.
+type InventoryApp struct {
+	PackageName string `json:"package_name"`
+	DisplayName string `json:"display_name,omitempty"`
+
+	// DeviceCount is the number of devices with the package installed
+	DeviceCount int `json:"device_count"`
+
+	// MinVersionCode and MaxVersionCode are the version range of the installed package
+	MinVersionCode int64 `json:"min_version_code"`
+	MaxVersionCode int64 `json:"max_version_code"`
+
+	// Installations holds every report of the package, including removed ones, sorted by device name
+	Installations []*AppInstallation `json:"installations"`
+}
+
+// AppInventory is the result of DeviceService.AppInventory.
+type AppInventory struct {
+	// Enterprise is the enterprise resource name
+	Enterprise string `json:"enterprise"`
+
+	// GeneratedAt is the time the inventory was created
+	GeneratedAt time.Time `json:"generated_at"`
+
+	// TotalDevices is the number of devices scanned
+	TotalDevices int `json:"total_devices"`
+
+	// ReportingDevices is the number of devices with application reports
+	ReportingDevices int `json:"reporting_devices"`
+
+	// Apps holds one entry per package, sorted by package name
+	Apps []*InventoryApp `json:"apps"`
+}
+
+// App returns the inventory entry of a package, or nil if no device reported it.
+func (inv *AppInventory) App(packageName string) *InventoryApp {
+	for _, app := range inv.Apps {
+		if app.PackageName == packageName {
+			return app
+		}
+	}
+	return nil
+}
+
+// BelowVersion returns the installations of a package with a version code lower than versionCode.
+//
+// 用于漏洞应用响应，例如找出 com.example.app 低于修复版本的设备。
+func (inv *AppInventory) BelowVersion(packageName string, versionCode int64) []*AppInstallation {
+	var installations []*AppInstallation
+	if app := inv.App(packageName); app != nil {
+		for _, installation := range app.Installations {
+			if installation.IsInstalled() && installation.VersionCode < versionCode {
+				installations = append(installations, installation)
+			}
+		}
+	}
+	return installations
+}
+
+// Unmanaged returns the installed applications that are neither system apps nor listed in the device's policy.
+func (inv *AppInventory) Unmanaged() []*AppInstallation {
+	var installations []*AppInstallation
+	for _, app := range inv.Apps {
+		for _, installation := range app.Installations {
+			if installation.IsInstalled() && !installation.Managed && !installation.IsSystemApp() {
+				installations = append(installations, installation)
+			}
+		}
+	}
+	return installations
+}
+
+// Write writes the inventory in the given format.
+//
+// JSON 包含完整清单；CSV 每行是一台设备上的一个应用；表格按应用汇总设备数量和版本范围。
+func (inv *AppInventory) Write(w io.Writer, format types.ReportFormat) error {
+	switch format {
+	case types.ReportFormatJSON:
+		return writeJSON(w, inv)
+
+	case types.ReportFormatCSV:
+		var rows [][]string
+		for _, app := range inv.Apps {
+			for _, i := range app.Installations {
+				rows = append(rows, []string{i.DeviceName, i.PackageName, i.DisplayName, strconv.FormatInt(i.VersionCode, 10),
+					i.VersionName, i.State, i.ApplicationSource, i.InstallerPackageName, strconv.FormatBool(i.Managed)})
+			}
+		}
+		return writeCSV(w, []string{"device_name", "package_name", "display_name", "version_code", "version_name",
+			"state", "application_source", "installer_package_name", "managed"}, rows)
+
+	case types.ReportFormatTable:
+		fmt.Fprintf(w, "%s: %d devices, %d with application reports, %d packages\n\n",
+			inv.Enterprise, inv.TotalDevices, inv.ReportingDevices, len(inv.Apps))
+		rows := make([][]string, 0, len(inv.Apps))
+		for _, app := range inv.Apps {
+			rows = append(rows, []string{app.PackageName, app.DisplayName, strconv.Itoa(app.DeviceCount),
+				versionRange(app.MinVersionCode, app.MaxVersionCode)})
+		}
+		return writeTable(w, []string{"PACKAGE", "NAME", "DEVICES", "VERSIONS"}, rows)
+	}
+
+	return unsupportedFormat(format, types.ReportFormatJSON, types.ReportFormatCSV, types.ReportFormatTable)
+}
+
+// AppInventory aggregates Device.ApplicationReports across every device of an enterprise.
+//
+// 设备只有在策略启用 ApplicationReportsEnabled（以及 StatusReportingSettings）时才会上报应用。
+// 每个设备所应用策略中的 applications 决定安装记录的 Managed 字段，策略按名称读取一次；
+// 已删除的策略视为没有托管应用。
+//
+//	inventory, err := client.Devices().AppInventory(enterpriseID)
+//	for _, installation := range inventory.BelowVersion("com.example.app", 420) {
+//	    log.Printf("%s: %s", installation.DeviceName, installation.VersionName)
+//	}
+//	for _, installation := range inventory.Unmanaged() {
+//	    log.Printf("%s: %s", installation.DeviceName, installation.PackageName)
+//	}
+func (ds *DeviceService) AppInventory(enterpriseID string) (*AppInventory, error) {
+	if err := validateEnterpriseID(enterpriseID); err != nil {
+		return nil, err
+	}
+
+	enterpriseName := resolveEnterpriseName(enterpriseID)
+	inventory := &AppInventory{Enterprise: enterpriseName, GeneratedAt: time.Now(), Apps: make([]*InventoryApp, 0)}
+	apps := make(map[string]*InventoryApp)
+	policyApps := make(map[string]map[string]bool)
+
+	for device, err := range ds.All(enterpriseName, "", nil, "") {
+		if err != nil {
+			return nil, err
+		}
+
+		inventory.TotalDevices++
+		if len(device.ApplicationReports) == 0 {
+			continue
+		}
+		inventory.ReportingDevices++
+
+		managed, err := ds.policyPackages(policyApps, device)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, report := range device.ApplicationReports {
+			if report == nil || report.PackageName == "" {
+				continue
+			}
+
+			app, ok := apps[report.PackageName]
+			if !ok {
+				app = &InventoryApp{PackageName: report.PackageName}
+				apps[report.PackageName] = app
+				inventory.Apps = append(inventory.Apps, app)
+			}
+			addAppInstallation(app, device, report, managed[report.PackageName])
+		}
+	}
+
+	for _, app := range inventory.Apps {
+		sort.SliceStable(app.Installations, func(i, j int) bool {
+			return app.Installations[i].DeviceName < app.Installations[j].DeviceName
+		})
+	}
+	sort.Slice(inventory.Apps, func(i, j int) bool {
+		return inventory.Apps[i].PackageName < inventory.Apps[j].PackageName
+	})
+
+	return inventory, nil
+}
+
+// policyPackages returns the packages listed in the applied policy of a device, caching policies by name.
+func (ds *DeviceService) policyPackages(cache map[string]map[string]bool, device *androidmanagement.Device) (map[string]bool, error) {
+	policyName := device.AppliedPolicyName
+	if policyName == "" {
+		policyName = device.PolicyName
+	}
+	if policyName == "" {
+		return nil, nil
+	}
+	if packages, ok := cache[policyName]; ok {
+		return packages, nil
+	}
+
+	packages := make(map[string]bool)
+	policy, err := ds.client.Policies().Get(policyName)
+	if err != nil {
+		var apiErr *types.Error
+		if !errors.As(err, &apiErr) || apiErr.Code != types.ErrCodeNotFound {
+			return nil, err
+		}
+	} else {
+		for _, app := range policy.Applications {
+			if app != nil {
+				packages[app.PackageName] = true
+			}
+		}
+	}
+
+	cache[policyName] = packages
+	return packages, nil
+}
+
+// addAppInstallation records an application report of a device.
+func addAppInstallation(app *InventoryApp, device *androidmanagement.Device, report *androidmanagement.ApplicationReport, managed bool) {
+	installation := &AppInstallation{
+		DeviceName:           device.Name,
+		PackageName:          report.PackageName,
+		DisplayName:          report.DisplayName,
+		VersionCode:          report.VersionCode,
+		VersionName:          report.VersionName,
+		State:                report.State,
+		ApplicationSource:    report.ApplicationSource,
+		InstallerPackageName: report.InstallerPackageName,
+		Managed:              managed,
+	}
+	app.Installations = append(app.Installations, installation)
+
+	if app.DisplayName == "" {
+		app.DisplayName = report.DisplayName
+	}
+	if !installation.IsInstalled() {
+		return
+	}
+
+	if app.DeviceCount == 0 || report.VersionCode < app.MinVersionCode {
+		app.MinVersionCode = report.VersionCode
+	}
+	if app.DeviceCount == 0 || report.VersionCode > app.MaxVersionCode {
+		app.MaxVersionCode = report.VersionCode
+	}
+	app.DeviceCount++
+}
+
+// versionRange formats a version code range.
+func versionRange(lo, hi int64) string {
+	if lo == hi {
+		return strconv.FormatInt(lo, 10)
+	}
+	return fmt.Sprintf("%d-%d", lo, hi)
+}
@@ -0,0 +1,97 @@
+package client_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/client"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// 测试汇总应用报告并按版本和托管状态查询
+func TestAppInventory(t *testing.T) {
+	srv, c, device := newDeviceTestClient(t)
+	enterpriseName := device.Name[:len(device.Name)-len("/devices/d1")]
+	kiosk := enterpriseName + "/policies/kiosk"
+	srv.AddPolicy(&androidmanagement.Policy{
+		Name:         kiosk,
+		Applications: []*androidmanagement.ApplicationPolicy{{PackageName: "com.example.mail"}},
+	})
+
+	mail := func(version int64) *androidmanagement.ApplicationReport {
+		return &androidmanagement.ApplicationReport{
+			PackageName: "com.example.mail", DisplayName: "Mail", VersionCode: version, VersionName: fmt.Sprintf("1.%d", version),
+			State: client.AppStateInstalled, ApplicationSource: client.AppSourcePlayStore,
+		}
+	}
+	sideloaded := &androidmanagement.ApplicationReport{
+		PackageName: "com.example.game", DisplayName: "Game", VersionCode: 3, State: client.AppStateInstalled,
+		InstallerPackageName: "com.android.packageinstaller",
+	}
+	camera := &androidmanagement.ApplicationReport{
+		PackageName: "com.android.camera", VersionCode: 1, State: client.AppStateInstalled, ApplicationSource: client.AppSourceSystemFactory,
+	}
+
+	srv.AddDevice(&androidmanagement.Device{Name: enterpriseName + "/devices/d2", AppliedPolicyName: kiosk,
+		ApplicationReports: []*androidmanagement.ApplicationReport{mail(40), sideloaded, camera}})
+	srv.AddDevice(&androidmanagement.Device{Name: enterpriseName + "/devices/d3", AppliedPolicyName: kiosk,
+		ApplicationReports: []*androidmanagement.ApplicationReport{mail(42), camera}})
+	srv.AddDevice(&androidmanagement.Device{Name: enterpriseName + "/devices/d4", AppliedPolicyName: enterpriseName + "/policies/deleted",
+		ApplicationReports: []*androidmanagement.ApplicationReport{mail(38), {PackageName: "com.example.game", VersionCode: 2, State: client.AppStateRemoved}}})
+
+	inventory, err := c.Devices().AppInventory(enterpriseName)
+	if err != nil {
+		t.Fatalf("AppInventory() unexpected error: %v", err)
+	}
+
+	if inventory.TotalDevices != 4 || inventory.ReportingDevices != 3 || len(inventory.Apps) != 3 {
+		t.Fatalf("inventory = %d devices, %d reporting, %d apps, want 4, 3, 3", inventory.TotalDevices, inventory.ReportingDevices, len(inventory.Apps))
+	}
+	if inventory.Apps[0].PackageName != "com.android.camera" {
+		t.Errorf("Apps[0] = %s, want apps sorted by package name", inventory.Apps[0].PackageName)
+	}
+
+	app := inventory.App("com.example.mail")
+	if app == nil || app.DeviceCount != 3 || app.MinVersionCode != 38 || app.MaxVersionCode != 42 || app.DisplayName != "Mail" {
+		t.Fatalf("App(mail) = %+v, want 3 devices with versions 38-42", app)
+	}
+	if game := inventory.App("com.example.game"); game.DeviceCount != 1 || len(game.Installations) != 2 {
+		t.Errorf("App(game) = %+v, want 1 installed and 1 removed", game)
+	}
+
+	below := inventory.BelowVersion("com.example.mail", 41)
+	if len(below) != 2 || below[0].DeviceName != enterpriseName+"/devices/d2" || below[1].DeviceName != enterpriseName+"/devices/d4" {
+		t.Errorf("BelowVersion(mail, 41) = %+v, want d2 and d4", below)
+	}
+	if below := inventory.BelowVersion("com.example.missing", 1); len(below) != 0 {
+		t.Errorf("BelowVersion(missing) = %+v, want none", below)
+	}
+
+	// d4 的策略已删除，因此其中的 mail 也是非托管应用；系统应用和已移除的应用不计入
+	var unmanaged []string
+	for _, installation := range inventory.Unmanaged() {
+		unmanaged = append(unmanaged, installation.PackageName+"@"+installation.DeviceName[len(enterpriseName+"/devices/"):])
+	}
+	if strings.Join(unmanaged, ",") != "com.example.game@d2,com.example.mail@d4" {
+		t.Errorf("Unmanaged() = %v, want game on d2 and mail on d4", unmanaged)
+	}
+
+	var buf bytes.Buffer
+	if err := inventory.Write(&buf, types.ReportFormatCSV); err != nil {
+		t.Fatalf("Write(csv) unexpected error: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil || len(records) != 8 || records[0][1] != "package_name" {
+		t.Errorf("csv = %v, %v, want header and one row per installation", records, err)
+	}
+
+	buf.Reset()
+	if err := inventory.Write(&buf, types.ReportFormatTable); err != nil || !strings.Contains(buf.String(), "38-42") {
+		t.Errorf("table = %s, %v, want version range", buf.String(), err)
+	}
+}
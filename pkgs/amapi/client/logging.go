@@ -0,0 +1,284 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"amapi-pkg/pkgs/amapi/config"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// maxLoggedBodySize limits the size of request and response bodies written to debug logs.
+const maxLoggedBodySize = 4096
+
+// redactedValue replaces sensitive values in logged bodies.
+const redactedValue = "[REDACTED]"
+
+// sensitiveFields lists the JSON fields whose values are never logged (compared case-insensitively).
+//
+// 包括企业令牌、注册令牌和迁移令牌的值（value、qrCode）、Web 令牌，
+// 以及服务账号和 OAuth 凭据、设备密码和 eSIM 激活码。
+var sensitiveFields = map[string]bool{
+	"value":           true,
+	"qrcode":          true,
+	"token":           true,
+	"enrollmenttoken": true,
+	"enterprisetoken": true,
+	"private_key":     true,
+	"client_secret":   true,
+	"access_token":    true,
+	"refresh_token":   true,
+	"password":        true,
+	"newpassword":     true,
+	"passphrase":      true,
+	"activationcode":  true,
+}
+
+// callInfo collects what happened during one logical API call.
+// It is stored in the request context so the HTTP transport can fill in the details.
+type callInfo struct {
+	operation     string
+	operationID   string
+	attempt       int
+	method        string
+	resource      string
+	status        int
+	rateLimitWait time.Duration
+}
+
+// callInfoKey is the context key for *callInfo.
+type callInfoKey struct{}
+
+// callInfoFromContext returns the callInfo of the API call running in ctx, or nil.
+func callInfoFromContext(ctx context.Context) *callInfo {
+	info, _ := ctx.Value(callInfoKey{}).(*callInfo)
+	return info
+}
+
+// Logger returns the logger used by the client.
+func (c *Client) Logger() *slog.Logger {
+	return c.logger
+}
+
+// newDefaultLogger creates the logger used when WithLogger is not given.
+// It writes text logs to stderr at cfg.LogLevel, or at debug level if EnableDebugLogging is set.
+func newDefaultLogger(cfg *config.Config) *slog.Logger {
+	level := parseLogLevel(cfg.LogLevel)
+	if cfg.EnableDebugLogging {
+		level = slog.LevelDebug
+	}
+
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+}
+
+// parseLogLevel converts a config log level to a slog level. Unknown values map to info.
+func parseLogLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// logCall writes the summary of a finished API call.
+//
+// 成功的调用记录为 Debug，默认级别下不会输出；客户端错误（4xx）记录为 Warn，其他错误记录为 Error。
+func (c *Client) logCall(ctx context.Context, info *callInfo, latency time.Duration, err error) {
+	level := slog.LevelDebug
+	if err != nil {
+		level = slog.LevelError
+		status := info.status
+		var apiErr *types.Error
+		if status == 0 && errors.As(err, &apiErr) {
+			status = apiErr.Code
+		}
+		if status >= 400 && status < 500 {
+			level = slog.LevelWarn
+		}
+	}
+
+	if !c.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("operation", info.operation),
+		slog.String("operation_id", info.operationID),
+		slog.String("method", info.method),
+		slog.String("resource", info.resource),
+		slog.Int("status", info.status),
+		slog.Duration("latency", latency),
+		slog.Int("attempts", info.attempt),
+		slog.Duration("rate_limit_wait", info.rateLimitWait),
+	}
+
+	message := "API call completed"
+	if err != nil {
+		message = "API call failed"
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	c.logger.LogAttrs(ctx, level, message, attrs...)
+}
+
+// loggingTransport is an http.RoundTripper that logs every HTTP attempt at debug level.
+//
+// 它记录请求方法、资源名称、状态码和耗时，并把这些信息写回 callInfo。
+// 启用 logBodies 时还会记录请求体和响应体，敏感字段会被替换为 [REDACTED]。
+// 请求头（包括 Authorization）永远不会被记录。
+type loggingTransport struct {
+	base      http.RoundTripper
+	logger    *slog.Logger
+	logBodies bool
+}
+
+// newLoggingHTTPClient returns a copy of hc whose transport logs through logger.
+// hc itself is not modified.
+func newLoggingHTTPClient(hc *http.Client, logger *slog.Logger, logBodies bool) *http.Client {
+	base := hc.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	wrapped := *hc
+	wrapped.Transport = &loggingTransport{base: base, logger: logger, logBodies: logBodies}
+	return &wrapped
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	resource := resourceFromPath(req.URL.Path)
+
+	info := callInfoFromContext(ctx)
+	if info != nil {
+		info.method = req.Method
+		info.resource = resource
+	}
+
+	debug := t.logger.Enabled(ctx, slog.LevelDebug)
+	logBodies := debug && t.logBodies
+
+	var requestBody string
+	if logBodies && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			body.Close()
+			requestBody = redactBody(data)
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	latency := time.Since(start)
+
+	if info != nil && resp != nil {
+		info.status = resp.StatusCode
+	}
+
+	if !debug {
+		return resp, err
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("resource", resource),
+		slog.Duration("latency", latency),
+	}
+	if info != nil {
+		attrs = append(attrs, slog.String("operation", info.operation), slog.Int("attempt", info.attempt))
+	}
+	if resp != nil {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	if logBodies {
+		if requestBody != "" {
+			attrs = append(attrs, slog.String("request_body", requestBody))
+		}
+		if resp != nil && resp.Body != nil {
+			data, readErr := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			resp.Body = io.NopCloser(bytes.NewReader(data))
+			if readErr == nil && len(data) > 0 {
+				attrs = append(attrs, slog.String("response_body", redactBody(data)))
+			}
+		}
+	}
+
+	t.logger.LogAttrs(ctx, slog.LevelDebug, "HTTP request", attrs...)
+	return resp, err
+}
+
+// CloseIdleConnections closes idle connections of the underlying transport.
+func (t *loggingTransport) CloseIdleConnections() {
+	if closer, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// resourceFromPath extracts the resource name from an API URL path,
+// e.g. "/v1/enterprises/e1/devices/d1" becomes "enterprises/e1/devices/d1".
+func resourceFromPath(path string) string {
+	if i := strings.Index(path, "/v1/"); i >= 0 {
+		return path[i+len("/v1/"):]
+	}
+	return strings.TrimPrefix(path, "/")
+}
+
+// redactBody returns a loggable form of a JSON body with sensitive fields removed.
+// Bodies that are not JSON are not logged, since they cannot be redacted reliably.
+func redactBody(data []byte) string {
+	var body any
+	if err := json.Unmarshal(data, &body); err != nil {
+		return "[non-JSON body omitted]"
+	}
+
+	redacted, err := json.Marshal(redactValue(body, sensitiveFields))
+	if err != nil {
+		return "[body omitted]"
+	}
+
+	if len(redacted) > maxLoggedBodySize {
+		return string(redacted[:maxLoggedBodySize]) + "...(truncated)"
+	}
+	return string(redacted)
+}
+
+// redactValue replaces the values of the given fields (lower case) in a decoded JSON value.
+func redactValue(v any, fields map[string]bool) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if fields[strings.ToLower(key)] {
+				v[key] = redactedValue
+			} else {
+				v[key] = redactValue(value, fields)
+			}
+		}
+		return v
+	case []any:
+		for i, value := range v {
+			v[i] = redactValue(value, fields)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,217 @@
+package client_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/amapitest"
+	"amapi-pkg/pkgs/amapi/client"
+)
+
+// newLoggedClient 创建把日志以 JSON 格式写入 buf 的客户端
+func newLoggedClient(t *testing.T, debugBodies bool, buf *bytes.Buffer) (*amapitest.Server, *client.Client) {
+	t.Helper()
+
+	srv := amapitest.NewServer()
+	t.Cleanup(srv.Close)
+
+	cfg := srv.Config()
+	cfg.EnableDebugLogging = debugBodies
+	cfg.RetryDelay = time.Millisecond
+
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c, err := client.New(cfg, append(srv.ClientOptions(), client.WithLogger(logger))...)
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	return srv, c
+}
+
+// logRecords 解析 buf 中的 JSON 日志，返回 msg 等于 message 的记录
+func logRecords(t *testing.T, buf *bytes.Buffer, message string) []map[string]any {
+	t.Helper()
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		if record["msg"] == message {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// 测试每次 API 调用都会记录摘要日志
+func TestLogAPICall(t *testing.T) {
+	var buf bytes.Buffer
+	srv, c := newLoggedClient(t, false, &buf)
+	enterprise := srv.AddEnterprise(&androidmanagement.Enterprise{})
+	device := srv.AddDevice(&androidmanagement.Device{Name: enterprise.Name + "/devices/d1"})
+
+	if _, err := c.Devices().Get(device.Name); err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+
+	records := logRecords(t, &buf, "API call completed")
+	if len(records) != 1 {
+		t.Fatalf("got %d summary records, want 1:\n%s", len(records), buf.String())
+	}
+
+	record := records[0]
+	for key, expected := range map[string]any{
+		"level":     "DEBUG",
+		"operation": "get device",
+		"method":    http.MethodGet,
+		"resource":  device.Name,
+		"status":    float64(http.StatusOK),
+		"attempts":  float64(1),
+	} {
+		if record[key] != expected {
+			t.Errorf("%s = %v, want %v", key, record[key], expected)
+		}
+	}
+	for _, key := range []string{"latency", "rate_limit_wait"} {
+		if _, ok := record[key]; !ok {
+			t.Errorf("summary record has no %s attribute", key)
+		}
+	}
+
+	// 未启用 EnableDebugLogging 时不记录请求体和响应体
+	for _, record := range logRecords(t, &buf, "HTTP request") {
+		if _, ok := record["response_body"]; ok {
+			t.Error("response body logged without EnableDebugLogging")
+		}
+	}
+}
+
+// 测试 info 级别下成功的调用不输出日志
+func TestLogAPICallInfoLevel(t *testing.T) {
+	srv := amapitest.NewServer()
+	t.Cleanup(srv.Close)
+	enterprise := srv.AddEnterprise(&androidmanagement.Enterprise{})
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	c, err := client.New(srv.Config(), append(srv.ClientOptions(), client.WithLogger(logger))...)
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	if _, err := c.Enterprises().Get(enterprise.Name); err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("successful call logged at info level:\n%s", buf.String())
+	}
+
+	if _, err := c.Devices().Get(enterprise.Name + "/devices/missing"); err == nil {
+		t.Fatal("Get() expected error for a missing device")
+	}
+	if records := logRecords(t, &buf, "API call failed"); len(records) != 1 {
+		t.Errorf("got %d failure records, want 1:\n%s", len(records), buf.String())
+	}
+}
+
+// 测试操作 ID 不受敏感字段影响，无法由日志反推密码
+func TestLogOperationIDRedacted(t *testing.T) {
+	var buf bytes.Buffer
+	srv, c := newLoggedClient(t, false, &buf)
+	enterprise := srv.AddEnterprise(&androidmanagement.Enterprise{})
+	device := srv.AddDevice(&androidmanagement.Device{Name: enterprise.Name + "/devices/d1"})
+
+	for _, pin := range []string{"123456", "654321"} {
+		if _, err := c.Devices().ResetPassword(device.Name, pin); err != nil {
+			t.Fatalf("ResetPassword() unexpected error: %v", err)
+		}
+	}
+
+	records := logRecords(t, &buf, "API call completed")
+	if len(records) != 2 {
+		t.Fatalf("got %d summary records, want 2:\n%s", len(records), buf.String())
+	}
+	first, _ := records[0]["operation_id"].(string)
+	if first == "" || records[1]["operation_id"] != first {
+		t.Errorf("operation IDs = %v and %v, want the same ID for different passwords",
+			records[0]["operation_id"], records[1]["operation_id"])
+	}
+}
+
+// 测试重试和失败的日志
+func TestLogRetriesAndFailures(t *testing.T) {
+	var buf bytes.Buffer
+	srv, c := newLoggedClient(t, false, &buf)
+	enterprise := srv.AddEnterprise(&androidmanagement.Enterprise{})
+
+	srv.FailNext(http.StatusServiceUnavailable, "try again")
+	if _, err := c.Enterprises().Get(enterprise.Name); err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+
+	retries := logRecords(t, &buf, "retrying API call")
+	if len(retries) != 1 || retries[0]["previous_status"] != float64(http.StatusServiceUnavailable) {
+		t.Errorf("retry records = %v, want one retry after 503", retries)
+	}
+	if records := logRecords(t, &buf, "API call completed"); len(records) != 1 || records[0]["attempts"] != float64(2) {
+		t.Errorf("summary records = %v, want attempts = 2", records)
+	}
+
+	buf.Reset()
+	if _, err := c.Devices().Get(enterprise.Name + "/devices/missing"); err == nil {
+		t.Fatal("Get() expected error for a missing device")
+	}
+
+	records := logRecords(t, &buf, "API call failed")
+	if len(records) != 1 {
+		t.Fatalf("got %d failure records, want 1:\n%s", len(records), buf.String())
+	}
+	if records[0]["level"] != "WARN" || records[0]["status"] != float64(http.StatusNotFound) || records[0]["error"] == nil {
+		t.Errorf("failure record = %v, want WARN with status 404 and error", records[0])
+	}
+}
+
+// 测试调试模式下记录请求体和响应体，并隐藏令牌的值
+func TestLogBodiesRedacted(t *testing.T) {
+	var buf bytes.Buffer
+	srv, c := newLoggedClient(t, true, &buf)
+	enterprise := srv.AddEnterprise(&androidmanagement.Enterprise{})
+	policy := srv.AddPolicy(&androidmanagement.Policy{Name: enterprise.Name + "/policies/default"})
+
+	token, err := c.EnrollmentTokens().Create(enterprise.Name, policy.Name, time.Hour, false, false, nil)
+	if err != nil {
+		t.Fatalf("Create() unexpected error: %v", err)
+	}
+	if token.Value == "" {
+		t.Fatal("fake server returned an enrollment token without a value")
+	}
+
+	records := logRecords(t, &buf, "HTTP request")
+	if len(records) != 1 {
+		t.Fatalf("got %d HTTP records, want 1:\n%s", len(records), buf.String())
+	}
+	if _, ok := records[0]["request_body"]; !ok {
+		t.Error("request body not logged with EnableDebugLogging")
+	}
+	body, _ := records[0]["response_body"].(string)
+	if !strings.Contains(body, "[REDACTED]") {
+		t.Errorf("response body = %q, want redacted value", body)
+	}
+	if strings.Contains(buf.String(), token.Value) {
+		t.Errorf("enrollment token value leaked into logs:\n%s", buf.String())
+	}
+}
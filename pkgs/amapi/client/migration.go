@@ -1,6 +1,8 @@
 package client
 
 import (
+	"context"
+	"iter"
 	"time"
 
 	"google.golang.org/api/androidmanagement/v1"
@@ -18,6 +20,11 @@ func (c *Client) MigrationTokens() *MigrationService {
 	return &MigrationService{client: c}
 }
 
+// WithContext returns a copy of the service whose API calls use ctx.
+func (ms *MigrationService) WithContext(ctx context.Context) *MigrationService {
+	return &MigrationService{client: ms.client.WithContext(ctx)}
+}
+
 // Create creates a new migration token.
 func (ms *MigrationService) Create(enterpriseName, policyName string) (*androidmanagement.MigrationToken, error) {
 	if enterpriseName == "" {
@@ -34,8 +41,8 @@ func (ms *MigrationService) Create(enterpriseName, policyName string) (*androidm
 	var result *androidmanagement.MigrationToken
 	var err error
 
-	err = ms.client.executeAPICall(func() error {
-		result, err = ms.client.service.Enterprises.MigrationTokens.Create(enterpriseName, token).Context(ms.client.ctx).Do()
+	err = ms.client.executeAPICall(apiRequest{operation: "create migration token", resource: enterpriseName + "/migrationTokens", body: token}, func(ctx context.Context) error {
+		result, err = ms.client.service.Enterprises.MigrationTokens.Create(enterpriseName, token).Context(ctx).Do()
 		return err
 	})
 
@@ -71,8 +78,8 @@ func (ms *MigrationService) Get(tokenName string) (*androidmanagement.MigrationT
 	var result *androidmanagement.MigrationToken
 	var err error
 
-	err = ms.client.executeAPICall(func() error {
-		result, err = ms.client.service.Enterprises.MigrationTokens.Get(tokenName).Context(ms.client.ctx).Do()
+	err = ms.client.executeAPICall(apiRequest{operation: "get migration token", resource: tokenName}, func(ctx context.Context) error {
+		result, err = ms.client.service.Enterprises.MigrationTokens.Get(tokenName).Context(ctx).Do()
 		return err
 	})
 
@@ -106,7 +113,7 @@ func (ms *MigrationService) List(enterpriseName string, pageSize int, pageToken
 	var result *androidmanagement.ListMigrationTokensResponse
 	var err error
 
-	err = ms.client.executeAPICall(func() error {
+	err = ms.client.executeAPICall(apiRequest{operation: "list migration tokens", resource: enterpriseName + "/migrationTokens", body: pageParams(pageSize, pageToken)}, func(ctx context.Context) error {
 		call := ms.client.service.Enterprises.MigrationTokens.List(enterpriseName)
 
 		if pageSize > 0 {
@@ -117,7 +124,7 @@ func (ms *MigrationService) List(enterpriseName string, pageSize int, pageToken
 			call.PageToken(pageToken)
 		}
 
-		result, err = call.Context(ms.client.ctx).Do()
+		result, err = call.Context(ctx).Do()
 		return err
 	})
 
@@ -135,6 +142,18 @@ func (ms *MigrationService) List(enterpriseName string, pageSize int, pageToken
 	}, nil
 }
 
+// All returns an iterator over all migration tokens, following page tokens automatically.
+func (ms *MigrationService) All(enterpriseName string) iter.Seq2[*androidmanagement.MigrationToken, error] {
+	return paginate(func(pageToken string) (*types.ListResult[*androidmanagement.MigrationToken], error) {
+		return ms.List(enterpriseName, DefaultIteratorPageSize, pageToken)
+	})
+}
+
+// ListAll returns all migration tokens across every page.
+func (ms *MigrationService) ListAll(enterpriseName string) (*types.ListResult[*androidmanagement.MigrationToken], error) {
+	return collect(ms.All(enterpriseName))
+}
+
 // ListByEnterpriseID lists migration tokens for an enterprise by enterprise ID.
 func (ms *MigrationService) ListByEnterpriseID(enterpriseID string, pageSize int, pageToken string) (*types.ListResult[*androidmanagement.MigrationToken], error) {
 	if err := validateEnterpriseID(enterpriseID); err != nil {
@@ -175,7 +194,7 @@ func (ms *MigrationService) DeleteByID(enterpriseID, tokenID string) error {
 // Note: Filtering by active status is no longer supported since we use Google's native type.
 func (ms *MigrationService) GetActiveTokens(enterpriseID string) (*types.ListResult[*androidmanagement.MigrationToken], error) {
 	enterpriseName := buildEnterpriseName(enterpriseID)
-	return ms.List(enterpriseName, 0, "")
+	return ms.ListAll(enterpriseName)
 }
 
 
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// Default polling settings of WaitForOperation.
+const (
+	DefaultWaitTimeout         = 5 * time.Minute
+	DefaultWaitInitialInterval = 2 * time.Second
+	DefaultWaitMaxInterval     = 30 * time.Second
+	DefaultWaitMultiplier      = 2.0
+)
+
+// WaitOptions configures WaitForOperation.
+//
+// 零值字段使用默认值：超时 5 分钟，首次间隔 2 秒，每次乘以 2，最长 30 秒。
+type WaitOptions struct {
+	// Timeout bounds the whole wait. Default: DefaultWaitTimeout
+	Timeout time.Duration
+
+	// InitialInterval is the delay before the second poll. Default: DefaultWaitInitialInterval
+	InitialInterval time.Duration
+
+	// MaxInterval caps the delay between polls. Default: DefaultWaitMaxInterval
+	MaxInterval time.Duration
+
+	// Multiplier grows the delay after each poll. Default: DefaultWaitMultiplier
+	Multiplier float64
+
+	// OnPoll is called with the result of every poll, including the last one
+	OnPoll func(*types.CommandResult)
+}
+
+// withDefaults returns a copy of opts with zero fields set to their defaults.
+func (opts *WaitOptions) withDefaults() WaitOptions {
+	o := WaitOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultWaitTimeout
+	}
+	if o.InitialInterval <= 0 {
+		o.InitialInterval = DefaultWaitInitialInterval
+	}
+	if o.MaxInterval <= 0 {
+		o.MaxInterval = DefaultWaitMaxInterval
+	}
+	if o.MaxInterval < o.InitialInterval {
+		o.MaxInterval = o.InitialInterval
+	}
+	if o.Multiplier < 1 {
+		o.Multiplier = DefaultWaitMultiplier
+	}
+	return o
+}
+
+// WaitForOperation polls a device operation until it finishes, the timeout expires or ctx is done.
+//
+// 每次轮询都通过 GetOperation 发出，因此经过速率限制和重试处理；轮询间隔按指数退避增长。
+// 操作完成时返回解码后的 CommandResult，命令失败或被取消不会作为 error 返回，
+// 请检查 result.Status 或调用 result.Err()：
+//
+//	op, err := client.Devices().Lock(deviceName, "600s")
+//	if err != nil {
+//	    return err
+//	}
+//	result, err := client.Devices().WaitForOperation(ctx, op.Name, nil)
+//	if err != nil {
+//	    return err // 超时、ctx 取消或 API 错误
+//	}
+//	if result.Status != types.CommandStatusSucceeded {
+//	    return result.Err()
+//	}
+//
+// 超时或 ctx 取消时返回 ErrCodeTimeout 错误，以及最后一次轮询得到的 PENDING 结果（可能为 nil）。
+func (ds *DeviceService) WaitForOperation(ctx context.Context, operationName string, opts *WaitOptions) (*types.CommandResult, error) {
+	if operationName == "" {
+		return nil, types.NewError(types.ErrCodeInvalidInput, "operation name is required")
+	}
+
+	o := opts.withDefaults()
+	ctx, cancel := context.WithTimeout(ctx, o.Timeout)
+	defer cancel()
+
+	service := ds.WithContext(ctx)
+	interval := o.InitialInterval
+	var last *types.CommandResult
+
+	for {
+		op, err := service.GetOperation(operationName)
+		if err != nil {
+			if ctx.Err() != nil {
+				return last, waitError(ctx.Err(), operationName)
+			}
+			return last, err
+		}
+
+		result, err := types.NewCommandResult(op)
+		if err != nil {
+			return nil, err
+		}
+		last = result
+
+		if o.OnPoll != nil {
+			o.OnPoll(result)
+		}
+		if result.Status.IsTerminal() {
+			return result, nil
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return last, waitError(ctx.Err(), operationName)
+		case <-timer.C:
+		}
+
+		interval = time.Duration(float64(interval) * o.Multiplier)
+		if interval > o.MaxInterval {
+			interval = o.MaxInterval
+		}
+	}
+}
+
+// waitError reports why WaitForOperation stopped before the operation finished.
+func waitError(err error, operationName string) error {
+	message := "operation wait canceled"
+	if errors.Is(err, context.DeadlineExceeded) {
+		message = "timed out waiting for operation"
+	}
+
+	wrapped := types.NewErrorWithCause(types.ErrCodeTimeout, message, err)
+	wrapped.Details = operationName
+	wrapped.Retryable = false
+	return wrapped
+}
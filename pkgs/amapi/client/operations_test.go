@@ -0,0 +1,138 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/api/androidmanagement/v1"
+	"google.golang.org/api/googleapi"
+
+	"amapi-pkg/pkgs/amapi/client"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// fastWait 返回适合测试的短轮询间隔
+func fastWait(onPoll func(*types.CommandResult)) *client.WaitOptions {
+	return &client.WaitOptions{
+		Timeout:         time.Second,
+		InitialInterval: time.Millisecond,
+		MaxInterval:     5 * time.Millisecond,
+		OnPoll:          onPoll,
+	}
+}
+
+// 测试等待操作成功完成
+func TestWaitForOperationSucceeded(t *testing.T) {
+	srv, c, device := newDeviceTestClient(t)
+
+	op, err := c.Devices().Lock(device.Name, "600s")
+	if err != nil {
+		t.Fatalf("Lock() unexpected error: %v", err)
+	}
+
+	polls := 0
+	result, err := c.Devices().WaitForOperation(context.Background(), op.Name, fastWait(func(r *types.CommandResult) {
+		polls++
+		if polls == 2 {
+			srv.CompleteOperation(op.Name, nil)
+		}
+	}))
+	if err != nil {
+		t.Fatalf("WaitForOperation() unexpected error: %v", err)
+	}
+	if result.Status != types.CommandStatusSucceeded || result.Err() != nil {
+		t.Errorf("Status = %s, Err() = %v, want SUCCEEDED", result.Status, result.Err())
+	}
+	if result.Command == nil || result.Command.Type != string(types.CommandTypeLock) || result.Command.Duration != "600s" {
+		t.Errorf("Command = %+v, want decoded LOCK metadata", result.Command)
+	}
+	if polls != 3 {
+		t.Errorf("polled %d times, want 3", polls)
+	}
+}
+
+// 测试失败和取消的操作
+func TestWaitForOperationFailedAndCancelled(t *testing.T) {
+	srv, c, device := newDeviceTestClient(t)
+
+	op, err := c.Devices().Reboot(device.Name)
+	if err != nil {
+		t.Fatalf("Reboot() unexpected error: %v", err)
+	}
+	srv.CompleteOperation(op.Name, &androidmanagement.Status{
+		Code:    types.RPCCodeFailedPrecondition,
+		Message: "device is offline",
+		Details: []googleapi.RawMessage{googleapi.RawMessage(`{"@type":"type.googleapis.com/google.rpc.ErrorInfo","reason":"OFFLINE"}`)},
+	})
+
+	result, err := c.Devices().WaitForOperation(context.Background(), op.Name, fastWait(nil))
+	if err != nil {
+		t.Fatalf("WaitForOperation() unexpected error: %v", err)
+	}
+	if result.Status != types.CommandStatusFailed {
+		t.Errorf("Status = %s, want FAILED", result.Status)
+	}
+	var opErr *types.OperationError
+	if !errors.As(result.Err(), &opErr) || opErr.Status != "FAILED_PRECONDITION" || opErr.Message != "device is offline" {
+		t.Errorf("Err() = %v, want FAILED_PRECONDITION error", result.Err())
+	}
+	if len(opErr.Details) != 1 || opErr.Details[0]["reason"] != "OFFLINE" {
+		t.Errorf("Details = %v, want decoded ErrorInfo", opErr.Details)
+	}
+
+	op, err = c.Devices().Reboot(device.Name)
+	if err != nil {
+		t.Fatalf("Reboot() unexpected error: %v", err)
+	}
+	if err := c.Devices().CancelOperation(op.Name); err != nil {
+		t.Fatalf("CancelOperation() unexpected error: %v", err)
+	}
+	result, err = c.Devices().WaitForOperation(context.Background(), op.Name, fastWait(nil))
+	if err != nil {
+		t.Fatalf("WaitForOperation() unexpected error: %v", err)
+	}
+	if result.Status != types.CommandStatusCancelled || result.Error.Status != "CANCELLED" {
+		t.Errorf("Status = %s, Error = %+v, want CANCELLED", result.Status, result.Error)
+	}
+
+	// 完成但 Command.errorCode 非空的操作视为失败
+	failed := srv.AddOperation(&androidmanagement.Operation{
+		Name:     device.Name + "/operations/api-level",
+		Done:     true,
+		Metadata: googleapi.RawMessage(`{"type":"RESET","errorCode":"API_LEVEL"}`),
+	})
+	result, err = c.Devices().WaitForOperation(context.Background(), failed.Name, fastWait(nil))
+	if err != nil {
+		t.Fatalf("WaitForOperation() unexpected error: %v", err)
+	}
+	if result.Status != types.CommandStatusFailed || result.Command.ErrorCode != "API_LEVEL" {
+		t.Errorf("Status = %s, Command = %+v, want FAILED with API_LEVEL", result.Status, result.Command)
+	}
+}
+
+// 测试等待超时和参数校验
+func TestWaitForOperationTimeout(t *testing.T) {
+	_, c, device := newDeviceTestClient(t)
+
+	op, err := c.Devices().Lock(device.Name, "")
+	if err != nil {
+		t.Fatalf("Lock() unexpected error: %v", err)
+	}
+
+	opts := fastWait(nil)
+	opts.Timeout = 30 * time.Millisecond
+	result, err := c.Devices().WaitForOperation(context.Background(), op.Name, opts)
+	var apiErr *types.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != types.ErrCodeTimeout || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitForOperation() error = %v, want timeout", err)
+	}
+	if result == nil || result.Status != types.CommandStatusPending {
+		t.Errorf("result = %+v, want last PENDING result", result)
+	}
+
+	if _, err := c.Devices().WaitForOperation(context.Background(), "", nil); err == nil {
+		t.Error("WaitForOperation() expected error for empty operation name")
+	}
+}
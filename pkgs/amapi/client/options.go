@@ -0,0 +1,108 @@
+package client
+
+import (
+	"log/slog"
+	"net/http"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"amapi-pkg/pkgs/amapi/utils"
+)
+
+// Option configures optional client behaviour not covered by config.Config.
+type Option func(*clientOptions)
+
+// clientOptions holds the values set by Option functions.
+type clientOptions struct {
+	// endpoint overrides the Android Management API base URL
+	endpoint string
+
+	// httpClient replaces the authenticated HTTP client built from the config credentials
+	httpClient *http.Client
+
+	// logger receives API call logs instead of the default stderr logger
+	logger *slog.Logger
+
+	// tracerProvider and meterProvider replace the global OpenTelemetry providers
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+
+	// history replaces the policy history store built from config.PolicyHistory
+	history utils.HistoryStore
+}
+
+// WithEndpoint points the client at a different API base URL.
+//
+// 主要用于测试，例如指向 amapitest.Server：
+//
+//	srv := amapitest.NewServer()
+//	defer srv.Close()
+//
+//	c, err := client.New(srv.Config(), client.WithEndpoint(srv.URL), client.WithHTTPClient(srv.Client()))
+func WithEndpoint(endpoint string) Option {
+	return func(o *clientOptions) {
+		o.endpoint = endpoint
+	}
+}
+
+// WithHTTPClient uses hc for all API requests instead of an authenticated client
+// created from the config credentials. The caller is responsible for authentication.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(o *clientOptions) {
+		o.httpClient = hc
+	}
+}
+
+// WithLogger sends the client's logs to logger.
+//
+// 默认情况下，客户端按照 config.LogLevel 把文本日志写到标准错误输出。
+// 传入的 logger 会原样使用，日志级别由它的 Handler 决定；
+// 请求体和响应体仍然只在 config.EnableDebugLogging 为 true 时记录。
+//
+//	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+//	c, err := client.New(cfg, client.WithLogger(logger))
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *clientOptions) {
+		o.logger = logger
+	}
+}
+
+// WithTelemetry sets the OpenTelemetry providers used for spans and metrics.
+//
+// 默认使用 otel.GetTracerProvider() 和 otel.GetMeterProvider() 返回的全局 provider，
+// 未注册时不产生任何数据。传入 nil 的参数同样使用全局 provider。
+//
+//	c, err := client.New(cfg, client.WithTelemetry(sdktrace.NewTracerProvider(...), sdkmetric.NewMeterProvider(...)))
+func WithTelemetry(tp trace.TracerProvider, mp metric.MeterProvider) Option {
+	return func(o *clientOptions) {
+		o.tracerProvider = tp
+		o.meterProvider = mp
+	}
+}
+
+// WithPolicyHistory records policy snapshots in store instead of the store selected by config.PolicyHistory.
+//
+// 启用后，PolicyService 的 Create、Update 以及基于 Update 的便捷方法（AddApplication、
+// RemoveApplication、SetKioskMode 等）会按 Policy.Version 记录快照，
+// 可以通过 PolicyService.History 查看并通过 PolicyService.Rollback 回滚。
+// Client.Close 会关闭 store。
+//
+//	store, err := utils.NewDirHistoryStore("./policy-history", 50)
+//	c, err := client.New(cfg, client.WithPolicyHistory(store))
+func WithPolicyHistory(store utils.HistoryStore) Option {
+	return func(o *clientOptions) {
+		o.history = store
+	}
+}
+
+// applyOptions applies opts in order and returns the result.
+func applyOptions(opts []Option) *clientOptions {
+	o := &clientOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
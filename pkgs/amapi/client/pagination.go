@@ -0,0 +1,57 @@
+package client
+
+import (
+	"iter"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// DefaultIteratorPageSize is the page size requested by the All iterators.
+const DefaultIteratorPageSize = 100
+
+// pageFetcher fetches a single page of results for the given page token.
+type pageFetcher[T any] func(pageToken string) (*types.ListResult[T], error)
+
+// paginate returns an iterator that follows NextPageToken until the last page.
+//
+// 每一页都通过对应的 List 方法获取，因此会经过速率限制和重试处理。
+// 出错时迭代器产出一次 (零值, err) 后结束；调用方提前 break 时不会再请求后续页面。
+func paginate[T any](fetch pageFetcher[T]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		pageToken := ""
+		for {
+			page, err := fetch(pageToken)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range page.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			// 防止服务端返回相同的令牌导致死循环
+			if page.NextPageToken == "" || page.NextPageToken == pageToken {
+				return
+			}
+			pageToken = page.NextPageToken
+		}
+	}
+}
+
+// collect drains an iterator into a ListResult.
+// On error the items collected so far are returned together with the error.
+func collect[T any](seq iter.Seq2[T, error]) (*types.ListResult[T], error) {
+	items := make([]T, 0)
+	for item, err := range seq {
+		if err != nil {
+			return &types.ListResult[T]{Items: items, TotalCount: len(items)}, err
+		}
+		items = append(items, item)
+	}
+
+	return &types.ListResult[T]{Items: items, TotalCount: len(items)}, nil
+}
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"context"
+	"iter"
 	"strings"
 
 	"google.golang.org/api/androidmanagement/v1"
@@ -18,6 +20,11 @@ func (c *Client) Policies() *PolicyService {
 	return &PolicyService{client: c}
 }
 
+// WithContext returns a copy of the service whose API calls use ctx.
+func (ps *PolicyService) WithContext(ctx context.Context) *PolicyService {
+	return &PolicyService{client: ps.client.WithContext(ctx)}
+}
+
 // Create creates a new policy.
 func (ps *PolicyService) Create(enterpriseName, policyID string, policy *androidmanagement.Policy) (*androidmanagement.Policy, error) {
 	if enterpriseName == "" {
@@ -40,11 +47,11 @@ func (ps *PolicyService) Create(enterpriseName, policyID string, policy *android
 	var result *androidmanagement.Policy
 	var err error
 
-	err = ps.client.executeAPICall(func() error {
+	err = ps.client.executeAPICall(apiRequest{operation: "create policy", resource: buildResourceName(enterpriseName, "policies", policyID), body: policy}, func(ctx context.Context) error {
 		result, err = ps.client.service.Enterprises.Policies.Patch(
-			buildPolicyName(enterpriseName, policyID),
+			buildResourceName(enterpriseName, "policies", policyID),
 			policy,
-		).Context(ps.client.ctx).Do()
+		).Context(ctx).Do()
 		return err
 	})
 
@@ -52,6 +59,9 @@ func (ps *PolicyService) Create(enterpriseName, policyID string, policy *android
 		return nil, ps.client.wrapAPIError(err, "create policy")
 	}
 
+	ps.client.invalidateCache(result.Name)
+	ps.recordHistory(result, "create policy")
+
 	return result, nil
 }
 
@@ -78,8 +88,8 @@ func (ps *PolicyService) Get(policyName string) (*androidmanagement.Policy, erro
 	var result *androidmanagement.Policy
 	var err error
 
-	err = ps.client.executeAPICall(func() error {
-		result, err = ps.client.service.Enterprises.Policies.Get(policyName).Context(ps.client.ctx).Do()
+	err = ps.client.cachedAPICall(apiRequest{operation: "get policy", resource: policyName}, &result, func(ctx context.Context) error {
+		result, err = ps.client.service.Enterprises.Policies.Get(policyName).Context(ctx).Do()
 		return err
 	})
 
@@ -105,7 +115,14 @@ func (ps *PolicyService) GetByID(enterpriseID, policyID string) (*androidmanagem
 }
 
 // Update updates an existing policy.
+//
+// 如果启用了策略历史，更新前会记录当前版本（尚未记录时，见 snapshotCurrent），更新后记录新版本。
 func (ps *PolicyService) Update(policyName string, policy *androidmanagement.Policy, updateMask []string) (*androidmanagement.Policy, error) {
+	return ps.update(policyName, policy, updateMask, "update policy")
+}
+
+// update patches a policy and records the new version in the history as operation.
+func (ps *PolicyService) update(policyName string, policy *androidmanagement.Policy, updateMask []string, operation string) (*androidmanagement.Policy, error) {
 	if policyName == "" {
 		return nil, types.ErrInvalidPolicyID
 	}
@@ -119,10 +136,12 @@ func (ps *PolicyService) Update(policyName string, policy *androidmanagement.Pol
 		return nil, err
 	}
 
+	ps.snapshotCurrent(policyName, policy)
+
 	var result *androidmanagement.Policy
 	var err error
 
-	err = ps.client.executeAPICall(func() error {
+	err = ps.client.executeAPICall(apiRequest{operation: "update policy", resource: policyName, body: []any{policy, updateMask}}, func(ctx context.Context) error {
 		call := ps.client.service.Enterprises.Policies.Patch(policyName, policy)
 
 		if len(updateMask) > 0 {
@@ -131,7 +150,7 @@ func (ps *PolicyService) Update(policyName string, policy *androidmanagement.Pol
 			call.UpdateMask(maskString)
 		}
 
-		result, err = call.Context(ps.client.ctx).Do()
+		result, err = call.Context(ctx).Do()
 		return err
 	})
 
@@ -139,6 +158,9 @@ func (ps *PolicyService) Update(policyName string, policy *androidmanagement.Pol
 		return nil, ps.client.wrapAPIError(err, "update policy")
 	}
 
+	ps.client.invalidateCache(policyName)
+	ps.recordHistory(result, operation)
+
 	return result, nil
 }
 
@@ -165,7 +187,9 @@ func (ps *PolicyService) List(enterpriseName string, pageSize int, pageToken str
 	var result *androidmanagement.ListPoliciesResponse
 	var err error
 
-	err = ps.client.executeAPICall(func() error {
+	params := pageParams(pageSize, pageToken)
+
+	err = ps.client.cachedAPICall(apiRequest{operation: "list policies", resource: enterpriseName + "/policies", body: params}, &result, func(ctx context.Context) error {
 		call := ps.client.service.Enterprises.Policies.List(enterpriseName)
 
 		if pageSize > 0 {
@@ -176,7 +200,7 @@ func (ps *PolicyService) List(enterpriseName string, pageSize int, pageToken str
 			call.PageToken(pageToken)
 		}
 
-		result, err = call.Context(ps.client.ctx).Do()
+		result, err = call.Context(ctx).Do()
 		return err
 	})
 
@@ -194,6 +218,18 @@ func (ps *PolicyService) List(enterpriseName string, pageSize int, pageToken str
 	}, nil
 }
 
+// All returns an iterator over all policies, following page tokens automatically.
+func (ps *PolicyService) All(enterpriseName string) iter.Seq2[*androidmanagement.Policy, error] {
+	return paginate(func(pageToken string) (*types.ListResult[*androidmanagement.Policy], error) {
+		return ps.List(enterpriseName, DefaultIteratorPageSize, pageToken)
+	})
+}
+
+// ListAll returns all policies across every page.
+func (ps *PolicyService) ListAll(enterpriseName string) (*types.ListResult[*androidmanagement.Policy], error) {
+	return collect(ps.All(enterpriseName))
+}
+
 // ListByEnterpriseID lists policies for an enterprise by enterprise ID.
 func (ps *PolicyService) ListByEnterpriseID(enterpriseID string, pageSize int, pageToken string) (*types.ListResult[*androidmanagement.Policy], error) {
 	if err := validateEnterpriseID(enterpriseID); err != nil {
@@ -210,8 +246,8 @@ func (ps *PolicyService) Delete(policyName string) error {
 		return types.ErrInvalidPolicyID
 	}
 
-	err := ps.client.executeAPICall(func() error {
-		_, err := ps.client.service.Enterprises.Policies.Delete(policyName).Context(ps.client.ctx).Do()
+	err := ps.client.executeAPICall(apiRequest{operation: "delete policy", resource: policyName}, func(ctx context.Context) error {
+		_, err := ps.client.service.Enterprises.Policies.Delete(policyName).Context(ctx).Do()
 		return err
 	})
 
@@ -219,6 +255,8 @@ func (ps *PolicyService) Delete(policyName string) error {
 		return ps.client.wrapAPIError(err, "delete policy")
 	}
 
+	ps.client.invalidateCache(policyName)
+
 	return nil
 }
 
@@ -270,7 +308,7 @@ func (ps *PolicyService) Clone(sourcePolicyName, targetEnterpriseID, targetPolic
 // AddApplication adds an application to a policy.
 func (ps *PolicyService) AddApplication(policyName string, app *androidmanagement.ApplicationPolicy) (*androidmanagement.Policy, error) {
 	// Get current policy
-	policy, err := ps.Get(policyName)
+	policy, err := ps.getForUpdate(policyName)
 	if err != nil {
 		return nil, err
 	}
@@ -285,7 +323,7 @@ func (ps *PolicyService) AddApplication(policyName string, app *androidmanagemen
 // RemoveApplication removes an application from a policy.
 func (ps *PolicyService) RemoveApplication(policyName, packageName string) (*androidmanagement.Policy, error) {
 	// Get current policy
-	policy, err := ps.Get(policyName)
+	policy, err := ps.getForUpdate(policyName)
 	if err != nil {
 		return nil, err
 	}
@@ -300,7 +338,7 @@ func (ps *PolicyService) RemoveApplication(policyName, packageName string) (*and
 // SetApplicationInstallType sets the install type for an application in a policy.
 func (ps *PolicyService) SetApplicationInstallType(policyName, packageName string, installType types.ApplicationInstallType) (*androidmanagement.Policy, error) {
 	// Get current policy
-	policy, err := ps.Get(policyName)
+	policy, err := ps.getForUpdate(policyName)
 	if err != nil {
 		return nil, err
 	}
@@ -343,7 +381,7 @@ func (ps *PolicyService) RequireApplication(policyName, packageName string) (*an
 // SetKioskMode configures a policy for kiosk mode with a single application.
 func (ps *PolicyService) SetKioskMode(policyName, kioskAppPackage string) (*androidmanagement.Policy, error) {
 	// Get current policy
-	policy, err := ps.Get(policyName)
+	policy, err := ps.getForUpdate(policyName)
 	if err != nil {
 		return nil, err
 	}
@@ -370,7 +408,7 @@ func (ps *PolicyService) SetKioskMode(policyName, kioskAppPackage string) (*andr
 // SetFullyManagedMode configures a policy for fully managed device mode.
 func (ps *PolicyService) SetFullyManagedMode(policyName string) (*androidmanagement.Policy, error) {
 	// Get current policy
-	policy, err := ps.Get(policyName)
+	policy, err := ps.getForUpdate(policyName)
 	if err != nil {
 		return nil, err
 	}
@@ -388,7 +426,7 @@ func (ps *PolicyService) SetFullyManagedMode(policyName string) (*androidmanagem
 // SetWorkProfileMode configures a policy for work profile mode.
 func (ps *PolicyService) SetWorkProfileMode(policyName string) (*androidmanagement.Policy, error) {
 	// Get current policy
-	policy, err := ps.Get(policyName)
+	policy, err := ps.getForUpdate(policyName)
 	if err != nil {
 		return nil, err
 	}
@@ -411,23 +449,20 @@ func (ps *PolicyService) GetDevicesUsingPolicy(policyName string) (*types.ListRe
 		return nil, err
 	}
 
-	// Get all devices for the enterprise
-	deviceService := ps.client.Devices()
-	allDevices, err := deviceService.ListByEnterpriseID(enterpriseID, 0, "", "", nil, "")
-	if err != nil {
-		return nil, err
-	}
-
-	// Filter devices using this policy
-	var devicesUsingPolicy []*androidmanagement.Device
-	for _, device := range allDevices.Items {
+	// Walk every page of devices for the enterprise
+	devicesUsingPolicy := make([]*androidmanagement.Device, 0)
+	for device, err := range ps.client.Devices().All(buildEnterpriseName(enterpriseID), "", nil, "") {
+		if err != nil {
+			return nil, err
+		}
 		if device.AppliedPolicyName == policyName {
 			devicesUsingPolicy = append(devicesUsingPolicy, device)
 		}
 	}
 
 	return &types.ListResult[*androidmanagement.Device]{
-		Items: devicesUsingPolicy,
+		Items:      devicesUsingPolicy,
+		TotalCount: len(devicesUsingPolicy),
 	}, nil
 }
 
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"context"
+
 	"google.golang.org/api/androidmanagement/v1"
 
 	"amapi-pkg/pkgs/amapi/types"
@@ -16,6 +18,11 @@ func (c *Client) ProvisioningInfo() *ProvisioningService {
 	return &ProvisioningService{client: c}
 }
 
+// WithContext returns a copy of the service whose API calls use ctx.
+func (ps *ProvisioningService) WithContext(ctx context.Context) *ProvisioningService {
+	return &ProvisioningService{client: ps.client.WithContext(ctx)}
+}
+
 // Get retrieves provisioning information by its resource name.
 func (ps *ProvisioningService) Get(provisioningInfoName string) (*androidmanagement.ProvisioningInfo, error) {
 	if provisioningInfoName == "" {
@@ -25,8 +32,8 @@ func (ps *ProvisioningService) Get(provisioningInfoName string) (*androidmanagem
 	var result *androidmanagement.ProvisioningInfo
 	var err error
 
-	err = ps.client.executeAPICall(func() error {
-		result, err = ps.client.service.ProvisioningInfo.Get(provisioningInfoName).Context(ps.client.ctx).Do()
+	err = ps.client.executeAPICall(apiRequest{operation: "get provisioning info", resource: provisioningInfoName}, func(ctx context.Context) error {
+		result, err = ps.client.service.ProvisioningInfo.Get(provisioningInfoName).Context(ctx).Do()
 		return err
 	})
 
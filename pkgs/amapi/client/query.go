@@ -0,0 +1,54 @@
+package client
+
+import (
+	"reflect"
+	"time"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/query"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// Query returns every device of an enterprise matching a filter expression, across all pages.
+//
+// enterpriseID 可以是企业 ID，也可以是完整的资源名称 enterprises/{id}。
+// 表达式语法见 query 包，字段路径是 androidmanagement.Device 的 JSON 字段名：
+//
+//	devices, err := client.Devices().Query(enterpriseID,
+//	    "appliedPolicyName = 'kiosk' AND hardwareInfo.brand = 'samsung' AND lastStatusReportTime < -7d")
+//
+// API 不支持服务端过滤，因此表达式在客户端求值；语法错误和未知字段在发出任何请求之前
+// 以 ErrCodeInvalidInput 返回。相对时间以调用时刻为基准，所有分页使用同一基准。
+func (ds *DeviceService) Query(enterpriseID, expr string) (*types.ListResult[*androidmanagement.Device], error) {
+	if err := validateEnterpriseID(enterpriseID); err != nil {
+		return nil, err
+	}
+
+	filter, err := query.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	if err := filter.ValidateFields(reflect.TypeOf(androidmanagement.Device{})); err != nil {
+		return nil, err
+	}
+
+	enterpriseName := resolveEnterpriseName(enterpriseID)
+	now := time.Now()
+	devices := make([]*androidmanagement.Device, 0)
+	for device, err := range ds.All(enterpriseName, "", nil, "") {
+		if err != nil {
+			return nil, err
+		}
+
+		matched, err := filter.MatchAt(device, now)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			devices = append(devices, device)
+		}
+	}
+
+	return &types.ListResult[*androidmanagement.Device]{Items: devices, TotalCount: len(devices)}, nil
+}
@@ -0,0 +1,99 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/client"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// 测试表达式查询遍历所有分页并在客户端过滤
+func TestDeviceQuery(t *testing.T) {
+	srv, c, device := newDeviceTestClient(t)
+	enterpriseName := device.Name[:len(device.Name)-len("/devices/d1")]
+	enterpriseID := enterpriseName[len("enterprises/"):]
+
+	stale := time.Now().Add(-10 * 24 * time.Hour).Format(time.RFC3339)
+	fresh := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	for i, brand := range []string{"samsung", "google", "samsung"} {
+		lastReport := stale
+		if i == 2 {
+			lastReport = fresh
+		}
+		srv.AddDevice(&androidmanagement.Device{
+			Name:                 enterpriseName + fmt.Sprintf("/devices/k%d", i+1),
+			AppliedPolicyName:    enterpriseName + "/policies/kiosk",
+			State:                "ACTIVE",
+			LastStatusReportTime: lastReport,
+			HardwareInfo:         &androidmanagement.HardwareInfo{Brand: brand},
+		})
+	}
+	// 超过一页的其他设备
+	for i := 0; i < client.DefaultIteratorPageSize; i++ {
+		srv.AddDevice(&androidmanagement.Device{
+			Name:              enterpriseName + fmt.Sprintf("/devices/x%d", i),
+			AppliedPolicyName: enterpriseName + "/policies/default",
+			State:             "ACTIVE",
+		})
+	}
+
+	const expr = "appliedPolicyName = 'kiosk' AND hardwareInfo.brand = 'samsung' AND lastStatusReportTime < -7d"
+	for _, id := range []string{enterpriseID, enterpriseName} {
+		result, err := c.Devices().Query(id, expr)
+		if err != nil {
+			t.Fatalf("Query(%s) unexpected error: %v", id, err)
+		}
+		if len(result.Items) != 1 || result.Items[0].Name != enterpriseName+"/devices/k1" || result.TotalCount != 1 {
+			t.Errorf("Query(%s) = %d devices, want only k1", id, len(result.Items))
+		}
+	}
+
+	result, err := c.Devices().Query(enterpriseID, "appliedPolicyName = 'default'")
+	if err != nil {
+		t.Fatalf("Query() unexpected error: %v", err)
+	}
+	if len(result.Items) != client.DefaultIteratorPageSize {
+		t.Errorf("Query(default policy) = %d devices, want %d", len(result.Items), client.DefaultIteratorPageSize)
+	}
+
+	// 语法错误和未知字段在请求之前返回
+	for _, invalid := range []string{"state = ", "hardwareInfo.brnd = 'samsung'"} {
+		_, err := c.Devices().Query(enterpriseID, invalid)
+		var apiErr *types.Error
+		if !errors.As(err, &apiErr) || apiErr.Code != types.ErrCodeInvalidInput {
+			t.Errorf("Query(%q) error = %v, want invalid input", invalid, err)
+		}
+	}
+}
+
+// 测试按表达式选择批量命令的目标设备
+func TestBulkIssueCommandByQuery(t *testing.T) {
+	srv, c, device := newDeviceTestClient(t)
+	enterpriseName := device.Name[:len(device.Name)-len("/devices/d1")]
+	srv.AddDevice(&androidmanagement.Device{
+		Name:         enterpriseName + "/devices/d2",
+		State:        "ACTIVE",
+		HardwareInfo: &androidmanagement.HardwareInfo{Brand: "samsung"},
+	})
+
+	command, _ := types.NewRebootCommand()
+	report, err := c.Devices().BulkIssueCommandByFilter(context.Background(), enterpriseName,
+		client.DeviceListFilter{State: types.DeviceStateActive, Query: "hardwareInfo.brand = 'samsung'"}, command, &client.BulkOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("BulkIssueCommandByFilter() unexpected error: %v", err)
+	}
+	if len(report.Results) != 1 || report.Results[0].DeviceName != enterpriseName+"/devices/d2" {
+		t.Errorf("report = %+v, want only d2", report.Results)
+	}
+
+	if _, err := c.Devices().BulkIssueCommandByFilter(context.Background(), enterpriseName,
+		client.DeviceListFilter{Query: "brand = 'samsung'"}, command, nil); err == nil {
+		t.Error("BulkIssueCommandByFilter() expected error for unknown query field")
+	}
+}
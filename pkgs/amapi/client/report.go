@@ -0,0 +1,51 @@
+package client
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// unsupportedFormat returns the error for a report format that is not supported.
+func unsupportedFormat(format types.ReportFormat, supported ...types.ReportFormat) error {
+	names := make([]string, len(supported))
+	for i, f := range supported {
+		names[i] = string(f)
+	}
+	return types.NewErrorWithDetails(types.ErrCodeInvalidInput, "unsupported report format",
+		fmt.Sprintf("%q (supported: %s)", format, strings.Join(names, ", ")))
+}
+
+// writeJSON writes v as indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// writeCSV writes a header row followed by rows.
+func writeCSV(w io.Writer, header []string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// writeTable writes a header row followed by rows as aligned text columns.
+func writeTable(w io.Writer, header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
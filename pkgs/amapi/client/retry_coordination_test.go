@@ -0,0 +1,67 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/amapitest"
+	"amapi-pkg/pkgs/amapi/client"
+	"amapi-pkg/pkgs/amapi/utils"
+)
+
+// 测试不同进程中的同一个逻辑操作使用相同的重试操作 ID
+func TestRedisRetryOperationIDSharedAcrossProcesses(t *testing.T) {
+	mr := miniredis.RunT(t)
+	srv := amapitest.NewServer()
+	t.Cleanup(srv.Close)
+	enterprise := srv.AddEnterprise(&androidmanagement.Enterprise{})
+
+	newProcess := func() *client.Client {
+		cfg := srv.Config()
+		cfg.RedisAddress = mr.Addr()
+		cfg.RedisKeyPrefix = "test:"
+		cfg.UseRedisRetry = true
+
+		c, err := client.New(cfg, srv.ClientOptions()...)
+		if err != nil {
+			t.Fatalf("New() unexpected error: %v", err)
+		}
+		t.Cleanup(func() { c.Close() })
+		return c
+	}
+
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	handler := utils.NewRedisRetryHandler(rdb, "test:", utils.RetryConfig{})
+	operationID := "get_enterprise:" + enterprise.Name
+
+	for i, c := range []*client.Client{newProcess(), newProcess()} {
+		srv.FailNext(http.StatusServiceUnavailable, "try again")
+		if _, err := c.Enterprises().Get(enterprise.Name); err != nil {
+			t.Fatalf("process %d: Get() unexpected error: %v", i+1, err)
+		}
+
+		count, err := handler.GetRetryCount(context.Background(), operationID)
+		if err != nil {
+			t.Fatalf("GetRetryCount() unexpected error: %v", err)
+		}
+		if count != int64(i+1) {
+			t.Errorf("after process %d: retry count = %d, want %d", i+1, count, i+1)
+		}
+	}
+
+	// 调用方提供的 key
+	c := newProcess()
+	srv.FailNext(http.StatusServiceUnavailable, "try again")
+	if _, err := c.WithIdempotencyKey("job-42").Enterprises().Get(enterprise.Name); err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+	if count, _ := handler.GetRetryCount(context.Background(), "get_enterprise:job-42"); count != 1 {
+		t.Errorf("retry count for job-42 = %d, want 1", count)
+	}
+}
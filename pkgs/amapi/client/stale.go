@@ -0,0 +1,181 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// StaleNudge is the action FindStaleWithOptions takes on devices that are not healthy.
+type StaleNudge string
+
+const (
+	// StaleNudgeNone only reports the devices
+	StaleNudgeNone StaleNudge = ""
+
+	// StaleNudgeReboot issues a REBOOT command
+	StaleNudgeReboot StaleNudge = "REBOOT"
+)
+
+// StaleOptions configures FindStaleWithOptions.
+type StaleOptions struct {
+	// Nudge is applied to ACTIVE devices classified as stale or never synced
+	Nudge StaleNudge
+
+	// Bulk configures how the nudge is issued (concurrency, dry run, wait, progress); nil uses the defaults
+	Bulk *BulkOptions
+}
+
+// StaleDevice is the health classification of one device.
+type StaleDevice struct {
+	// Device is the device as returned by the list call
+	Device *androidmanagement.Device `json:"device"`
+
+	// Health is the classification of the device
+	Health types.DeviceHealth `json:"health"`
+
+	// LastSeen is the most recent of the status report, policy sync and compliance report times; zero if none is set
+	LastSeen time.Time `json:"last_seen"`
+
+	// Since is the time elapsed since LastSeen; zero if LastSeen is zero
+	Since time.Duration `json:"since"`
+}
+
+// StaleReport is the result of FindStale.
+type StaleReport struct {
+	// Threshold is the age after which a device is stale
+	Threshold time.Duration `json:"threshold"`
+
+	// CheckedAt is the reference time of the classification
+	CheckedAt time.Time `json:"checked_at"`
+
+	// Devices holds every device of the enterprise, in list order
+	Devices []*StaleDevice `json:"devices"`
+
+	// Healthy, Stale and NeverSynced count the devices of each class
+	Healthy     int `json:"healthy"`
+	Stale       int `json:"stale"`
+	NeverSynced int `json:"never_synced"`
+
+	// Nudge is the result of the nudge; nil if no nudge was requested
+	Nudge *BulkReport `json:"nudge,omitempty"`
+}
+
+// ByHealth returns the devices with the given classification.
+func (r *StaleReport) ByHealth(health types.DeviceHealth) []*StaleDevice {
+	var devices []*StaleDevice
+	for _, device := range r.Devices {
+		if device.Health == health {
+			devices = append(devices, device)
+		}
+	}
+	return devices
+}
+
+// FindStale classifies every device of an enterprise as healthy, stale or never synced.
+//
+// 设备从未同步过策略（没有 LastPolicySyncTime）时为 NEVER_SYNCED；否则取
+// LastStatusReportTime、LastPolicySyncTime 和 LastPolicyComplianceReportTime 中最近的时间作为
+// 最后一次联系，早于 threshold 时为 STALE。策略不变时设备不会重新同步策略，
+// 因此只要状态上报仍然新鲜，较早的 LastPolicySyncTime 不会使设备被判定为 STALE。
+//
+//	report, err := client.Devices().FindStale(enterpriseID, 7*24*time.Hour)
+//	for _, device := range report.ByHealth(types.DeviceHealthStale) {
+//	    log.Printf("%s 已 %s 未联系", device.Device.Name, device.Since.Round(time.Hour))
+//	}
+func (ds *DeviceService) FindStale(enterpriseID string, threshold time.Duration) (*StaleReport, error) {
+	return ds.FindStaleWithOptions(ds.client.Context(), enterpriseID, threshold, nil)
+}
+
+// FindStaleWithOptions is like FindStale and can nudge the ACTIVE devices that are not healthy.
+//
+// API 没有强制设备同步策略的调用，重新分配相同的策略不会改变设备，因此唯一的唤醒方式是重启。
+// 唤醒操作通过批量命令的 worker 池执行，结果记录在 StaleReport.Nudge 中，
+// 单台设备失败不会使整个调用失败；配合 Bulk.DryRun 可以先预览会被唤醒的设备。
+func (ds *DeviceService) FindStaleWithOptions(ctx context.Context, enterpriseID string, threshold time.Duration, opts *StaleOptions) (*StaleReport, error) {
+	if err := validateEnterpriseID(enterpriseID); err != nil {
+		return nil, err
+	}
+	if threshold <= 0 {
+		return nil, types.NewError(types.ErrCodeInvalidInput, "stale threshold must be positive")
+	}
+
+	o := StaleOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	switch o.Nudge {
+	case StaleNudgeNone, StaleNudgeReboot:
+	default:
+		return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "unknown stale nudge", string(o.Nudge))
+	}
+
+	service := ds.WithContext(ctx)
+	report := &StaleReport{Threshold: threshold, CheckedAt: time.Now(), Devices: make([]*StaleDevice, 0)}
+	for device, err := range service.All(resolveEnterpriseName(enterpriseID), "", nil, "") {
+		if err != nil {
+			return nil, err
+		}
+
+		stale := classifyDevice(device, threshold, report.CheckedAt)
+		switch stale.Health {
+		case types.DeviceHealthHealthy:
+			report.Healthy++
+		case types.DeviceHealthStale:
+			report.Stale++
+		case types.DeviceHealthNeverSynced:
+			report.NeverSynced++
+		}
+		report.Devices = append(report.Devices, stale)
+	}
+
+	if o.Nudge == StaleNudgeNone {
+		return report, nil
+	}
+
+	bulk := BulkOptions{}
+	if o.Bulk != nil {
+		bulk = *o.Bulk
+	}
+
+	var deviceNames []string
+	for _, stale := range report.Devices {
+		if stale.Health != types.DeviceHealthHealthy && stale.Device.State == string(types.DeviceStateActive) {
+			deviceNames = append(deviceNames, stale.Device.Name)
+		}
+	}
+
+	command, err := types.NewRebootCommand()
+	if err != nil {
+		return nil, err
+	}
+	if report.Nudge, err = ds.BulkIssueCommand(ctx, deviceNames, command, &bulk); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// classifyDevice returns the health of a device at now.
+func classifyDevice(device *androidmanagement.Device, threshold time.Duration, now time.Time) *StaleDevice {
+	stale := &StaleDevice{Device: device, Health: types.DeviceHealthHealthy}
+
+	for _, timestamp := range []string{device.LastStatusReportTime, device.LastPolicySyncTime, device.LastPolicyComplianceReportTime} {
+		if t, err := time.Parse(time.RFC3339Nano, timestamp); err == nil && t.After(stale.LastSeen) {
+			stale.LastSeen = t
+		}
+	}
+	if !stale.LastSeen.IsZero() {
+		stale.Since = now.Sub(stale.LastSeen)
+	}
+
+	switch {
+	case device.LastPolicySyncTime == "":
+		stale.Health = types.DeviceHealthNeverSynced
+	case stale.Since > threshold:
+		stale.Health = types.DeviceHealthStale
+	}
+	return stale
+}
@@ -0,0 +1,127 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/amapitest"
+	"amapi-pkg/pkgs/amapi/client"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// newStaleTestClient 在 d1 之外添加不同上报时间的设备：
+// fresh 最近上报，old-sync 策略同步较早但状态上报新鲜，stale 和 stale-disabled 超过 7 天未联系，never 从未同步
+func newStaleTestClient(t *testing.T) (*amapitest.Server, *client.Client, string) {
+	t.Helper()
+
+	srv, c, device := newDeviceTestClient(t)
+	enterpriseName := device.Name[:len(device.Name)-len("/devices/d1")]
+	policyName := enterpriseName + "/policies/kiosk"
+	ago := func(d time.Duration) string { return time.Now().Add(-d).Format(time.RFC3339) }
+	day := 24 * time.Hour
+
+	srv.AddDevice(&androidmanagement.Device{
+		Name: enterpriseName + "/devices/fresh", State: "ACTIVE", PolicyName: policyName,
+		LastStatusReportTime: ago(time.Hour), LastPolicySyncTime: ago(2 * time.Hour), LastPolicyComplianceReportTime: ago(time.Hour),
+	})
+	srv.AddDevice(&androidmanagement.Device{
+		Name: enterpriseName + "/devices/old-sync", State: "ACTIVE", PolicyName: policyName,
+		LastStatusReportTime: ago(time.Hour), LastPolicySyncTime: ago(30 * day),
+	})
+	srv.AddDevice(&androidmanagement.Device{
+		Name: enterpriseName + "/devices/stale", State: "ACTIVE", PolicyName: policyName,
+		LastStatusReportTime: ago(10 * day), LastPolicySyncTime: ago(20 * day), LastPolicyComplianceReportTime: ago(9 * day),
+	})
+	srv.AddDevice(&androidmanagement.Device{
+		Name: enterpriseName + "/devices/stale-disabled", State: "DISABLED", PolicyName: policyName,
+		LastStatusReportTime: ago(10 * day), LastPolicySyncTime: ago(10 * day),
+	})
+	srv.AddDevice(&androidmanagement.Device{
+		Name: enterpriseName + "/devices/never", State: "ACTIVE", PolicyName: policyName,
+	})
+
+	return srv, c, enterpriseName
+}
+
+// 测试按最后联系时间分类设备
+func TestFindStale(t *testing.T) {
+	_, c, enterpriseName := newStaleTestClient(t)
+
+	report, err := c.Devices().FindStale(strings.TrimPrefix(enterpriseName, "enterprises/"), 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("FindStale() unexpected error: %v", err)
+	}
+
+	want := map[string]types.DeviceHealth{
+		"d1":             types.DeviceHealthNeverSynced,
+		"fresh":          types.DeviceHealthHealthy,
+		"old-sync":       types.DeviceHealthHealthy,
+		"stale":          types.DeviceHealthStale,
+		"stale-disabled": types.DeviceHealthStale,
+		"never":          types.DeviceHealthNeverSynced,
+	}
+	if len(report.Devices) != len(want) || report.Healthy != 2 || report.Stale != 2 || report.NeverSynced != 2 {
+		t.Fatalf("report = %d devices (%d healthy, %d stale, %d never synced), want 6 (2, 2, 2)",
+			len(report.Devices), report.Healthy, report.Stale, report.NeverSynced)
+	}
+	for _, device := range report.Devices {
+		id := device.Device.Name[len(enterpriseName+"/devices/"):]
+		if device.Health != want[id] {
+			t.Errorf("%s health = %s, want %s", id, device.Health, want[id])
+		}
+		if id == "stale" && (device.Since < 9*24*time.Hour || device.Since > 9*24*time.Hour+time.Minute) {
+			t.Errorf("stale Since = %s, want about 9 days (latest timestamp)", device.Since)
+		}
+	}
+	if report.Nudge != nil {
+		t.Error("Nudge is set without a nudge option")
+	}
+	if stale := report.ByHealth(types.DeviceHealthStale); len(stale) != 2 {
+		t.Errorf("ByHealth(STALE) = %d devices, want 2", len(stale))
+	}
+
+	if _, err := c.Devices().FindStale(enterpriseName, 0); err == nil {
+		t.Error("FindStale() expected error for zero threshold")
+	}
+
+	// FindStale 使用视图的 context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.WithContext(ctx).Devices().FindStale(enterpriseName, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("FindStale() on a canceled view = %v, want context.Canceled", err)
+	}
+}
+
+// 测试对不健康的活跃设备发送重启
+func TestFindStaleNudge(t *testing.T) {
+	srv, c, enterpriseName := newStaleTestClient(t)
+	threshold := 7 * 24 * time.Hour
+	nudged := []string{enterpriseName + "/devices/d1", enterpriseName + "/devices/stale", enterpriseName + "/devices/never"}
+
+	report, err := c.Devices().FindStaleWithOptions(context.Background(), enterpriseName, threshold, &client.StaleOptions{Nudge: client.StaleNudgeReboot})
+	if err != nil {
+		t.Fatalf("FindStaleWithOptions(REBOOT) unexpected error: %v", err)
+	}
+	if report.Nudge == nil || len(report.Nudge.Results) != 3 || report.Nudge.Failed != 0 {
+		t.Fatalf("Nudge = %+v, want 3 reboots", report.Nudge)
+	}
+	for _, name := range nudged {
+		if ops := srv.Operations(name); len(ops) != 1 {
+			t.Errorf("%s has %d operations, want 1 reboot", name, len(ops))
+		}
+	}
+	if ops := srv.Operations(enterpriseName + "/devices/stale-disabled"); len(ops) != 0 {
+		t.Error("disabled device was rebooted")
+	}
+
+	for _, nudge := range []client.StaleNudge{"WIPE", "POLICY_SYNC"} {
+		if _, err := c.Devices().FindStaleWithOptions(context.Background(), enterpriseName, threshold, &client.StaleOptions{Nudge: nudge}); err == nil {
+			t.Errorf("FindStaleWithOptions() expected error for unknown nudge %s", nudge)
+		}
+	}
+}
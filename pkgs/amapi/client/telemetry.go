@@ -0,0 +1,180 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// instrumentationName identifies the tracer and meter used by the client.
+const instrumentationName = "amapi-pkg/pkgs/amapi/client"
+
+// Attribute keys recorded on spans and metrics.
+const (
+	attrOperation   = attribute.Key("amapi.operation")
+	attrOperationID = attribute.Key("amapi.operation_id")
+	attrResource    = attribute.Key("amapi.resource")
+	attrAttempt     = attribute.Key("amapi.attempt")
+	attrAttempts    = attribute.Key("amapi.attempts")
+	attrErrorCode   = attribute.Key("amapi.error.code")
+	attrHTTPMethod  = attribute.Key("http.request.method")
+	attrHTTPStatus  = attribute.Key("http.response.status_code")
+)
+
+// telemetry holds the OpenTelemetry tracer and instruments used by a client.
+//
+// 每次逻辑调用创建一个 span，速率限制等待和每次尝试各是一个子 span。
+// 指标包括：
+//   - amapi.client.requests: 逻辑调用次数
+//   - amapi.client.errors: 失败的调用次数，按 types.Error.Code 区分
+//   - amapi.client.retries: 重试次数（不含首次尝试）
+//   - amapi.client.duration: 逻辑调用耗时（秒），包括速率限制等待和重试
+//   - amapi.client.rate_limit.wait: 速率限制等待时间（秒）
+type telemetry struct {
+	tracer trace.Tracer
+
+	requests      metric.Int64Counter
+	errors        metric.Int64Counter
+	retries       metric.Int64Counter
+	duration      metric.Float64Histogram
+	rateLimitWait metric.Float64Histogram
+}
+
+// newTelemetry creates the tracer and instruments from the given providers.
+// Nil providers fall back to the global providers registered with otel.
+func newTelemetry(tp trace.TracerProvider, mp metric.MeterProvider) (*telemetry, error) {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+
+	meter := mp.Meter(instrumentationName, metric.WithInstrumentationVersion(ClientVersion))
+	t := &telemetry{
+		tracer: tp.Tracer(instrumentationName, trace.WithInstrumentationVersion(ClientVersion)),
+	}
+
+	var err error
+	if t.requests, err = meter.Int64Counter("amapi.client.requests",
+		metric.WithDescription("Number of Android Management API calls"),
+		metric.WithUnit("{call}")); err != nil {
+		return nil, err
+	}
+	if t.errors, err = meter.Int64Counter("amapi.client.errors",
+		metric.WithDescription("Number of failed Android Management API calls by error code"),
+		metric.WithUnit("{call}")); err != nil {
+		return nil, err
+	}
+	if t.retries, err = meter.Int64Counter("amapi.client.retries",
+		metric.WithDescription("Number of retried attempts"),
+		metric.WithUnit("{attempt}")); err != nil {
+		return nil, err
+	}
+	if t.duration, err = meter.Float64Histogram("amapi.client.duration",
+		metric.WithDescription("Duration of Android Management API calls, including rate limiting and retries"),
+		metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if t.rateLimitWait, err = meter.Float64Histogram("amapi.client.rate_limit.wait",
+		metric.WithDescription("Time spent waiting for the rate limiter"),
+		metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// startCall starts the span of a logical API call.
+func (t *telemetry) startCall(ctx context.Context, info *callInfo) (context.Context, trace.Span) {
+	return t.tracer.Start(ctx, info.operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrOperation.String(info.operation), attrOperationID.String(info.operationID)))
+}
+
+// endCall records the outcome of a logical API call and ends its span.
+// code is the types.Error code of err, or 0 on success.
+func (t *telemetry) endCall(ctx context.Context, span trace.Span, info *callInfo, latency time.Duration, err error, code int) {
+	operation := attrOperation.String(info.operation)
+
+	span.SetAttributes(
+		attrResource.String(info.resource),
+		attrHTTPMethod.String(info.method),
+		attrAttempts.Int(info.attempt),
+	)
+	if info.status != 0 {
+		span.SetAttributes(attrHTTPStatus.Int(info.status))
+	}
+
+	attrs := []attribute.KeyValue{operation}
+	if err != nil {
+		attrs = append(attrs, attrErrorCode.Int(code))
+		span.SetAttributes(attrErrorCode.Int(code))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		t.errors.Add(ctx, 1, metric.WithAttributes(attrs...))
+	}
+
+	t.requests.Add(ctx, 1, metric.WithAttributes(attrs...))
+	t.duration.Record(ctx, latency.Seconds(), metric.WithAttributes(attrs...))
+	if info.attempt > 1 {
+		t.retries.Add(ctx, int64(info.attempt-1), metric.WithAttributes(operation))
+	}
+
+	span.End()
+}
+
+// startRateLimitWait starts the child span covering the rate limiter wait.
+func (t *telemetry) startRateLimitWait(ctx context.Context) (context.Context, trace.Span) {
+	return t.tracer.Start(ctx, "rate limit wait")
+}
+
+// endRateLimitWait records the rate limiter wait and ends its span.
+func (t *telemetry) endRateLimitWait(ctx context.Context, span trace.Span, info *callInfo, err error) {
+	t.rateLimitWait.Record(ctx, info.rateLimitWait.Seconds(),
+		metric.WithAttributes(attrOperation.String(info.operation)))
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// startAttempt starts the child span of one attempt.
+func (t *telemetry) startAttempt(ctx context.Context, info *callInfo) (context.Context, trace.Span) {
+	return t.tracer.Start(ctx, "attempt",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrAttempt.Int(info.attempt)))
+}
+
+// endAttempt records the HTTP outcome of an attempt and ends its span.
+func (t *telemetry) endAttempt(span trace.Span, info *callInfo, err error) {
+	if info.status != 0 {
+		span.SetAttributes(attrHTTPStatus.Int(info.status))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// errorCode returns the types.Error code that wrapAPIError would report for err.
+func (c *Client) errorCode(err error, operation string) int {
+	if err == nil {
+		return 0
+	}
+	if apiErr, ok := c.wrapAPIError(err, operation).(*types.Error); ok {
+		return apiErr.Code
+	}
+	return types.ErrCodeInternalServerError
+}
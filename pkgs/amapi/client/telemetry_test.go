@@ -0,0 +1,183 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/amapitest"
+	"amapi-pkg/pkgs/amapi/client"
+)
+
+// newTracedClient 创建把 span 和指标写入内存的客户端
+func newTracedClient(t *testing.T) (*amapitest.Server, *client.Client, *tracetest.SpanRecorder, *sdkmetric.ManualReader) {
+	t.Helper()
+
+	srv := amapitest.NewServer()
+	t.Cleanup(srv.Close)
+
+	cfg := srv.Config()
+	cfg.RetryDelay = time.Millisecond
+
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	c, err := client.New(cfg, append(srv.ClientOptions(), client.WithTelemetry(tp, mp))...)
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	return srv, c, spans, reader
+}
+
+// spanAttr 返回 span 上指定属性的值
+func spanAttr(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+// counterValues 返回计数器各数据点的值，键为 amapi.error.code 属性（没有时为 -1）
+func counterValues(t *testing.T, reader *sdkmetric.ManualReader, name string) map[int64]int64 {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect() unexpected error: %v", err)
+	}
+
+	values := map[int64]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if !ok {
+				t.Fatalf("%s has data type %T, want Sum[int64]", name, m.Data)
+			}
+			for _, dp := range sum.DataPoints {
+				code := int64(-1)
+				if v, ok := dp.Attributes.Value("amapi.error.code"); ok {
+					code = v.AsInt64()
+				}
+				values[code] += dp.Value
+			}
+		}
+	}
+	return values
+}
+
+// 测试每次调用的 span 结构：调用 span 下有速率限制等待和每次尝试的子 span
+func TestTelemetrySpans(t *testing.T) {
+	srv, c, spans, _ := newTracedClient(t)
+	enterprise := srv.AddEnterprise(&androidmanagement.Enterprise{})
+
+	srv.FailNext(http.StatusServiceUnavailable, "try again")
+	if _, err := c.Enterprises().Get(enterprise.Name); err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+
+	ended := spans.Ended()
+	var root sdktrace.ReadOnlySpan
+	for _, span := range ended {
+		if span.Name() == "get enterprise" {
+			root = span
+		}
+	}
+	if root == nil {
+		t.Fatalf("no span for the logical call, got %d spans", len(ended))
+	}
+	if got := spanAttr(root, "amapi.resource").AsString(); got != enterprise.Name {
+		t.Errorf("amapi.resource = %q, want %q", got, enterprise.Name)
+	}
+	if got := spanAttr(root, "amapi.attempts").AsInt64(); got != 2 {
+		t.Errorf("amapi.attempts = %d, want 2", got)
+	}
+
+	var waits, attempts []sdktrace.ReadOnlySpan
+	for _, span := range ended {
+		if span.Parent().SpanID() != root.SpanContext().SpanID() {
+			continue
+		}
+		switch span.Name() {
+		case "rate limit wait":
+			waits = append(waits, span)
+		case "attempt":
+			attempts = append(attempts, span)
+		}
+	}
+
+	if len(waits) != 1 {
+		t.Errorf("got %d rate limit wait spans, want 1", len(waits))
+	}
+	if len(attempts) != 2 {
+		t.Fatalf("got %d attempt spans, want 2", len(attempts))
+	}
+	if attempts[0].Status().Code != codes.Error || spanAttr(attempts[0], "http.response.status_code").AsInt64() != http.StatusServiceUnavailable {
+		t.Errorf("first attempt status = %v, want error with 503", attempts[0].Status())
+	}
+	if attempts[1].Status().Code == codes.Error || spanAttr(attempts[1], "http.response.status_code").AsInt64() != http.StatusOK {
+		t.Errorf("second attempt status = %v, want success with 200", attempts[1].Status())
+	}
+}
+
+// 测试请求数、错误码和重试次数指标
+func TestTelemetryMetrics(t *testing.T) {
+	srv, c, _, reader := newTracedClient(t)
+	enterprise := srv.AddEnterprise(&androidmanagement.Enterprise{})
+
+	srv.FailNext(http.StatusServiceUnavailable, "try again")
+	if _, err := c.Enterprises().Get(enterprise.Name); err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+	if _, err := c.Policies().Get(enterprise.Name + "/policies/missing"); err == nil {
+		t.Fatal("Get() expected error for a missing policy")
+	}
+
+	if got := counterValues(t, reader, "amapi.client.requests"); got[-1] != 1 || got[http.StatusNotFound] != 1 {
+		t.Errorf("requests = %v, want one success and one 404", got)
+	}
+	if got := counterValues(t, reader, "amapi.client.errors"); len(got) != 1 || got[http.StatusNotFound] != 1 {
+		t.Errorf("errors = %v, want one 404", got)
+	}
+	// 503 重试一次；404 属于原始 googleapi 错误，首次失败后也会重试一次
+	if got := counterValues(t, reader, "amapi.client.retries"); got[-1] != 2 {
+		t.Errorf("retries = %v, want 2", got)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect() unexpected error: %v", err)
+	}
+	histograms := map[string]uint64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if h, ok := m.Data.(metricdata.Histogram[float64]); ok {
+				for _, dp := range h.DataPoints {
+					histograms[m.Name] += dp.Count
+				}
+			}
+		}
+	}
+	for _, name := range []string{"amapi.client.duration", "amapi.client.rate_limit.wait"} {
+		if histograms[name] != 2 {
+			t.Errorf("%s count = %d, want 2", name, histograms[name])
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"context"
+	"iter"
 	"strings"
 
 	"google.golang.org/api/androidmanagement/v1"
@@ -18,6 +20,11 @@ func (c *Client) WebApps() *WebAppService {
 	return &WebAppService{client: c}
 }
 
+// WithContext returns a copy of the service whose API calls use ctx.
+func (was *WebAppService) WithContext(ctx context.Context) *WebAppService {
+	return &WebAppService{client: was.client.WithContext(ctx)}
+}
+
 // Create creates a new web app.
 func (was *WebAppService) Create(enterpriseName, startURL string, icons []*androidmanagement.WebAppIcon, versionCode int64) (*androidmanagement.WebApp, error) {
 	if enterpriseName == "" {
@@ -38,8 +45,8 @@ func (was *WebAppService) Create(enterpriseName, startURL string, icons []*andro
 	var result *androidmanagement.WebApp
 	var err error
 
-	err = was.client.executeAPICall(func() error {
-		result, err = was.client.service.Enterprises.WebApps.Create(enterpriseName, webApp).Context(was.client.ctx).Do()
+	err = was.client.executeAPICall(apiRequest{operation: "create web app", resource: enterpriseName + "/webApps", body: webApp}, func(ctx context.Context) error {
+		result, err = was.client.service.Enterprises.WebApps.Create(enterpriseName, webApp).Context(ctx).Do()
 		return err
 	})
 
@@ -69,8 +76,8 @@ func (was *WebAppService) Get(webAppName string) (*androidmanagement.WebApp, err
 	var result *androidmanagement.WebApp
 	var err error
 
-	err = was.client.executeAPICall(func() error {
-		result, err = was.client.service.Enterprises.WebApps.Get(webAppName).Context(was.client.ctx).Do()
+	err = was.client.executeAPICall(apiRequest{operation: "get web app", resource: webAppName}, func(ctx context.Context) error {
+		result, err = was.client.service.Enterprises.WebApps.Get(webAppName).Context(ctx).Do()
 		return err
 	})
 
@@ -108,7 +115,7 @@ func (was *WebAppService) Update(webAppName string, webApp *androidmanagement.We
 	var result *androidmanagement.WebApp
 	var err error
 
-	err = was.client.executeAPICall(func() error {
+	err = was.client.executeAPICall(apiRequest{operation: "update web app", resource: webAppName, body: []any{webApp, updateMask}}, func(ctx context.Context) error {
 		call := was.client.service.Enterprises.WebApps.Patch(webAppName, webApp)
 
 		if len(updateMask) > 0 {
@@ -117,7 +124,7 @@ func (was *WebAppService) Update(webAppName string, webApp *androidmanagement.We
 			call.UpdateMask(maskString)
 		}
 
-		result, err = call.Context(was.client.ctx).Do()
+		result, err = call.Context(ctx).Do()
 		return err
 	})
 
@@ -151,7 +158,7 @@ func (was *WebAppService) List(enterpriseName string, pageSize int, pageToken st
 	var result *androidmanagement.ListWebAppsResponse
 	var err error
 
-	err = was.client.executeAPICall(func() error {
+	err = was.client.executeAPICall(apiRequest{operation: "list web apps", resource: enterpriseName + "/webApps", body: pageParams(pageSize, pageToken)}, func(ctx context.Context) error {
 		call := was.client.service.Enterprises.WebApps.List(enterpriseName)
 
 		if pageSize > 0 {
@@ -162,7 +169,7 @@ func (was *WebAppService) List(enterpriseName string, pageSize int, pageToken st
 			call.PageToken(pageToken)
 		}
 
-		result, err = call.Context(was.client.ctx).Do()
+		result, err = call.Context(ctx).Do()
 		return err
 	})
 
@@ -180,6 +187,18 @@ func (was *WebAppService) List(enterpriseName string, pageSize int, pageToken st
 	}, nil
 }
 
+// All returns an iterator over all web apps, following page tokens automatically.
+func (was *WebAppService) All(enterpriseName string) iter.Seq2[*androidmanagement.WebApp, error] {
+	return paginate(func(pageToken string) (*types.ListResult[*androidmanagement.WebApp], error) {
+		return was.List(enterpriseName, DefaultIteratorPageSize, pageToken)
+	})
+}
+
+// ListAll returns all web apps across every page.
+func (was *WebAppService) ListAll(enterpriseName string) (*types.ListResult[*androidmanagement.WebApp], error) {
+	return collect(was.All(enterpriseName))
+}
+
 // ListByEnterpriseID lists web apps for an enterprise by enterprise ID.
 func (was *WebAppService) ListByEnterpriseID(enterpriseID string, pageSize int, pageToken string) (*types.ListResult[*androidmanagement.WebApp], error) {
 	if err := validateEnterpriseID(enterpriseID); err != nil {
@@ -196,8 +215,8 @@ func (was *WebAppService) Delete(webAppName string) error {
 		return types.NewError(types.ErrCodeInvalidInput, "web app name is required")
 	}
 
-	err := was.client.executeAPICall(func() error {
-		_, err := was.client.service.Enterprises.WebApps.Delete(webAppName).Context(was.client.ctx).Do()
+	err := was.client.executeAPICall(apiRequest{operation: "delete web app", resource: webAppName}, func(ctx context.Context) error {
+		_, err := was.client.service.Enterprises.WebApps.Delete(webAppName).Context(ctx).Do()
 		return err
 	})
 
@@ -225,7 +244,7 @@ func (was *WebAppService) DeleteByID(enterpriseID, webAppID string) error {
 // GetActiveWebApps returns all active web apps for an enterprise.
 func (was *WebAppService) GetActiveWebApps(enterpriseID string) (*types.ListResult[*androidmanagement.WebApp], error) {
 	enterpriseName := buildEnterpriseName(enterpriseID)
-	return was.List(enterpriseName, 0, "")
+	return was.ListAll(enterpriseName)
 }
 
 // Helper function to build web app name
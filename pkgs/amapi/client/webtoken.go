@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"time"
 
 	"google.golang.org/api/androidmanagement/v1"
@@ -18,6 +19,11 @@ func (c *Client) WebTokens() *WebTokenService {
 	return &WebTokenService{client: c}
 }
 
+// WithContext returns a copy of the service whose API calls use ctx.
+func (wts *WebTokenService) WithContext(ctx context.Context) *WebTokenService {
+	return &WebTokenService{client: wts.client.WithContext(ctx)}
+}
+
 // Create creates a new web token.
 func (wts *WebTokenService) Create(enterpriseName, parentFrameUrl string, enabledFeatures []string) (*androidmanagement.WebToken, error) {
 	if enterpriseName == "" {
@@ -43,8 +49,8 @@ func (wts *WebTokenService) Create(enterpriseName, parentFrameUrl string, enable
 	var result *androidmanagement.WebToken
 	var err error
 
-	err = wts.client.executeAPICall(func() error {
-		result, err = wts.client.service.Enterprises.WebTokens.Create(enterpriseName, token).Context(wts.client.ctx).Do()
+	err = wts.client.executeAPICall(apiRequest{operation: "create web token", resource: enterpriseName + "/webTokens", body: token}, func(ctx context.Context) error {
+		result, err = wts.client.service.Enterprises.WebTokens.Create(enterpriseName, token).Context(ctx).Do()
 		return err
 	})
 
@@ -129,14 +129,40 @@ type Config struct {
 
 	// 缓存配置
 
-	// EnableCache 控制是否启用响应缓存（实验性功能）。
+	// EnableCache 控制是否启用响应缓存。
+	// 启用后，企业、策略、设备和应用的 Get/List 结果会被缓存，修改操作会使相关条目失效。
 	// 默认为 false。
+	// 可通过环境变量 AMAPI_ENABLE_CACHE 设置。
 	EnableCache bool `yaml:"enable_cache" json:"enable_cache"`
 
 	// CacheTTL 是缓存的有效期。
 	// 默认为 5 分钟。
+	// 可通过环境变量 AMAPI_CACHE_TTL 设置。
 	CacheTTL time.Duration `yaml:"cache_ttl" json:"cache_ttl"`
 
+	// CacheSize 是内存缓存最多保存的条目数，超出后淘汰最久未使用的条目。
+	// 默认为 1000。使用 Redis 缓存时此选项无效。
+	// 可通过环境变量 AMAPI_CACHE_SIZE 设置。
+	CacheSize int `yaml:"cache_size" json:"cache_size"`
+
+	// 策略历史配置
+
+	// PolicyHistory 是策略版本历史的存储方式，可选值：memory、dir、redis。
+	// 启用后，PolicyService 的创建和更新操作会按 Policy.Version 记录策略快照，
+	// 可以通过 PolicyService.History 查看并通过 PolicyService.Rollback 回滚。
+	// 默认为空，即不记录历史。使用 redis 时需要设置 RedisAddress。
+	// 可通过环境变量 AMAPI_POLICY_HISTORY 设置。
+	PolicyHistory string `yaml:"policy_history" json:"policy_history"`
+
+	// PolicyHistoryDir 是 PolicyHistory 为 dir 时保存快照的目录。
+	// 可通过环境变量 AMAPI_POLICY_HISTORY_DIR 设置。
+	PolicyHistoryDir string `yaml:"policy_history_dir" json:"policy_history_dir"`
+
+	// PolicyHistoryLimit 是每个策略最多保留的版本数，超出后删除最旧的版本。
+	// 默认为 0，即保留所有版本。
+	// 可通过环境变量 AMAPI_POLICY_HISTORY_LIMIT 设置。
+	PolicyHistoryLimit int `yaml:"policy_history_limit" json:"policy_history_limit"`
+
 	// 日志配置
 
 	// LogLevel 是日志级别，可选值：debug, info, warn, error。
@@ -218,6 +244,13 @@ type Config struct {
 	//
 	// 启用后，多个进程不会同时重试同一个失败的操作，减少重复的 API 调用。
 	UseRedisRetry bool `yaml:"use_redis_retry" json:"use_redis_retry"`
+
+	// UseRedisCache 控制是否使用 Redis 保存响应缓存。
+	// 如果 RedisAddress 未设置或 EnableCache 为 false，此选项无效。
+	// 默认为 false。
+	//
+	// 启用后，所有进程共享同一份缓存，一个进程中的修改操作会使其他进程的缓存条目失效。
+	UseRedisCache bool `yaml:"use_redis_cache" json:"use_redis_cache"`
 }
 
 // DefaultConfig 返回一个包含合理默认值的配置对象。
@@ -249,6 +282,7 @@ func DefaultConfig() *Config {
 		EnableRetry:        true,
 		EnableCache:        false,
 		CacheTTL:           5 * time.Minute,
+		CacheSize:          1000,
 		LogLevel:           "info",
 		EnableDebugLogging: false,
 		RateLimit:          100,
@@ -269,6 +303,8 @@ func DefaultConfig() *Config {
 //   - Timeout 必须大于 0
 //   - RetryAttempts 必须非负
 //   - RetryDelay 必须非负
+//   - 启用缓存时 CacheTTL 必须大于 0，CacheSize 必须非负
+//   - PolicyHistory 必须是 memory/dir/redis 之一，dir 需要 PolicyHistoryDir，redis 需要 RedisAddress
 //   - LogLevel 必须是 debug/info/warn/error 之一
 //
 // 返回第一个发现的验证错误，如果配置有效则返回 nil。
@@ -306,6 +342,32 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("retry_delay must be non-negative")
 	}
 
+	if c.EnableCache && c.CacheTTL <= 0 {
+		return fmt.Errorf("cache_ttl must be positive when cache is enabled")
+	}
+
+	if c.CacheSize < 0 {
+		return fmt.Errorf("cache_size must be non-negative")
+	}
+
+	switch c.PolicyHistory {
+	case "", "memory":
+	case "dir":
+		if c.PolicyHistoryDir == "" {
+			return fmt.Errorf("policy_history_dir is required when policy_history is dir")
+		}
+	case "redis":
+		if c.RedisAddress == "" {
+			return fmt.Errorf("redis_address is required when policy_history is redis")
+		}
+	default:
+		return fmt.Errorf("invalid policy_history: %s (must be memory, dir, or redis)", c.PolicyHistory)
+	}
+
+	if c.PolicyHistoryLimit < 0 {
+		return fmt.Errorf("policy_history_limit must be non-negative")
+	}
+
 	validLogLevels := map[string]bool{
 		"debug": true,
 		"info":  true,
@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -93,6 +95,72 @@ func TestConfigValidation(t *testing.T) {
 	}
 }
 
+// 测试策略历史配置的校验
+func TestPolicyHistoryValidation(t *testing.T) {
+	credentials := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(credentials, []byte(`{"type": "service_account"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(cfg *Config)
+		errorMsg string
+	}{
+		{
+			name: "dir history",
+			modify: func(cfg *Config) {
+				cfg.PolicyHistory = "dir"
+				cfg.PolicyHistoryDir = t.TempDir()
+				cfg.PolicyHistoryLimit = 10
+			},
+		},
+		{
+			name:     "invalid policy history",
+			modify:   func(cfg *Config) { cfg.PolicyHistory = "s3" },
+			errorMsg: "invalid policy_history: s3",
+		},
+		{
+			name:     "policy history dir without directory",
+			modify:   func(cfg *Config) { cfg.PolicyHistory = "dir" },
+			errorMsg: "policy_history_dir is required when policy_history is dir",
+		},
+		{
+			name:     "redis history without address",
+			modify:   func(cfg *Config) { cfg.PolicyHistory = "redis" },
+			errorMsg: "redis_address is required when policy_history is redis",
+		},
+		{
+			name:     "negative policy history limit",
+			modify:   func(cfg *Config) { cfg.PolicyHistoryLimit = -1 },
+			errorMsg: "policy_history_limit must be non-negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				ProjectID:       "test-project",
+				CredentialsFile: credentials,
+				Timeout:         10 * time.Second,
+				LogLevel:        "info",
+			}
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Expected no validation error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
 func TestLoadFromEnv(t *testing.T) {
 	// Save original env vars
 	originalVars := map[string]string{
@@ -25,6 +25,12 @@ const (
 	// Cache configuration
 	EnvEnableCache            = "AMAPI_ENABLE_CACHE"
 	EnvCacheTTL               = "AMAPI_CACHE_TTL"
+	EnvCacheSize              = "AMAPI_CACHE_SIZE"
+
+	// Policy history configuration
+	EnvPolicyHistory          = "AMAPI_POLICY_HISTORY"
+	EnvPolicyHistoryDir       = "AMAPI_POLICY_HISTORY_DIR"
+	EnvPolicyHistoryLimit     = "AMAPI_POLICY_HISTORY_LIMIT"
 
 	// Logging configuration
 	EnvLogLevel               = "AMAPI_LOG_LEVEL"
@@ -104,6 +104,23 @@ func loadFromEnv(config *Config) {
 		config.CacheTTL = parseDuration(cacheTTL, config.CacheTTL)
 	}
 
+	if cacheSize := GetEnvVar(EnvCacheSize); cacheSize != "" {
+		config.CacheSize = parseInt(cacheSize, config.CacheSize)
+	}
+
+	// Policy history configuration
+	if policyHistory := GetEnvVar(EnvPolicyHistory); policyHistory != "" {
+		config.PolicyHistory = strings.ToLower(policyHistory)
+	}
+
+	if policyHistoryDir := GetEnvVar(EnvPolicyHistoryDir); policyHistoryDir != "" {
+		config.PolicyHistoryDir = policyHistoryDir
+	}
+
+	if policyHistoryLimit := GetEnvVar(EnvPolicyHistoryLimit); policyHistoryLimit != "" {
+		config.PolicyHistoryLimit = parseInt(policyHistoryLimit, config.PolicyHistoryLimit)
+	}
+
 	// Logging configuration
 	if logLevel := GetEnvVar(EnvLogLevel); logLevel != "" {
 		config.LogLevel = strings.ToLower(logLevel)
@@ -208,6 +225,11 @@ func (c *Config) GetConfigSummary() string {
 	summary.WriteString(fmt.Sprintf("Cache Enabled: %t\n", c.EnableCache))
 	if c.EnableCache {
 		summary.WriteString(fmt.Sprintf("Cache TTL: %v\n", c.CacheTTL))
+		summary.WriteString(fmt.Sprintf("Cache Size: %d\n", c.CacheSize))
+	}
+
+	if c.PolicyHistory != "" {
+		summary.WriteString(fmt.Sprintf("Policy History: %s\n", c.PolicyHistory))
 	}
 
 	summary.WriteString(fmt.Sprintf("Log Level: %s\n", c.LogLevel))
@@ -0,0 +1,174 @@
+package notifications
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// DeviceHandler handles notifications whose payload is a Device.
+type DeviceHandler func(ctx context.Context, device *androidmanagement.Device, msg *Message) error
+
+// OperationHandler handles COMMAND notifications.
+type OperationHandler func(ctx context.Context, operation *androidmanagement.Operation, msg *Message) error
+
+// UsageLogsHandler handles USAGE_LOGS notifications.
+type UsageLogsHandler func(ctx context.Context, events *androidmanagement.BatchUsageLogEvents, msg *Message) error
+
+// MessageHandler handles notifications that have no typed payload.
+type MessageHandler func(ctx context.Context, msg *Message) error
+
+// DecodeErrorHandler handles push messages that cannot be parsed or decoded.
+// msg is nil if the push request body is not a valid push message.
+type DecodeErrorHandler func(ctx context.Context, err error, msg *Message)
+
+// Dispatcher routes decoded notifications to typed handlers.
+//
+// 每种通知类型只保留最后注册的处理函数。没有注册处理函数的通知会交给 OnUnknown，
+// 如果也没有注册 OnUnknown 则直接忽略（视为处理成功）。
+// Dispatcher 可以在多个 goroutine 中并发使用。
+type Dispatcher struct {
+	mu sync.RWMutex
+
+	enrollment       DeviceHandler
+	statusReport     DeviceHandler
+	complianceReport DeviceHandler
+	command          OperationHandler
+	usageLogs        UsageLogsHandler
+	test             MessageHandler
+	unknown          MessageHandler
+	decodeError      DecodeErrorHandler
+}
+
+// NewDispatcher creates a dispatcher with no handlers registered.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{}
+}
+
+// OnEnrollment registers the handler for ENROLLMENT notifications.
+func (d *Dispatcher) OnEnrollment(h DeviceHandler) *Dispatcher {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.enrollment = h
+	return d
+}
+
+// OnStatusReport registers the handler for STATUS_REPORT notifications.
+func (d *Dispatcher) OnStatusReport(h DeviceHandler) *Dispatcher {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.statusReport = h
+	return d
+}
+
+// OnComplianceReport registers the handler for the deprecated COMPLIANCE_REPORT notifications.
+func (d *Dispatcher) OnComplianceReport(h DeviceHandler) *Dispatcher {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.complianceReport = h
+	return d
+}
+
+// OnCommand registers the handler for COMMAND notifications.
+func (d *Dispatcher) OnCommand(h OperationHandler) *Dispatcher {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.command = h
+	return d
+}
+
+// OnUsageLogs registers the handler for USAGE_LOGS notifications.
+func (d *Dispatcher) OnUsageLogs(h UsageLogsHandler) *Dispatcher {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.usageLogs = h
+	return d
+}
+
+// OnTest registers the handler for the test notification sent when a topic is configured.
+func (d *Dispatcher) OnTest(h MessageHandler) *Dispatcher {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.test = h
+	return d
+}
+
+// OnUnknown registers the fallback handler for notifications without a specific handler.
+func (d *Dispatcher) OnUnknown(h MessageHandler) *Dispatcher {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.unknown = h
+	return d
+}
+
+// OnDecodeError registers the handler for push messages that cannot be parsed or decoded.
+//
+// 这类消息重新投递也无法处理，PushHandler 会确认（204）并交给这个处理函数记录，
+// 避免 Pub/Sub 无限重新投递。
+func (d *Dispatcher) OnDecodeError(h DecodeErrorHandler) *Dispatcher {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.decodeError = h
+	return d
+}
+
+// reportDecodeError passes a decode error to the OnDecodeError handler, if any.
+func (d *Dispatcher) reportDecodeError(ctx context.Context, err error, msg *Message) {
+	d.mu.RLock()
+	h := d.decodeError
+	d.mu.RUnlock()
+
+	if h != nil {
+		h(ctx, err, msg)
+	}
+}
+
+// Dispatch decodes msg and calls the matching handler.
+func (d *Dispatcher) Dispatch(ctx context.Context, msg *Message) error {
+	n, err := Decode(msg)
+	if err != nil {
+		return err
+	}
+	return d.DispatchNotification(ctx, n)
+}
+
+// DispatchNotification calls the handler matching an already decoded notification.
+func (d *Dispatcher) DispatchNotification(ctx context.Context, n *Notification) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	switch n.Type {
+	case types.NotificationTypeEnrollment:
+		if d.enrollment != nil {
+			return d.enrollment(ctx, n.Device, n.Message)
+		}
+	case types.NotificationTypeStatusReport:
+		if d.statusReport != nil {
+			return d.statusReport(ctx, n.Device, n.Message)
+		}
+	case types.NotificationTypeComplianceReport:
+		if d.complianceReport != nil {
+			return d.complianceReport(ctx, n.Device, n.Message)
+		}
+	case types.NotificationTypeCommand:
+		if d.command != nil {
+			return d.command(ctx, n.Operation, n.Message)
+		}
+	case types.NotificationTypeUsageLogs:
+		if d.usageLogs != nil {
+			return d.usageLogs(ctx, n.UsageLogs, n.Message)
+		}
+	case types.NotificationTypeTest:
+		if d.test != nil {
+			return d.test(ctx, n.Message)
+		}
+	}
+
+	if d.unknown != nil {
+		return d.unknown(ctx, n.Message)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+// Package notifications decodes Android Management API Pub/Sub notifications.
+//
+// 启用 EnterpriseService.SetPubSubTopic 和 EnableNotifications 后，
+// Android Management API 会把设备注册、状态报告、命令结果等事件发布到 Pub/Sub 主题。
+// 这个包负责解析 Pub/Sub 的推送（push）和拉取（pull）消息，根据 notificationType
+// 属性把数据解码为对应的 androidmanagement 类型，并分发给类型化的处理函数。
+//
+// # 推送订阅
+//
+//	d := notifications.NewDispatcher().
+//	    OnEnrollment(func(ctx context.Context, device *androidmanagement.Device, msg *notifications.Message) error {
+//	        log.Printf("设备已注册: %s", device.Name)
+//	        return nil
+//	    }).
+//	    OnCommand(func(ctx context.Context, op *androidmanagement.Operation, msg *notifications.Message) error {
+//	        log.Printf("命令完成: %s done=%t", op.Name, op.Done)
+//	        return nil
+//	    })
+//
+//	http.Handle("/amapi/events", d.PushHandler(os.Getenv("AMAPI_PUSH_TOKEN")))
+//
+// 处理函数返回错误时响应 500，Pub/Sub 会重新投递该消息。无法解析的消息会被确认，
+// 并交给 OnDecodeError 注册的处理函数，避免无限重新投递。
+//
+// # 拉取订阅
+//
+// 使用 cloud.google.com/go/pubsub 时，把收到的消息转换为 Message 后调用 Dispatch：
+//
+//	err := sub.Receive(ctx, func(ctx context.Context, m *pubsub.Message) {
+//	    msg := &notifications.Message{ID: m.ID, Data: m.Data, Attributes: m.Attributes, PublishTime: m.PublishTime}
+//	    if err := d.Dispatch(ctx, msg); err != nil {
+//	        m.Nack()
+//	        return
+//	    }
+//	    m.Ack()
+//	})
+//
+// 直接调用 Pub/Sub REST API 拉取时，可使用 ParsePullResponse 解析响应体。
+package notifications
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// AttributeNotificationType is the Pub/Sub attribute that carries the notification type.
+const AttributeNotificationType = "notificationType"
+
+// Message is a Pub/Sub message independent of how it was delivered.
+type Message struct {
+	// ID is the Pub/Sub message ID
+	ID string `json:"id"`
+
+	// Data is the decoded message payload
+	Data []byte `json:"data,omitempty"`
+
+	// Attributes are the Pub/Sub message attributes
+	Attributes map[string]string `json:"attributes,omitempty"`
+
+	// PublishTime is when the message was published
+	PublishTime time.Time `json:"publish_time"`
+
+	// Subscription is the subscription the message was delivered on (push only)
+	Subscription string `json:"subscription,omitempty"`
+
+	// AckID is the acknowledgement ID (REST pull only)
+	AckID string `json:"ack_id,omitempty"`
+}
+
+// NotificationType returns the notificationType attribute of the message.
+func (m *Message) NotificationType() string {
+	return m.Attributes[AttributeNotificationType]
+}
+
+// pubsubMessage is the JSON form of a Pub/Sub message.
+// The push format uses both camelCase and snake_case field names.
+type pubsubMessage struct {
+	Data         []byte            `json:"data"`
+	Attributes   map[string]string `json:"attributes"`
+	MessageID    string            `json:"messageId"`
+	MessageIDAlt string            `json:"message_id"`
+	PublishTime  string            `json:"publishTime"`
+	PublishAlt   string            `json:"publish_time"`
+}
+
+// toMessage converts the JSON form to a Message.
+func (pm *pubsubMessage) toMessage() (*Message, error) {
+	msg := &Message{
+		ID:         firstNonEmpty(pm.MessageID, pm.MessageIDAlt),
+		Data:       pm.Data,
+		Attributes: pm.Attributes,
+	}
+	if msg.Attributes == nil {
+		msg.Attributes = map[string]string{}
+	}
+
+	if publishTime := firstNonEmpty(pm.PublishTime, pm.PublishAlt); publishTime != "" {
+		t, err := time.Parse(time.RFC3339Nano, publishTime)
+		if err != nil {
+			return nil, types.WrapError(err, types.ErrCodeInvalidInput, "invalid publish time")
+		}
+		msg.PublishTime = t
+	}
+
+	return msg, nil
+}
+
+// pushEnvelope is the body of a Pub/Sub push request.
+type pushEnvelope struct {
+	Message      *pubsubMessage `json:"message"`
+	Subscription string         `json:"subscription"`
+}
+
+// pullResponse is the body of a Pub/Sub REST pull response.
+type pullResponse struct {
+	ReceivedMessages []struct {
+		AckID   string         `json:"ackId"`
+		Message *pubsubMessage `json:"message"`
+	} `json:"receivedMessages"`
+}
+
+// ParsePushRequest parses the body of a Pub/Sub push request.
+func ParsePushRequest(r io.Reader) (*Message, error) {
+	var envelope pushEnvelope
+	if err := json.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInvalidInput, "invalid push envelope")
+	}
+
+	if envelope.Message == nil {
+		return nil, types.NewError(types.ErrCodeInvalidInput, "push envelope has no message")
+	}
+
+	msg, err := envelope.Message.toMessage()
+	if err != nil {
+		return nil, err
+	}
+
+	msg.Subscription = envelope.Subscription
+	return msg, nil
+}
+
+// ParsePullResponse parses the body of a Pub/Sub REST pull response.
+func ParsePullResponse(r io.Reader) ([]*Message, error) {
+	var response pullResponse
+	if err := json.NewDecoder(r).Decode(&response); err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInvalidInput, "invalid pull response")
+	}
+
+	messages := make([]*Message, 0, len(response.ReceivedMessages))
+	for _, received := range response.ReceivedMessages {
+		if received.Message == nil {
+			continue
+		}
+
+		msg, err := received.Message.toMessage()
+		if err != nil {
+			return nil, err
+		}
+
+		msg.AckID = received.AckID
+		messages = append(messages, msg)
+	}
+
+	return messages, nil
+}
+
+// firstNonEmpty returns the first non-empty string.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
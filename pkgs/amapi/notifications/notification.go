@@ -0,0 +1,80 @@
+package notifications
+
+import (
+	"encoding/json"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// Notification is a decoded Android Management API notification.
+// Exactly one payload field is set, depending on Type.
+type Notification struct {
+	// Type is the notificationType attribute, e.g. types.NotificationTypeEnrollment
+	Type string
+
+	// Message is the Pub/Sub message the notification was decoded from
+	Message *Message
+
+	// Device is set for ENROLLMENT, STATUS_REPORT and COMPLIANCE_REPORT notifications
+	Device *androidmanagement.Device
+
+	// Operation is set for COMMAND notifications
+	Operation *androidmanagement.Operation
+
+	// UsageLogs is set for USAGE_LOGS notifications
+	UsageLogs *androidmanagement.BatchUsageLogEvents
+}
+
+// Decode decodes a Pub/Sub message into a Notification.
+//
+// 未知的通知类型不会返回错误，只设置 Type 字段，原始数据可通过 Message.Data 获取。
+// 缺少 notificationType 属性或数据无法解析时返回 ErrCodeInvalidInput 错误。
+func Decode(msg *Message) (*Notification, error) {
+	if msg == nil {
+		return nil, types.NewError(types.ErrCodeInvalidInput, "message is required")
+	}
+
+	notificationType := msg.NotificationType()
+	if notificationType == "" {
+		return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput,
+			"missing notification type", "attribute "+AttributeNotificationType+" is not set")
+	}
+
+	n := &Notification{Type: notificationType, Message: msg}
+
+	switch notificationType {
+	case types.NotificationTypeEnrollment, types.NotificationTypeStatusReport, types.NotificationTypeComplianceReport:
+		n.Device = &androidmanagement.Device{}
+		if err := decodePayload(msg, n.Device); err != nil {
+			return nil, err
+		}
+	case types.NotificationTypeCommand:
+		n.Operation = &androidmanagement.Operation{}
+		if err := decodePayload(msg, n.Operation); err != nil {
+			return nil, err
+		}
+	case types.NotificationTypeUsageLogs:
+		n.UsageLogs = &androidmanagement.BatchUsageLogEvents{}
+		if err := decodePayload(msg, n.UsageLogs); err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+// decodePayload unmarshals the message data into v.
+func decodePayload(msg *Message, v any) error {
+	if len(msg.Data) == 0 {
+		return types.NewErrorWithDetails(types.ErrCodeInvalidInput,
+			"empty notification payload", "notification type "+msg.NotificationType())
+	}
+
+	if err := json.Unmarshal(msg.Data, v); err != nil {
+		return types.WrapError(err, types.ErrCodeInvalidInput, "invalid "+msg.NotificationType()+" payload")
+	}
+
+	return nil
+}
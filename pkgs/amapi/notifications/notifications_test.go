@@ -0,0 +1,226 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// openTestdata 打开 testdata 目录下录制的 Pub/Sub 消息
+func openTestdata(t *testing.T, name string) *os.File {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("open %s: %v", name, err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+// 测试解析推送消息并解码设备数据
+func TestParsePushRequest(t *testing.T) {
+	msg, err := ParsePushRequest(openTestdata(t, "push_enrollment.json"))
+	if err != nil {
+		t.Fatalf("ParsePushRequest() unexpected error: %v", err)
+	}
+
+	if msg.ID != "11111111111" {
+		t.Errorf("ID = %q, want 11111111111", msg.ID)
+	}
+	if msg.Subscription != "projects/my-project/subscriptions/amapi-events-row-push" {
+		t.Errorf("Subscription = %q", msg.Subscription)
+	}
+	if msg.PublishTime.IsZero() {
+		t.Error("PublishTime was not parsed")
+	}
+
+	n, err := Decode(msg)
+	if err != nil {
+		t.Fatalf("Decode() unexpected error: %v", err)
+	}
+	if n.Type != types.NotificationTypeEnrollment || n.Device == nil {
+		t.Fatalf("Decode() = %+v, want ENROLLMENT with device", n)
+	}
+	if n.Device.Name != "enterprises/LC00abc123/devices/3a4b5c6d7e8f" || n.Device.HardwareInfo.Model != "Pixel 8" {
+		t.Errorf("Device = %+v", n.Device)
+	}
+}
+
+// 测试解析 REST 拉取响应
+func TestParsePullResponse(t *testing.T) {
+	messages, err := ParsePullResponse(openTestdata(t, "pull_response.json"))
+	if err != nil {
+		t.Fatalf("ParsePullResponse() unexpected error: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("ParsePullResponse() returned %d messages, want 2", len(messages))
+	}
+
+	if messages[0].AckID != "ack-1" || messages[0].NotificationType() != types.NotificationTypeEnrollment {
+		t.Errorf("messages[0] = %+v", messages[0])
+	}
+
+	n, err := Decode(messages[1])
+	if err != nil {
+		t.Fatalf("Decode() unexpected error: %v", err)
+	}
+	if n.Operation == nil || !n.Operation.Done {
+		t.Errorf("Decode() operation = %+v, want done operation", n.Operation)
+	}
+}
+
+// 测试解码错误
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  *Message
+	}{
+		{"missing type", &Message{Data: []byte(`{}`)}},
+		{"empty payload", &Message{Attributes: map[string]string{AttributeNotificationType: "STATUS_REPORT"}}},
+		{"invalid payload", &Message{
+			Attributes: map[string]string{AttributeNotificationType: "COMMAND"},
+			Data:       []byte(`not json`),
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(tt.msg); err == nil {
+				t.Error("Decode() expected error, got nil")
+			}
+		})
+	}
+
+	// 未知类型不是错误
+	n, err := Decode(&Message{Attributes: map[string]string{AttributeNotificationType: "ENTERPRISE_UPGRADE"}})
+	if err != nil || n.Type != "ENTERPRISE_UPGRADE" {
+		t.Errorf("Decode(unknown) = %+v, %v", n, err)
+	}
+}
+
+// 测试推送处理器使用录制的消息分发到类型化处理函数
+func TestPushHandler(t *testing.T) {
+	var got []string
+	d := NewDispatcher().
+		OnEnrollment(func(ctx context.Context, device *androidmanagement.Device, msg *Message) error {
+			got = append(got, "enrollment:"+device.HardwareInfo.SerialNumber)
+			return nil
+		}).
+		OnStatusReport(func(ctx context.Context, device *androidmanagement.Device, msg *Message) error {
+			got = append(got, "status:"+device.NonComplianceDetails[0].SettingName)
+			return nil
+		}).
+		OnCommand(func(ctx context.Context, op *androidmanagement.Operation, msg *Message) error {
+			got = append(got, "command:"+op.Name)
+			return nil
+		}).
+		OnTest(func(ctx context.Context, msg *Message) error {
+			got = append(got, "test")
+			return nil
+		})
+
+	handler := d.PushHandler("secret")
+
+	for _, name := range []string{"push_enrollment.json", "push_status_report.json", "push_command.json", "push_test.json"} {
+		req := httptest.NewRequest(http.MethodPost, "/events?token=secret", openTestdata(t, name))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNoContent {
+			t.Errorf("%s: status = %d, want 204 (%s)", name, rec.Code, rec.Body.String())
+		}
+	}
+
+	expected := []string{
+		"enrollment:35XY0123",
+		"status:passwordPolicies",
+		"command:enterprises/LC00abc123/devices/3a4b5c6d7e8f/operations/1700000000000",
+		"test",
+	}
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("handled = %v, want %v", got, expected)
+	}
+}
+
+// 测试推送处理器的错误状态码
+func TestPushHandlerStatusCodes(t *testing.T) {
+	failing := NewDispatcher().OnEnrollment(func(ctx context.Context, device *androidmanagement.Device, msg *Message) error {
+		return errors.New("database unavailable")
+	})
+	ignoring := NewDispatcher()
+	var decodeErrors []string
+	reporting := NewDispatcher().OnDecodeError(func(ctx context.Context, err error, msg *Message) {
+		id := "<nil>"
+		if msg != nil {
+			id = msg.ID
+		}
+		decodeErrors = append(decodeErrors, id)
+	})
+	undecodable := `{"message":{"messageId":"m1","data":"ew==","attributes":{"notificationType":"ENROLLMENT"}},"subscription":"s"}`
+
+	tests := []struct {
+		name     string
+		handler  http.Handler
+		method   string
+		target   string
+		body     string
+		file     string
+		expected int
+	}{
+		{"wrong method", ignoring.PushHandler(""), http.MethodGet, "/", "", "", http.StatusMethodNotAllowed},
+		{"wrong token", ignoring.PushHandler("secret"), http.MethodPost, "/?token=nope", "", "push_enrollment.json", http.StatusForbidden},
+		{"malformed body", reporting.PushHandler(""), http.MethodPost, "/", "{", "", http.StatusNoContent},
+		{"no message", ignoring.PushHandler(""), http.MethodPost, "/", `{"subscription":"s"}`, "", http.StatusNoContent},
+		{"undecodable payload", reporting.PushHandler(""), http.MethodPost, "/", undecodable, "", http.StatusNoContent},
+		{"handler error", failing.PushHandler(""), http.MethodPost, "/", "", "push_enrollment.json", http.StatusInternalServerError},
+		{"no handler registered", ignoring.PushHandler(""), http.MethodPost, "/", "", "push_command.json", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req *http.Request
+			if tt.file != "" {
+				req = httptest.NewRequest(tt.method, tt.target, openTestdata(t, tt.file))
+			} else {
+				req = httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			}
+
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, req)
+			if rec.Code != tt.expected {
+				t.Errorf("status = %d, want %d", rec.Code, tt.expected)
+			}
+		})
+	}
+
+	// 无法解析的消息被确认，并交给 OnDecodeError
+	if strings.Join(decodeErrors, ",") != "<nil>,m1" {
+		t.Errorf("decode errors = %v, want the malformed body and message m1", decodeErrors)
+	}
+}
+
+// 测试没有专用处理函数时交给 OnUnknown
+func TestDispatchUnknown(t *testing.T) {
+	var unknownType string
+	d := NewDispatcher().OnUnknown(func(ctx context.Context, msg *Message) error {
+		unknownType = msg.NotificationType()
+		return nil
+	})
+
+	msg := &Message{Attributes: map[string]string{AttributeNotificationType: "ENTERPRISE_UPGRADE"}}
+	if err := d.Dispatch(context.Background(), msg); err != nil {
+		t.Fatalf("Dispatch() unexpected error: %v", err)
+	}
+	if unknownType != "ENTERPRISE_UPGRADE" {
+		t.Errorf("OnUnknown received %q, want ENTERPRISE_UPGRADE", unknownType)
+	}
+}
@@ -0,0 +1,61 @@
+package notifications
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// MaxPushBodyBytes limits the size of a push request body.
+// Pub/Sub messages are at most 10 MB, which is about 13.4 MB after base64 encoding.
+const MaxPushBodyBytes = 16 << 20
+
+// PushHandler returns an http.Handler for a Pub/Sub push subscription.
+//
+// 如果 verificationToken 不为空，请求必须携带相同的 ?token= 查询参数，
+// 对应推送端点 URL 中配置的共享密钥。响应状态码：
+//
+//   - 204：消息已处理（包括没有注册处理函数的通知），或者请求体不是有效的推送消息、
+//     通知数据无法解析；后者重新投递也无法处理，因此确认消息并交给 OnDecodeError 处理函数
+//   - 403：token 不匹配
+//   - 405：非 POST 请求
+//   - 500：处理函数返回错误
+//
+// Pub/Sub 把所有非 2xx 响应视为未确认并重新投递该消息。
+func (d *Dispatcher) PushHandler(verificationToken string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if verificationToken != "" {
+			token := r.URL.Query().Get("token")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(verificationToken)) != 1 {
+				http.Error(w, "invalid token", http.StatusForbidden)
+				return
+			}
+		}
+
+		msg, err := ParsePushRequest(http.MaxBytesReader(w, r.Body, MaxPushBodyBytes))
+		if err != nil {
+			d.reportDecodeError(r.Context(), err, nil)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		n, err := Decode(msg)
+		if err != nil {
+			d.reportDecodeError(r.Context(), err, msg)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if err := d.DispatchNotification(r.Context(), n); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,281 @@
+// Package policydiff compares Android Management API policies field by field.
+//
+// 比较在策略的 JSON 表示上进行，路径使用 JSON 字段名。applications 列表按 packageName
+// 匹配，因此调整应用顺序不会产生差异，单个应用的修改显示为 applications[包名].字段。
+//
+//	result, err := policydiff.Diff(current, desired)
+//	if err != nil {
+//	    return err
+//	}
+//	fmt.Print(result)          // 人类可读的差异
+//	mask := result.UpdateMask() // 传给 PolicyService.Update 的最小 updateMask
+//
+// Merge 对同一基础版本的两份并发修改进行三方合并，互不冲突的修改都会保留。
+package policydiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// ChangeType is the kind of a change.
+type ChangeType string
+
+const (
+	ChangeAdded    ChangeType = "added"
+	ChangeRemoved  ChangeType = "removed"
+	ChangeModified ChangeType = "modified"
+)
+
+// Change is a difference at one field path.
+type Change struct {
+	// Path is the JSON field path, e.g. "passwordRequirements.passwordMinimumLength"
+	// or "applications[com.example.app].installType"
+	Path string `json:"path"`
+
+	// Type is the kind of change
+	Type ChangeType `json:"type"`
+
+	// Old and New are the JSON values before and after; nil if absent
+	Old any `json:"old,omitempty"`
+	New any `json:"new,omitempty"`
+}
+
+// Field returns the top-level policy field of the change, as used in an updateMask.
+func (c Change) Field() string {
+	if i := strings.IndexAny(c.Path, ".["); i >= 0 {
+		return c.Path[:i]
+	}
+	return c.Path
+}
+
+// String formats the change as one line, e.g. "~ cameraDisabled: false -> true".
+func (c Change) String() string {
+	switch c.Type {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s: %s", c.Path, formatValue(c.New))
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s: %s", c.Path, formatValue(c.Old))
+	}
+	return fmt.Sprintf("~ %s: %s -> %s", c.Path, formatValue(c.Old), formatValue(c.New))
+}
+
+// Result is the list of changes between two policies.
+type Result struct {
+	Changes []Change `json:"changes"`
+}
+
+// Empty reports whether the policies are equal.
+func (r *Result) Empty() bool {
+	return len(r.Changes) == 0
+}
+
+// UpdateMask returns the sorted top-level fields that changed.
+//
+// Policies.Patch 会把掩码中在新策略里缺失的字段重置为默认值，
+// 因此删除的字段也包含在掩码中。
+func (r *Result) UpdateMask() []string {
+	fields := make([]string, 0, len(r.Changes))
+	seen := make(map[string]bool)
+	for _, change := range r.Changes {
+		if field := change.Field(); !seen[field] {
+			seen[field] = true
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// String returns the changes, one per line.
+func (r *Result) String() string {
+	var sb strings.Builder
+	for _, change := range r.Changes {
+		sb.WriteString(change.String())
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// ignoredFields are output-only or identifying fields that are not compared.
+var ignoredFields = map[string]bool{
+	"name":    true,
+	"version": true,
+}
+
+// keyedLists maps list paths to the field that identifies their elements.
+var keyedLists = map[string]string{
+	"applications": "packageName",
+}
+
+// Diff returns the changes from oldPolicy to newPolicy, ordered by field name;
+// applications keep the order of oldPolicy, followed by the added ones.
+// A nil policy is treated as an empty policy. The name and version fields are ignored.
+func Diff(oldPolicy, newPolicy *androidmanagement.Policy) (*Result, error) {
+	oldDoc, err := toDocument(oldPolicy)
+	if err != nil {
+		return nil, err
+	}
+	newDoc, err := toDocument(newPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Changes: make([]Change, 0)}
+	diffValues("", "", oldDoc, newDoc, &result.Changes)
+	return result, nil
+}
+
+// diffValues appends the changes between a and b at path.
+// schemaPath is path without list keys, used to look up keyedLists.
+func diffValues(path, schemaPath string, a, b any, changes *[]Change) {
+	if reflect.DeepEqual(a, b) {
+		return
+	}
+	switch {
+	case a == nil:
+		*changes = append(*changes, Change{Path: path, Type: ChangeAdded, New: b})
+		return
+	case b == nil:
+		*changes = append(*changes, Change{Path: path, Type: ChangeRemoved, Old: a})
+		return
+	}
+
+	aMap, aIsMap := a.(map[string]any)
+	bMap, bIsMap := b.(map[string]any)
+	if aIsMap && bIsMap {
+		for _, key := range unionKeys(aMap, bMap) {
+			if path == "" && ignoredFields[key] {
+				continue
+			}
+			diffValues(joinPath(path, key), joinPath(schemaPath, key), aMap[key], bMap[key], changes)
+		}
+		return
+	}
+
+	if key, ok := keyedLists[schemaPath]; ok {
+		aItems, aOK := indexList(a, key)
+		bItems, bOK := indexList(b, key)
+		if aOK && bOK {
+			for _, id := range unionIDs(aItems, bItems) {
+				diffValues(fmt.Sprintf("%s[%s]", path, id), schemaPath, aItems.byID[id], bItems.byID[id], changes)
+			}
+			return
+		}
+	}
+
+	*changes = append(*changes, Change{Path: path, Type: ChangeModified, Old: a, New: b})
+}
+
+// keyedList is a list indexed by the identifying field of its elements.
+type keyedList struct {
+	ids  []string
+	byID map[string]any
+}
+
+// indexList indexes a JSON list by key. ok is false if v is not a list of objects with unique, non-empty keys.
+func indexList(v any, key string) (list keyedList, ok bool) {
+	list.byID = make(map[string]any)
+	if v == nil {
+		return list, true
+	}
+
+	items, isList := v.([]any)
+	if !isList {
+		return list, false
+	}
+	for _, item := range items {
+		object, isObject := item.(map[string]any)
+		if !isObject {
+			return list, false
+		}
+		id, _ := object[key].(string)
+		if id == "" || list.byID[id] != nil {
+			return list, false
+		}
+		list.ids = append(list.ids, id)
+		list.byID[id] = item
+	}
+	return list, true
+}
+
+// unionIDs returns the ids of a in order, followed by the ids only in b.
+func unionIDs(a, b keyedList) []string {
+	ids := append([]string(nil), a.ids...)
+	for _, id := range b.ids {
+		if _, ok := a.byID[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// unionKeys returns the sorted keys of both maps.
+func unionKeys(maps ...map[string]any) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// toDocument converts a policy to its generic JSON representation.
+func toDocument(policy *androidmanagement.Policy) (map[string]any, error) {
+	doc := make(map[string]any)
+	if policy == nil {
+		return doc, nil
+	}
+
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInvalidInput, "failed to encode policy")
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInvalidInput, "failed to decode policy")
+	}
+	return doc, nil
+}
+
+// fromDocument converts a generic JSON document back to a policy.
+func fromDocument(doc map[string]any) (*androidmanagement.Policy, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInternalServerError, "failed to encode merged policy")
+	}
+
+	var policy androidmanagement.Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInternalServerError, "failed to decode merged policy")
+	}
+	return &policy, nil
+}
+
+// formatValue formats a JSON value compactly.
+func formatValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,117 @@
+package policydiff
+
+import (
+	"fmt"
+	"reflect"
+
+	"google.golang.org/api/androidmanagement/v1"
+)
+
+// Conflict is a field changed differently by both sides of a merge.
+type Conflict struct {
+	Path   string `json:"path"`
+	Base   any    `json:"base,omitempty"`
+	Ours   any    `json:"ours,omitempty"`
+	Theirs any    `json:"theirs,omitempty"`
+}
+
+// String formats the conflict as one line.
+func (c Conflict) String() string {
+	return fmt.Sprintf("! %s: base %s, ours %s, theirs %s", c.Path, formatValue(c.Base), formatValue(c.Ours), formatValue(c.Theirs))
+}
+
+// Merge combines two policies edited concurrently from the same base.
+//
+// 只有一方修改的字段采用该方的值；双方修改为相同值的字段直接采用。对象逐字段合并，
+// applications 按 packageName 逐个应用合并，因此双方各自添加或修改不同应用不会冲突。
+// 双方对同一字段做了不同修改时记录一个 Conflict 并保留 ours 的值，调用方可以据此决定是否接受结果。
+//
+//	merged, conflicts, err := policydiff.Merge(base, local, remote)
+//	if len(conflicts) > 0 {
+//	    // 人工处理冲突
+//	}
+func Merge(base, ours, theirs *androidmanagement.Policy) (*androidmanagement.Policy, []Conflict, error) {
+	baseDoc, err := toDocument(base)
+	if err != nil {
+		return nil, nil, err
+	}
+	oursDoc, err := toDocument(ours)
+	if err != nil {
+		return nil, nil, err
+	}
+	theirsDoc, err := toDocument(theirs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var conflicts []Conflict
+	merged, _ := mergeValues("", "", baseDoc, oursDoc, theirsDoc, &conflicts).(map[string]any)
+	if merged == nil {
+		merged = make(map[string]any)
+	}
+
+	policy, err := fromDocument(merged)
+	if err != nil {
+		return nil, nil, err
+	}
+	return policy, conflicts, nil
+}
+
+// mergeValues returns the three-way merge of a value; nil means the value is absent.
+func mergeValues(path, schemaPath string, base, ours, theirs any, conflicts *[]Conflict) any {
+	switch {
+	case reflect.DeepEqual(ours, theirs):
+		return ours
+	case reflect.DeepEqual(base, ours):
+		return theirs
+	case reflect.DeepEqual(base, theirs):
+		return ours
+	}
+
+	oursMap, oursIsMap := ours.(map[string]any)
+	theirsMap, theirsIsMap := theirs.(map[string]any)
+	baseMap, baseIsMap := base.(map[string]any)
+	if oursIsMap && theirsIsMap && (base == nil || baseIsMap) {
+		merged := make(map[string]any)
+		for _, key := range unionKeys(baseMap, oursMap, theirsMap) {
+			value := mergeValues(joinPath(path, key), joinPath(schemaPath, key), baseMap[key], oursMap[key], theirsMap[key], conflicts)
+			if value != nil {
+				merged[key] = value
+			}
+		}
+		if len(merged) == 0 {
+			return nil
+		}
+		return merged
+	}
+
+	if key, ok := keyedLists[schemaPath]; ok && ours != nil && theirs != nil {
+		baseItems, baseOK := indexList(base, key)
+		oursItems, oursOK := indexList(ours, key)
+		theirsItems, theirsOK := indexList(theirs, key)
+		if baseOK && oursOK && theirsOK {
+			return mergeList(path, schemaPath, baseItems, oursItems, theirsItems, conflicts)
+		}
+	}
+
+	*conflicts = append(*conflicts, Conflict{Path: path, Base: base, Ours: ours, Theirs: theirs})
+	return ours
+}
+
+// mergeList merges a keyed list element by element.
+// The result keeps the order of ours, followed by the elements only in theirs;
+// an element removed by one side and unchanged by the other is dropped.
+func mergeList(path, schemaPath string, base, ours, theirs keyedList, conflicts *[]Conflict) any {
+	ids := unionIDs(ours, theirs)
+	merged := make([]any, 0, len(ids))
+	for _, id := range ids {
+		value := mergeValues(fmt.Sprintf("%s[%s]", path, id), schemaPath, base.byID[id], ours.byID[id], theirs.byID[id], conflicts)
+		if value != nil {
+			merged = append(merged, value)
+		}
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
@@ -0,0 +1,135 @@
+package policydiff_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/policydiff"
+)
+
+// basePolicy 返回测试使用的基础策略
+func basePolicy() *androidmanagement.Policy {
+	return &androidmanagement.Policy{
+		Name:           "enterprises/e1/policies/p1",
+		Version:        3,
+		CameraDisabled: true,
+		PasswordRequirements: &androidmanagement.PasswordRequirements{
+			PasswordMinimumLength: 6,
+			PasswordQuality:       "NUMERIC",
+		},
+		Applications: []*androidmanagement.ApplicationPolicy{
+			{PackageName: "com.example.mail", InstallType: "FORCE_INSTALLED"},
+			{PackageName: "com.example.chat", InstallType: "AVAILABLE"},
+		},
+	}
+}
+
+// 测试逐字段比较、应用按包名匹配以及最小 updateMask
+func TestDiff(t *testing.T) {
+	oldPolicy := basePolicy()
+	newPolicy := basePolicy()
+	newPolicy.Version = 4
+	newPolicy.CameraDisabled = false
+	newPolicy.PasswordRequirements.PasswordMinimumLength = 8
+	newPolicy.ScreenCaptureDisabled = true
+	newPolicy.Applications = []*androidmanagement.ApplicationPolicy{
+		{PackageName: "com.example.chat", InstallType: "FORCE_INSTALLED"},
+		{PackageName: "com.example.notes", InstallType: "AVAILABLE"},
+	}
+
+	result, err := policydiff.Diff(oldPolicy, newPolicy)
+	if err != nil {
+		t.Fatalf("Diff() unexpected error: %v", err)
+	}
+
+	want := strings.Join([]string{
+		`- applications[com.example.mail]: {"installType":"FORCE_INSTALLED","packageName":"com.example.mail"}`,
+		`~ applications[com.example.chat].installType: "AVAILABLE" -> "FORCE_INSTALLED"`,
+		`+ applications[com.example.notes]: {"installType":"AVAILABLE","packageName":"com.example.notes"}`,
+		`- cameraDisabled: true`,
+		`~ passwordRequirements.passwordMinimumLength: 6 -> 8`,
+		`+ screenCaptureDisabled: true`,
+	}, "\n") + "\n"
+	if result.String() != want {
+		t.Errorf("String() =\n%s\nwant\n%s", result, want)
+	}
+
+	mask := result.UpdateMask()
+	if !reflect.DeepEqual(mask, []string{"applications", "cameraDisabled", "passwordRequirements", "screenCaptureDisabled"}) {
+		t.Errorf("UpdateMask() = %v", mask)
+	}
+
+	// 只调整应用顺序、修改名称和版本不产生差异
+	reordered := basePolicy()
+	reordered.Name = "enterprises/e1/policies/p2"
+	reordered.Version = 9
+	reordered.Applications[0], reordered.Applications[1] = reordered.Applications[1], reordered.Applications[0]
+	if result, err := policydiff.Diff(basePolicy(), reordered); err != nil || !result.Empty() {
+		t.Errorf("Diff(reordered) = %v, %v, want no changes", result, err)
+	}
+
+	if result, err := policydiff.Diff(nil, basePolicy()); err != nil || len(result.Changes) != 3 {
+		t.Errorf("Diff(nil) = %v, %v, want 3 added fields", result, err)
+	}
+}
+
+// 测试三方合并：互不冲突的修改都保留，冲突时保留 ours 并报告
+func TestMerge(t *testing.T) {
+	base := basePolicy()
+
+	ours := basePolicy()
+	ours.PasswordRequirements.PasswordMinimumLength = 8
+	ours.Applications[0].InstallType = "BLOCKED"
+	ours.Applications = append(ours.Applications, &androidmanagement.ApplicationPolicy{PackageName: "com.example.notes"})
+	ours.StatusBarDisabled = true
+
+	theirs := basePolicy()
+	theirs.PasswordRequirements.PasswordQuality = "ALPHANUMERIC"
+	theirs.Applications = theirs.Applications[:1]
+	theirs.Applications[0].DefaultPermissionPolicy = "GRANT"
+	theirs.StatusBarDisabled = true
+	theirs.CameraDisabled = false
+
+	merged, conflicts, err := policydiff.Merge(base, ours, theirs)
+	if err != nil {
+		t.Fatalf("Merge() unexpected error: %v", err)
+	}
+	if len(conflicts) != 0 {
+		t.Fatalf("Merge() conflicts = %v, want none", conflicts)
+	}
+
+	if merged.CameraDisabled || !merged.StatusBarDisabled {
+		t.Errorf("merged camera/statusBar = %v/%v, want false/true", merged.CameraDisabled, merged.StatusBarDisabled)
+	}
+	if merged.PasswordRequirements.PasswordMinimumLength != 8 || merged.PasswordRequirements.PasswordQuality != "ALPHANUMERIC" {
+		t.Errorf("merged passwordRequirements = %+v", merged.PasswordRequirements)
+	}
+
+	var apps []string
+	for _, app := range merged.Applications {
+		apps = append(apps, app.PackageName+":"+app.InstallType+":"+app.DefaultPermissionPolicy)
+	}
+	if strings.Join(apps, ",") != "com.example.mail:BLOCKED:GRANT,com.example.notes::" {
+		t.Errorf("merged applications = %v, want mail merged, chat removed, notes added", apps)
+	}
+
+	// 双方把同一字段改为不同值
+	theirs.PasswordRequirements.PasswordMinimumLength = 12
+	// ours 修改了 theirs 删除的应用
+	ours.Applications[1].InstallType = "FORCE_INSTALLED"
+	merged, conflicts, err = policydiff.Merge(base, ours, theirs)
+	if err != nil {
+		t.Fatalf("Merge() unexpected error: %v", err)
+	}
+	if len(conflicts) != 2 ||
+		conflicts[0].Path != "applications[com.example.chat]" || conflicts[0].Theirs != nil ||
+		conflicts[1].Path != "passwordRequirements.passwordMinimumLength" || conflicts[1].Theirs != float64(12) {
+		t.Fatalf("Merge() conflicts = %v, want chat and passwordMinimumLength", conflicts)
+	}
+	if merged.PasswordRequirements.PasswordMinimumLength != 8 || len(merged.Applications) != 3 {
+		t.Errorf("merged = %+v, want ours kept on conflict", merged)
+	}
+}
@@ -0,0 +1,185 @@
+// Package policylint checks Android Management API policies for semantic mistakes.
+//
+// types.ValidatePolicy 只检查应用包名是否为空或重复，这个包在此基础上按规则检查
+// 会导致策略在设备上不生效或被 API 拒绝的配置，例如缺少 KIOSK 应用的自助服务终端模式、
+// 超出范围的密码长度、同时被阻止和被依赖的应用、过长的系统更新冻结期等。
+//
+//	report, err := policylint.Lint(policy, nil)
+//	if err != nil {
+//	    return err
+//	}
+//	fmt.Print(report)
+//	if report.HasErrors() {
+//	    return errors.New("policy has lint errors")
+//	}
+//
+// 每条结果包含规则 ID、严重程度和字段路径。路径使用 JSON 字段名，与 policydiff 相同，
+// 例如 applications[com.example.app].installType。通过 Options 可以只运行或跳过指定的规则。
+package policylint
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// Severity is the severity of a finding.
+type Severity string
+
+const (
+	// SeverityError marks settings the API rejects or that cannot work on devices
+	SeverityError Severity = "error"
+
+	// SeverityWarning marks settings that are ignored or likely unintended
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is one problem reported by a rule.
+type Finding struct {
+	// RuleID is the ID of the rule that reported the finding
+	RuleID string `json:"rule_id"`
+
+	Severity Severity `json:"severity"`
+
+	// Path is the JSON field path, e.g. "passwordRequirements.passwordMinimumLength"
+	Path string `json:"path"`
+
+	Message string `json:"message"`
+}
+
+// String formats the finding as "severity path: message [rule]".
+func (f Finding) String() string {
+	return fmt.Sprintf("%-7s %s: %s [%s]", f.Severity, f.Path, f.Message, f.RuleID)
+}
+
+// Rule is a lint check.
+type Rule struct {
+	// ID identifies the rule in Options and findings, e.g. "kiosk-app"
+	ID string `json:"id"`
+
+	// Description explains what the rule checks
+	Description string `json:"description"`
+
+	// Check returns the findings for a policy. RuleID is filled in by Lint.
+	Check func(p *androidmanagement.Policy) []Finding `json:"-"`
+}
+
+// Options selects the rules to run.
+type Options struct {
+	// Rules runs only these rule IDs; empty runs every built-in rule
+	Rules []string
+
+	// Disable skips these rule IDs
+	Disable []string
+}
+
+// Report is the result of Lint.
+type Report struct {
+	// Findings are ordered by rule, in the order of Rules()
+	Findings []Finding `json:"findings"`
+}
+
+// Count returns the number of findings of a severity.
+func (r *Report) Count(severity Severity) int {
+	count := 0
+	for _, finding := range r.Findings {
+		if finding.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// HasErrors reports whether any finding is an error.
+func (r *Report) HasErrors() bool {
+	return r.Count(SeverityError) > 0
+}
+
+// String formats the report with one finding per line and a summary line.
+func (r *Report) String() string {
+	var sb strings.Builder
+	for _, finding := range r.Findings {
+		sb.WriteString(finding.String())
+		sb.WriteByte('\n')
+	}
+	fmt.Fprintf(&sb, "%d errors, %d warnings\n", r.Count(SeverityError), r.Count(SeverityWarning))
+	return sb.String()
+}
+
+// Rules returns the built-in rules.
+func Rules() []Rule {
+	return []Rule{
+		{ID: "kiosk-app", Description: "自助服务终端设置需要且只能有一个 installType 为 KIOSK 的应用", Check: checkKioskApp},
+		{ID: "kiosk-custom-launcher", Description: "kioskCustomLauncherEnabled 不能与 KIOSK 应用同时使用", Check: checkKioskCustomLauncher},
+		{ID: "password-minimum-length", Description: "passwordMinimumLength 必须在 4 到 16 之间，并且只在要求密码复杂度时生效", Check: checkPasswordMinimumLength},
+		{ID: "blocked-app-required", Description: "被阻止的应用不能同时被安装、用作默认应用、VPN 或设置操作", Check: checkBlockedAppRequired},
+		{ID: "system-update", Description: "系统更新冻结期不超过 90 天且间隔至少 60 天，维护窗口在一天之内", Check: checkSystemUpdate},
+		{ID: "management-mode-conflict", Description: "完全托管设备专用的设置不应与工作资料设置混用", Check: checkManagementModeConflict},
+	}
+}
+
+// Lint runs the selected rules against a policy.
+//
+// opts 为 nil 时运行所有内置规则。Options 中出现未知的规则 ID 时返回 ErrCodeInvalidInput 错误。
+func Lint(p *androidmanagement.Policy, opts *Options) (*Report, error) {
+	if p == nil {
+		return nil, types.NewError(types.ErrCodeInvalidInput, "policy is required")
+	}
+
+	rules, err := selectRules(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Findings: make([]Finding, 0)}
+	for _, rule := range rules {
+		for _, finding := range rule.Check(p) {
+			finding.RuleID = rule.ID
+			report.Findings = append(report.Findings, finding)
+		}
+	}
+	return report, nil
+}
+
+// selectRules returns the built-in rules enabled by opts.
+func selectRules(opts *Options) ([]Rule, error) {
+	rules := Rules()
+	if opts == nil {
+		return rules, nil
+	}
+
+	known := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		known[rule.ID] = true
+	}
+	enabled := make(map[string]bool)
+	for _, id := range opts.Rules {
+		if !known[id] {
+			return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "unknown lint rule", id)
+		}
+		enabled[id] = true
+	}
+	disabled := make(map[string]bool)
+	for _, id := range opts.Disable {
+		if !known[id] {
+			return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "unknown lint rule", id)
+		}
+		disabled[id] = true
+	}
+
+	selected := make([]Rule, 0, len(rules))
+	for _, rule := range rules {
+		if (len(enabled) == 0 || enabled[rule.ID]) && !disabled[rule.ID] {
+			selected = append(selected, rule)
+		}
+	}
+	return selected, nil
+}
+
+// appPath returns the path of a field of an application, e.g. applications[com.example.app].installType.
+func appPath(packageName, field string) string {
+	return fmt.Sprintf("applications[%s].%s", packageName, field)
+}
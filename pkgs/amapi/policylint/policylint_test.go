@@ -0,0 +1,193 @@
+package policylint
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/presets"
+)
+
+// findings 返回报告中 "规则 严重程度 路径" 形式的结果，便于比较
+func findings(t *testing.T, p *androidmanagement.Policy, opts *Options) []string {
+	t.Helper()
+	report, err := Lint(p, opts)
+	if err != nil {
+		t.Fatalf("Lint() unexpected error: %v", err)
+	}
+	var got []string
+	for _, f := range report.Findings {
+		got = append(got, f.RuleID+" "+string(f.Severity)+" "+f.Path)
+	}
+	return got
+}
+
+func date(month, day int64) *androidmanagement.Date {
+	return &androidmanagement.Date{Month: month, Day: day}
+}
+
+// 测试各条内置规则
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *androidmanagement.Policy
+		want   []string
+	}{
+		{
+			name:   "empty policy",
+			policy: &androidmanagement.Policy{},
+		},
+		{
+			name:   "kiosk settings without kiosk app",
+			policy: &androidmanagement.Policy{StatusBarDisabled: true, KeyguardDisabled: true},
+			want:   []string{"kiosk-app warning applications"},
+		},
+		{
+			name:   "kiosk customization without kiosk app",
+			policy: &androidmanagement.Policy{KioskCustomization: &androidmanagement.KioskCustomization{}},
+			want:   []string{"kiosk-app error kioskCustomization"},
+		},
+		{
+			name: "two kiosk apps and custom launcher",
+			policy: &androidmanagement.Policy{
+				KioskCustomLauncherEnabled: true,
+				Applications: []*androidmanagement.ApplicationPolicy{
+					{PackageName: "com.example.a", InstallType: "KIOSK"},
+					{PackageName: "com.example.b", InstallType: "KIOSK"},
+				},
+			},
+			want: []string{
+				"kiosk-app error applications[com.example.b].installType",
+				"kiosk-custom-launcher error kioskCustomLauncherEnabled",
+				"kiosk-custom-launcher error kioskCustomLauncherEnabled",
+			},
+		},
+		{
+			name: "password minimum length",
+			policy: &androidmanagement.Policy{
+				PasswordRequirements: &androidmanagement.PasswordRequirements{PasswordMinimumLength: 20, PasswordQuality: "NUMERIC"},
+				PasswordPolicies: []*androidmanagement.PasswordRequirements{
+					{PasswordMinimumLength: 6, PasswordQuality: "ALPHANUMERIC"},
+					{PasswordMinimumLength: 6, PasswordQuality: "BIOMETRIC_WEAK"},
+				},
+			},
+			want: []string{
+				"password-minimum-length error passwordRequirements.passwordMinimumLength",
+				"password-minimum-length warning passwordPolicies[1].passwordMinimumLength",
+			},
+		},
+		{
+			name: "blocked app required elsewhere",
+			policy: &androidmanagement.Policy{
+				Applications: []*androidmanagement.ApplicationPolicy{
+					{PackageName: "com.example.vpn", InstallType: "BLOCKED"},
+					{PackageName: "com.example.vpn", InstallType: "REQUIRED"},
+					{PackageName: "com.example.ok", InstallType: "REQUIRED"},
+				},
+				AlwaysOnVpnPackage:            &androidmanagement.AlwaysOnVpnPackage{PackageName: "com.example.vpn"},
+				PersistentPreferredActivities: []*androidmanagement.PersistentPreferredActivity{{ReceiverActivity: "com.example.vpn/.Home"}},
+				SetupActions:                  []*androidmanagement.SetupAction{{LaunchApp: &androidmanagement.LaunchAppAction{PackageName: "com.example.ok"}}},
+				PermittedInputMethods:         &androidmanagement.PackageNameList{PackageNames: []string{"com.example.vpn"}},
+			},
+			want: []string{
+				"blocked-app-required error applications[com.example.vpn].installType",
+				"blocked-app-required error alwaysOnVpnPackage.packageName",
+				"blocked-app-required error persistentPreferredActivities[0].receiverActivity",
+				"blocked-app-required warning permittedInputMethods.packageNames[0]",
+			},
+		},
+		{
+			name: "system update freeze periods",
+			policy: &androidmanagement.Policy{SystemUpdate: &androidmanagement.SystemUpdate{
+				Type:         "WINDOWED",
+				StartMinutes: 1440,
+				FreezePeriods: []*androidmanagement.FreezePeriod{
+					{StartDate: date(12, 1), EndDate: date(3, 31)}, // 跨年 121 天
+					{StartDate: date(5, 1), EndDate: date(5, 31)},  // 与上一个冻结期间隔 30 天
+					{StartDate: date(2, 30), EndDate: date(3, 1)},
+					{StartDate: date(9, 1)},
+				},
+			}},
+			want: []string{
+				"system-update error systemUpdate.startMinutes",
+				"system-update error systemUpdate.freezePeriods[0]",
+				"system-update error systemUpdate.freezePeriods[2].startDate",
+				"system-update error systemUpdate.freezePeriods[3]",
+				"system-update error systemUpdate.freezePeriods[1]",
+			},
+		},
+		{
+			name: "valid freeze periods",
+			policy: &androidmanagement.Policy{SystemUpdate: &androidmanagement.SystemUpdate{
+				FreezePeriods: []*androidmanagement.FreezePeriod{
+					{StartDate: date(12, 15), EndDate: date(1, 15)},
+					{StartDate: date(6, 1), EndDate: date(8, 29)},
+				},
+			}},
+		},
+		{
+			name: "kiosk settings in a work profile policy",
+			policy: &androidmanagement.Policy{
+				CrossProfilePolicies: &androidmanagement.CrossProfilePolicies{},
+				StatusBarDisabled:    true,
+				Applications:         []*androidmanagement.ApplicationPolicy{{PackageName: "com.example.kiosk", InstallType: "KIOSK"}},
+			},
+			want: []string{
+				"management-mode-conflict warning applications[com.example.kiosk].installType",
+				"management-mode-conflict warning statusBarDisabled",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findings(t, tt.policy, nil); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// 测试通过 Options 选择规则
+func TestLintOptions(t *testing.T) {
+	p := &androidmanagement.Policy{
+		PasswordRequirements: &androidmanagement.PasswordRequirements{PasswordMinimumLength: 2},
+		KioskCustomization:   &androidmanagement.KioskCustomization{},
+	}
+
+	if got := findings(t, p, &Options{Rules: []string{"password-minimum-length"}}); len(got) != 1 || got[0] != "password-minimum-length error passwordRequirements.passwordMinimumLength" {
+		t.Errorf("Rules option findings = %q", got)
+	}
+	if got := findings(t, p, &Options{Disable: []string{"password-minimum-length"}}); len(got) != 1 || got[0] != "kiosk-app error kioskCustomization" {
+		t.Errorf("Disable option findings = %q", got)
+	}
+	if _, err := Lint(p, &Options{Rules: []string{"missing"}}); err == nil {
+		t.Error("Lint() expected error for unknown rule")
+	}
+	if _, err := Lint(nil, nil); err == nil {
+		t.Error("Lint(nil) expected error")
+	}
+
+	report, _ := Lint(p, nil)
+	if !report.HasErrors() || report.Count(SeverityError) != 2 {
+		t.Errorf("report = %s", report)
+	}
+}
+
+// 测试内置预设没有错误
+func TestPresets(t *testing.T) {
+	for _, preset := range presets.GetAllPresets() {
+		policy, err := presets.CreatePolicyFromPreset(preset.Name, nil)
+		if err != nil {
+			t.Fatalf("CreatePolicyFromPreset(%s) unexpected error: %v", preset.Name, err)
+		}
+		report, err := Lint(policy, nil)
+		if err != nil {
+			t.Fatalf("Lint(%s) unexpected error: %v", preset.Name, err)
+		}
+		if report.HasErrors() {
+			t.Errorf("preset %s has lint errors:\n%s", preset.Name, report)
+		}
+	}
+}
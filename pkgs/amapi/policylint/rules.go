@@ -0,0 +1,348 @@
+package policylint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// Limits checked by the built-in rules.
+const (
+	MinPasswordLength = 4
+	MaxPasswordLength = 16
+
+	// MaxFreezePeriodDays and MinFreezePeriodGapDays are the system update freeze limits of the API
+	MaxFreezePeriodDays    = 90
+	MinFreezePeriodGapDays = 60
+)
+
+// passwordLengthQualities are the password qualities that enforce passwordMinimumLength.
+var passwordLengthQualities = map[string]bool{
+	"NUMERIC":         true,
+	"NUMERIC_COMPLEX": true,
+	"ALPHABETIC":      true,
+	"ALPHANUMERIC":    true,
+	"COMPLEX":         true,
+}
+
+// kioskApps returns the applications with installType KIOSK.
+func kioskApps(p *androidmanagement.Policy) []*androidmanagement.ApplicationPolicy {
+	var apps []*androidmanagement.ApplicationPolicy
+	for _, app := range p.Applications {
+		if app != nil && app.InstallType == string(types.InstallTypeKiosk) {
+			apps = append(apps, app)
+		}
+	}
+	return apps
+}
+
+// checkKioskApp reports kiosk settings without exactly one KIOSK application.
+//
+// 使用 kioskCustomLauncherEnabled 时不需要 KIOSK 应用，由 kiosk-custom-launcher 规则检查。
+func checkKioskApp(p *androidmanagement.Policy) []Finding {
+	apps := kioskApps(p)
+
+	var findings []Finding
+	for _, app := range apps[min(1, len(apps)):] {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Path:     appPath(app.PackageName, "installType"),
+			Message:  fmt.Sprintf("only one application can have installType KIOSK, %s already has it", apps[0].PackageName),
+		})
+	}
+
+	if len(apps) > 0 || p.KioskCustomLauncherEnabled {
+		return findings
+	}
+	switch {
+	case p.KioskCustomization != nil:
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Path:     "kioskCustomization",
+			Message:  "kioskCustomization has no effect without an application with installType KIOSK or kioskCustomLauncherEnabled",
+		})
+	case p.StatusBarDisabled && p.KeyguardDisabled:
+		// SetKioskMode 会同时设置这两个字段，没有 KIOSK 应用时设备只是被锁定而不会进入自助服务终端模式
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Path:     "applications",
+			Message:  "statusBarDisabled and keyguardDisabled are set as for kiosk mode, but no application has installType KIOSK",
+		})
+	}
+	return findings
+}
+
+// checkKioskCustomLauncher reports kioskCustomLauncherEnabled combined with a KIOSK application.
+func checkKioskCustomLauncher(p *androidmanagement.Policy) []Finding {
+	if !p.KioskCustomLauncherEnabled {
+		return nil
+	}
+
+	var findings []Finding
+	for _, app := range kioskApps(p) {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Path:     "kioskCustomLauncherEnabled",
+			Message:  fmt.Sprintf("kioskCustomLauncherEnabled cannot be combined with the KIOSK application %s", app.PackageName),
+		})
+	}
+	return findings
+}
+
+// checkPasswordMinimumLength reports out-of-range or ignored minimum password lengths.
+func checkPasswordMinimumLength(p *androidmanagement.Policy) []Finding {
+	paths := []string{"passwordRequirements"}
+	requirements := []*androidmanagement.PasswordRequirements{p.PasswordRequirements}
+	for i, policy := range p.PasswordPolicies {
+		paths = append(paths, fmt.Sprintf("passwordPolicies[%d]", i))
+		requirements = append(requirements, policy)
+	}
+
+	var findings []Finding
+	for i, r := range requirements {
+		path := paths[i]
+		if r == nil || r.PasswordMinimumLength == 0 {
+			continue
+		}
+
+		switch {
+		case r.PasswordMinimumLength < MinPasswordLength || r.PasswordMinimumLength > MaxPasswordLength:
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Path:     path + ".passwordMinimumLength",
+				Message:  fmt.Sprintf("passwordMinimumLength %d is outside %d-%d", r.PasswordMinimumLength, MinPasswordLength, MaxPasswordLength),
+			})
+		case !passwordLengthQualities[r.PasswordQuality]:
+			quality := r.PasswordQuality
+			if quality == "" {
+				quality = "unset"
+			}
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Path:     path + ".passwordMinimumLength",
+				Message:  fmt.Sprintf("passwordMinimumLength is not enforced with passwordQuality %s", quality),
+			})
+		}
+	}
+	return findings
+}
+
+// checkBlockedAppRequired reports blocked applications that other settings install or depend on.
+func checkBlockedAppRequired(p *androidmanagement.Policy) []Finding {
+	blocked := make(map[string]bool)
+	for _, app := range p.Applications {
+		if app != nil && app.InstallType == string(types.InstallTypeBlocked) {
+			blocked[app.PackageName] = true
+		}
+	}
+	if len(blocked) == 0 {
+		return nil
+	}
+
+	var findings []Finding
+	report := func(severity Severity, path, packageName, usage string) {
+		if blocked[packageName] {
+			findings = append(findings, Finding{
+				Severity: severity,
+				Path:     path,
+				Message:  fmt.Sprintf("%s is blocked in applications but %s", packageName, usage),
+			})
+		}
+	}
+
+	// 重复的应用条目会被 types.ValidatePolicy 拒绝，这里指出具体冲突的安装类型
+	for _, app := range p.Applications {
+		if app != nil && app.InstallType != string(types.InstallTypeBlocked) {
+			installType := app.InstallType
+			if installType == "" {
+				installType = "AVAILABLE"
+			}
+			report(SeverityError, appPath(app.PackageName, "installType"), app.PackageName, "also listed with installType "+installType)
+		}
+	}
+	if vpn := p.AlwaysOnVpnPackage; vpn != nil {
+		report(SeverityError, "alwaysOnVpnPackage.packageName", vpn.PackageName, "is the always-on VPN")
+	}
+	for i, activity := range p.PersistentPreferredActivities {
+		if activity != nil {
+			packageName, _, _ := strings.Cut(activity.ReceiverActivity, "/")
+			report(SeverityError, fmt.Sprintf("persistentPreferredActivities[%d].receiverActivity", i), packageName, "is a persistent preferred activity")
+		}
+	}
+	for i, action := range p.SetupActions {
+		if action != nil && action.LaunchApp != nil {
+			report(SeverityError, fmt.Sprintf("setupActions[%d].launchApp.packageName", i), action.LaunchApp.PackageName, "is launched during setup")
+		}
+	}
+	fields := []string{"permittedAccessibilityServices", "permittedInputMethods"}
+	for i, list := range []*androidmanagement.PackageNameList{p.PermittedAccessibilityServices, p.PermittedInputMethods} {
+		if list == nil {
+			continue
+		}
+		for j, packageName := range list.PackageNames {
+			report(SeverityWarning, fmt.Sprintf("%s.packageNames[%d]", fields[i], j), packageName, "is permitted in "+fields[i])
+		}
+	}
+	return findings
+}
+
+// freezePeriod is a valid freeze period as days of a non-leap year.
+type freezePeriod struct {
+	index  int
+	start  int
+	length int
+}
+
+// checkSystemUpdate reports invalid maintenance windows and freeze periods.
+//
+// 冻结期按不含闰日的年份计算天数，2 月 29 日按 3 月 1 日处理；结束日期早于开始日期表示跨年。
+func checkSystemUpdate(p *androidmanagement.Policy) []Finding {
+	su := p.SystemUpdate
+	if su == nil {
+		return nil
+	}
+
+	var findings []Finding
+	if su.Type == "WINDOWED" {
+		fields := []string{"startMinutes", "endMinutes"}
+		for i, minutes := range []int64{su.StartMinutes, su.EndMinutes} {
+			if minutes < 0 || minutes >= 24*60 {
+				findings = append(findings, Finding{
+					Severity: SeverityError,
+					Path:     "systemUpdate." + fields[i],
+					Message:  fmt.Sprintf("%s %d is outside 0-1439", fields[i], minutes),
+				})
+			}
+		}
+	}
+
+	var periods []freezePeriod
+	for i, fp := range su.FreezePeriods {
+		path := fmt.Sprintf("systemUpdate.freezePeriods[%d]", i)
+		if fp == nil || fp.StartDate == nil || fp.EndDate == nil {
+			findings = append(findings, Finding{Severity: SeverityError, Path: path, Message: "startDate and endDate are required"})
+			continue
+		}
+
+		start, err := dayOfYear(fp.StartDate)
+		if err != nil {
+			findings = append(findings, Finding{Severity: SeverityError, Path: path + ".startDate", Message: err.Error()})
+			continue
+		}
+		end, err := dayOfYear(fp.EndDate)
+		if err != nil {
+			findings = append(findings, Finding{Severity: SeverityError, Path: path + ".endDate", Message: err.Error()})
+			continue
+		}
+
+		length := end - start + 1
+		if length <= 0 {
+			length += 365
+		}
+		if length > MaxFreezePeriodDays {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Path:     path,
+				Message:  fmt.Sprintf("freeze period lasts %d days, more than %d", length, MaxFreezePeriodDays),
+			})
+		}
+		periods = append(periods, freezePeriod{index: i, start: start, length: length})
+	}
+
+	if len(periods) < 2 {
+		return findings
+	}
+
+	// 按开始日期排序后检查相邻冻结期之间的间隔，最后一个与下一年的第一个比较
+	sort.Slice(periods, func(i, j int) bool { return periods[i].start < periods[j].start })
+	for i, current := range periods {
+		next := periods[(i+1)%len(periods)]
+		nextStart := next.start
+		if i == len(periods)-1 {
+			nextStart += 365
+		}
+
+		gap := nextStart - (current.start + current.length)
+		path := fmt.Sprintf("systemUpdate.freezePeriods[%d]", next.index)
+		switch {
+		case gap < 0:
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Path:     path,
+				Message:  fmt.Sprintf("freeze period overlaps freezePeriods[%d]", current.index),
+			})
+		case gap < MinFreezePeriodGapDays:
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Path:     path,
+				Message:  fmt.Sprintf("freeze period starts %d days after freezePeriods[%d] ends, less than %d", gap, current.index, MinFreezePeriodGapDays),
+			})
+		}
+	}
+	return findings
+}
+
+// dayOfYear returns the 1-based day of a month and day in a non-leap year.
+func dayOfYear(d *androidmanagement.Date) (int, error) {
+	if d.Year != 0 {
+		return 0, fmt.Errorf("year must not be set")
+	}
+	if d.Month < 1 || d.Month > 12 {
+		return 0, fmt.Errorf("invalid month %d", d.Month)
+	}
+
+	// 2000 是闰年，用来判断日期是否存在
+	date := time.Date(2000, time.Month(d.Month), int(d.Day), 0, 0, 0, 0, time.UTC)
+	if d.Day < 1 || date.Month() != time.Month(d.Month) {
+		return 0, fmt.Errorf("invalid day %d of month %d", d.Day, d.Month)
+	}
+	return time.Date(2001, time.Month(d.Month), int(d.Day), 0, 0, 0, 0, time.UTC).YearDay(), nil
+}
+
+// checkManagementModeConflict reports fully managed settings in a policy that also configures work profiles.
+//
+// 这些设置在工作资料中会被忽略，通常说明策略是由完全托管策略复制而来，或者混用了 SetKioskMode 和 SetWorkProfileMode。
+func checkManagementModeConflict(p *androidmanagement.Policy) []Finding {
+	var workProfile []string
+	if p.CrossProfilePolicies != nil {
+		workProfile = append(workProfile, "crossProfilePolicies")
+	}
+	if p.PersonalUsagePolicies != nil {
+		workProfile = append(workProfile, "personalUsagePolicies")
+	}
+	if len(workProfile) == 0 {
+		return nil
+	}
+
+	var fullyManaged []string
+	for _, app := range kioskApps(p) {
+		fullyManaged = append(fullyManaged, appPath(app.PackageName, "installType"))
+	}
+	if p.KioskCustomization != nil {
+		fullyManaged = append(fullyManaged, "kioskCustomization")
+	}
+	if p.KioskCustomLauncherEnabled {
+		fullyManaged = append(fullyManaged, "kioskCustomLauncherEnabled")
+	}
+	if p.StatusBarDisabled {
+		fullyManaged = append(fullyManaged, "statusBarDisabled")
+	}
+	if p.KeyguardDisabled {
+		fullyManaged = append(fullyManaged, "keyguardDisabled")
+	}
+
+	var findings []Finding
+	for _, path := range fullyManaged {
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Path:     path,
+			Message:  fmt.Sprintf("only applies to fully managed devices, but the policy also sets %s for work profiles", strings.Join(workProfile, " and ")),
+		})
+	}
+	return findings
+}
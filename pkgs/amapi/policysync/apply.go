@@ -0,0 +1,170 @@
+package policysync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"amapi-pkg/pkgs/amapi/client"
+	"amapi-pkg/pkgs/amapi/presets"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// Summary is the result of Apply.
+type Summary struct {
+	// Created, Updated and Deleted are the policies changed by the apply.
+	// Changes undone by a rollback are not included.
+	Created []string `json:"created"`
+	Updated []string `json:"updated"`
+	Deleted []string `json:"deleted"`
+
+	// Unchanged is the number of policies that already matched their documents
+	Unchanged int `json:"unchanged"`
+
+	// Failed is the policy whose action failed, if any
+	Failed string `json:"failed,omitempty"`
+
+	// RolledBack are the policies restored after the failure
+	RolledBack []string `json:"rolled_back,omitempty"`
+
+	// RollbackFailed are the policies that could not be restored and need manual repair
+	RollbackFailed []string `json:"rollback_failed,omitempty"`
+}
+
+// String formats the summary as one line.
+func (s *Summary) String() string {
+	line := fmt.Sprintf("created %d, updated %d, deleted %d, unchanged %d", len(s.Created), len(s.Updated), len(s.Deleted), s.Unchanged)
+	if s.Failed != "" {
+		line += fmt.Sprintf("; failed at %s, rolled back %d", s.Failed, len(s.RolledBack))
+		if len(s.RollbackFailed) > 0 {
+			line += ", rollback failed for " + strings.Join(s.RollbackFailed, ", ")
+		}
+	}
+	return line
+}
+
+// Apply executes a plan in order.
+//
+// 执行前重新读取企业策略，如果计划涉及的策略在生成计划后被修改（版本变化、被创建或被删除），
+// 返回 ErrCodeConflict 且不做任何修改，需要重新生成计划。
+//
+// 任一操作失败时按相反顺序撤销已执行的操作：删除新建的策略，用原策略和相同的 updateMask 恢复更新，
+// 重新创建已删除的策略（版本号会变化）。撤销使用不可取消的 ctx 副本，即使 ctx 已取消也会执行。
+// 返回的 Summary 描述最终生效的修改，以及无法撤销、需要人工处理的策略。
+func (s *Syncer) Apply(ctx context.Context, plan *Plan) (*Summary, error) {
+	if plan == nil {
+		return nil, types.NewError(types.ErrCodeInvalidInput, "plan is required")
+	}
+
+	summary := &Summary{
+		Created:   make([]string, 0),
+		Updated:   make([]string, 0),
+		Deleted:   make([]string, 0),
+		Unchanged: len(plan.Unchanged),
+	}
+	if err := s.checkPlan(ctx, plan); err != nil {
+		return summary, err
+	}
+
+	policies := s.policies(ctx)
+	var applied []*Action
+	for _, action := range plan.Actions {
+		if err := applyAction(policies, plan.Enterprise, action); err != nil {
+			summary.Failed = action.PolicyName
+			kept := s.rollback(context.WithoutCancel(ctx), plan.Enterprise, applied, summary)
+			summary.record(kept)
+
+			code := types.ErrCodeInternalServerError
+			var apiErr *types.Error
+			if errors.As(err, &apiErr) {
+				code = apiErr.Code
+			}
+			return summary, types.WrapError(err, code, fmt.Sprintf("failed to %s policy %s", action.Type, action.PolicyName))
+		}
+		applied = append(applied, action)
+	}
+
+	summary.record(applied)
+	return summary, nil
+}
+
+// checkPlan verifies that the policies of the plan did not change since it was created.
+func (s *Syncer) checkPlan(ctx context.Context, plan *Plan) error {
+	existing, err := s.listPolicies(ctx, plan.Enterprise)
+	if err != nil {
+		return err
+	}
+
+	for _, action := range plan.Actions {
+		current, ok := existing[action.PolicyName]
+		switch {
+		case action.Type == ActionCreate && !ok:
+			continue
+		case action.Type != ActionCreate && ok && action.Current != nil && current.Version == action.Current.Version:
+			continue
+		}
+		return types.NewErrorWithDetails(types.ErrCodeConflict, "policy changed since the plan was created",
+			action.PolicyName+" was modified; create a new plan")
+	}
+	return nil
+}
+
+// applyAction executes one action.
+func applyAction(policies *client.PolicyService, enterpriseName string, action *Action) error {
+	switch action.Type {
+	case ActionCreate:
+		_, err := policies.Create(enterpriseName, action.PolicyID(), action.Desired)
+		return err
+	case ActionUpdate:
+		_, err := policies.Update(action.PolicyName, action.Desired, action.UpdateMask)
+		return err
+	case ActionDelete:
+		return policies.Delete(action.PolicyName)
+	}
+	return types.NewErrorWithDetails(types.ErrCodeInvalidInput, "unknown plan action", string(action.Type))
+}
+
+// rollback undoes the applied actions in reverse order and returns the actions that remain in effect.
+func (s *Syncer) rollback(ctx context.Context, enterpriseName string, applied []*Action, summary *Summary) []*Action {
+	policies := s.policies(ctx)
+	var kept []*Action
+	for i := len(applied) - 1; i >= 0; i-- {
+		action := applied[i]
+
+		var err error
+		switch action.Type {
+		case ActionCreate:
+			err = policies.Delete(action.PolicyName)
+		case ActionUpdate:
+			_, err = policies.Update(action.PolicyName, action.Current, action.UpdateMask)
+		case ActionDelete:
+			policy := presets.ClonePolicy(action.Current)
+			policy.Name = ""
+			policy.Version = 0
+			_, err = policies.Create(enterpriseName, action.PolicyID(), policy)
+		}
+
+		if err != nil {
+			summary.RollbackFailed = append(summary.RollbackFailed, action.PolicyName)
+			kept = append([]*Action{action}, kept...)
+			continue
+		}
+		summary.RolledBack = append(summary.RolledBack, action.PolicyName)
+	}
+	return kept
+}
+
+// record adds the applied actions to the summary.
+func (s *Summary) record(actions []*Action) {
+	for _, action := range actions {
+		switch action.Type {
+		case ActionCreate:
+			s.Created = append(s.Created, action.PolicyName)
+		case ActionUpdate:
+			s.Updated = append(s.Updated, action.PolicyName)
+		case ActionDelete:
+			s.Deleted = append(s.Deleted, action.PolicyName)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+// Package policysync manages policies declaratively from a directory of policy documents.
+//
+// 每个 .yaml、.yml 或 .json 文件描述一个策略，文件名（去掉扩展名）是策略 ID。文件可以是
+// 原始策略（androidmanagement.Policy 的 JSON 字段），也可以基于预设：
+//
+//	# kiosk-store-1.yaml
+//	id: kiosk-store-1          # 可选，默认为文件名
+//	preset: retail_kiosk       # 可选，使用 presets.CreatePolicyFromPreset 构建基础策略
+//	policy:                    # 按 presets.MergeFields 的规则合并到预设策略
+//	  statusBarDisabled: true
+//	  applications:            # 按 packageName 合并
+//	    - packageName: com.android.chrome
+//	      $remove: true
+//	    - packageName: com.example.store
+//	      installType: KIOSK
+//
+// 同步分为两步：Plan 将文档与企业现有策略比较，生成创建、更新（附带最小 updateMask）和删除操作；
+// 审核计划后 Apply 依次执行，任一操作失败时撤销已执行的操作。
+//
+//	docs, err := policysync.LoadDir("policies")
+//	syncer := policysync.New(client)
+//	plan, err := syncer.Plan(ctx, enterpriseID, docs, nil)
+//	fmt.Print(plan)
+//	summary, err := syncer.Apply(ctx, plan)
+package policysync
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"google.golang.org/api/androidmanagement/v1"
+	"gopkg.in/yaml.v3"
+
+	"amapi-pkg/pkgs/amapi/presets"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// Document is a desired policy read from a file.
+type Document struct {
+	// ID is the policy ID, the last segment of the policy resource name
+	ID string `json:"id"`
+
+	// Preset is the preset the policy is built on, if any
+	Preset string `json:"preset,omitempty"`
+
+	// Source is the file the document was read from
+	Source string `json:"source,omitempty"`
+
+	// Policy is the desired policy
+	Policy *androidmanagement.Policy `json:"policy"`
+}
+
+// LoadDir reads every .yaml, .yml and .json file of a directory, sorted by policy ID.
+// Subdirectories and files starting with "." are skipped.
+func LoadDir(dir string) ([]*Document, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInvalidInput, "failed to read policy directory")
+	}
+
+	var docs []*Document
+	sources := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || !isDocumentFile(entry.Name()) {
+			continue
+		}
+
+		doc, err := LoadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if source, ok := sources[doc.ID]; ok {
+			return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "duplicate policy ID",
+				doc.ID+" is defined in "+source+" and "+doc.Source)
+		}
+		sources[doc.ID] = doc.Source
+		docs = append(docs, doc)
+	}
+
+	sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+	return docs, nil
+}
+
+// LoadFile reads a policy document from a JSON or YAML file.
+func LoadFile(path string) (*Document, error) {
+	if !isDocumentFile(path) {
+		return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "unsupported policy file format",
+			path+" (supported: .yaml, .yml, .json)")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInvalidInput, "failed to read policy file")
+	}
+
+	id := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	doc, err := ParseDocument(id, data)
+	if err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInvalidInput, "invalid policy file "+path)
+	}
+	doc.Source = path
+	return doc, nil
+}
+
+// ParseDocument parses a JSON or YAML policy document. defaultID is used when the document has no id.
+//
+// 顶层包含 preset 或 policy 键的文档是预设格式，否则整个文档是原始策略。
+// 预设格式中 policy 的字段按 presets.MergeFields 的规则合并到预设策略：对象逐字段合并，
+// 列表整体替换，applications 按 packageName 合并。int64 字段（例如 maximumTimeToLock）
+// 可以写成普通数字。
+func ParseDocument(defaultID string, data []byte) (*Document, error) {
+	// YAML 是 JSON 的超集，统一解码为通用结构后通过 JSON 转换，以复用 Policy 的 JSON 字段名
+	var generic any
+	if err := yaml.Unmarshal(data, &generic); err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInvalidInput, "failed to parse policy document")
+	}
+	fields, ok := generic.(map[string]any)
+	if !ok {
+		return nil, types.NewError(types.ErrCodeInvalidInput, "policy document must be an object")
+	}
+
+	doc := &Document{ID: defaultID}
+	policyFields := fields
+	_, hasPreset := fields["preset"]
+	_, hasPolicy := fields["policy"]
+	if hasPreset || hasPolicy {
+		id, idOK := stringField(fields, "id")
+		preset, presetOK := stringField(fields, "preset")
+		policyFields, ok = fields["policy"].(map[string]any)
+		if !idOK || !presetOK || (!ok && fields["policy"] != nil) {
+			return nil, types.NewError(types.ErrCodeInvalidInput, "id and preset must be strings and policy must be an object")
+		}
+		if id != "" {
+			doc.ID = id
+		}
+		doc.Preset = preset
+	}
+
+	if doc.ID == "" || strings.Contains(doc.ID, "/") {
+		return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "invalid policy ID", doc.ID)
+	}
+
+	if policyFields == nil {
+		policyFields = make(map[string]any)
+	}
+	// name 和 version 由服务端管理
+	delete(policyFields, "name")
+	delete(policyFields, "version")
+
+	if doc.Preset != "" {
+		policy, err := presets.CreatePolicyFromPreset(doc.Preset, nil)
+		if err != nil {
+			return nil, types.WrapError(err, types.ErrCodeInvalidInput, "invalid preset")
+		}
+		base, err := presets.PolicyFields(policy)
+		if err != nil {
+			return nil, err
+		}
+		if policyFields, err = presets.MergeFields(base, policyFields); err != nil {
+			return nil, types.WrapError(err, types.ErrCodeInvalidInput, "failed to merge policy with preset "+doc.Preset)
+		}
+	}
+
+	policy, err := presets.PolicyFromFields(policyFields)
+	if err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInvalidInput, "failed to parse policy")
+	}
+	doc.Policy = policy
+
+	if err := types.ValidatePolicy(doc.Policy); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func isDocumentFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// stringField returns a string field of a document; ok is false if the field is not a string.
+func stringField(fields map[string]any, key string) (value string, ok bool) {
+	if fields[key] == nil {
+		return "", true
+	}
+	value, ok = fields[key].(string)
+	return value, ok
+}
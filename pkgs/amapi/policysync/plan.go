@@ -0,0 +1,216 @@
+package policysync
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/client"
+	"amapi-pkg/pkgs/amapi/policydiff"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// ActionType is the kind of a planned change.
+type ActionType string
+
+const (
+	ActionCreate ActionType = "create"
+	ActionUpdate ActionType = "update"
+	ActionDelete ActionType = "delete"
+)
+
+// Action is one planned change to a policy.
+type Action struct {
+	Type ActionType `json:"type"`
+
+	// PolicyName is the policy resource name
+	PolicyName string `json:"policy_name"`
+
+	// Source is the document file of a create or update
+	Source string `json:"source,omitempty"`
+
+	// Current is the existing policy; nil for a create
+	Current *androidmanagement.Policy `json:"current,omitempty"`
+
+	// Desired is the policy from the document; nil for a delete
+	Desired *androidmanagement.Policy `json:"desired,omitempty"`
+
+	// Changes are the field changes of an update
+	Changes []policydiff.Change `json:"changes,omitempty"`
+
+	// UpdateMask is the updateMask used for an update
+	UpdateMask []string `json:"update_mask,omitempty"`
+}
+
+// PolicyID returns the last segment of the policy name.
+func (a *Action) PolicyID() string {
+	return a.PolicyName[strings.LastIndex(a.PolicyName, "/")+1:]
+}
+
+// PlanOptions configures Plan.
+type PlanOptions struct {
+	// KeepUnlisted keeps existing policies that have no document instead of deleting them
+	KeepUnlisted bool
+}
+
+// Plan is the set of changes that makes an enterprise match the documents.
+type Plan struct {
+	// Enterprise is the enterprise resource name
+	Enterprise string `json:"enterprise"`
+
+	// Actions are ordered creates, then updates, then deletes, each sorted by policy name
+	Actions []*Action `json:"actions"`
+
+	// Unchanged are the policies that already match their documents
+	Unchanged []string `json:"unchanged"`
+}
+
+// Empty reports whether the plan has no actions.
+func (p *Plan) Empty() bool {
+	return len(p.Actions) == 0
+}
+
+// Count returns the number of actions of a type.
+func (p *Plan) Count(actionType ActionType) int {
+	count := 0
+	for _, action := range p.Actions {
+		if action.Type == actionType {
+			count++
+		}
+	}
+	return count
+}
+
+// String formats the plan for review.
+//
+// 每个操作一行，"+" 表示创建、"~" 表示更新（后面缩进列出字段变更）、"-" 表示删除，最后一行是各类操作的数量。
+func (p *Plan) String() string {
+	var sb strings.Builder
+	for _, action := range p.Actions {
+		switch action.Type {
+		case ActionCreate:
+			fmt.Fprintf(&sb, "+ create %s (%s)\n", action.PolicyName, action.Source)
+		case ActionUpdate:
+			fmt.Fprintf(&sb, "~ update %s [%s]\n", action.PolicyName, strings.Join(action.UpdateMask, ","))
+			for _, change := range action.Changes {
+				fmt.Fprintf(&sb, "    %s\n", change)
+			}
+		case ActionDelete:
+			fmt.Fprintf(&sb, "- delete %s\n", action.PolicyName)
+		}
+	}
+	if len(p.Actions) > 0 {
+		sb.WriteByte('\n')
+	}
+	fmt.Fprintf(&sb, "Plan: %d to create, %d to update, %d to delete, %d unchanged.\n",
+		p.Count(ActionCreate), p.Count(ActionUpdate), p.Count(ActionDelete), len(p.Unchanged))
+	return sb.String()
+}
+
+// Syncer plans and applies policy documents.
+type Syncer struct {
+	client *client.Client
+}
+
+// New returns a Syncer using c. Policies are always read from the API, bypassing the cache.
+func New(c *client.Client) *Syncer {
+	return &Syncer{client: c}
+}
+
+// policies returns the policy service for ctx without cache reads.
+func (s *Syncer) policies(ctx context.Context) *client.PolicyService {
+	return s.client.WithContext(ctx).WithoutCache().Policies()
+}
+
+// Plan compares the documents with the policies of an enterprise.
+//
+// 文档中有而企业中没有的策略会被创建；两边都有且存在差异的策略会被更新，updateMask 只包含变化的顶层字段；
+// 企业中有而文档中没有的策略会被删除，除非设置了 opts.KeepUnlisted。
+// enterpriseID 可以是企业 ID 或 enterprises/ 开头的资源名称。
+func (s *Syncer) Plan(ctx context.Context, enterpriseID string, docs []*Document, opts *PlanOptions) (*Plan, error) {
+	if enterpriseID == "" {
+		return nil, types.ErrInvalidEnterpriseID
+	}
+	if opts == nil {
+		opts = &PlanOptions{}
+	}
+
+	enterpriseName := enterpriseID
+	if !strings.HasPrefix(enterpriseName, "enterprises/") {
+		enterpriseName = "enterprises/" + enterpriseID
+	}
+
+	existing, err := s.listPolicies(ctx, enterpriseName)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Enterprise: enterpriseName, Actions: make([]*Action, 0), Unchanged: make([]string, 0)}
+	var creates, updates, deletes []*Action
+	listed := make(map[string]bool)
+	for _, doc := range docs {
+		if doc == nil || doc.Policy == nil {
+			return nil, types.NewError(types.ErrCodeInvalidInput, "policy document is required")
+		}
+
+		name := enterpriseName + "/policies/" + doc.ID
+		if listed[name] {
+			return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "duplicate policy ID", doc.ID)
+		}
+		listed[name] = true
+
+		current, ok := existing[name]
+		if !ok {
+			creates = append(creates, &Action{Type: ActionCreate, PolicyName: name, Source: doc.Source, Desired: doc.Policy})
+			continue
+		}
+
+		result, err := policydiff.Diff(current, doc.Policy)
+		if err != nil {
+			return nil, err
+		}
+		if result.Empty() {
+			plan.Unchanged = append(plan.Unchanged, name)
+			continue
+		}
+		updates = append(updates, &Action{
+			Type:       ActionUpdate,
+			PolicyName: name,
+			Source:     doc.Source,
+			Current:    current,
+			Desired:    doc.Policy,
+			Changes:    result.Changes,
+			UpdateMask: result.UpdateMask(),
+		})
+	}
+
+	if !opts.KeepUnlisted {
+		for name, current := range existing {
+			if !listed[name] {
+				deletes = append(deletes, &Action{Type: ActionDelete, PolicyName: name, Current: current})
+			}
+		}
+	}
+
+	for _, actions := range [][]*Action{creates, updates, deletes} {
+		sort.Slice(actions, func(i, j int) bool { return actions[i].PolicyName < actions[j].PolicyName })
+		plan.Actions = append(plan.Actions, actions...)
+	}
+	sort.Strings(plan.Unchanged)
+	return plan, nil
+}
+
+// listPolicies returns the policies of an enterprise by name.
+func (s *Syncer) listPolicies(ctx context.Context, enterpriseName string) (map[string]*androidmanagement.Policy, error) {
+	policies := make(map[string]*androidmanagement.Policy)
+	for policy, err := range s.policies(ctx).All(enterpriseName) {
+		if err != nil {
+			return nil, err
+		}
+		policies[policy.Name] = policy
+	}
+	return policies, nil
+}
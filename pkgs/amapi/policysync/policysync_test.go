@@ -0,0 +1,238 @@
+package policysync_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/amapitest"
+	"amapi-pkg/pkgs/amapi/client"
+	"amapi-pkg/pkgs/amapi/policysync"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// writeFiles 在临时目录中写入策略文档并返回目录
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+// newSyncTestClient 创建包含 default、same 和 old 三个策略的测试企业
+func newSyncTestClient(t *testing.T) (*amapitest.Server, *client.Client, string) {
+	t.Helper()
+
+	srv := amapitest.NewServer()
+	t.Cleanup(srv.Close)
+
+	c, err := srv.NewClient()
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	enterprise := srv.AddEnterprise(&androidmanagement.Enterprise{})
+	srv.AddPolicy(&androidmanagement.Policy{Name: enterprise.Name + "/policies/default", Version: 1})
+	srv.AddPolicy(&androidmanagement.Policy{Name: enterprise.Name + "/policies/same", Version: 1, ScreenCaptureDisabled: true})
+	srv.AddPolicy(&androidmanagement.Policy{Name: enterprise.Name + "/policies/old", Version: 1})
+	return srv, c, enterprise.Name
+}
+
+// 测试原始策略文档和基于预设的文档
+func TestParseDocument(t *testing.T) {
+	doc, err := policysync.ParseDocument("raw", []byte("cameraDisabled: true\nversion: 7\napplications:\n  - packageName: com.example.app\n    installType: REQUIRED\n"))
+	if err != nil {
+		t.Fatalf("ParseDocument(raw) unexpected error: %v", err)
+	}
+	if doc.ID != "raw" || !doc.Policy.CameraDisabled || doc.Policy.Version != 0 || len(doc.Policy.Applications) != 1 {
+		t.Errorf("raw document = %+v, policy %+v", doc, doc.Policy)
+	}
+
+	doc, err = policysync.ParseDocument("file-name", []byte(`{"id": "store-1", "preset": "retail_kiosk",
+		"policy": {"statusReportingSettings": {"memoryInfoEnabled": false}, "applications": [{"packageName": "com.example.store", "installType": "KIOSK"}]}}`))
+	if err != nil {
+		t.Fatalf("ParseDocument(preset) unexpected error: %v", err)
+	}
+	// 对象逐字段合并，新应用追加到预设的应用之后
+	if doc.ID != "store-1" || doc.Preset != "retail_kiosk" || doc.Policy.PlayStoreMode != "WHITELIST" ||
+		doc.Policy.StatusReportingSettings.MemoryInfoEnabled || !doc.Policy.StatusReportingSettings.NetworkInfoEnabled ||
+		len(doc.Policy.Applications) != 2 || doc.Policy.Applications[1].PackageName != "com.example.store" {
+		t.Errorf("preset document = %+v, policy %+v", doc, doc.Policy)
+	}
+
+	// int64 字段可以写成普通数字；同名应用与预设中的应用逐字段合并
+	doc, err = policysync.ParseDocument("store-2", []byte(`preset: retail_kiosk
+policy:
+  maximumTimeToLock: 300000
+  applications:
+    - packageName: com.android.chrome
+      installType: FORCE_INSTALLED
+`))
+	if err != nil {
+		t.Fatalf("ParseDocument(int64 field) unexpected error: %v", err)
+	}
+	if doc.Policy.MaximumTimeToLock != 300000 || len(doc.Policy.Applications) != 1 {
+		t.Fatalf("policy = %+v, want maximumTimeToLock 300000 and one application", doc.Policy)
+	}
+	if app := doc.Policy.Applications[0]; app.PackageName != "com.android.chrome" || app.InstallType != "FORCE_INSTALLED" || !app.LockTaskAllowed {
+		t.Errorf("application = %+v, want the preset's chrome with installType FORCE_INSTALLED", app)
+	}
+	doc, err = policysync.ParseDocument("raw", []byte("maximumTimeToLock: 60000\n"))
+	if err != nil || doc.Policy.MaximumTimeToLock != 60000 {
+		t.Errorf("ParseDocument(raw int64 field) = %+v, %v", doc, err)
+	}
+
+	invalid := map[string]string{
+		"unknown preset":   "preset: missing",
+		"invalid ID":       "id: a/b\npolicy: {}",
+		"duplicate app":    "applications: [{packageName: a}, {packageName: a}]",
+		"not an object":    "- cameraDisabled",
+		"wrong field type": "cameraDisabled: [1]",
+	}
+	for name, content := range invalid {
+		if _, err := policysync.ParseDocument("p", []byte(content)); err == nil {
+			t.Errorf("ParseDocument(%s) expected error", name)
+		}
+	}
+}
+
+// 测试读取目录时按策略 ID 排序并拒绝重复 ID
+func TestLoadDir(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"b.yaml":      "cameraDisabled: true",
+		"a.json":      `{"preset": "work_profile"}`,
+		"README.md":   "# policies",
+		".hidden.yml": "cameraDisabled: false",
+	})
+	if err := os.Mkdir(filepath.Join(dir, "archive.yaml"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	docs, err := policysync.LoadDir(dir)
+	if err != nil {
+		t.Fatalf("LoadDir() unexpected error: %v", err)
+	}
+	if len(docs) != 2 || docs[0].ID != "a" || docs[1].ID != "b" || docs[1].Source != filepath.Join(dir, "b.yaml") {
+		t.Fatalf("LoadDir() = %+v, want a and b", docs)
+	}
+
+	dir = writeFiles(t, map[string]string{"a.yaml": "{}", "b.yaml": "id: a\npolicy: {}"})
+	if _, err := policysync.LoadDir(dir); err == nil {
+		t.Error("LoadDir() expected error for duplicate policy ID")
+	}
+}
+
+// 测试生成创建、更新和删除计划并执行
+func TestPlanAndApply(t *testing.T) {
+	srv, c, enterpriseName := newSyncTestClient(t)
+	ctx := context.Background()
+	dir := writeFiles(t, map[string]string{
+		"default.yaml": "cameraDisabled: true",
+		"same.yaml":    "screenCaptureDisabled: true",
+		"kiosk.yaml":   "preset: kiosk_mode",
+	})
+	docs, err := policysync.LoadDir(dir)
+	if err != nil {
+		t.Fatalf("LoadDir() unexpected error: %v", err)
+	}
+
+	syncer := policysync.New(c)
+	plan, err := syncer.Plan(ctx, enterpriseName[len("enterprises/"):], docs, nil)
+	if err != nil {
+		t.Fatalf("Plan() unexpected error: %v", err)
+	}
+
+	var actions []string
+	for _, action := range plan.Actions {
+		actions = append(actions, string(action.Type)+" "+action.PolicyID())
+	}
+	if !reflect.DeepEqual(actions, []string{"create kiosk", "update default", "delete old"}) {
+		t.Fatalf("plan actions = %v", actions)
+	}
+	if !reflect.DeepEqual(plan.Actions[1].UpdateMask, []string{"cameraDisabled"}) {
+		t.Errorf("update mask = %v, want [cameraDisabled]", plan.Actions[1].UpdateMask)
+	}
+	if len(plan.Unchanged) != 1 || plan.Count(policysync.ActionDelete) != 1 {
+		t.Errorf("plan = %s", plan)
+	}
+
+	if kept, err := syncer.Plan(ctx, enterpriseName, docs, &policysync.PlanOptions{KeepUnlisted: true}); err != nil || kept.Count(policysync.ActionDelete) != 0 {
+		t.Errorf("Plan(KeepUnlisted) = %v, %v, want no deletes", kept, err)
+	}
+
+	summary, err := syncer.Apply(ctx, plan)
+	if err != nil {
+		t.Fatalf("Apply() unexpected error: %v", err)
+	}
+	if summary.String() != "created 1, updated 1, deleted 1, unchanged 1" {
+		t.Errorf("summary = %s", summary)
+	}
+	if !srv.Policy(enterpriseName+"/policies/default").CameraDisabled || srv.Policy(enterpriseName+"/policies/old") != nil ||
+		len(srv.Policy(enterpriseName+"/policies/kiosk").Applications) != 1 {
+		t.Error("Apply() did not update the enterprise policies")
+	}
+
+	plan, err = syncer.Plan(ctx, enterpriseName, docs, nil)
+	if err != nil || !plan.Empty() || len(plan.Unchanged) != 3 {
+		t.Errorf("Plan() after apply = %v, %v, want no actions", plan, err)
+	}
+}
+
+// 测试计划过期时拒绝执行，以及操作失败时撤销已执行的操作
+func TestApplyConflictAndRollback(t *testing.T) {
+	srv, c, enterpriseName := newSyncTestClient(t)
+	ctx := context.Background()
+	docs := []*policysync.Document{
+		{ID: "default", Policy: &androidmanagement.Policy{CameraDisabled: true}},
+		{ID: "same", Policy: &androidmanagement.Policy{ScreenCaptureDisabled: true}},
+		{ID: "new", Policy: &androidmanagement.Policy{StatusBarDisabled: true}},
+	}
+
+	syncer := policysync.New(c)
+	plan, err := syncer.Plan(ctx, enterpriseName, docs, &policysync.PlanOptions{KeepUnlisted: true})
+	if err != nil {
+		t.Fatalf("Plan() unexpected error: %v", err)
+	}
+
+	// 生成计划后策略被其他人修改
+	if _, err := c.Policies().Update(enterpriseName+"/policies/default", &androidmanagement.Policy{BluetoothDisabled: true}, []string{"bluetoothDisabled"}); err != nil {
+		t.Fatalf("Update() unexpected error: %v", err)
+	}
+	_, err = syncer.Apply(ctx, plan)
+	var apiErr *types.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != types.ErrCodeConflict {
+		t.Fatalf("Apply() error = %v, want conflict", err)
+	}
+	if srv.Policy(enterpriseName+"/policies/new") != nil {
+		t.Error("Apply() created a policy from a stale plan")
+	}
+
+	plan, err = syncer.Plan(ctx, enterpriseName, docs, &policysync.PlanOptions{KeepUnlisted: true})
+	if err != nil {
+		t.Fatalf("Plan() unexpected error: %v", err)
+	}
+	// 计划生成后被改为无效策略，使更新在创建之后失败
+	plan.Actions[1].Desired = &androidmanagement.Policy{Applications: []*androidmanagement.ApplicationPolicy{{PackageName: ""}}}
+
+	summary, err := syncer.Apply(ctx, plan)
+	if err == nil {
+		t.Fatal("Apply() expected error")
+	}
+	if summary.Failed != enterpriseName+"/policies/default" || len(summary.Created) != 0 ||
+		!reflect.DeepEqual(summary.RolledBack, []string{enterpriseName + "/policies/new"}) {
+		t.Errorf("summary = %+v, want the created policy rolled back", summary)
+	}
+	if srv.Policy(enterpriseName+"/policies/new") != nil {
+		t.Error("rollback did not delete the created policy")
+	}
+}
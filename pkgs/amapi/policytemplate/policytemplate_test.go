@@ -0,0 +1,188 @@
+package policytemplate
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// parseLayers 解析按顺序给出的模板内容
+func parseLayers(t *testing.T, contents ...string) []*Template {
+	t.Helper()
+	var layers []*Template
+	for i, content := range contents {
+		layer, err := Parse("layer"+string(rune('0'+i)), []byte(content))
+		if err != nil {
+			t.Fatalf("Parse(layer %d) unexpected error: %v", i, err)
+		}
+		layers = append(layers, layer)
+	}
+	return layers
+}
+
+const baseTemplate = `
+variables:
+  kioskPackage:
+    type: package
+    required: true
+  wifiSSID:
+    default: Store-WiFi
+  brandColor:
+    type: color
+    default: "#FF6600"
+  screenTimeoutMs:
+    type: int
+    default: 60000
+policy:
+  cameraDisabled: true
+  maximumTimeToLock: ${screenTimeoutMs}
+  statusReportingSettings:
+    applicationReportsEnabled: true
+    deviceSettingsEnabled: true
+  applications:
+    - packageName: ${kioskPackage}
+      installType: KIOSK
+      managedConfiguration:
+        brandColor: ${brandColor}
+        ssid: ${wifiSSID}
+        title: "Store ${wifiSSID} $${literal}"
+    - packageName: com.example.support
+      installType: FORCE_INSTALLED
+`
+
+// 测试变量替换和多层合并
+func TestRender(t *testing.T) {
+	layers := parseLayers(t, baseTemplate, `
+values:
+  wifiSSID: EU-Store
+policy:
+  cameraDisabled: null
+  statusReportingSettings:
+    deviceSettingsEnabled: false
+  applications:
+    - packageName: com.example.support
+      $remove: true
+    - packageName: com.example.eu
+      installType: AVAILABLE
+`, `
+values:
+  kioskPackage: com.example.store
+policy:
+  applications:
+    - packageName: com.example.store
+      lockTaskAllowed: true
+`)
+
+	policy, err := Render(layers, map[string]any{"screenTimeoutMs": "30000"})
+	if err != nil {
+		t.Fatalf("Render() unexpected error: %v", err)
+	}
+
+	if policy.CameraDisabled || policy.MaximumTimeToLock != 30000 ||
+		!policy.StatusReportingSettings.ApplicationReportsEnabled || policy.StatusReportingSettings.DeviceSettingsEnabled {
+		t.Errorf("policy = %+v", policy)
+	}
+
+	var packages []string
+	for _, app := range policy.Applications {
+		packages = append(packages, app.PackageName)
+	}
+	if !reflect.DeepEqual(packages, []string{"com.example.store", "com.example.eu"}) {
+		t.Fatalf("applications = %v, want store then eu", packages)
+	}
+	kiosk := policy.Applications[0]
+	if kiosk.InstallType != "KIOSK" || !kiosk.LockTaskAllowed {
+		t.Errorf("kiosk app = %+v, want merged fields", kiosk)
+	}
+
+	fields, err := RenderFields(layers, nil)
+	if err != nil {
+		t.Fatalf("RenderFields() unexpected error: %v", err)
+	}
+	config := fields["applications"].([]any)[0].(map[string]any)["managedConfiguration"]
+	want := map[string]any{"brandColor": "#FF6600", "ssid": "EU-Store", "title": "Store EU-Store ${literal}"}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("managedConfiguration = %v, want %v", config, want)
+	}
+}
+
+// 测试基于预设的模板
+func TestRenderPreset(t *testing.T) {
+	layers := parseLayers(t, "preset: work_profile\npolicy:\n  cameraDisabled: true")
+	policy, err := Render(layers, nil)
+	if err != nil {
+		t.Fatalf("Render() unexpected error: %v", err)
+	}
+	if !policy.CameraDisabled || policy.PersonalUsagePolicies == nil {
+		t.Errorf("policy = %+v, want preset fields and override", policy)
+	}
+
+	layers = parseLayers(t, "policy: {}", "preset: work_profile")
+	if _, err := Render(layers, nil); err == nil {
+		t.Error("Render() expected error for preset in an overlay")
+	}
+}
+
+// 测试变量类型检查和缺失变量
+func TestRenderErrors(t *testing.T) {
+	tests := map[string]struct {
+		layers []string
+		values map[string]any
+	}{
+		"missing required":      {layers: []string{baseTemplate}},
+		"invalid package":       {layers: []string{baseTemplate}, values: map[string]any{"kioskPackage": "store"}},
+		"invalid color":         {layers: []string{baseTemplate}, values: map[string]any{"kioskPackage": "com.example.store", "brandColor": "orange"}},
+		"invalid int":           {layers: []string{baseTemplate}, values: map[string]any{"kioskPackage": "com.example.store", "screenTimeoutMs": "1m"}},
+		"unknown variable":      {layers: []string{baseTemplate}, values: map[string]any{"kioskPackage": "com.example.store", "ssid": "x"}},
+		"unresolved reference":  {layers: []string{"policy:\n  shortSupportMessage:\n    defaultMessage: ${missing}"}},
+		"type redeclared":       {layers: []string{"variables:\n  a:\n    type: int", "variables:\n  a:\n    type: bool"}},
+		"enum":                  {layers: []string{"variables:\n  mode:\n    enum: [WHITELIST, BLACKLIST]"}, values: map[string]any{"mode": "OTHER"}},
+		"application not keyed": {layers: []string{"policy:\n  applications:\n    - installType: KIOSK"}},
+		"invalid policy field":  {layers: []string{"policy:\n  cameraDisabled: [1]"}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Render(parseLayers(t, tt.layers...), tt.values); err == nil {
+				t.Error("Render() expected error")
+			}
+		})
+	}
+
+	invalid := []string{"- a", "unknown: 1", "variables:\n  a:\n    type: float", "variables:\n  b:\n    type: color\n    default: red", "policy: []"}
+	for _, content := range invalid {
+		if _, err := Parse("invalid", []byte(content)); err == nil {
+			t.Errorf("Parse(%q) expected error", content)
+		}
+	}
+}
+
+// 测试从文件加载模板层
+func TestLoadFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yaml")
+	site := filepath.Join(dir, "site.json")
+	if err := os.WriteFile(base, []byte(baseTemplate), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(site, []byte(`{"values": {"kioskPackage": "com.example.site"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	layers, err := LoadFiles(base, site)
+	if err != nil {
+		t.Fatalf("LoadFiles() unexpected error: %v", err)
+	}
+	if layers[1].Name != "site" || layers[1].Source != site {
+		t.Errorf("layer = %+v", layers[1])
+	}
+	policy, err := Render(layers, nil)
+	if err != nil || policy.Applications[0].PackageName != "com.example.site" {
+		t.Errorf("Render() = %v, %v", policy, err)
+	}
+
+	if _, err := LoadFile(filepath.Join(dir, "base.txt")); err == nil {
+		t.Error("LoadFile() expected error for unsupported extension")
+	}
+}
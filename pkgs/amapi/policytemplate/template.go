@@ -0,0 +1,265 @@
+// Package policytemplate renders policies from layered template files with typed variables.
+//
+// 模板是 YAML 或 JSON 文件，包含变量声明、变量值和部分策略。多个模板按顺序叠加，
+// 例如安全基线、区域覆盖和门店覆盖，后面的层覆盖前面的层：
+//
+//	# base.yaml
+//	preset: retail_kiosk         # 可选，只能出现在第一层
+//	variables:
+//	  kioskPackage:
+//	    type: package
+//	    required: true
+//	  wifiSSID:
+//	    type: string
+//	    default: Store-WiFi
+//	  brandColor:
+//	    type: color
+//	    default: "#FF6600"
+//	policy:
+//	  applications:
+//	    - packageName: ${kioskPackage}
+//	      installType: KIOSK
+//	      managedConfiguration:
+//	        brandColor: ${brandColor}
+//	        ssid: ${wifiSSID}
+//
+//	# stores/store-042.yaml
+//	values:
+//	  kioskPackage: com.example.store
+//	  wifiSSID: Store-042
+//
+// 合并规则：
+//   - 对象逐字段深度合并，后面的层覆盖同名字段
+//   - 值为 null 的字段从结果中删除
+//   - 列表整体替换，applications 除外
+//   - applications 按 packageName 合并：同名应用逐字段深度合并，新应用追加到末尾，
+//     包含 "$remove: true" 的条目删除该应用
+//
+// 变量在合并前替换。字符串中的 ${name} 替换为变量值；整个字符串只有一个 ${name} 时保留变量类型，
+// 例如 int 变量替换为数字。$${ 表示字面量 ${。
+//
+//	layers, err := policytemplate.LoadFiles("base.yaml", "regions/eu.yaml", "stores/store-042.yaml")
+//	policy, err := policytemplate.Render(layers, map[string]any{"wifiSSID": "Store-042-Guest"})
+package policytemplate
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"google.golang.org/api/androidmanagement/v1"
+	"gopkg.in/yaml.v3"
+
+	"amapi-pkg/pkgs/amapi/presets"
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// Template is one layer of a policy template.
+type Template struct {
+	// Name is the file name without extension
+	Name string `json:"name"`
+
+	// Source is the file the template was read from
+	Source string `json:"source,omitempty"`
+
+	// Preset is the preset the policy starts from; only allowed in the first layer
+	Preset string `json:"preset,omitempty"`
+
+	// Variables are the variables declared by this layer
+	Variables map[string]*Variable `json:"variables,omitempty"`
+
+	// Values sets variables declared by this or an earlier layer
+	Values map[string]any `json:"values,omitempty"`
+
+	// Policy holds the policy fields of this layer, using the JSON field names of androidmanagement.Policy
+	Policy map[string]any `json:"policy,omitempty"`
+}
+
+// templateKeys are the top-level keys of a template file.
+var templateKeys = map[string]bool{"preset": true, "variables": true, "values": true, "policy": true}
+
+// LoadFiles reads template layers in order.
+func LoadFiles(paths ...string) ([]*Template, error) {
+	layers := make([]*Template, 0, len(paths))
+	for _, path := range paths {
+		layer, err := LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
+
+// LoadFile reads a template from a JSON or YAML file.
+func LoadFile(path string) (*Template, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+	default:
+		return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "unsupported template file format",
+			path+" (supported: .yaml, .yml, .json)")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInvalidInput, "failed to read template file")
+	}
+
+	t, err := Parse(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), data)
+	if err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInvalidInput, "invalid template file "+path)
+	}
+	t.Source = path
+	return t, nil
+}
+
+// Parse parses a JSON or YAML template.
+func Parse(name string, data []byte) (*Template, error) {
+	var generic any
+	if err := yaml.Unmarshal(data, &generic); err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInvalidInput, "failed to parse template")
+	}
+	if generic == nil {
+		generic = map[string]any{}
+	}
+	fields, ok := generic.(map[string]any)
+	if !ok {
+		return nil, types.NewError(types.ErrCodeInvalidInput, "template must be an object")
+	}
+
+	for key := range fields {
+		if !templateKeys[key] {
+			return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "unknown template key",
+				key+" (supported: preset, variables, values, policy)")
+		}
+	}
+
+	t := &Template{Name: name, Variables: make(map[string]*Variable), Values: make(map[string]any), Policy: make(map[string]any)}
+	if preset, ok := fields["preset"].(string); ok {
+		t.Preset = preset
+	} else if fields["preset"] != nil {
+		return nil, types.NewError(types.ErrCodeInvalidInput, "preset must be a string")
+	}
+
+	if err := decodeObject(fields, "values", &t.Values); err != nil {
+		return nil, err
+	}
+	if err := decodeObject(fields, "policy", &t.Policy); err != nil {
+		return nil, err
+	}
+
+	var declarations map[string]any
+	if err := decodeObject(fields, "variables", &declarations); err != nil {
+		return nil, err
+	}
+	for varName, declaration := range declarations {
+		v, err := parseVariable(varName, declaration)
+		if err != nil {
+			return nil, err
+		}
+		t.Variables[varName] = v
+	}
+
+	// name 和 version 由服务端管理
+	delete(t.Policy, "name")
+	delete(t.Policy, "version")
+	return t, nil
+}
+
+// decodeObject stores fields[key] in dst if it is an object.
+func decodeObject(fields map[string]any, key string, dst *map[string]any) error {
+	switch value := fields[key].(type) {
+	case nil:
+		return nil
+	case map[string]any:
+		*dst = value
+		return nil
+	default:
+		return types.NewErrorWithDetails(types.ErrCodeInvalidInput, "template field must be an object", key)
+	}
+}
+
+// Render resolves the variables of the layers, substitutes them, merges the layers in order and
+// returns the resulting policy.
+//
+// values 覆盖模板中的变量值，可以是变量类型的值或字符串（例如命令行参数），字符串会按变量类型转换。
+// 设置未声明的变量、缺少必需变量或引用没有值的变量都会返回 ErrCodeInvalidInput 错误。
+func Render(layers []*Template, values map[string]any) (*androidmanagement.Policy, error) {
+	fields, err := RenderFields(layers, values)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := presets.PolicyFromFields(fields)
+	if err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInvalidInput, "failed to convert rendered policy")
+	}
+
+	if err := types.ValidatePolicy(policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// RenderFields is Render without the conversion to androidmanagement.Policy.
+func RenderFields(layers []*Template, values map[string]any) (map[string]any, error) {
+	if len(layers) == 0 {
+		return nil, types.NewError(types.ErrCodeInvalidInput, "at least one template is required")
+	}
+
+	resolved, err := Resolve(layers, values)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]any)
+	for i, layer := range layers {
+		if layer.Preset != "" {
+			if i > 0 {
+				return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "preset is only allowed in the first template", layer.Name)
+			}
+			base, err := presetFields(layer.Preset)
+			if err != nil {
+				return nil, err
+			}
+			result = base
+		}
+
+		policy, err := substitute(layer.Policy, resolved)
+		if err != nil {
+			return nil, types.WrapError(err, types.ErrCodeInvalidInput, "template "+layer.Name)
+		}
+		result, err = Merge(result, policy.(map[string]any))
+		if err != nil {
+			return nil, types.WrapError(err, types.ErrCodeInvalidInput, "template "+layer.Name)
+		}
+	}
+	return result, nil
+}
+
+// presetFields returns the fields of a preset policy.
+func presetFields(name string) (map[string]any, error) {
+	policy, err := presets.CreatePolicyFromPreset(name, nil)
+	if err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInvalidInput, "invalid preset")
+	}
+	return presets.PolicyFields(policy)
+}
+
+// Merge deep-merges overlay into base and returns the result. base and overlay are not modified.
+//
+// 合并规则见包文档，与 presets.MergeFields 相同。
+func Merge(base, overlay map[string]any) (map[string]any, error) {
+	return presets.MergeFields(base, overlay)
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
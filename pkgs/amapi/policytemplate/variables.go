@@ -0,0 +1,308 @@
+package policytemplate
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// VariableType is the type of a template variable.
+type VariableType string
+
+const (
+	// TypeString accepts any string; numbers and booleans are converted to strings
+	TypeString VariableType = "string"
+
+	// TypeInt accepts integers
+	TypeInt VariableType = "int"
+
+	// TypeBool accepts true or false
+	TypeBool VariableType = "bool"
+
+	// TypeColor accepts a #RRGGBB color
+	TypeColor VariableType = "color"
+
+	// TypePackage accepts an Android package name, e.g. com.example.app
+	TypePackage VariableType = "package"
+)
+
+var (
+	colorPattern    = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+	packagePattern  = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*(\.[A-Za-z][A-Za-z0-9_]*)+$`)
+	variablePattern = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+	namePattern     = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// Variable is a typed template variable.
+type Variable struct {
+	Name string `json:"name"`
+
+	// Type defaults to TypeString
+	Type VariableType `json:"type"`
+
+	Description string `json:"description,omitempty"`
+
+	// Required variables must have a value from a template or from Render
+	Required bool `json:"required,omitempty"`
+
+	// Default is used when no value is set
+	Default any `json:"default,omitempty"`
+
+	// Enum lists the allowed values, if not empty
+	Enum []string `json:"enum,omitempty"`
+}
+
+// parseVariable parses a variable declaration of a template file.
+func parseVariable(name string, declaration any) (*Variable, error) {
+	if !namePattern.MatchString(name) {
+		return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "invalid variable name", name)
+	}
+
+	fields, ok := declaration.(map[string]any)
+	if declaration != nil && !ok {
+		return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "variable declaration must be an object", name)
+	}
+
+	v := &Variable{Name: name, Type: TypeString}
+	for key, value := range fields {
+		var ok bool
+		switch key {
+		case "type":
+			var typeName string
+			typeName, ok = value.(string)
+			v.Type = VariableType(typeName)
+		case "description":
+			v.Description, ok = value.(string)
+		case "required":
+			v.Required, ok = value.(bool)
+		case "default":
+			v.Default, ok = value, true
+		case "enum":
+			var values []any
+			values, ok = value.([]any)
+			for _, value := range values {
+				v.Enum = append(v.Enum, fmt.Sprint(value))
+			}
+		default:
+			return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "unknown variable field",
+				name+"."+key+" (supported: type, description, required, default, enum)")
+		}
+		if !ok {
+			return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "invalid variable field", name+"."+key)
+		}
+	}
+
+	switch v.Type {
+	case TypeString, TypeInt, TypeBool, TypeColor, TypePackage:
+	default:
+		return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "invalid variable type",
+			fmt.Sprintf("%s: %s (supported: string, int, bool, color, package)", name, v.Type))
+	}
+
+	if v.Default != nil {
+		value, err := v.Convert(v.Default)
+		if err != nil {
+			return nil, types.WrapError(err, types.ErrCodeInvalidInput, "invalid default of variable "+name)
+		}
+		v.Default = value
+	}
+	return v, nil
+}
+
+// Convert checks a value against the variable type and converts strings to the type.
+func (v *Variable) Convert(value any) (any, error) {
+	var converted any
+	switch v.Type {
+	case TypeInt:
+		switch n := value.(type) {
+		case int:
+			converted = int64(n)
+		case int64:
+			converted = n
+		case float64:
+			if n != math.Trunc(n) {
+				return nil, fmt.Errorf("%s must be an integer, got %v", v.Name, value)
+			}
+			converted = int64(n)
+		case string:
+			i, err := strconv.ParseInt(n, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be an integer, got %q", v.Name, n)
+			}
+			converted = i
+		}
+	case TypeBool:
+		switch b := value.(type) {
+		case bool:
+			converted = b
+		case string:
+			parsed, err := strconv.ParseBool(b)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be true or false, got %q", v.Name, b)
+			}
+			converted = parsed
+		}
+	default:
+		switch s := value.(type) {
+		case string:
+			converted = s
+		case int, int64, float64, bool:
+			if v.Type == TypeString {
+				converted = fmt.Sprint(s)
+			}
+		}
+	}
+	if converted == nil {
+		return nil, fmt.Errorf("%s must be a %s, got %v", v.Name, v.Type, value)
+	}
+
+	switch s, _ := converted.(string); {
+	case v.Type == TypeColor && !colorPattern.MatchString(s):
+		return nil, fmt.Errorf("%s must be a #RRGGBB color, got %q", v.Name, s)
+	case v.Type == TypePackage && !packagePattern.MatchString(s):
+		return nil, fmt.Errorf("%s must be an Android package name, got %q", v.Name, s)
+	}
+
+	if len(v.Enum) > 0 {
+		allowed := false
+		for _, option := range v.Enum {
+			allowed = allowed || option == fmt.Sprint(converted)
+		}
+		if !allowed {
+			return nil, fmt.Errorf("%s must be one of %s, got %v", v.Name, strings.Join(v.Enum, ", "), converted)
+		}
+	}
+	return converted, nil
+}
+
+// Variables returns the variables declared by the layers, by name.
+//
+// 后面的层可以重新声明变量以修改描述、默认值等，但不能改变变量类型。
+func Variables(layers []*Template) (map[string]*Variable, error) {
+	variables := make(map[string]*Variable)
+	for _, layer := range layers {
+		for _, name := range sortedKeys(layer.Variables) {
+			v := layer.Variables[name]
+			if existing, ok := variables[name]; ok && existing.Type != v.Type {
+				return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "variable redeclared with a different type",
+					fmt.Sprintf("%s: %s in an earlier template, %s in %s", name, existing.Type, v.Type, layer.Name))
+			}
+			variables[name] = v
+		}
+	}
+	return variables, nil
+}
+
+// Resolve returns the value of every variable that has one.
+//
+// 优先级从高到低：values 参数、后面层的 values、前面层的 values、变量默认值。
+func Resolve(layers []*Template, values map[string]any) (map[string]any, error) {
+	variables, err := Variables(layers)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make(map[string]any)
+	for name, v := range variables {
+		if v.Default != nil {
+			resolved[name] = v.Default
+		}
+	}
+
+	set := func(source string, values map[string]any) error {
+		for _, name := range sortedKeys(values) {
+			v, ok := variables[name]
+			if !ok {
+				return types.NewErrorWithDetails(types.ErrCodeInvalidInput, "unknown template variable", name+" set in "+source)
+			}
+			value, err := v.Convert(values[name])
+			if err != nil {
+				return types.WrapError(err, types.ErrCodeInvalidInput, "invalid value in "+source)
+			}
+			resolved[name] = value
+		}
+		return nil
+	}
+	for _, layer := range layers {
+		if err := set(layer.Name, layer.Values); err != nil {
+			return nil, err
+		}
+	}
+	if err := set("render values", values); err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, name := range sortedKeys(variables) {
+		if _, ok := resolved[name]; !ok && variables[name].Required {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "missing required template variables", strings.Join(missing, ", "))
+	}
+	return resolved, nil
+}
+
+// substitute replaces variable references in the strings of value.
+func substitute(value any, resolved map[string]any) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			substituted, err := substitute(item, resolved)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = substituted
+		}
+		return result, nil
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			substituted, err := substitute(item, resolved)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = substituted
+		}
+		return result, nil
+	case string:
+		return substituteString(v, resolved)
+	default:
+		return value, nil
+	}
+}
+
+// substituteString replaces ${name} references; a string that is a single reference keeps the variable type.
+func substituteString(s string, resolved map[string]any) (any, error) {
+	if match := variablePattern.FindStringSubmatchIndex(s); match != nil && match[0] == 0 && match[1] == len(s) && match[2] >= 0 {
+		name := s[match[2]:match[3]]
+		value, ok := resolved[name]
+		if !ok {
+			return nil, fmt.Errorf("variable %s has no value", name)
+		}
+		return value, nil
+	}
+
+	var err error
+	result := variablePattern.ReplaceAllStringFunc(s, func(ref string) string {
+		if ref == "$${" {
+			return "${"
+		}
+		name := ref[2 : len(ref)-1]
+		value, ok := resolved[name]
+		if !ok && err == nil {
+			err = fmt.Errorf("variable %s has no value", name)
+		}
+		return fmt.Sprint(value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
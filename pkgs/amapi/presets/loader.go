@@ -0,0 +1,232 @@
+package presets
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// presetKeys are the top-level keys of a preset file.
+var presetKeys = map[string]bool{
+	"name": true, "displayName": true, "description": true, "tags": true,
+	"version": true, "requires": true, "policy": true,
+}
+
+// LoadDefinitionFile reads a preset definition from a JSON or YAML file.
+//
+// 预设文件格式：
+//
+//	name: acme_kiosk             # 可选，默认为不含扩展名的文件名
+//	displayName: ACME Kiosk
+//	description: 在 retail_kiosk 基础上加固的门店策略
+//	tags: [kiosk, hardened]
+//	version: 1.2.0
+//	requires: [retail_kiosk]     # 依赖的预设，按顺序合并
+//	policy:
+//	  screenCaptureDisabled: true
+//	  applications:
+//	    - packageName: com.acme.store
+//	      installType: FORCE_INSTALLED
+func LoadDefinitionFile(path string) (*PresetDefinition, error) {
+	if !isPresetFile(path) {
+		return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "unsupported preset file format",
+			path+" (supported: .yaml, .yml, .json)")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInvalidInput, "failed to read preset file")
+	}
+
+	def, err := ParseDefinition(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), data)
+	if err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInvalidInput, "invalid preset file "+path)
+	}
+	def.Source = path
+	return def, nil
+}
+
+// ParseDefinition parses a JSON or YAML preset definition; name is used when the definition has no name.
+func ParseDefinition(name string, data []byte) (*PresetDefinition, error) {
+	var generic any
+	if err := yaml.Unmarshal(data, &generic); err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInvalidInput, "failed to parse preset")
+	}
+	fields, ok := generic.(map[string]any)
+	if !ok {
+		return nil, types.NewError(types.ErrCodeInvalidInput, "preset must be an object")
+	}
+
+	for key := range fields {
+		if !presetKeys[key] {
+			return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "unknown preset key",
+				key+" (supported: name, displayName, description, tags, version, requires, policy)")
+		}
+	}
+
+	def := &PresetDefinition{Name: name, Fields: make(map[string]any)}
+	for key, target := range map[string]*string{"name": &def.Name, "displayName": &def.DisplayName, "description": &def.Description} {
+		switch value := fields[key].(type) {
+		case nil:
+		case string:
+			*target = value
+		default:
+			return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "preset field must be a string", key)
+		}
+	}
+
+	switch version := fields["version"].(type) {
+	case nil:
+	case string, int, float64:
+		def.Version = fmt.Sprint(version)
+	default:
+		return nil, types.NewError(types.ErrCodeInvalidInput, "preset version must be a string")
+	}
+
+	var err error
+	if def.Tags, err = stringList(fields, "tags"); err != nil {
+		return nil, err
+	}
+	if def.Requires, err = stringList(fields, "requires"); err != nil {
+		return nil, err
+	}
+
+	switch policy := fields["policy"].(type) {
+	case nil:
+	case map[string]any:
+		def.Fields = policy
+	default:
+		return nil, types.NewError(types.ErrCodeInvalidInput, "preset policy must be an object")
+	}
+	return def, nil
+}
+
+// stringList returns fields[key] as a list of strings.
+func stringList(fields map[string]any, key string) ([]string, error) {
+	if fields[key] == nil {
+		return nil, nil
+	}
+	items, ok := fields[key].([]any)
+	if !ok {
+		return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "preset field must be a list of strings", key)
+	}
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok || s == "" {
+			return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "preset field must be a list of strings", key)
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+// LoadDir registers the presets defined by the .yaml, .yml and .json files of dir and returns them.
+//
+// 目录中的预设可以互相依赖，也可以依赖已注册的预设，注册顺序按依赖关系确定。
+// 任何文件无效、名称冲突、依赖缺失或存在循环依赖时返回错误，且不注册该目录中的任何预设。
+func (r *Registry) LoadDir(dir string) ([]*PolicyPreset, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInvalidInput, "failed to read preset directory "+dir)
+	}
+
+	defs := make(map[string]*PresetDefinition)
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || !isPresetFile(entry.Name()) {
+			continue
+		}
+		def, err := LoadDefinitionFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if existing, ok := defs[def.Name]; ok {
+			return nil, types.NewErrorWithDetails(types.ErrCodeConflict, "preset defined twice",
+				fmt.Sprintf("%s in %s and %s", def.Name, existing.Source, def.Source))
+		}
+		defs[def.Name] = def
+		names = append(names, def.Name)
+	}
+	sort.Strings(names)
+
+	ordered, err := dependencyOrder(names, defs)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loaded := make([]*PolicyPreset, 0, len(ordered))
+	for _, def := range ordered {
+		if err := r.register(*def); err != nil {
+			for _, preset := range loaded {
+				r.unregister(preset.Name)
+			}
+			return nil, types.WrapError(err, types.ErrCodeInvalidInput, "failed to load preset file "+def.Source)
+		}
+		loaded = append(loaded, copyPreset(r.presets[def.Name].preset))
+	}
+	return loaded, nil
+}
+
+// LoadDir registers the presets defined by the files of dir in the default registry.
+func LoadDir(dir string) ([]*PolicyPreset, error) {
+	return defaultRegistry.LoadDir(dir)
+}
+
+// dependencyOrder sorts defs so that every preset comes after the presets it requires from defs.
+func dependencyOrder(names []string, defs map[string]*PresetDefinition) ([]*PresetDefinition, error) {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	var ordered []*PresetDefinition
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return types.NewErrorWithDetails(types.ErrCodeInvalidInput, "circular preset dependency",
+				strings.Join(append(path, name), " -> "))
+		}
+		state[name] = visiting
+		for _, required := range defs[name].Requires {
+			// 目录之外的依赖在注册时检查
+			if _, ok := defs[required]; ok {
+				if err := visit(required, append(path, name)); err != nil {
+					return err
+				}
+			}
+		}
+		state[name] = visited
+		ordered = append(ordered, defs[name])
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// isPresetFile reports whether path has a preset file extension.
+func isPresetFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
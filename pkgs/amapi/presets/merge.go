@@ -0,0 +1,260 @@
+package presets
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// keyedLists maps list fields merged by key to the key field of their items.
+var keyedLists = map[string]string{"applications": "packageName"}
+
+// removeKey marks a keyed list item that removes the item of the same key.
+const removeKey = "$remove"
+
+// MergeFields deep-merges overlay into base and returns the result. base and overlay are not modified.
+//
+// 合并规则：对象逐字段深度合并，值为 null 的字段从结果中删除，列表整体替换；
+// applications 按 packageName 合并，同名应用深度合并，新应用追加到末尾，包含 "$remove: true" 的条目删除该应用。
+// 预设依赖和 policytemplate 的分层模板都使用这个规则。
+func MergeFields(base, overlay map[string]any) (map[string]any, error) {
+	merged, err := mergeValues("", base, overlay)
+	if err != nil {
+		return nil, err
+	}
+	result, _ := merged.(map[string]any)
+	if result == nil {
+		result = make(map[string]any)
+	}
+	return result, nil
+}
+
+// mergeValues merges overlay into base at path.
+func mergeValues(path string, base, overlay any) (any, error) {
+	overlayMap, ok := overlay.(map[string]any)
+	if !ok {
+		return copyValue(overlay), nil
+	}
+	baseMap, _ := base.(map[string]any)
+
+	result := make(map[string]any, len(baseMap)+len(overlayMap))
+	for key, value := range baseMap {
+		result[key] = copyValue(value)
+	}
+	for _, key := range sortedKeys(overlayMap) {
+		value := overlayMap[key]
+		fieldPath := formatPath(path, key)
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+
+		if itemKey, ok := keyedLists[fieldPath]; ok {
+			merged, err := mergeKeyedList(fieldPath, itemKey, result[key], value)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = merged
+			continue
+		}
+
+		merged, err := mergeValues(fieldPath, result[key], value)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = merged
+	}
+	return result, nil
+}
+
+// mergeKeyedList merges the items of two lists by itemKey, keeping the order of base and appending new items.
+func mergeKeyedList(path, itemKey string, base, overlay any) (any, error) {
+	overlayItems, ok := overlay.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s must be a list", path)
+	}
+	baseItems, _ := base.([]any)
+
+	var result []any
+	index := make(map[string]int)
+	for _, item := range baseItems {
+		if key, ok := listItemKey(item, itemKey); ok {
+			index[key] = len(result)
+		}
+		result = append(result, copyValue(item))
+	}
+
+	removed := make(map[int]bool)
+	for i, item := range overlayItems {
+		key, ok := listItemKey(item, itemKey)
+		if !ok {
+			return nil, fmt.Errorf("%s[%d] must be an object with a string %s", path, i, itemKey)
+		}
+		fields := item.(map[string]any)
+		itemPath := fmt.Sprintf("%s[%s]", path, key)
+
+		if remove, _ := fields[removeKey].(bool); remove {
+			if j, ok := index[key]; ok {
+				removed[j] = true
+				delete(index, key)
+			}
+			continue
+		}
+		if _, ok := fields[removeKey]; ok {
+			return nil, fmt.Errorf("%s.%s must be true", itemPath, removeKey)
+		}
+
+		j, exists := index[key]
+		if !exists {
+			index[key] = len(result)
+			result = append(result, copyValue(item))
+			continue
+		}
+		merged, err := mergeValues(itemPath, result[j], item)
+		if err != nil {
+			return nil, err
+		}
+		result[j] = merged
+	}
+
+	kept := make([]any, 0, len(result))
+	for i, item := range result {
+		if !removed[i] {
+			kept = append(kept, item)
+		}
+	}
+	return kept, nil
+}
+
+// listItemKey returns the string key field of a list item.
+func listItemKey(item any, itemKey string) (string, bool) {
+	fields, ok := item.(map[string]any)
+	if !ok {
+		return "", false
+	}
+	key, ok := fields[itemKey].(string)
+	return key, ok && key != ""
+}
+
+// copyValue returns a deep copy of a decoded value.
+func copyValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[key] = copyValue(item)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = copyValue(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// PolicyFields converts a policy to its JSON fields.
+func PolicyFields(policy *androidmanagement.Policy) (map[string]any, error) {
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInvalidInput, "failed to convert policy")
+	}
+	fields := make(map[string]any)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInvalidInput, "failed to convert policy")
+	}
+	return fields, nil
+}
+
+// PolicyFromFields converts JSON fields, such as the result of MergeFields, to a policy.
+//
+// 以 JSON 字符串编码的 int64 字段（例如 maximumTimeToLock）也可以使用普通数字。
+// fields 中的数字会被原地转换为字符串。
+func PolicyFromFields(fields map[string]any) (*androidmanagement.Policy, error) {
+	data, err := json.Marshal(quoteInt64Fields(fields, reflect.TypeOf(androidmanagement.Policy{})))
+	if err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInvalidInput, "failed to convert policy fields")
+	}
+	policy := &androidmanagement.Policy{}
+	if err := json.Unmarshal(data, policy); err != nil {
+		return nil, types.WrapError(err, types.ErrCodeInvalidInput, "failed to parse policy fields")
+	}
+	return policy, nil
+}
+
+// quoteInt64Fields converts numbers to strings for the fields of t encoded as JSON strings,
+// such as maximumTimeToLock, so that templates and int variables can use plain numbers.
+func quoteInt64Fields(value any, t reflect.Type) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		if t.Kind() == reflect.Map {
+			for key, item := range v {
+				v[key] = quoteInt64Fields(item, t.Elem())
+			}
+			return v
+		}
+		if t.Kind() != reflect.Struct {
+			return v
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+			item, ok := v[name]
+			if !ok {
+				continue
+			}
+			if strings.Contains(","+options+",", ",string,") {
+				switch n := item.(type) {
+				case int, int64:
+					v[name] = fmt.Sprint(n)
+				case float64:
+					v[name] = strconv.FormatFloat(n, 'f', -1, 64)
+				}
+				continue
+			}
+			v[name] = quoteInt64Fields(item, field.Type)
+		}
+		return v
+	case []any:
+		if t.Kind() == reflect.Slice {
+			for i, item := range v {
+				v[i] = quoteInt64Fields(item, t.Elem())
+			}
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatPath joins a parent path and a field name.
+func formatPath(parent, field string) string {
+	if parent == "" {
+		return field
+	}
+	return fmt.Sprintf("%s.%s", parent, field)
+}
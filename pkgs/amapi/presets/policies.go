@@ -48,11 +48,29 @@
 //   - SECURITY_LOGS: 安全日志
 //
 // 这个配置可以让设备上报所有基础数据，便于全面的设备监控和管理。
+//
+// # 预设注册表
+//
+// 内置预设注册在默认注册表中，GetAllPresets、GetPresetByName 和 CreatePolicyFromPreset 都使用默认注册表。
+// 应用可以注册自己的预设，或者从 YAML/JSON 文件目录加载预设（格式见 LoadDefinitionFile）。
+// 预设可以通过 Requires 依赖其他预设，在其基础上覆盖字段：
+//
+//	err := presets.Register(presets.PresetDefinition{
+//	    Name:     "acme_kiosk",
+//	    Tags:     []string{"kiosk", "hardened"},
+//	    Version:  "1.0.0",
+//	    Requires: []string{"retail_kiosk"},
+//	    Fields:   map[string]any{"screenCaptureDisabled": true},
+//	})
+//
+//	loaded, err := presets.LoadDir("/etc/amapi/presets")
+//	kiosks := presets.FindPresetsByTag("kiosk")
+//
+// 需要隔离的场景可以使用 NewRegistry 或 NewBuiltinRegistry 创建独立的注册表。
 package presets
 
 import (
 	"encoding/json"
-	"fmt"
 
 	"google.golang.org/api/androidmanagement/v1"
 )
@@ -109,23 +127,22 @@ type PolicyPreset struct {
 	DisplayName string
 	Description string
 	Tags        []string
+	Version     string
+	Requires    []string
+	Source      string
 	Policy      *androidmanagement.Policy
 }
 
-type policyPresetDefinition struct {
-	Name        string
-	DisplayName string
-	Description string
-	Tags        []string
-	Builder     func() *androidmanagement.Policy
-}
+// builtinVersion is the version of the built-in presets.
+const builtinVersion = "1.0.0"
 
-var policyPresetDefinitions = []policyPresetDefinition{
+var builtinPresets = []PresetDefinition{
 	{
 		Name:        "fully_managed",
 		DisplayName: "Fully Managed Device",
 		Description: "全面托管模式，启用完整的状态上报与安全基线。",
 		Tags:        []string{"enterprise", "managed"},
+		Version:     builtinVersion,
 		Builder:     func() *androidmanagement.Policy { return clonePolicy(newBasePolicy()) },
 	},
 	{
@@ -133,6 +150,7 @@ var policyPresetDefinitions = []policyPresetDefinition{
 		DisplayName: "Dedicated Device",
 		Description: "适用于专用场景的单应用/多应用固定设备，启用自助亭模式。",
 		Tags:        []string{"kiosk", "lock-task"},
+		Version:     builtinVersion,
 		Builder:     buildDedicatedDevicePolicy,
 	},
 	{
@@ -140,6 +158,7 @@ var policyPresetDefinitions = []policyPresetDefinition{
 		DisplayName: "Work Profile",
 		Description: "在公司拥有设备上启用工作资料分区，允许个人与工作并存。",
 		Tags:        []string{"COPE", "BYOD"},
+		Version:     builtinVersion,
 		Builder:     buildWorkProfilePolicy,
 	},
 	{
@@ -147,6 +166,7 @@ var policyPresetDefinitions = []policyPresetDefinition{
 		DisplayName: "Kiosk Mode",
 		Description: "锁定到单一应用，隐藏系统 UI 元素，适合展台设备。",
 		Tags:        []string{"kiosk", "single-app"},
+		Version:     builtinVersion,
 		Builder:     buildRetailKioskPolicy,
 	},
 	{
@@ -154,6 +174,7 @@ var policyPresetDefinitions = []policyPresetDefinition{
 		DisplayName: "Company Owned, Personally Enabled",
 		Description: "公司拥有并允许个人使用的模式，强调个人隐私保护。",
 		Tags:        []string{"COPE", "privacy"},
+		Version:     builtinVersion,
 		Builder:     buildCopePolicy,
 	},
 	{
@@ -161,6 +182,7 @@ var policyPresetDefinitions = []policyPresetDefinition{
 		DisplayName: "Secure Workstation",
 		Description: "高安全要求的终端策略，禁用截屏与外部存储。",
 		Tags:        []string{"security", "compliance"},
+		Version:     builtinVersion,
 		Builder:     buildSecureWorkstationPolicy,
 	},
 	{
@@ -168,6 +190,7 @@ var policyPresetDefinitions = []policyPresetDefinition{
 		DisplayName: "Education Tablet",
 		Description: "教育场景推荐策略，保留学习应用同时限制娱乐功能。",
 		Tags:        []string{"education", "tablet"},
+		Version:     builtinVersion,
 		Builder:     buildEducationTabletPolicy,
 	},
 	{
@@ -175,60 +198,29 @@ var policyPresetDefinitions = []policyPresetDefinition{
 		DisplayName: "Retail Kiosk",
 		Description: "零售门店展台策略，固定应用并启用自助任务锁定。",
 		Tags:        []string{"retail", "kiosk"},
+		Version:     builtinVersion,
 		Builder:     buildRetailKioskPolicy,
 	},
 }
 
-// GetAllPresets returns available policy presets.
+// GetAllPresets returns the presets of the default registry.
 func GetAllPresets() []*PolicyPreset {
-	result := make([]*PolicyPreset, 0, len(policyPresetDefinitions))
-	for _, def := range policyPresetDefinitions {
-		result = append(result, buildPolicyPreset(def))
-	}
-	return result
+	return defaultRegistry.List()
 }
 
-// GetPresetByName finds a preset by identifier.
+// GetPresetByName finds a preset of the default registry by identifier.
 func GetPresetByName(name string) *PolicyPreset {
-	for _, def := range policyPresetDefinitions {
-		if def.Name == name {
-			return buildPolicyPreset(def)
-		}
-	}
-	return nil
+	return defaultRegistry.Get(name)
 }
 
 // CreatePolicyFromPreset returns a cloned policy from the preset and applies an optional customization function.
 func CreatePolicyFromPreset(name string, customize func(*androidmanagement.Policy) *androidmanagement.Policy) (*androidmanagement.Policy, error) {
-	preset := GetPresetByName(name)
-	if preset == nil {
-		return nil, fmt.Errorf("unknown policy preset: %s", name)
-	}
-
-	policy := clonePolicy(preset.Policy)
-	if customize != nil {
-		if customized := customize(clonePolicy(preset.Policy)); customized != nil {
-			policy = customized
-		}
-	}
-
-	return policy, nil
-}
-
-func buildPolicyPreset(def policyPresetDefinition) *PolicyPreset {
-	policy := def.Builder()
-	return &PolicyPreset{
-		Name:        def.Name,
-		DisplayName: def.DisplayName,
-		Description: def.Description,
-		Tags:        append([]string(nil), def.Tags...),
-		Policy:      policy,
-	}
+	return defaultRegistry.CreatePolicy(name, customize)
 }
 
 func buildDedicatedDevicePolicy() *androidmanagement.Policy {
 	policy := newBasePolicy()
-	policy.KioskCustomLauncherEnabled = true
+	// KIOSK 应用本身就是启动器，不能再启用 kioskCustomLauncherEnabled
 	policy.StatusBarDisabled = true
 	policy.Applications = []*androidmanagement.ApplicationPolicy{
 		{
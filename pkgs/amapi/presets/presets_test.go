@@ -0,0 +1,188 @@
+package presets
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/androidmanagement/v1"
+)
+
+// presetNames 返回预设名称列表，便于比较
+func presetNames(presets []*PolicyPreset) []string {
+	var names []string
+	for _, p := range presets {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// writeFiles 在临时目录中写入文件并返回目录
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// 测试内置预设和标签查找
+func TestBuiltinPresets(t *testing.T) {
+	all := GetAllPresets()
+	if len(all) != len(builtinPresets) || all[0].Name != "fully_managed" {
+		t.Fatalf("GetAllPresets() = %v", presetNames(all))
+	}
+	for _, p := range all {
+		if p.Source != BuiltinSource || p.Version != builtinVersion || p.Policy == nil {
+			t.Errorf("preset %s = %+v", p.Name, p)
+		}
+	}
+
+	if got := presetNames(FindPresetsByTag("Kiosk")); !reflect.DeepEqual(got, []string{"dedicated_device", "kiosk_mode", "retail_kiosk"}) {
+		t.Errorf("FindPresetsByTag(Kiosk) = %v", got)
+	}
+	if got := presetNames(FindPresetsByTag("kiosk", "retail")); !reflect.DeepEqual(got, []string{"retail_kiosk"}) {
+		t.Errorf("FindPresetsByTag(kiosk, retail) = %v", got)
+	}
+
+	// 返回的预设是副本
+	preset := GetPresetByName("retail_kiosk")
+	preset.Policy.Applications = nil
+	preset.Tags[0] = "changed"
+	if again := GetPresetByName("retail_kiosk"); len(again.Policy.Applications) == 0 || again.Tags[0] != "retail" {
+		t.Errorf("GetPresetByName() returned shared state: %+v", again)
+	}
+
+	if _, err := CreatePolicyFromPreset("missing", nil); err == nil {
+		t.Error("CreatePolicyFromPreset() expected error for unknown preset")
+	}
+}
+
+// 测试注册带依赖的预设
+func TestRegister(t *testing.T) {
+	r := NewBuiltinRegistry()
+	err := r.Register(PresetDefinition{
+		Name:     "hardened_kiosk",
+		Tags:     []string{"kiosk", "hardened"},
+		Requires: []string{"retail_kiosk", "secure_workstation"},
+		Fields: map[string]any{
+			"statusBarDisabled": false,
+			"maximumTimeToLock": 60000,
+			"applications": []any{
+				map[string]any{"packageName": "com.android.chrome", "installType": "KIOSK", "autoUpdateMode": "AUTO_UPDATE_HIGH_PRIORITY"},
+				map[string]any{"packageName": "com.example.support", "installType": "FORCE_INSTALLED"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Register() unexpected error: %v", err)
+	}
+
+	policy, err := r.CreatePolicy("hardened_kiosk", nil)
+	if err != nil {
+		t.Fatalf("CreatePolicy() unexpected error: %v", err)
+	}
+	if policy.PlayStoreMode != "WHITELIST" || !policy.ScreenCaptureDisabled || policy.StatusBarDisabled || policy.MaximumTimeToLock != 60000 {
+		t.Errorf("policy = %+v, want fields of both presets and overrides", policy)
+	}
+	if len(policy.Applications) != 2 || !policy.Applications[0].LockTaskAllowed || policy.Applications[0].AutoUpdateMode == "" {
+		t.Errorf("applications = %+v, want merged by package name", policy.Applications)
+	}
+
+	err = r.Register(PresetDefinition{
+		Name:     "hardened_kiosk_camera",
+		Requires: []string{"hardened_kiosk"},
+		Builder: func() *androidmanagement.Policy {
+			return &androidmanagement.Policy{CameraDisabled: true}
+		},
+	})
+	if err != nil {
+		t.Fatalf("Register() unexpected error: %v", err)
+	}
+	if p := r.Get("hardened_kiosk_camera"); !p.Policy.CameraDisabled || p.Policy.MaximumTimeToLock != 60000 {
+		t.Errorf("policy = %+v, want dependency fields", p.Policy)
+	}
+
+	if got := presetNames(r.FindByTag("hardened")); !reflect.DeepEqual(got, []string{"hardened_kiosk"}) {
+		t.Errorf("FindByTag(hardened) = %v", got)
+	}
+	if GetPresetByName("hardened_kiosk") != nil {
+		t.Error("Register() on a new registry changed the default registry")
+	}
+
+	invalid := map[string]PresetDefinition{
+		"duplicate":          {Name: "retail_kiosk", Fields: map[string]any{}},
+		"invalid name":       {Name: "a b", Fields: map[string]any{}},
+		"missing dependency": {Name: "x", Requires: []string{"missing"}},
+		"no policy":          {Name: "x"},
+		"builder and fields": {Name: "x", Fields: map[string]any{}, Builder: newBasePolicy},
+		"invalid field":      {Name: "x", Fields: map[string]any{"cameraDisabled": "yes"}},
+	}
+	for name, def := range invalid {
+		if err := r.Register(def); err == nil {
+			t.Errorf("Register(%s) expected error", name)
+		}
+	}
+}
+
+// 测试从目录加载预设
+func TestLoadDir(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"acme-base.yaml": `
+displayName: ACME Baseline
+description: ACME 安全基线
+tags: [hardened]
+version: 2
+requires: [secure_workstation]
+policy:
+  cameraAccess: null
+  addUserDisabled: true
+`,
+		"acme-kiosk.json": `{"name": "acme_kiosk", "version": "1.2.0", "tags": ["kiosk", "hardened"], "requires": ["acme-base", "retail_kiosk"],
+			"policy": {"applications": [{"packageName": "com.android.chrome", "$remove": true}, {"packageName": "com.acme.store", "installType": "KIOSK"}]}}`,
+		"README.md": "ignored",
+	})
+
+	r := NewBuiltinRegistry()
+	loaded, err := r.LoadDir(dir)
+	if err != nil {
+		t.Fatalf("LoadDir() unexpected error: %v", err)
+	}
+	if got := presetNames(loaded); !reflect.DeepEqual(got, []string{"acme-base", "acme_kiosk"}) {
+		t.Fatalf("LoadDir() = %v", got)
+	}
+	base := loaded[0]
+	if base.DisplayName != "ACME Baseline" || base.Version != "2" || base.Source != filepath.Join(dir, "acme-base.yaml") ||
+		base.Policy.CameraAccess != "" || !base.Policy.ScreenCaptureDisabled || !base.Policy.AddUserDisabled {
+		t.Errorf("acme-base = %+v, policy = %+v", base, base.Policy)
+	}
+	kiosk := r.Get("acme_kiosk")
+	if len(kiosk.Policy.Applications) != 1 || kiosk.Policy.Applications[0].PackageName != "com.acme.store" ||
+		!kiosk.Policy.AddUserDisabled || kiosk.Policy.PlayStoreMode != "WHITELIST" {
+		t.Errorf("acme_kiosk policy = %+v", kiosk.Policy)
+	}
+
+	invalid := map[string]map[string]string{
+		"cycle":        {"a.yaml": "requires: [b]", "b.yaml": "requires: [a]"},
+		"missing":      {"a.yaml": "tags: [x]", "b.yaml": "requires: [missing]"},
+		"duplicate":    {"a.yaml": "tags: [x]", "b.yaml": "name: a"},
+		"unknown key":  {"a.yaml": "policies: {}"},
+		"invalid tags": {"a.yaml": "tags: x"},
+		"builtin name": {"fully_managed.yaml": "tags: [x]"},
+	}
+	for name, files := range invalid {
+		t.Run(name, func(t *testing.T) {
+			r := NewBuiltinRegistry()
+			if _, err := r.LoadDir(writeFiles(t, files)); err == nil {
+				t.Fatal("LoadDir() expected error")
+			}
+			if len(r.List()) != len(builtinPresets) {
+				t.Errorf("LoadDir() registered presets after an error: %v", presetNames(r.List()))
+			}
+		})
+	}
+}
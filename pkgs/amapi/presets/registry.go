@@ -0,0 +1,266 @@
+package presets
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// BuiltinSource is the Source of the presets shipped with this package.
+const BuiltinSource = "builtin"
+
+var presetNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// PresetDefinition describes a preset to register.
+//
+// 策略由 Requires 中的预设按顺序合并，再合并 Builder 或 Fields 的结果得到，合并规则见 MergeFields。
+// Builder 返回完整的策略，其中为零值的字段不会覆盖依赖的预设；需要关闭依赖中启用的选项时使用 Fields。
+type PresetDefinition struct {
+	Name        string
+	DisplayName string
+	Description string
+	Tags        []string
+	Version     string
+
+	// Requires lists the presets this preset is based on; they must be registered first
+	Requires []string
+
+	// Builder builds the policy of the preset; mutually exclusive with Fields
+	Builder func() *androidmanagement.Policy
+
+	// Fields holds policy fields using the JSON field names of androidmanagement.Policy;
+	// mutually exclusive with Builder
+	Fields map[string]any
+
+	// Source records where the preset was defined, e.g. the file it was loaded from
+	Source string
+}
+
+// registeredPreset is a preset with its resolved policy.
+type registeredPreset struct {
+	preset *PolicyPreset
+	fields map[string]any
+}
+
+// Registry holds policy presets by name. It is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	presets map[string]*registeredPreset
+	order   []string
+}
+
+// NewRegistry creates an empty preset registry.
+func NewRegistry() *Registry {
+	return &Registry{presets: make(map[string]*registeredPreset)}
+}
+
+// NewBuiltinRegistry creates a registry with the built-in presets.
+func NewBuiltinRegistry() *Registry {
+	r := NewRegistry()
+	for _, def := range builtinPresets {
+		def.Source = BuiltinSource
+		if err := r.Register(def); err != nil {
+			panic(fmt.Sprintf("presets: invalid built-in preset %s: %v", def.Name, err))
+		}
+	}
+	return r
+}
+
+var defaultRegistry = NewBuiltinRegistry()
+
+// DefaultRegistry returns the registry used by GetAllPresets, GetPresetByName and CreatePolicyFromPreset.
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// Register adds a preset to the registry.
+//
+// 名称重复返回 ErrCodeConflict 错误；依赖的预设未注册或定义无效返回 ErrCodeInvalidInput 错误。
+// 依赖必须先注册，因此不会出现循环依赖。
+func (r *Registry) Register(def PresetDefinition) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.register(def)
+}
+
+// register adds a preset to the registry. The caller holds r.mu.
+func (r *Registry) register(def PresetDefinition) error {
+	if !presetNamePattern.MatchString(def.Name) {
+		return types.NewErrorWithDetails(types.ErrCodeInvalidInput, "invalid preset name", def.Name)
+	}
+	if def.Builder != nil && def.Fields != nil {
+		return types.NewErrorWithDetails(types.ErrCodeInvalidInput, "preset builder and fields are mutually exclusive", def.Name)
+	}
+	if def.Builder == nil && def.Fields == nil && len(def.Requires) == 0 {
+		return types.NewErrorWithDetails(types.ErrCodeInvalidInput, "preset requires a builder, fields or required presets", def.Name)
+	}
+	if _, exists := r.presets[def.Name]; exists {
+		return types.NewErrorWithDetails(types.ErrCodeConflict, "preset already registered", def.Name)
+	}
+
+	registered, err := r.resolve(def)
+	if err != nil {
+		return types.WrapError(err, types.ErrCodeInvalidInput, "invalid preset "+def.Name)
+	}
+	r.presets[def.Name] = registered
+	r.order = append(r.order, def.Name)
+	return nil
+}
+
+// unregister removes a preset from the registry. The caller holds r.mu.
+func (r *Registry) unregister(name string) {
+	delete(r.presets, name)
+	for i, registered := range r.order {
+		if registered == name {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// resolve merges the required presets and the definition into a policy. The caller holds r.mu.
+func (r *Registry) resolve(def PresetDefinition) (*registeredPreset, error) {
+	fields := make(map[string]any)
+	for _, name := range def.Requires {
+		required, ok := r.presets[name]
+		if !ok {
+			return nil, types.NewErrorWithDetails(types.ErrCodeInvalidInput, "required preset not registered", name)
+		}
+		merged, err := MergeFields(fields, required.fields)
+		if err != nil {
+			return nil, err
+		}
+		fields = merged
+	}
+
+	own := def.Fields
+	if def.Builder != nil {
+		policy := def.Builder()
+		if policy == nil {
+			return nil, types.NewError(types.ErrCodeInvalidInput, "preset builder returned nil")
+		}
+		var err error
+		if own, err = PolicyFields(policy); err != nil {
+			return nil, err
+		}
+	}
+	fields, err := MergeFields(fields, own)
+	if err != nil {
+		return nil, err
+	}
+	// name 和 version 由服务端管理
+	delete(fields, "name")
+	delete(fields, "version")
+
+	policy, err := PolicyFromFields(copyValue(fields).(map[string]any))
+	if err != nil {
+		return nil, err
+	}
+	if err := types.ValidatePolicy(policy); err != nil {
+		return nil, err
+	}
+
+	return &registeredPreset{
+		preset: &PolicyPreset{
+			Name:        def.Name,
+			DisplayName: def.DisplayName,
+			Description: def.Description,
+			Tags:        append([]string(nil), def.Tags...),
+			Version:     def.Version,
+			Requires:    append([]string(nil), def.Requires...),
+			Source:      def.Source,
+			Policy:      policy,
+		},
+		fields: fields,
+	}, nil
+}
+
+// Get returns a copy of the named preset, or nil if it is not registered.
+func (r *Registry) Get(name string) *PolicyPreset {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	registered, ok := r.presets[name]
+	if !ok {
+		return nil
+	}
+	return copyPreset(registered.preset)
+}
+
+// List returns copies of all presets in registration order; built-in presets come first.
+func (r *Registry) List() []*PolicyPreset {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*PolicyPreset, 0, len(r.order))
+	for _, name := range r.order {
+		result = append(result, copyPreset(r.presets[name].preset))
+	}
+	return result
+}
+
+// FindByTag returns the presets that have all of the given tags, ignoring case.
+func (r *Registry) FindByTag(tags ...string) []*PolicyPreset {
+	var result []*PolicyPreset
+	for _, preset := range r.List() {
+		if hasTags(preset, tags) {
+			result = append(result, preset)
+		}
+	}
+	return result
+}
+
+// CreatePolicy returns a cloned policy from the named preset and applies an optional customization function.
+func (r *Registry) CreatePolicy(name string, customize func(*androidmanagement.Policy) *androidmanagement.Policy) (*androidmanagement.Policy, error) {
+	preset := r.Get(name)
+	if preset == nil {
+		return nil, types.NewErrorWithDetails(types.ErrCodeNotFound, "unknown policy preset", name)
+	}
+
+	policy := preset.Policy
+	if customize != nil {
+		if customized := customize(clonePolicy(preset.Policy)); customized != nil {
+			policy = customized
+		}
+	}
+
+	return policy, nil
+}
+
+// Register adds a preset to the default registry.
+func Register(def PresetDefinition) error {
+	return defaultRegistry.Register(def)
+}
+
+// FindPresetsByTag returns the presets of the default registry that have all of the given tags.
+func FindPresetsByTag(tags ...string) []*PolicyPreset {
+	return defaultRegistry.FindByTag(tags...)
+}
+
+// hasTags reports whether the preset has all tags.
+func hasTags(preset *PolicyPreset, tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, presetTag := range preset.Tags {
+			found = found || strings.EqualFold(presetTag, tag)
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// copyPreset returns a copy of a preset that callers may modify.
+func copyPreset(p *PolicyPreset) *PolicyPreset {
+	clone := *p
+	clone.Tags = append([]string(nil), p.Tags...)
+	clone.Requires = append([]string(nil), p.Requires...)
+	clone.Policy = clonePolicy(p.Policy)
+	return &clone
+}
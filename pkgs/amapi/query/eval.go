@@ -0,0 +1,374 @@
+package query
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// Filter is a parsed filter expression.
+type Filter struct {
+	expr string
+	root node
+}
+
+// String returns the original expression.
+func (f *Filter) String() string {
+	return f.expr
+}
+
+// Match reports whether v matches the filter, evaluating relative times against the current time.
+// v is evaluated through its JSON representation, so any API resource can be matched.
+func (f *Filter) Match(v any) (bool, error) {
+	return f.MatchAt(v, time.Now())
+}
+
+// MatchAt is like Match but evaluates relative times such as -7d against now.
+func (f *Filter) MatchAt(v any, now time.Time) (bool, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return false, types.WrapError(err, types.ErrCodeInvalidInput, "failed to encode query input")
+	}
+
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return false, types.WrapError(err, types.ErrCodeInvalidInput, "failed to decode query input")
+	}
+
+	return f.root.eval(doc, now), nil
+}
+
+// ValidateFields checks that every field path of the filter exists in t,
+// following the JSON field names of structs, pointers, slices and maps.
+//
+// 用于在遍历所有分页之前发现拼写错误，例如 hardwareInfo.brnd：
+//
+//	err := filter.ValidateFields(reflect.TypeOf(androidmanagement.Device{}))
+func (f *Filter) ValidateFields(t reflect.Type) error {
+	var err error
+	f.root.walk(func(c *comparison) {
+		if _, ok := FieldType(t, c.field); err == nil && !ok {
+			err = types.NewErrorWithDetails(types.ErrCodeInvalidInput, "invalid query", "unknown field "+c.field)
+		}
+	})
+	return err
+}
+
+// FieldType returns the type of the dotted JSON field path in t, following the rules of ValidateFields.
+// Slices and pointers along the path are dereferenced; ok is false if the path does not exist.
+func FieldType(t reflect.Type, field string) (ft reflect.Type, ok bool) {
+	for _, name := range strings.Split(field, ".") {
+		t = elemType(t)
+		if t.Kind() == reflect.Map || t.Kind() == reflect.Interface {
+			return t, true
+		}
+		if t.Kind() != reflect.Struct {
+			return nil, false
+		}
+		sf, ok := jsonField(t, name)
+		if !ok {
+			return nil, false
+		}
+		t = sf.Type
+	}
+	return elemType(t), true
+}
+
+// elemType dereferences pointers, slices and arrays.
+func elemType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	return t
+}
+
+// Lookup returns the values of a dotted JSON field path in doc, a value decoded from JSON into any.
+// Arrays along the path contribute every element; a missing field returns no values.
+func Lookup(doc any, field string) []any {
+	return lookup(doc, strings.Split(field, "."))
+}
+
+// jsonField returns the field of struct type t with the given JSON name.
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tag == "-" {
+			continue
+		}
+		if tag == "" {
+			tag = field.Name
+		}
+		if tag == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// node is a node of the expression tree.
+type node interface {
+	eval(doc any, now time.Time) bool
+	walk(fn func(*comparison))
+}
+
+type andNode struct{ left, right node }
+
+func (n *andNode) eval(doc any, now time.Time) bool {
+	return n.left.eval(doc, now) && n.right.eval(doc, now)
+}
+
+func (n *andNode) walk(fn func(*comparison)) {
+	n.left.walk(fn)
+	n.right.walk(fn)
+}
+
+type orNode struct{ left, right node }
+
+func (n *orNode) eval(doc any, now time.Time) bool {
+	return n.left.eval(doc, now) || n.right.eval(doc, now)
+}
+
+func (n *orNode) walk(fn func(*comparison)) {
+	n.left.walk(fn)
+	n.right.walk(fn)
+}
+
+type notNode struct{ x node }
+
+func (n *notNode) eval(doc any, now time.Time) bool {
+	return !n.x.eval(doc, now)
+}
+
+func (n *notNode) walk(fn func(*comparison)) {
+	n.x.walk(fn)
+}
+
+// valueKind is the type of a literal value.
+type valueKind int
+
+const (
+	kindString valueKind = iota
+	kindNumber
+	kindBool
+	kindNull
+	kindTime
+)
+
+// value is a literal value of an expression.
+type value struct {
+	kind    valueKind
+	str     string
+	num     float64
+	boolean bool
+
+	// offset is the offset from the evaluation time for kindTime
+	offset time.Duration
+}
+
+// comparison is a "field op value" node.
+type comparison struct {
+	field   string
+	path    []string
+	op      string
+	values  []value
+	pattern *regexp.Regexp
+}
+
+func (c *comparison) walk(fn func(*comparison)) {
+	fn(c)
+}
+
+func (c *comparison) eval(doc any, now time.Time) bool {
+	fields := lookup(doc, c.path)
+	if len(fields) == 0 && c.values[0].kind == kindBool {
+		// API 的 JSON 表示省略值为 false 的布尔字段
+		fields = []any{false}
+	}
+
+	switch c.op {
+	case "!=":
+		return !c.equal(fields, c.values[0], now)
+	case "!~":
+		return !c.match(fields)
+	case "~":
+		return c.match(fields)
+	case "=":
+		return c.equal(fields, c.values[0], now)
+	case "IN":
+		for _, v := range c.values {
+			if c.equal(fields, v, now) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, field := range fields {
+		cmp, ok := compare(field, c.values[0], now)
+		if !ok {
+			continue
+		}
+		switch {
+		case c.op == "<" && cmp < 0,
+			c.op == "<=" && cmp <= 0,
+			c.op == ">" && cmp > 0,
+			c.op == ">=" && cmp >= 0:
+			return true
+		}
+	}
+	return false
+}
+
+// equal reports whether any of the field values equals v.
+func (c *comparison) equal(fields []any, v value, now time.Time) bool {
+	if v.kind == kindNull {
+		for _, field := range fields {
+			if field != nil {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, field := range fields {
+		if s, ok := field.(string); ok && v.kind == kindString && !isTime(v.str) && !isNumber(v.str) {
+			if strings.EqualFold(s, v.str) || (strings.Contains(s, "/") && !strings.Contains(v.str, "/") &&
+				strings.EqualFold(s[strings.LastIndex(s, "/")+1:], v.str)) {
+				return true
+			}
+			continue
+		}
+		if cmp, ok := compare(field, v, now); ok && cmp == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// match reports whether any string field value matches the pattern.
+func (c *comparison) match(fields []any) bool {
+	for _, field := range fields {
+		if s, ok := field.(string); ok && c.pattern.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the values at path, descending into every element of arrays.
+// Missing fields produce no values.
+func lookup(doc any, path []string) []any {
+	if len(path) == 0 {
+		if items, ok := doc.([]any); ok {
+			return items
+		}
+		return []any{doc}
+	}
+
+	switch v := doc.(type) {
+	case map[string]any:
+		child, ok := v[path[0]]
+		if !ok {
+			return nil
+		}
+		return lookup(child, path[1:])
+	case []any:
+		var values []any
+		for _, item := range v {
+			values = append(values, lookup(item, path)...)
+		}
+		return values
+	}
+	return nil
+}
+
+// compare compares a JSON field value with a literal.
+// ok is false if the two cannot be compared.
+func compare(field any, v value, now time.Time) (cmp int, ok bool) {
+	switch f := field.(type) {
+	case string:
+		switch v.kind {
+		case kindTime:
+			t, err := parseTime(f)
+			if err != nil {
+				return 0, false
+			}
+			return t.Compare(now.Add(v.offset)), true
+
+		case kindNumber:
+			n, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return 0, false
+			}
+			return compareFloat(n, v.num), true
+
+		case kindString:
+			if t, err := parseTime(v.str); err == nil {
+				if ft, err := parseTime(f); err == nil {
+					return ft.Compare(t), true
+				}
+			}
+			if n, err := strconv.ParseFloat(v.str, 64); err == nil {
+				if fn, err := strconv.ParseFloat(f, 64); err == nil {
+					return compareFloat(fn, n), true
+				}
+			}
+			return strings.Compare(f, v.str), true
+		}
+
+	case float64:
+		switch v.kind {
+		case kindNumber:
+			return compareFloat(f, v.num), true
+		case kindString:
+			n, err := strconv.ParseFloat(v.str, 64)
+			if err != nil {
+				return 0, false
+			}
+			return compareFloat(f, n), true
+		}
+
+	case bool:
+		if v.kind == kindBool && f == v.boolean {
+			return 0, true
+		}
+	}
+	return 0, false
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// parseTime parses an RFC 3339 timestamp or a date.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, s)
+}
+
+func isTime(s string) bool {
+	_, err := parseTime(s)
+	return err == nil
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
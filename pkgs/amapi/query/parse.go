@@ -0,0 +1,383 @@
+// Package query implements the filter expressions used by DeviceService.Query.
+//
+// 表达式在客户端针对资源的 JSON 表示求值，字段路径使用 API 的 JSON 字段名（camelCase），
+// 例如：
+//
+//	appliedPolicyName = 'kiosk' AND hardwareInfo.brand = 'samsung' AND lastStatusReportTime < -7d
+//	state IN ('ACTIVE', 'DISABLED') AND NOT policyCompliant = true
+//	softwareInfo.androidVersion >= 13 OR applicationReports.packageName = 'com.example.app'
+//	enrollmentTokenName = null OR hardwareInfo.model ~ '(?i)^pixel'
+//
+// # 语法
+//
+//   - 逻辑运算：AND、OR、NOT 和括号，优先级 NOT > AND > OR；关键字不区分大小写
+//   - 比较运算：=、!=、<、<=、>、>=、~（正则匹配）、!~、IN (v1, v2, ...)
+//   - 值：'字符串' 或 "字符串"、数字、true、false、null、now，以及相对时间
+//     -7d、-12h、-30m、-90s、-2w（相对于求值时刻，+ 表示未来）
+//
+// # 求值规则
+//
+//   - 字符串的 = 和 IN 不区分大小写；资源名称字段（包含 "/"）可以只写最后一段 ID，
+//     例如 appliedPolicyName = 'kiosk' 匹配 enterprises/LC00abc/policies/kiosk
+//   - 字段和值都能解析为时间（RFC 3339 或 2006-01-02）时按时间比较；
+//     都能解析为数字时按数字比较（int64 字段在 JSON 中是字符串）
+//   - 路径经过数组时，任一元素满足条件即为真；!= 和 !~ 是 = 和 ~ 的取反
+//   - 缺失的字段等于 null，大小比较和正则匹配都为假；与 true/false 比较时缺失的字段视为 false，
+//     因为 API 在 JSON 中省略值为 false 的布尔字段
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// tokenKind is the kind of a lexical token.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenDuration
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+// token is a lexical token and its position in the expression.
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// lex splits expr into tokens.
+func lex(expr string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expr)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{tokenComma, ",", i})
+			i++
+
+		case r == '\'' || r == '"':
+			start := i
+			var sb strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, syntaxError(start, "unterminated string")
+			}
+			i++
+			tokens = append(tokens, token{tokenString, sb.String(), start})
+
+		case strings.ContainsRune("=!<>~", r):
+			start := i
+			op := string(r)
+			if i+1 < len(runes) && (runes[i+1] == '=' || (r == '!' && runes[i+1] == '~')) {
+				op += string(runes[i+1])
+			}
+			i += len([]rune(op))
+			switch op {
+			case "=", "!=", "<", "<=", ">", ">=", "~", "!~":
+			default:
+				return nil, syntaxError(start, "unknown operator %q", op)
+			}
+			tokens = append(tokens, token{tokenOperator, op, start})
+
+		case unicode.IsDigit(r) || ((r == '-' || r == '+') && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			kind := tokenNumber
+			if i < len(runes) && strings.ContainsRune("smhdw", runes[i]) {
+				kind = tokenDuration
+				i++
+			}
+			if i < len(runes) && isIdentRune(runes[i]) {
+				return nil, syntaxError(start, "invalid number %q", string(runes[start:i+1]))
+			}
+			tokens = append(tokens, token{kind, string(runes[start:i]), start})
+
+		case isIdentRune(r):
+			start := i
+			for i < len(runes) && (isIdentRune(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokenIdent, string(runes[start:i]), start})
+
+		default:
+			return nil, syntaxError(i, "unexpected character %q", r)
+		}
+	}
+
+	return append(tokens, token{tokenEOF, "", len(runes)}), nil
+}
+
+// isIdentRune reports whether r can start an identifier.
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || r == '_'
+}
+
+// parser is a recursive descent parser over the tokens of an expression.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// Parse parses a filter expression.
+// Syntax errors are returned as ErrCodeInvalidInput errors with the position in the details.
+func Parse(expr string) (*Filter, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, types.NewError(types.ErrCodeInvalidInput, "query expression is required")
+	}
+
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, syntaxError(t.pos, "unexpected %q", t.text)
+	}
+
+	return &Filter{expr: expr, root: root}, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// keyword reports whether the next token is the given keyword and consumes it if so.
+func (p *parser) keyword(word string) bool {
+	if t := p.peek(); t.kind == tokenIdent && strings.EqualFold(t.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.keyword("NOT") {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{x}, nil
+	}
+
+	if p.peek().kind == tokenLParen {
+		p.next()
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokenRParen {
+			return nil, syntaxError(t.pos, "expected \")\"")
+		}
+		return x, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	field := p.next()
+	if field.kind != tokenIdent || isKeyword(field.text) {
+		return nil, syntaxError(field.pos, "expected field name, got %q", field.text)
+	}
+	path := strings.Split(field.text, ".")
+	for _, segment := range path {
+		if segment == "" {
+			return nil, syntaxError(field.pos, "invalid field name %q", field.text)
+		}
+	}
+
+	if p.keyword("IN") {
+		if t := p.next(); t.kind != tokenLParen {
+			return nil, syntaxError(t.pos, "expected \"(\" after IN")
+		}
+		var values []value
+		for {
+			v, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+
+			t := p.next()
+			if t.kind == tokenRParen {
+				break
+			}
+			if t.kind != tokenComma {
+				return nil, syntaxError(t.pos, "expected \",\" or \")\" in IN list")
+			}
+		}
+		return &comparison{field: field.text, path: path, op: "IN", values: values}, nil
+	}
+
+	op := p.next()
+	if op.kind != tokenOperator {
+		return nil, syntaxError(op.pos, "expected operator after %q", field.text)
+	}
+
+	v, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &comparison{field: field.text, path: path, op: op.text, values: []value{v}}
+	if op.text == "~" || op.text == "!~" {
+		if v.kind != kindString {
+			return nil, syntaxError(op.pos, "%s requires a string pattern", op.text)
+		}
+		if c.pattern, err = regexp.Compile(v.str); err != nil {
+			return nil, syntaxError(op.pos, "invalid pattern: %v", err)
+		}
+	}
+	if v.kind == kindNull && op.text != "=" && op.text != "!=" {
+		return nil, syntaxError(op.pos, "null can only be compared with = and !=")
+	}
+
+	return c, nil
+}
+
+func (p *parser) parseValue() (value, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return value{kind: kindString, str: t.text}, nil
+
+	case tokenNumber:
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return value{}, syntaxError(t.pos, "invalid number %q", t.text)
+		}
+		return value{kind: kindNumber, num: n, str: t.text}, nil
+
+	case tokenDuration:
+		d, err := parseRelativeDuration(t.text)
+		if err != nil {
+			return value{}, syntaxError(t.pos, "%v", err)
+		}
+		return value{kind: kindTime, offset: d}, nil
+
+	case tokenIdent:
+		switch strings.ToLower(t.text) {
+		case "true", "false":
+			return value{kind: kindBool, boolean: strings.EqualFold(t.text, "true")}, nil
+		case "null":
+			return value{kind: kindNull}, nil
+		case "now":
+			return value{kind: kindTime}, nil
+		}
+	}
+
+	if t.kind == tokenEOF {
+		return value{}, syntaxError(t.pos, "expected value at end of expression")
+	}
+	return value{}, syntaxError(t.pos, "expected value, got %q (quote strings with ')", t.text)
+}
+
+// parseRelativeDuration parses durations such as "-7d", "12h" or "+2w".
+func parseRelativeDuration(text string) (time.Duration, error) {
+	units := map[byte]time.Duration{
+		's': time.Second,
+		'm': time.Minute,
+		'h': time.Hour,
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+	}
+
+	unit := units[text[len(text)-1]]
+	n, err := strconv.ParseFloat(text[:len(text)-1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid relative time %q", text)
+	}
+	return time.Duration(n * float64(unit)), nil
+}
+
+// isKeyword reports whether word is a reserved keyword.
+func isKeyword(word string) bool {
+	switch strings.ToUpper(word) {
+	case "AND", "OR", "NOT", "IN":
+		return true
+	}
+	return false
+}
+
+// syntaxError returns an ErrCodeInvalidInput error for a problem at pos.
+func syntaxError(pos int, format string, args ...any) error {
+	return types.NewErrorWithDetails(types.ErrCodeInvalidInput, "invalid query",
+		fmt.Sprintf("position %d: %s", pos+1, fmt.Sprintf(format, args...)))
+}
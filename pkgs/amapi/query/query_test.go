@@ -0,0 +1,141 @@
+package query
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/api/androidmanagement/v1"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// 测试表达式在设备上的求值结果
+func TestFilterMatch(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	device := &androidmanagement.Device{
+		Name:                 "enterprises/LC00abc/devices/d1",
+		AppliedPolicyName:    "enterprises/LC00abc/policies/kiosk",
+		State:                "ACTIVE",
+		PolicyCompliant:      false,
+		LastStatusReportTime: now.Add(-10 * 24 * time.Hour).Format(time.RFC3339),
+		HardwareInfo:         &androidmanagement.HardwareInfo{Brand: "samsung", Model: "SM-T500"},
+		SoftwareInfo:         &androidmanagement.SoftwareInfo{AndroidVersion: "13"},
+		MemoryInfo:           &androidmanagement.MemoryInfo{TotalRam: 4 << 30},
+		ApplicationReports: []*androidmanagement.ApplicationReport{
+			{PackageName: "com.example.mail", VersionCode: 42},
+			{PackageName: "com.example.chat", VersionCode: 7},
+		},
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"appliedPolicyName = 'kiosk' AND hardwareInfo.brand = 'samsung' AND lastStatusReportTime < -7d", true},
+		{"appliedPolicyName = 'enterprises/LC00abc/policies/kiosk'", true},
+		{"appliedPolicyName = 'default'", false},
+		{"lastStatusReportTime < -14d", false},
+		{"lastStatusReportTime >= '2025-06-01'", true},
+		{"lastStatusReportTime < now", true},
+		{"state = 'active'", true},
+		{"state IN ('DISABLED', 'DELETED')", false},
+		{"state in ('DISABLED', 'ACTIVE')", true},
+		{"policyCompliant = false", true},
+		{"NOT policyCompliant = true", true},
+		{"policyCompliant != false", false},
+		{"softwareInfo.androidVersion >= 13", true},
+		{"softwareInfo.androidVersion > 13", false},
+		{"memoryInfo.totalRam > 2000000000", true},
+		{"applicationReports.packageName = 'com.example.chat'", true},
+		{"applicationReports.packageName != 'com.example.chat'", false},
+		{"applicationReports.versionCode > 40", true},
+		{"hardwareInfo.model ~ '^SM-'", true},
+		{"hardwareInfo.model !~ '(?i)^pixel'", true},
+		{"enrollmentTokenName = null", true},
+		{"hardwareInfo.serialNumber != null", false},
+		{"lastPolicySyncTime < -1d", false},
+		{"state = 'DISABLED' OR (hardwareInfo.brand = \"samsung\" AND NOT state = 'DELETED')", true},
+		{"state = 'DISABLED' OR hardwareInfo.brand = 'google' AND state = 'ACTIVE'", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			filter, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			got, err := filter.MatchAt(device, now)
+			if err != nil {
+				t.Fatalf("MatchAt() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("MatchAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// 测试语法错误返回带位置的 ErrCodeInvalidInput 错误
+func TestParseErrors(t *testing.T) {
+	invalid := []string{
+		"",
+		"state",
+		"state =",
+		"state = ACTIVE",
+		"state = 'ACTIVE",
+		"state == 'ACTIVE'",
+		"(state = 'ACTIVE'",
+		"state = 'ACTIVE')",
+		"state = 'ACTIVE' AND",
+		"state IN 'ACTIVE'",
+		"state IN ('ACTIVE' 'DISABLED')",
+		"hardwareInfo.model ~ '['",
+		"hardwareInfo.model ~ 5",
+		"enrollmentTokenName < null",
+		"lastStatusReportTime < -7days",
+		"hardwareInfo..brand = 'x'",
+		"AND = 'x'",
+		"state = 'ACTIVE' # comment",
+	}
+
+	for _, expr := range invalid {
+		_, err := Parse(expr)
+		var apiErr *types.Error
+		if !errors.As(err, &apiErr) || apiErr.Code != types.ErrCodeInvalidInput {
+			t.Errorf("Parse(%q) error = %v, want invalid input", expr, err)
+		}
+	}
+}
+
+// 测试字段路径校验
+func TestValidateFields(t *testing.T) {
+	deviceType := reflect.TypeOf(androidmanagement.Device{})
+
+	valid := []string{
+		"hardwareInfo.brand = 'samsung'",
+		"applicationReports.packageName = 'com.example.app'",
+		"nonComplianceDetails.settingName = 'applications' OR NOT state = 'ACTIVE'",
+		"policyCompliant = true",
+	}
+	for _, expr := range valid {
+		filter, err := Parse(expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) unexpected error: %v", expr, err)
+		}
+		if err := filter.ValidateFields(deviceType); err != nil {
+			t.Errorf("ValidateFields(%q) unexpected error: %v", expr, err)
+		}
+	}
+
+	for _, expr := range []string{"hardwareInfo.brnd = 'samsung'", "state = 'ACTIVE' AND colour = 'red'", "state.value = 'x'"} {
+		filter, err := Parse(expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) unexpected error: %v", expr, err)
+		}
+		if err := filter.ValidateFields(deviceType); err == nil {
+			t.Errorf("ValidateFields(%q) expected error", expr)
+		}
+	}
+}
@@ -0,0 +1,489 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"google.golang.org/api/androidmanagement/v1"
+)
+
+// DeviceCommand is the request body of enterprises.devices.issueCommand and the
+// metadata of the operation it creates.
+//
+// 字段与 androidmanagement.Command 一一对应，并补充了当前依赖的生成客户端尚未包含的
+// 参数和状态（REQUEST_DEVICE_INFO、WIPE、ADD_ESIM、REMOVE_ESIM）。请优先使用
+// New*Command 构造函数创建命令，它们会在发送之前校验参数。
+type DeviceCommand struct {
+	// Type is the command type, e.g. "LOCK"
+	Type string `json:"type,omitempty"`
+
+	// Duration is how long the command stays valid, e.g. "600s". Default: ten minutes
+	Duration string `json:"duration,omitempty"`
+
+	// CreateTime is set by the server
+	CreateTime string `json:"createTime,omitempty"`
+
+	// UserName is set by the server to the user owning the device
+	UserName string `json:"userName,omitempty"`
+
+	// ErrorCode is set by the server when the command failed
+	ErrorCode string `json:"errorCode,omitempty"`
+
+	// NewPassword is the new password of a RESET_PASSWORD command; empty removes the password
+	NewPassword string `json:"newPassword,omitempty"`
+
+	// ResetPasswordFlags are the flags of a RESET_PASSWORD command
+	ResetPasswordFlags []string `json:"resetPasswordFlags,omitempty"`
+
+	ClearAppsDataParams *androidmanagement.ClearAppsDataParams `json:"clearAppsDataParams,omitempty"`
+	ClearAppsDataStatus *androidmanagement.ClearAppsDataStatus `json:"clearAppsDataStatus,omitempty"`
+
+	StartLostModeParams *androidmanagement.StartLostModeParams `json:"startLostModeParams,omitempty"`
+	StartLostModeStatus *androidmanagement.StartLostModeStatus `json:"startLostModeStatus,omitempty"`
+
+	StopLostModeParams *androidmanagement.StopLostModeParams `json:"stopLostModeParams,omitempty"`
+	StopLostModeStatus *androidmanagement.StopLostModeStatus `json:"stopLostModeStatus,omitempty"`
+
+	RequestDeviceInfoParams *RequestDeviceInfoParams `json:"requestDeviceInfoParams,omitempty"`
+	RequestDeviceInfoStatus *RequestDeviceInfoStatus `json:"requestDeviceInfoStatus,omitempty"`
+
+	WipeParams *WipeParams `json:"wipeParams,omitempty"`
+
+	AddEsimParams    *AddEsimParams     `json:"addEsimParams,omitempty"`
+	RemoveEsimParams *RemoveEsimParams  `json:"removeEsimParams,omitempty"`
+	EsimStatus       *EsimCommandStatus `json:"esimStatus,omitempty"`
+}
+
+// ResetPasswordFlag is a flag of the RESET_PASSWORD command.
+type ResetPasswordFlag string
+
+const (
+	// ResetPasswordFlagRequireEntry prevents other admins from changing the password until the user has entered it.
+	ResetPasswordFlagRequireEntry ResetPasswordFlag = "REQUIRE_ENTRY"
+
+	// ResetPasswordFlagDoNotAskCredentialsOnBoot skips asking for credentials on device boot.
+	ResetPasswordFlagDoNotAskCredentialsOnBoot ResetPasswordFlag = "DO_NOT_ASK_CREDENTIALS_ON_BOOT"
+
+	// ResetPasswordFlagLockNow locks the device after the password is reset.
+	ResetPasswordFlagLockNow ResetPasswordFlag = "LOCK_NOW"
+)
+
+// WipeDataFlag is a flag of the WIPE command.
+type WipeDataFlag string
+
+const (
+	// WipeDataFlagPreserveResetProtectionData keeps factory reset protection data on the device.
+	WipeDataFlagPreserveResetProtectionData WipeDataFlag = "PRESERVE_RESET_PROTECTION_DATA"
+
+	// WipeDataFlagWipeExternalStorage also wipes the external storage.
+	WipeDataFlagWipeExternalStorage WipeDataFlag = "WIPE_EXTERNAL_STORAGE"
+
+	// WipeDataFlagWipeEsims also removes the eSIMs of the device.
+	WipeDataFlagWipeEsims WipeDataFlag = "WIPE_ESIMS"
+)
+
+// DeviceInfoType is the information requested by a REQUEST_DEVICE_INFO command.
+type DeviceInfoType string
+
+const (
+	// DeviceInfoEID requests the eSIM identifiers of the device.
+	DeviceInfoEID DeviceInfoType = "EID"
+)
+
+// EsimActivationState is the activation state of an eSIM added by ADD_ESIM.
+type EsimActivationState string
+
+const (
+	EsimActivated    EsimActivationState = "ACTIVATED"
+	EsimNotActivated EsimActivationState = "NOT_ACTIVATED"
+)
+
+// RequestDeviceInfoParams are the parameters of the REQUEST_DEVICE_INFO command.
+type RequestDeviceInfoParams struct {
+	DeviceInfo string `json:"deviceInfo,omitempty"`
+}
+
+// RequestDeviceInfoStatus is the result of the REQUEST_DEVICE_INFO command.
+type RequestDeviceInfoStatus struct {
+	// Status is e.g. "SUCCEEDED", "PENDING" or "UNSUPPORTED"
+	Status  string   `json:"status,omitempty"`
+	EidInfo *EidInfo `json:"eidInfo,omitempty"`
+}
+
+// EidInfo lists the EIDs reported by a device.
+type EidInfo struct {
+	Eids []*Eid `json:"eids,omitempty"`
+}
+
+// Eid is the identifier of an eSIM chip.
+type Eid struct {
+	Eid string `json:"eid,omitempty"`
+}
+
+// WipeParams are the parameters of the WIPE command.
+type WipeParams struct {
+	WipeDataFlags []string                             `json:"wipeDataFlags,omitempty"`
+	WipeReason    *androidmanagement.UserFacingMessage `json:"wipeReason,omitempty"`
+}
+
+// AddEsimParams are the parameters of the ADD_ESIM command.
+type AddEsimParams struct {
+	ActivationCode  string `json:"activationCode,omitempty"`
+	ActivationState string `json:"activationState,omitempty"`
+}
+
+// RemoveEsimParams are the parameters of the REMOVE_ESIM command.
+type RemoveEsimParams struct {
+	IccID string `json:"iccId,omitempty"`
+}
+
+// EsimCommandStatus is the result of the ADD_ESIM and REMOVE_ESIM commands.
+type EsimCommandStatus struct {
+	Status   string    `json:"status,omitempty"`
+	EsimInfo *EsimInfo `json:"esimInfo,omitempty"`
+
+	// InternalErrorDetails holds the raw error details reported by the device
+	InternalErrorDetails map[string]any `json:"internalErrorDetails,omitempty"`
+}
+
+// EsimInfo identifies an eSIM.
+type EsimInfo struct {
+	IccID string `json:"iccId,omitempty"`
+}
+
+// LostModeOptions configures the START_LOST_MODE command.
+//
+// 至少需要提供 Message、PhoneNumber 或 EmailAddress 之一，以便拾到设备的人联系组织。
+type LostModeOptions struct {
+	Message      string
+	PhoneNumber  string
+	EmailAddress string
+	Address      string
+	Organization string
+}
+
+// WipeOptions configures the WIPE command.
+type WipeOptions struct {
+	// Flags controls which data is wiped in addition to the user data
+	Flags []WipeDataFlag
+
+	// Reason is shown to the user; on personally owned devices it is shown in a notification
+	Reason string
+}
+
+var (
+	// commandDurationPattern matches the protobuf duration format used by Command.Duration, e.g. "600s" or "1.5s".
+	commandDurationPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]{1,9})?s$`)
+
+	// packageNamePattern matches Android application IDs, e.g. "com.example.app".
+	packageNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*(\.[A-Za-z][A-Za-z0-9_]*)+$`)
+
+	// phoneNumberPattern allows an optional leading "+" followed by digits and common separators.
+	phoneNumberPattern = regexp.MustCompile(`^\+?[0-9][0-9 ()\-.]{2,30}$`)
+
+	// emailPattern is a deliberately loose check for obviously malformed addresses.
+	emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+	// iccidPattern matches ICCIDs of 18 to 22 digits.
+	iccidPattern = regexp.MustCompile(`^[0-9]{18,22}$`)
+)
+
+// NewLockCommand creates a LOCK command. duration is optional, e.g. "600s".
+func NewLockCommand(duration string) (*DeviceCommand, error) {
+	return newCommand(&DeviceCommand{Type: string(CommandTypeLock), Duration: duration})
+}
+
+// NewRebootCommand creates a REBOOT command.
+func NewRebootCommand() (*DeviceCommand, error) {
+	return newCommand(&DeviceCommand{Type: string(CommandTypeReboot)})
+}
+
+// NewRelinquishOwnershipCommand creates a RELINQUISH_OWNERSHIP command.
+func NewRelinquishOwnershipCommand() (*DeviceCommand, error) {
+	return newCommand(&DeviceCommand{Type: string(CommandTypeRelinquishOwnership)})
+}
+
+// NewResetPasswordCommand creates a RESET_PASSWORD command.
+//
+// newPassword 为空时移除设备密码。Android 14 设备要求纯数字密码至少 6 位，
+// 因此纯数字密码少于 6 位会被拒绝，其他密码至少 4 个字符。
+func NewResetPasswordCommand(newPassword string, flags ...ResetPasswordFlag) (*DeviceCommand, error) {
+	command := &DeviceCommand{Type: string(CommandTypeResetPassword), NewPassword: newPassword}
+	for _, flag := range flags {
+		command.ResetPasswordFlags = append(command.ResetPasswordFlags, string(flag))
+	}
+	return newCommand(command)
+}
+
+// NewClearAppDataCommand creates a CLEAR_APP_DATA command for the given packages.
+func NewClearAppDataCommand(packageNames ...string) (*DeviceCommand, error) {
+	return newCommand(&DeviceCommand{
+		Type:                string(CommandTypeClearAppData),
+		ClearAppsDataParams: &androidmanagement.ClearAppsDataParams{PackageNames: packageNames},
+	})
+}
+
+// NewStartLostModeCommand creates a START_LOST_MODE command.
+func NewStartLostModeCommand(opts LostModeOptions) (*DeviceCommand, error) {
+	return newCommand(&DeviceCommand{
+		Type: string(CommandTypeStartLostMode),
+		StartLostModeParams: &androidmanagement.StartLostModeParams{
+			LostMessage:       userFacingMessage(opts.Message),
+			LostPhoneNumber:   userFacingMessage(opts.PhoneNumber),
+			LostEmailAddress:  strings.TrimSpace(opts.EmailAddress),
+			LostStreetAddress: userFacingMessage(opts.Address),
+			LostOrganization:  userFacingMessage(opts.Organization),
+		},
+	})
+}
+
+// NewStopLostModeCommand creates a STOP_LOST_MODE command.
+func NewStopLostModeCommand() (*DeviceCommand, error) {
+	return newCommand(&DeviceCommand{
+		Type:               string(CommandTypeStopLostMode),
+		StopLostModeParams: &androidmanagement.StopLostModeParams{},
+	})
+}
+
+// NewRequestDeviceInfoCommand creates a REQUEST_DEVICE_INFO command.
+// The result is reported in DeviceCommand.RequestDeviceInfoStatus of the finished operation.
+func NewRequestDeviceInfoCommand(info DeviceInfoType) (*DeviceCommand, error) {
+	return newCommand(&DeviceCommand{
+		Type:                    string(CommandTypeRequestDeviceInfo),
+		RequestDeviceInfoParams: &RequestDeviceInfoParams{DeviceInfo: string(info)},
+	})
+}
+
+// NewWipeCommand creates a WIPE command, a factory reset with data flags and a reason.
+func NewWipeCommand(opts WipeOptions) (*DeviceCommand, error) {
+	params := &WipeParams{WipeReason: userFacingMessage(opts.Reason)}
+	for _, flag := range opts.Flags {
+		params.WipeDataFlags = append(params.WipeDataFlags, string(flag))
+	}
+	return newCommand(&DeviceCommand{Type: string(CommandTypeWipe), WipeParams: params})
+}
+
+// NewAddEsimCommand creates an ADD_ESIM command.
+// An empty activationState lets the device decide whether to activate the eSIM.
+func NewAddEsimCommand(activationCode string, activationState EsimActivationState) (*DeviceCommand, error) {
+	return newCommand(&DeviceCommand{
+		Type: string(CommandTypeAddEsim),
+		AddEsimParams: &AddEsimParams{
+			ActivationCode:  strings.TrimSpace(activationCode),
+			ActivationState: string(activationState),
+		},
+	})
+}
+
+// NewRemoveEsimCommand creates a REMOVE_ESIM command for the eSIM with the given ICCID.
+func NewRemoveEsimCommand(iccID string) (*DeviceCommand, error) {
+	return newCommand(&DeviceCommand{
+		Type:             string(CommandTypeRemoveEsim),
+		RemoveEsimParams: &RemoveEsimParams{IccID: strings.TrimSpace(iccID)},
+	})
+}
+
+// APICommand converts the command to the request body of the generated client.
+// ok is false when the command has parameters the generated client cannot send.
+//
+// 当前依赖的生成客户端不包含 REQUEST_DEVICE_INFO、WIPE、ADD_ESIM、REMOVE_ESIM 的参数；
+// 不带这些参数的命令（包括没有 WipeParams 的 WIPE）都可以转换。APICommand 不校验命令，
+// 调用方应先调用 Validate。
+func (c *DeviceCommand) APICommand() (command *androidmanagement.Command, ok bool) {
+	if c.RequestDeviceInfoParams != nil || c.WipeParams != nil || c.AddEsimParams != nil || c.RemoveEsimParams != nil {
+		return nil, false
+	}
+
+	return &androidmanagement.Command{
+		Type:                c.Type,
+		Duration:            c.Duration,
+		NewPassword:         c.NewPassword,
+		ResetPasswordFlags:  c.ResetPasswordFlags,
+		ClearAppsDataParams: c.ClearAppsDataParams,
+		StartLostModeParams: c.StartLostModeParams,
+		StopLostModeParams:  c.StopLostModeParams,
+	}, true
+}
+
+// newCommand validates command and returns it.
+func newCommand(command *DeviceCommand) (*DeviceCommand, error) {
+	if err := command.Validate(); err != nil {
+		return nil, err
+	}
+	return command, nil
+}
+
+// userFacingMessage returns a message with the given default text, or nil if text is blank.
+func userFacingMessage(text string) *androidmanagement.UserFacingMessage {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	return &androidmanagement.UserFacingMessage{DefaultMessage: text}
+}
+
+// Validate checks that the command has a known type and valid parameters for it.
+//
+// 参数必须与命令类型匹配，例如 LOCK 命令不能携带 WipeParams。
+// IssueDeviceCommand 在发送前会调用 Validate，因此手动构造的命令同样会被校验。
+func (c *DeviceCommand) Validate() error {
+	if c == nil {
+		return NewError(ErrCodeInvalidInput, "command is required")
+	}
+	if c.Type == "" {
+		return NewError(ErrCodeInvalidInput, "command type is required")
+	}
+	if c.Duration != "" && !commandDurationPattern.MatchString(c.Duration) {
+		return invalidCommand(c.Type, "duration must be in seconds, e.g. \"600s\", got %q", c.Duration)
+	}
+
+	if err := c.validateParamsMatchType(); err != nil {
+		return err
+	}
+
+	switch CommandType(c.Type) {
+	case CommandTypeLock, CommandTypeReboot, CommandTypeReset, CommandTypeRemovePassword,
+		CommandTypeRelinquishOwnership, CommandTypeStopLostMode:
+		return nil
+	case CommandTypeResetPassword:
+		return c.validateResetPassword()
+	case CommandTypeClearAppData:
+		return c.validateClearAppData()
+	case CommandTypeStartLostMode:
+		return c.validateStartLostMode()
+	case CommandTypeRequestDeviceInfo:
+		if c.RequestDeviceInfoParams == nil || c.RequestDeviceInfoParams.DeviceInfo != string(DeviceInfoEID) {
+			return invalidCommand(c.Type, "device info must be %s", DeviceInfoEID)
+		}
+		return nil
+	case CommandTypeWipe:
+		return c.validateWipe()
+	case CommandTypeAddEsim:
+		return c.validateAddEsim()
+	case CommandTypeRemoveEsim:
+		if c.RemoveEsimParams == nil || !iccidPattern.MatchString(c.RemoveEsimParams.IccID) {
+			return invalidCommand(c.Type, "ICCID must be 18 to 22 digits")
+		}
+		return nil
+	default:
+		return NewErrorWithDetails(ErrCodeInvalidInput, "unsupported command type", c.Type)
+	}
+}
+
+// validateParamsMatchType rejects parameters that belong to a different command type.
+func (c *DeviceCommand) validateParamsMatchType() error {
+	params := []struct {
+		set         bool
+		commandType CommandType
+		field       string
+	}{
+		{c.NewPassword != "" || len(c.ResetPasswordFlags) > 0, CommandTypeResetPassword, "newPassword/resetPasswordFlags"},
+		{c.ClearAppsDataParams != nil, CommandTypeClearAppData, "clearAppsDataParams"},
+		{c.StartLostModeParams != nil, CommandTypeStartLostMode, "startLostModeParams"},
+		{c.StopLostModeParams != nil, CommandTypeStopLostMode, "stopLostModeParams"},
+		{c.RequestDeviceInfoParams != nil, CommandTypeRequestDeviceInfo, "requestDeviceInfoParams"},
+		{c.WipeParams != nil, CommandTypeWipe, "wipeParams"},
+		{c.AddEsimParams != nil, CommandTypeAddEsim, "addEsimParams"},
+		{c.RemoveEsimParams != nil, CommandTypeRemoveEsim, "removeEsimParams"},
+	}
+
+	for _, p := range params {
+		if p.set && CommandType(c.Type) != p.commandType {
+			return invalidCommand(c.Type, "%s can only be used with %s commands", p.field, p.commandType)
+		}
+	}
+	return nil
+}
+
+func (c *DeviceCommand) validateResetPassword() error {
+	if password := c.NewPassword; password != "" {
+		if strings.Trim(password, "0123456789") == "" && len(password) < 6 {
+			return invalidCommand(c.Type, "numeric passwords must have at least 6 digits")
+		}
+		if len([]rune(password)) < 4 {
+			return invalidCommand(c.Type, "password must have at least 4 characters")
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, flag := range c.ResetPasswordFlags {
+		switch ResetPasswordFlag(flag) {
+		case ResetPasswordFlagRequireEntry, ResetPasswordFlagDoNotAskCredentialsOnBoot, ResetPasswordFlagLockNow:
+		default:
+			return invalidCommand(c.Type, "unknown reset password flag %q", flag)
+		}
+		if seen[flag] {
+			return invalidCommand(c.Type, "duplicate reset password flag %q", flag)
+		}
+		seen[flag] = true
+	}
+	return nil
+}
+
+func (c *DeviceCommand) validateClearAppData() error {
+	if c.ClearAppsDataParams == nil || len(c.ClearAppsDataParams.PackageNames) == 0 {
+		return invalidCommand(c.Type, "at least one package name is required")
+	}
+	for _, packageName := range c.ClearAppsDataParams.PackageNames {
+		if !packageNamePattern.MatchString(packageName) {
+			return invalidCommand(c.Type, "invalid package name %q", packageName)
+		}
+	}
+	return nil
+}
+
+func (c *DeviceCommand) validateStartLostMode() error {
+	params := c.StartLostModeParams
+	if params == nil || (params.LostMessage == nil && params.LostPhoneNumber == nil && params.LostEmailAddress == "") {
+		return invalidCommand(c.Type, "a message, phone number or email address is required")
+	}
+	if params.LostPhoneNumber != nil && !phoneNumberPattern.MatchString(params.LostPhoneNumber.DefaultMessage) {
+		return invalidCommand(c.Type, "invalid phone number %q", params.LostPhoneNumber.DefaultMessage)
+	}
+	if params.LostEmailAddress != "" && !emailPattern.MatchString(params.LostEmailAddress) {
+		return invalidCommand(c.Type, "invalid email address %q", params.LostEmailAddress)
+	}
+	return nil
+}
+
+func (c *DeviceCommand) validateWipe() error {
+	if c.WipeParams == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for _, flag := range c.WipeParams.WipeDataFlags {
+		switch WipeDataFlag(flag) {
+		case WipeDataFlagPreserveResetProtectionData, WipeDataFlagWipeExternalStorage, WipeDataFlagWipeEsims:
+		default:
+			return invalidCommand(c.Type, "unknown wipe data flag %q", flag)
+		}
+		if seen[flag] {
+			return invalidCommand(c.Type, "duplicate wipe data flag %q", flag)
+		}
+		seen[flag] = true
+	}
+	if reason := c.WipeParams.WipeReason; reason != nil && len([]rune(reason.DefaultMessage)) > 200 {
+		return invalidCommand(c.Type, "wipe reason must not exceed 200 characters")
+	}
+	return nil
+}
+
+func (c *DeviceCommand) validateAddEsim() error {
+	params := c.AddEsimParams
+	if params == nil || params.ActivationCode == "" {
+		return invalidCommand(c.Type, "activation code is required")
+	}
+	switch EsimActivationState(params.ActivationState) {
+	case "", EsimActivated, EsimNotActivated:
+	default:
+		return invalidCommand(c.Type, "unknown activation state %q", params.ActivationState)
+	}
+	return nil
+}
+
+// invalidCommand returns an ErrCodeInvalidInput error for a command of the given type.
+func invalidCommand(commandType, format string, args ...any) *Error {
+	return NewErrorWithDetails(ErrCodeInvalidInput, "invalid "+commandType+" command", fmt.Sprintf(format, args...))
+}
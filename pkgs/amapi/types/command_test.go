@@ -0,0 +1,141 @@
+package types
+
+import (
+	"strings"
+	"testing"
+)
+
+// 测试命令构造函数的参数校验
+func TestCommandBuilders(t *testing.T) {
+	tests := []struct {
+		name    string
+		build   func() (*DeviceCommand, error)
+		wantErr bool
+	}{
+		{"lock without duration", func() (*DeviceCommand, error) { return NewLockCommand("") }, false},
+		{"lock with duration", func() (*DeviceCommand, error) { return NewLockCommand("600s") }, false},
+		{"lock with invalid duration", func() (*DeviceCommand, error) { return NewLockCommand("10m") }, true},
+
+		{"remove password", func() (*DeviceCommand, error) { return NewResetPasswordCommand("") }, false},
+		{"numeric password", func() (*DeviceCommand, error) { return NewResetPasswordCommand("123456") }, false},
+		{"short numeric password", func() (*DeviceCommand, error) { return NewResetPasswordCommand("12345") }, true},
+		{"short password", func() (*DeviceCommand, error) { return NewResetPasswordCommand("abc") }, true},
+		{"password flags", func() (*DeviceCommand, error) {
+			return NewResetPasswordCommand("abcd", ResetPasswordFlagLockNow, ResetPasswordFlagRequireEntry)
+		}, false},
+		{"unknown password flag", func() (*DeviceCommand, error) { return NewResetPasswordCommand("abcd", "NOW") }, true},
+		{"duplicate password flag", func() (*DeviceCommand, error) {
+			return NewResetPasswordCommand("abcd", ResetPasswordFlagLockNow, ResetPasswordFlagLockNow)
+		}, true},
+
+		{"clear app data", func() (*DeviceCommand, error) { return NewClearAppDataCommand("com.example.app") }, false},
+		{"clear app data without packages", func() (*DeviceCommand, error) { return NewClearAppDataCommand() }, true},
+		{"clear app data with invalid package", func() (*DeviceCommand, error) { return NewClearAppDataCommand("example") }, true},
+
+		{"lost mode with message", func() (*DeviceCommand, error) {
+			return NewStartLostModeCommand(LostModeOptions{Message: "Please return"})
+		}, false},
+		{"lost mode with contact details", func() (*DeviceCommand, error) {
+			return NewStartLostModeCommand(LostModeOptions{PhoneNumber: "+44 (20) 7946-0000", EmailAddress: "it@example.com"})
+		}, false},
+		{"lost mode without contact", func() (*DeviceCommand, error) {
+			return NewStartLostModeCommand(LostModeOptions{Organization: "Example Corp"})
+		}, true},
+		{"lost mode with invalid phone", func() (*DeviceCommand, error) {
+			return NewStartLostModeCommand(LostModeOptions{PhoneNumber: "call me"})
+		}, true},
+		{"lost mode with invalid email", func() (*DeviceCommand, error) {
+			return NewStartLostModeCommand(LostModeOptions{EmailAddress: "it@"})
+		}, true},
+
+		{"request EID", func() (*DeviceCommand, error) { return NewRequestDeviceInfoCommand(DeviceInfoEID) }, false},
+		{"request unknown info", func() (*DeviceCommand, error) { return NewRequestDeviceInfoCommand("IMEI") }, true},
+
+		{"wipe", func() (*DeviceCommand, error) { return NewWipeCommand(WipeOptions{}) }, false},
+		{"wipe with flags", func() (*DeviceCommand, error) {
+			return NewWipeCommand(WipeOptions{Flags: []WipeDataFlag{WipeDataFlagWipeExternalStorage, WipeDataFlagWipeEsims}, Reason: "Retired"})
+		}, false},
+		{"wipe with unknown flag", func() (*DeviceCommand, error) {
+			return NewWipeCommand(WipeOptions{Flags: []WipeDataFlag{"WIPE_EVERYTHING"}})
+		}, true},
+		{"wipe with long reason", func() (*DeviceCommand, error) {
+			return NewWipeCommand(WipeOptions{Reason: strings.Repeat("x", 201)})
+		}, true},
+
+		{"add esim", func() (*DeviceCommand, error) { return NewAddEsimCommand("LPA:1$smdp$code", "") }, false},
+		{"add esim without code", func() (*DeviceCommand, error) { return NewAddEsimCommand(" ", EsimActivated) }, true},
+		{"add esim with unknown state", func() (*DeviceCommand, error) { return NewAddEsimCommand("LPA:1$smdp$code", "ON") }, true},
+
+		{"remove esim", func() (*DeviceCommand, error) { return NewRemoveEsimCommand("89012601234567890123") }, false},
+		{"remove esim with invalid ICCID", func() (*DeviceCommand, error) { return NewRemoveEsimCommand("1234") }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command, err := tt.build()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got command %+v", command)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if command.Type == "" {
+				t.Error("command type is empty")
+			}
+		})
+	}
+}
+
+// 测试 Validate 拒绝与命令类型不匹配的参数和未知类型
+func TestDeviceCommandValidate(t *testing.T) {
+	invalid := []*DeviceCommand{
+		nil,
+		{},
+		{Type: "SELF_DESTRUCT"},
+		{Type: string(CommandTypeReboot), NewPassword: "abcdef"},
+		{Type: string(CommandTypeLock), RemoveEsimParams: &RemoveEsimParams{IccID: "89012601234567890123"}},
+		{Type: string(CommandTypeAddEsim)},
+	}
+	for _, command := range invalid {
+		if err := command.Validate(); err == nil {
+			t.Errorf("Validate(%+v) expected error", command)
+		}
+	}
+
+	if err := (&DeviceCommand{Type: string(CommandTypeStopLostMode)}).Validate(); err != nil {
+		t.Errorf("Validate(STOP_LOST_MODE) unexpected error: %v", err)
+	}
+}
+
+// 测试转换为生成客户端的命令
+func TestDeviceCommandAPICommand(t *testing.T) {
+	command, _ := NewResetPasswordCommand("correct-horse", ResetPasswordFlagLockNow)
+	apiCommand, ok := command.APICommand()
+	if !ok || apiCommand.Type != "RESET_PASSWORD" || apiCommand.NewPassword != "correct-horse" ||
+		len(apiCommand.ResetPasswordFlags) != 1 || apiCommand.ResetPasswordFlags[0] != "LOCK_NOW" {
+		t.Errorf("APICommand() = %+v, %v", apiCommand, ok)
+	}
+
+	lostMode, _ := NewStartLostModeCommand(LostModeOptions{Message: "Please return this phone"})
+	if apiCommand, ok := lostMode.APICommand(); !ok || apiCommand.StartLostModeParams != lostMode.StartLostModeParams {
+		t.Errorf("APICommand() = %+v, %v, want lost mode params", apiCommand, ok)
+	}
+
+	// 没有参数的 WIPE 只需要命令类型
+	if _, ok := (&DeviceCommand{Type: string(CommandTypeWipe)}).APICommand(); !ok {
+		t.Error("APICommand() not ok for WIPE without parameters")
+	}
+
+	// 生成客户端没有这些参数
+	info, _ := NewRequestDeviceInfoCommand(DeviceInfoEID)
+	wipe, _ := NewWipeCommand(WipeOptions{Reason: "Device retired"})
+	esim, _ := NewAddEsimCommand("LPA:1$smdp.example.com$CODE", "")
+	for _, c := range []*DeviceCommand{info, wipe, esim} {
+		if _, ok := c.APICommand(); ok {
+			t.Errorf("APICommand() ok for %s with parameters", c.Type)
+		}
+	}
+}
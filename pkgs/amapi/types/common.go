@@ -40,6 +40,25 @@ const (
 	DeviceStateProvisioning DeviceState = "PROVISIONING"
 )
 
+// DeviceHealth classifies a device by how recently it reported to the server.
+type DeviceHealth string
+
+const (
+	DeviceHealthHealthy     DeviceHealth = "HEALTHY"
+	DeviceHealthStale       DeviceHealth = "STALE"
+	DeviceHealthNeverSynced DeviceHealth = "NEVER_SYNCED"
+)
+
+// ReportFormat is the output format of the reports and exports.
+type ReportFormat string
+
+const (
+	ReportFormatJSON  ReportFormat = "json"
+	ReportFormatCSV   ReportFormat = "csv"
+	ReportFormatTable ReportFormat = "table"
+	ReportFormatJSONL ReportFormat = "jsonl"
+)
+
 // ApplicationInstallType represents how an application should be installed.
 type ApplicationInstallType string
 
@@ -63,6 +82,13 @@ const (
 	CommandTypeClearAppData   CommandType = "CLEAR_APP_DATA"
 	CommandTypeStartLostMode  CommandType = "START_LOST_MODE"
 	CommandTypeStopLostMode   CommandType = "STOP_LOST_MODE"
+
+	CommandTypeResetPassword       CommandType = "RESET_PASSWORD"
+	CommandTypeRelinquishOwnership CommandType = "RELINQUISH_OWNERSHIP"
+	CommandTypeRequestDeviceInfo   CommandType = "REQUEST_DEVICE_INFO"
+	CommandTypeWipe                CommandType = "WIPE"
+	CommandTypeAddEsim             CommandType = "ADD_ESIM"
+	CommandTypeRemoveEsim          CommandType = "REMOVE_ESIM"
 )
 
 // EnrollmentTokenType represents the type of enrollment token.
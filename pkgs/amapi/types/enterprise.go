@@ -67,5 +67,11 @@ const (
 	NotificationTypeComplianceReport = "COMPLIANCE_REPORT"
 	NotificationTypeStatusReport     = "STATUS_REPORT"
 	NotificationTypeCommand          = "COMMAND"
-	NotificationTypeUsageLog         = "USAGE_LOG_ENABLED"
+	NotificationTypeUsageLogs        = "USAGE_LOGS"
+
+	// NotificationTypeTest is sent once when a Pub/Sub topic is configured.
+	NotificationTypeTest = "test"
+
+	// Deprecated: the API value is USAGE_LOGS, use NotificationTypeUsageLogs.
+	NotificationTypeUsageLog = "USAGE_LOG_ENABLED"
 )
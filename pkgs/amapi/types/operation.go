@@ -0,0 +1,204 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/api/androidmanagement/v1"
+)
+
+// CommandStatus is the outcome of a device command operation.
+type CommandStatus string
+
+const (
+	// CommandStatusPending means the device has not reported the result yet.
+	CommandStatusPending CommandStatus = "PENDING"
+
+	// CommandStatusSucceeded means the device executed the command.
+	CommandStatusSucceeded CommandStatus = "SUCCEEDED"
+
+	// CommandStatusFailed means the command finished with an error.
+	CommandStatusFailed CommandStatus = "FAILED"
+
+	// CommandStatusCancelled means the operation was cancelled before the device executed it.
+	CommandStatusCancelled CommandStatus = "CANCELLED"
+)
+
+// IsTerminal returns true if the status will not change anymore.
+func (s CommandStatus) IsTerminal() bool {
+	return s == CommandStatusSucceeded || s == CommandStatusFailed || s == CommandStatusCancelled
+}
+
+// Status codes of google.rpc.Status used by operations.
+const (
+	RPCCodeOK                 = 0
+	RPCCodeCancelled          = 1
+	RPCCodeUnknown            = 2
+	RPCCodeInvalidArgument    = 3
+	RPCCodeDeadlineExceeded   = 4
+	RPCCodeNotFound           = 5
+	RPCCodeAlreadyExists      = 6
+	RPCCodePermissionDenied   = 7
+	RPCCodeResourceExhausted  = 8
+	RPCCodeFailedPrecondition = 9
+	RPCCodeAborted            = 10
+	RPCCodeOutOfRange         = 11
+	RPCCodeUnimplemented      = 12
+	RPCCodeInternal           = 13
+	RPCCodeUnavailable        = 14
+	RPCCodeDataLoss           = 15
+	RPCCodeUnauthenticated    = 16
+)
+
+// rpcCodeNames maps google.rpc.Code values to their canonical names.
+var rpcCodeNames = map[int]string{
+	RPCCodeOK:                 "OK",
+	RPCCodeCancelled:          "CANCELLED",
+	RPCCodeUnknown:            "UNKNOWN",
+	RPCCodeInvalidArgument:    "INVALID_ARGUMENT",
+	RPCCodeDeadlineExceeded:   "DEADLINE_EXCEEDED",
+	RPCCodeNotFound:           "NOT_FOUND",
+	RPCCodeAlreadyExists:      "ALREADY_EXISTS",
+	RPCCodePermissionDenied:   "PERMISSION_DENIED",
+	RPCCodeResourceExhausted:  "RESOURCE_EXHAUSTED",
+	RPCCodeFailedPrecondition: "FAILED_PRECONDITION",
+	RPCCodeAborted:            "ABORTED",
+	RPCCodeOutOfRange:         "OUT_OF_RANGE",
+	RPCCodeUnimplemented:      "UNIMPLEMENTED",
+	RPCCodeInternal:           "INTERNAL",
+	RPCCodeUnavailable:        "UNAVAILABLE",
+	RPCCodeDataLoss:           "DATA_LOSS",
+	RPCCodeUnauthenticated:    "UNAUTHENTICATED",
+}
+
+// OperationError is the decoded Operation.Error of a finished operation.
+type OperationError struct {
+	// Code is the google.rpc.Code of the error
+	Code int `json:"code"`
+
+	// Status is the canonical name of Code, e.g. "CANCELLED"
+	Status string `json:"status"`
+
+	// Message is the developer-facing error message
+	Message string `json:"message,omitempty"`
+
+	// Details holds the decoded error details; each one carries its "@type"
+	Details []map[string]any `json:"details,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *OperationError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("operation failed with %s: %s", e.Status, e.Message)
+	}
+	return fmt.Sprintf("operation failed with %s", e.Status)
+}
+
+// DecodeOperationError decodes status into an OperationError.
+// It returns nil if status is nil.
+func DecodeOperationError(status *androidmanagement.Status) *OperationError {
+	if status == nil {
+		return nil
+	}
+
+	opErr := &OperationError{
+		Code:    int(status.Code),
+		Status:  rpcCodeNames[int(status.Code)],
+		Message: status.Message,
+	}
+	if opErr.Status == "" {
+		opErr.Status = fmt.Sprintf("CODE_%d", status.Code)
+	}
+
+	for _, raw := range status.Details {
+		var detail map[string]any
+		if err := json.Unmarshal(raw, &detail); err == nil {
+			opErr.Details = append(opErr.Details, detail)
+		}
+	}
+
+	return opErr
+}
+
+// CommandResult is the typed view of a device command operation.
+//
+// 设备命令通过长时间运行的操作（Operation）返回结果：Metadata 是下发的 Command，
+// 命令完成后其中的 errorCode 和各命令的状态字段（如 clearAppsDataStatus、
+// requestDeviceInfoStatus）会被填充；失败或取消时 Error 是 google.rpc.Status。
+// CommandResult 把这些字段解码为 Status、Command 和 Error，调用方无需再处理原始 JSON。
+type CommandResult struct {
+	// Name is the operation resource name
+	Name string `json:"name"`
+
+	// Status is the outcome of the command
+	Status CommandStatus `json:"status"`
+
+	// Command is the decoded operation metadata; nil if the operation has none
+	Command *DeviceCommand `json:"command,omitempty"`
+
+	// Error is set when Status is FAILED or CANCELLED
+	Error *OperationError `json:"error,omitempty"`
+
+	// Operation is the raw operation the result was decoded from
+	Operation *androidmanagement.Operation `json:"-"`
+}
+
+// NewCommandResult decodes a device command operation.
+//
+// 判断规则：
+//   - 未完成（Done 为 false）：PENDING
+//   - Error 的 code 为 CANCELLED：CANCELLED
+//   - Error 非空，或 Command.ErrorCode 不是 COMMAND_ERROR_CODE_UNSPECIFIED：FAILED
+//   - 其他：SUCCEEDED
+//
+// 仅当 Metadata 不是合法的 Command JSON 时返回错误。
+func NewCommandResult(op *androidmanagement.Operation) (*CommandResult, error) {
+	if op == nil {
+		return nil, NewError(ErrCodeInvalidInput, "operation is required")
+	}
+
+	result := &CommandResult{
+		Name:      op.Name,
+		Status:    CommandStatusPending,
+		Error:     DecodeOperationError(op.Error),
+		Operation: op,
+	}
+
+	if len(op.Metadata) > 0 {
+		command := &DeviceCommand{}
+		if err := json.Unmarshal(op.Metadata, command); err != nil {
+			return nil, WrapError(err, ErrCodeInvalidResponse, "failed to decode operation metadata")
+		}
+		result.Command = command
+	}
+
+	if !op.Done {
+		return result, nil
+	}
+
+	switch {
+	case result.Error != nil && result.Error.Code == RPCCodeCancelled:
+		result.Status = CommandStatusCancelled
+	case result.Error != nil:
+		result.Status = CommandStatusFailed
+	case result.Command != nil && result.Command.ErrorCode != "" && result.Command.ErrorCode != "COMMAND_ERROR_CODE_UNSPECIFIED":
+		result.Status = CommandStatusFailed
+		result.Error = &OperationError{
+			Code:    RPCCodeFailedPrecondition,
+			Status:  rpcCodeNames[RPCCodeFailedPrecondition],
+			Message: "command failed with " + result.Command.ErrorCode,
+		}
+	default:
+		result.Status = CommandStatusSucceeded
+	}
+
+	return result, nil
+}
+
+// Err returns Error if the command failed or was cancelled, and nil otherwise.
+func (r *CommandResult) Err() error {
+	if r == nil || r.Error == nil {
+		return nil
+	}
+	return r.Error
+}
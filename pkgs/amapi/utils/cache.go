@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheInterface defines the interface for response caches.
+//
+// 此接口允许使用不同的缓存实现：
+//   - 本地实现：进程内的 LRU 缓存
+//   - 分布式实现：使用 Redis 在多个进程间共享缓存
+//
+// 实现此接口的类型包括：
+//   - LRUCache: 本地 LRU 缓存（用于单进程应用）
+//   - RedisCache: 分布式缓存（用于多进程应用）
+type CacheInterface interface {
+	// Get returns the cached value for key.
+	// 如果 key 不存在或已过期，返回 false。
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Set stores value under key for the given TTL.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// Delete removes key from the cache.
+	Delete(ctx context.Context, key string) error
+
+	// DeletePrefix removes every key that starts with prefix.
+	// 空前缀会清空整个缓存。
+	DeletePrefix(ctx context.Context, prefix string) error
+
+	// Close closes the cache and releases resources.
+	Close() error
+}
+
+// DefaultCacheSize is the capacity used by NewLRUCache when size is not positive.
+const DefaultCacheSize = 1000
+
+// LRUCache provides an in-memory cache with least-recently-used eviction.
+//
+// 每个条目有独立的过期时间，过期条目在读取时删除。
+// 条目数超过容量时，淘汰最久未使用的条目。LRUCache 可以在多个 goroutine 中并发使用。
+//
+// # 使用示例
+//
+//	cache := NewLRUCache(1000)
+//	defer cache.Close()
+//
+//	cache.Set(ctx, "enterprises/LC00abc", data, 5*time.Minute)
+//	if value, ok, _ := cache.Get(ctx, "enterprises/LC00abc"); ok {
+//	    // 使用缓存的值
+//	}
+type LRUCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+// lruEntry is an element of LRUCache.order.
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewLRUCache creates a new in-memory LRU cache holding at most size entries.
+func NewLRUCache(size int) *LRUCache {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+
+	return &LRUCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+		now:     time.Now,
+	}
+}
+
+// Get returns the cached value for key.
+func (c *LRUCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := elem.Value.(*lruEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.remove(elem)
+		return nil, false, nil
+	}
+
+	c.order.MoveToFront(elem)
+	return entry.value, true, nil
+}
+
+// Set stores value under key for the given TTL.
+func (c *LRUCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(ttl)
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+
+	return nil
+}
+
+// Delete removes key from the cache.
+func (c *LRUCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	return nil
+}
+
+// DeletePrefix removes every key that starts with prefix.
+func (c *LRUCache) DeletePrefix(ctx context.Context, prefix string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, elem := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(elem)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of entries, including expired entries not yet removed.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Close closes the cache (no-op for local cache).
+func (c *LRUCache) Close() error {
+	return nil
+}
+
+// remove deletes elem. The caller must hold c.mu.
+func (c *LRUCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*lruEntry).key)
+}
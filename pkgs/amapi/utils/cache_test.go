@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// 测试 LRU 缓存的读写、淘汰和过期
+func TestLRUCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cache := NewLRUCache(2)
+	cache.now = func() time.Time { return now }
+
+	cache.Set(ctx, "a", []byte("1"), time.Minute)
+	cache.Set(ctx, "b", []byte("2"), time.Minute)
+
+	// 读取 a 使 b 成为最久未使用的条目
+	if value, ok, _ := cache.Get(ctx, "a"); !ok || string(value) != "1" {
+		t.Fatalf("Get(a) = %q, %v, want 1", value, ok)
+	}
+
+	cache.Set(ctx, "c", []byte("3"), time.Minute)
+	if _, ok, _ := cache.Get(ctx, "b"); ok {
+		t.Error("Get(b) hit after eviction")
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", cache.Len())
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok, _ := cache.Get(ctx, "a"); ok {
+		t.Error("Get(a) hit after TTL expired")
+	}
+}
+
+// 测试按前缀删除
+func TestLRUCacheDeletePrefix(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLRUCache(0)
+
+	for _, key := range []string{"enterprises/e1", "enterprises/e1/devices/d1", "enterprises/e10", "enterprises?projectId=p"} {
+		cache.Set(ctx, key, []byte(key), time.Minute)
+	}
+
+	cache.DeletePrefix(ctx, "enterprises/e1/")
+	cache.Delete(ctx, "enterprises/e1")
+
+	for key, expected := range map[string]bool{
+		"enterprises/e1":            false,
+		"enterprises/e1/devices/d1": false,
+		"enterprises/e10":           true,
+		"enterprises?projectId=p":   true,
+	} {
+		if _, ok, _ := cache.Get(ctx, key); ok != expected {
+			t.Errorf("Get(%s) hit = %v, want %v", key, ok, expected)
+		}
+	}
+}
+
+// 测试 Redis 缓存
+func TestRedisCache(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	cache := NewRedisCache(client, "test:")
+
+	if _, ok, err := cache.Get(ctx, "enterprises/e1"); ok || err != nil {
+		t.Fatalf("Get() on empty cache = %v, %v, want miss", ok, err)
+	}
+
+	cache.Set(ctx, "enterprises/e1", []byte("e1"), time.Minute)
+	cache.Set(ctx, "enterprises/e1/policies/p*", []byte("p"), time.Minute)
+	cache.Set(ctx, "enterprises/e1/policies/px", []byte("p"), time.Minute)
+
+	if !mr.Exists("test:cache:enterprises/e1") {
+		t.Error("key was not stored with the expected prefix")
+	}
+	if ttl := mr.TTL("test:cache:enterprises/e1"); ttl != time.Minute {
+		t.Errorf("TTL = %v, want 1m", ttl)
+	}
+
+	// glob 字符按字面匹配
+	if err := cache.DeletePrefix(ctx, "enterprises/e1/policies/p*"); err != nil {
+		t.Fatalf("DeletePrefix() unexpected error: %v", err)
+	}
+	if _, ok, _ := cache.Get(ctx, "enterprises/e1/policies/px"); !ok {
+		t.Error("DeletePrefix() removed a key that only matched as a glob")
+	}
+
+	if err := cache.DeletePrefix(ctx, ""); err != nil {
+		t.Fatalf("DeletePrefix(\"\") unexpected error: %v", err)
+	}
+	if keys := mr.Keys(); len(keys) != 0 {
+		t.Errorf("keys after clearing = %v", keys)
+	}
+}
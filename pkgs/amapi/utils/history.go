@@ -0,0 +1,280 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HistoryEntry is a snapshot of one version of a resource.
+type HistoryEntry struct {
+	// Version is the resource version, e.g. Policy.Version
+	Version int64 `json:"version"`
+
+	// RecordedAt is the time the snapshot was recorded
+	RecordedAt time.Time `json:"recorded_at"`
+
+	// Operation is the operation that produced or replaced the version, e.g. "update policy"
+	Operation string `json:"operation,omitempty"`
+
+	// Data is the JSON encoding of the resource
+	Data json.RawMessage `json:"data"`
+}
+
+// HistoryStore defines the interface for resource version history.
+//
+// 此接口允许使用不同的存储实现：
+//   - MemoryHistoryStore: 进程内存储（用于测试和短期运行的进程）
+//   - DirHistoryStore: 本地目录，每个版本一个 JSON 文件（用于命令行工具和单机部署）
+//   - RedisHistoryStore: 在多个进程间共享历史（用于多进程应用）
+//
+// key 是资源名称，例如 enterprises/LC00abc/policies/default。
+type HistoryStore interface {
+	// Record stores a snapshot. A snapshot of an existing version replaces it.
+	// 超过保留数量时删除最旧的版本。
+	Record(ctx context.Context, key string, entry HistoryEntry) error
+
+	// List returns the snapshots of key ordered by version, oldest first.
+	List(ctx context.Context, key string) ([]HistoryEntry, error)
+
+	// Get returns the snapshot of a version.
+	// 如果该版本不存在，返回 false。
+	Get(ctx context.Context, key string, version int64) (*HistoryEntry, bool, error)
+
+	// Close closes the store and releases resources.
+	Close() error
+}
+
+// MemoryHistoryStore keeps history in memory. It can be used concurrently.
+type MemoryHistoryStore struct {
+	mu      sync.Mutex
+	limit   int
+	entries map[string][]HistoryEntry
+}
+
+// NewMemoryHistoryStore creates an in-memory store keeping at most limit versions per key.
+// A limit of 0 keeps every version.
+func NewMemoryHistoryStore(limit int) *MemoryHistoryStore {
+	return &MemoryHistoryStore{limit: limit, entries: make(map[string][]HistoryEntry)}
+}
+
+// Record stores a snapshot.
+func (s *MemoryHistoryStore) Record(ctx context.Context, key string, entry HistoryEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry.Data = append(json.RawMessage(nil), entry.Data...)
+	s.entries[key] = pruneHistory(insertHistory(s.entries[key], entry), s.limit)
+	return nil
+}
+
+// List returns the snapshots of key ordered by version.
+func (s *MemoryHistoryStore) List(ctx context.Context, key string) ([]HistoryEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]HistoryEntry{}, s.entries[key]...), nil
+}
+
+// Get returns the snapshot of a version.
+func (s *MemoryHistoryStore) Get(ctx context.Context, key string, version int64) (*HistoryEntry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range s.entries[key] {
+		if entry.Version == version {
+			return &entry, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// Close releases the stored history.
+func (s *MemoryHistoryStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = make(map[string][]HistoryEntry)
+	return nil
+}
+
+// insertHistory inserts entry into entries sorted by version, replacing an entry of the same version.
+func insertHistory(entries []HistoryEntry, entry HistoryEntry) []HistoryEntry {
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].Version >= entry.Version })
+	if i < len(entries) && entries[i].Version == entry.Version {
+		entries[i] = entry
+		return entries
+	}
+
+	entries = append(entries, HistoryEntry{})
+	copy(entries[i+1:], entries[i:])
+	entries[i] = entry
+	return entries
+}
+
+// pruneHistory drops the oldest entries beyond limit.
+func pruneHistory(entries []HistoryEntry, limit int) []HistoryEntry {
+	if limit > 0 && len(entries) > limit {
+		return append([]HistoryEntry(nil), entries[len(entries)-limit:]...)
+	}
+	return entries
+}
+
+// DirHistoryStore keeps history in a local directory.
+//
+// 每个 key 对应一个子目录（名称为 URL 转义后的 key），每个版本保存为 <version>.json，
+// 文件内容是 HistoryEntry 的 JSON，可以直接查看或纳入版本控制。
+type DirHistoryStore struct {
+	mu    sync.Mutex
+	dir   string
+	limit int
+}
+
+// NewDirHistoryStore creates a store under dir keeping at most limit versions per key.
+// A limit of 0 keeps every version. The directory is created if needed.
+func NewDirHistoryStore(dir string, limit int) (*DirHistoryStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &DirHistoryStore{dir: dir, limit: limit}, nil
+}
+
+// Record writes a snapshot file.
+func (s *DirHistoryStore) Record(ctx context.Context, key string, entry HistoryEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir := s.keyDir(key)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// 先写入临时文件再重命名，避免读取到写了一半的快照
+	path := filepath.Join(dir, strconv.FormatInt(entry.Version, 10)+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	if s.limit <= 0 {
+		return nil
+	}
+	versions, err := s.versions(dir)
+	if err != nil {
+		return err
+	}
+	for len(versions) > s.limit {
+		if err := os.Remove(filepath.Join(dir, strconv.FormatInt(versions[0], 10)+".json")); err != nil {
+			return err
+		}
+		versions = versions[1:]
+	}
+	return nil
+}
+
+// List reads the snapshot files of key ordered by version.
+func (s *DirHistoryStore) List(ctx context.Context, key string) ([]HistoryEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir := s.keyDir(key)
+	versions, err := s.versions(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]HistoryEntry, 0, len(versions))
+	for _, version := range versions {
+		entry, err := readHistoryFile(filepath.Join(dir, strconv.FormatInt(version, 10)+".json"))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+	return entries, nil
+}
+
+// Get reads the snapshot file of a version.
+func (s *DirHistoryStore) Get(ctx context.Context, key string, version int64) (*HistoryEntry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, err := readHistoryFile(filepath.Join(s.keyDir(key), strconv.FormatInt(version, 10)+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return entry, true, nil
+}
+
+// Close does nothing; the files are kept.
+func (s *DirHistoryStore) Close() error {
+	return nil
+}
+
+func (s *DirHistoryStore) keyDir(key string) string {
+	return filepath.Join(s.dir, url.PathEscape(key))
+}
+
+// versions returns the versions stored in dir in ascending order.
+func (s *DirHistoryStore) versions(dir string) ([]int64, error) {
+	files, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []int64
+	for _, file := range files {
+		name, ok := strings.CutSuffix(file.Name(), ".json")
+		if !ok {
+			continue
+		}
+		if version, err := strconv.ParseInt(name, 10, 64); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions, nil
+}
+
+func readHistoryFile(path string) (*HistoryEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry HistoryEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+
+	// 文件以缩进格式保存，读取时恢复为紧凑的 JSON
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, entry.Data); err != nil {
+		return nil, err
+	}
+	entry.Data = compact.Bytes()
+	return &entry, nil
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// testHistoryStore 对任意 HistoryStore 实现执行相同的检查，store 最多保留 3 个版本
+func testHistoryStore(t *testing.T, store HistoryStore) {
+	t.Helper()
+	ctx := context.Background()
+	const key = "enterprises/e1/policies/p1"
+
+	if entries, err := store.List(ctx, key); err != nil || len(entries) != 0 {
+		t.Fatalf("List() on empty store = %v, %v, want none", entries, err)
+	}
+
+	// 乱序写入，并重复写入版本 2
+	for _, version := range []int64{2, 1, 4, 2, 3} {
+		entry := HistoryEntry{
+			Version:    version,
+			RecordedAt: time.Unix(version, 0).UTC(),
+			Operation:  "update policy",
+			Data:       json.RawMessage(fmt.Sprintf(`{"version":"%d"}`, version)),
+		}
+		if err := store.Record(ctx, key, entry); err != nil {
+			t.Fatalf("Record(%d) unexpected error: %v", version, err)
+		}
+	}
+
+	entries, err := store.List(ctx, key)
+	if err != nil {
+		t.Fatalf("List() unexpected error: %v", err)
+	}
+	var versions []int64
+	for _, entry := range entries {
+		versions = append(versions, entry.Version)
+	}
+	if fmt.Sprint(versions) != "[2 3 4]" {
+		t.Errorf("List() versions = %v, want the 3 newest in order", versions)
+	}
+
+	entry, ok, err := store.Get(ctx, key, 3)
+	if err != nil || !ok || string(entry.Data) != `{"version":"3"}` || entry.Operation != "update policy" || !entry.RecordedAt.Equal(time.Unix(3, 0)) {
+		t.Errorf("Get(3) = %+v, %v, %v", entry, ok, err)
+	}
+	if _, ok, err := store.Get(ctx, key, 1); ok || err != nil {
+		t.Errorf("Get(1) = %v, %v, want pruned", ok, err)
+	}
+	if entries, _ := store.List(ctx, "enterprises/e1/policies/p2"); len(entries) != 0 {
+		t.Errorf("List(other key) = %v, want none", entries)
+	}
+
+	if err := store.Close(); err != nil {
+		t.Errorf("Close() unexpected error: %v", err)
+	}
+}
+
+// 测试内存历史存储
+func TestMemoryHistoryStore(t *testing.T) {
+	testHistoryStore(t, NewMemoryHistoryStore(3))
+}
+
+// 测试目录历史存储
+func TestDirHistoryStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewDirHistoryStore(dir, 3)
+	if err != nil {
+		t.Fatalf("NewDirHistoryStore() unexpected error: %v", err)
+	}
+	testHistoryStore(t, store)
+
+	// 重新打开目录后历史仍然存在
+	reopened, err := NewDirHistoryStore(dir, 3)
+	if err != nil {
+		t.Fatalf("NewDirHistoryStore() unexpected error: %v", err)
+	}
+	if entries, err := reopened.List(context.Background(), "enterprises/e1/policies/p1"); err != nil || len(entries) != 3 {
+		t.Errorf("List() after reopening = %d entries, %v, want 3", len(entries), err)
+	}
+}
+
+// 测试 Redis 历史存储
+func TestRedisHistoryStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	testHistoryStore(t, NewRedisHistoryStore(client, "test:", 3))
+
+	if !mr.Exists("test:history:enterprises/e1/policies/p1") {
+		t.Error("key was not stored with the expected prefix")
+	}
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"context"
+	"fmt"
 
 	"golang.org/x/time/rate"
 )
@@ -54,8 +55,17 @@ func NewRateLimiter(rateLimit, burst int) *RateLimiter {
 }
 
 // Wait waits until the rate limiter allows the request.
+//
+// 如果在 ctx 的截止时间之前无法获得配额，rate 包会立即返回错误而不是等待，
+// 此时返回的错误包装 context.DeadlineExceeded，与 RedisRateLimiter 一致。
 func (rl *RateLimiter) Wait(ctx context.Context) error {
-	return rl.limiter.Wait(ctx)
+	err := rl.limiter.Wait(ctx)
+	if err != nil && ctx.Err() == nil {
+		if _, ok := ctx.Deadline(); ok {
+			return fmt.Errorf("%w: %v", context.DeadlineExceeded, err)
+		}
+	}
+	return err
 }
 
 // Close closes the rate limiter (no-op for local limiter).
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisScanCount is the COUNT hint used when scanning keys for DeletePrefix.
+const redisScanCount = 500
+
+// RedisCache provides a response cache shared between processes using Redis.
+//
+// 所有使用同一个 Redis 实例和 key 前缀的进程共享同一份缓存，
+// 任一进程删除条目后，其他进程也不会再读到旧值。条目的过期由 Redis 的 TTL 处理。
+//
+// # 使用示例
+//
+//	client := redis.NewClient(&redis.Options{
+//	    Addr: "localhost:6379",
+//	})
+//
+//	cache := NewRedisCache(client, "amapi:")
+//	defer cache.Close()
+//
+//	cache.Set(ctx, "enterprises/LC00abc", data, 5*time.Minute)
+//
+// Close 不会关闭 Redis 客户端，客户端由调用方负责关闭。
+type RedisCache struct {
+	client    *redis.Client
+	keyPrefix string
+}
+
+// NewRedisCache creates a new Redis-based cache.
+// Keys are stored as keyPrefix + "cache:" + key.
+func NewRedisCache(client *redis.Client, keyPrefix string) *RedisCache {
+	return &RedisCache{
+		client:    client,
+		keyPrefix: keyPrefix + "cache:",
+	}
+}
+
+// Get returns the cached value for key.
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, c.keyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set stores value under key for the given TTL.
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, c.keyPrefix+key, value, ttl).Err()
+}
+
+// Delete removes key from the cache.
+func (c *RedisCache) Delete(ctx context.Context, key string) error {
+	return c.client.Del(ctx, c.keyPrefix+key).Err()
+}
+
+// DeletePrefix removes every key that starts with prefix.
+// Keys are found with SCAN, so this does not block Redis on large databases.
+func (c *RedisCache) DeletePrefix(ctx context.Context, prefix string) error {
+	pattern := escapeRedisPattern(c.keyPrefix+prefix) + "*"
+
+	var cursor uint64
+	for {
+		keys, next, err := c.client.Scan(ctx, cursor, pattern, redisScanCount).Result()
+		if err != nil {
+			return err
+		}
+
+		if len(keys) > 0 {
+			if err := c.client.Del(ctx, keys...).Err(); err != nil {
+				return err
+			}
+		}
+
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// Close closes the cache. The Redis client is owned by the caller and is not closed.
+func (c *RedisCache) Close() error {
+	return nil
+}
+
+// escapeRedisPattern escapes the glob characters used by SCAN MATCH.
+func escapeRedisPattern(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisHistoryStore keeps resource history in Redis, shared between processes.
+//
+// 每个 key 是一个有序集合，分数是版本号，成员是 HistoryEntry 的 JSON。
+// 写入、替换同一版本和删除超出保留数量的旧版本在同一个事务中完成。
+//
+// # 使用示例
+//
+//	client := redis.NewClient(&redis.Options{
+//	    Addr: "localhost:6379",
+//	})
+//
+//	store := NewRedisHistoryStore(client, "amapi:", 50)
+//	defer store.Close()
+//
+// Close 不会关闭 Redis 客户端，客户端由调用方负责关闭。
+type RedisHistoryStore struct {
+	client    *redis.Client
+	keyPrefix string
+	limit     int
+}
+
+// NewRedisHistoryStore creates a Redis-based store keeping at most limit versions per key.
+// A limit of 0 keeps every version. Keys are stored as keyPrefix + "history:" + key.
+func NewRedisHistoryStore(client *redis.Client, keyPrefix string, limit int) *RedisHistoryStore {
+	return &RedisHistoryStore{
+		client:    client,
+		keyPrefix: keyPrefix + "history:",
+		limit:     limit,
+	}
+}
+
+// Record stores a snapshot.
+func (s *RedisHistoryStore) Record(ctx context.Context, key string, entry HistoryEntry) error {
+	member, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	redisKey := s.keyPrefix + key
+	version := strconv.FormatInt(entry.Version, 10)
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, redisKey, version, version)
+		pipe.ZAdd(ctx, redisKey, redis.Z{Score: float64(entry.Version), Member: member})
+		if s.limit > 0 {
+			pipe.ZRemRangeByRank(ctx, redisKey, 0, int64(-s.limit-1))
+		}
+		return nil
+	})
+	return err
+}
+
+// List returns the snapshots of key ordered by version.
+func (s *RedisHistoryStore) List(ctx context.Context, key string) ([]HistoryEntry, error) {
+	members, err := s.client.ZRange(ctx, s.keyPrefix+key, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	return decodeHistoryMembers(members)
+}
+
+// Get returns the snapshot of a version.
+func (s *RedisHistoryStore) Get(ctx context.Context, key string, version int64) (*HistoryEntry, bool, error) {
+	score := strconv.FormatInt(version, 10)
+	members, err := s.client.ZRangeByScore(ctx, s.keyPrefix+key, &redis.ZRangeBy{Min: score, Max: score}).Result()
+	if err != nil {
+		return nil, false, err
+	}
+
+	entries, err := decodeHistoryMembers(members)
+	if err != nil || len(entries) == 0 {
+		return nil, false, err
+	}
+	return &entries[0], true, nil
+}
+
+// Close closes the store. The Redis client is owned by the caller and is not closed.
+func (s *RedisHistoryStore) Close() error {
+	return nil
+}
+
+func decodeHistoryMembers(members []string) ([]HistoryEntry, error) {
+	entries := make([]HistoryEntry, 0, len(members))
+	for _, member := range members {
+		var entry HistoryEntry
+		if err := json.Unmarshal([]byte(member), &entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
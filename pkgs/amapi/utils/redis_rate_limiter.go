@@ -4,22 +4,65 @@ package utils
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// tokenBucketScript atomically refills the bucket and takes one token if available.
+//
+// KEYS[1] is the bucket hash, ARGV[1] the refill rate in tokens per second and
+// ARGV[2] the capacity. The current time comes from the Redis server, so clock
+// differences between processes do not matter. Returns {allowed, retryAfterMicros}.
+// A rejected request does not consume a token.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+  tokens = capacity
+  ts = now
+end
+
+local elapsed = math.max(0, now - ts)
+tokens = math.min(capacity, tokens + elapsed * rate / 1000000)
+
+local allowed = 0
+local retryAfter = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retryAfter = math.ceil((1 - tokens) * 1000000 / rate)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(now))
+redis.call("PEXPIRE", KEYS[1], math.ceil(capacity / rate * 1000) + 1000)
+
+return {allowed, retryAfter}
+`)
+
 // RedisRateLimiter provides distributed rate limiting using Redis.
 //
-// 使用 Redis 的滑动窗口计数器算法实现分布式的 rate limiting。
-// 所有使用同一个 Redis 实例的进程会共享同一个 rate limit。
+// 使用 Redis 中的令牌桶算法实现分布式的 rate limiting。
+// 所有使用同一个 Redis 实例和 key 前缀的进程会共享同一个令牌桶。
 //
 // # 工作原理
 //
-// 1. 每个请求在 Redis sorted set 中记录一个带时间戳的条目
-// 2. 定期清理超出时间窗口（1分钟）的旧条目
-// 3. 统计当前时间窗口内的请求数
-// 4. 如果超过限制，等待直到有足够的配额
+//  1. 令牌桶的容量为 burst，令牌以每分钟 rateLimit 个的速度补充
+//  2. 每个请求通过一个 Lua 脚本原子地补充令牌并取走一个令牌
+//  3. 时间取自 Redis 服务器（微秒精度），不受各进程时钟差异影响
+//  4. 没有令牌时请求不计数，脚本返回需要等待的精确时间
+//
+// 这与本地 RateLimiter（golang.org/x/time/rate）的语义相同：
+// 空闲后最多允许 burst 个请求突发，长期速率不超过 rateLimit。
 //
 // # 使用示例
 //
@@ -40,12 +83,16 @@ import (
 //	if limiter.Allow(ctx) {
 //	    // 执行请求
 //	}
+//
+//	// 或者获取需要等待的时间
+//	ok, retryAfter, err := limiter.TryAcquire(ctx)
 type RedisRateLimiter struct {
 	client    *redis.Client
 	keyPrefix string
+
+	mu        sync.RWMutex
 	rateLimit int // requests per minute
 	burst     int
-	window    time.Duration // time window for rate limiting
 }
 
 // NewRedisRateLimiter creates a new Redis-based rate limiter.
@@ -58,109 +105,79 @@ func NewRedisRateLimiter(client *redis.Client, keyPrefix string, rateLimit, burs
 		burst = 10 // Default burst of 10
 	}
 
-	rl := &RedisRateLimiter{
+	return &RedisRateLimiter{
 		client:    client,
 		keyPrefix: keyPrefix,
 		rateLimit: rateLimit,
 		burst:     burst,
-		window:    60 * time.Second, // 1 minute window
 	}
-
-	return rl
 }
 
 // Wait waits until the rate limiter allows the request.
-// Uses Redis sliding window counter algorithm to ensure distributed rate limiting.
+// It sleeps for the retry-after reported by Redis between attempts.
 func (rl *RedisRateLimiter) Wait(ctx context.Context) error {
-	key := rl.keyPrefix + "ratelimit:requests"
-
-	// Get current time in seconds
-	now := time.Now().Unix()
-	windowStart := now - int64(rl.window.Seconds())
-
-	// Use Redis pipeline for atomic operations
-	pipe := rl.client.Pipeline()
-
-	// Remove old entries outside the window
-	pipe.ZRemRangeByScore(ctx, key, "0", fmt.Sprintf("%d", windowStart))
-
-	// Count current requests in the window
-	countCmd := pipe.ZCard(ctx, key)
-
-	// Add current request with score = current timestamp
-	pipe.ZAdd(ctx, key, redis.Z{
-		Score:  float64(now),
-		Member: fmt.Sprintf("%d", now),
-	})
-
-	// Set expiry on the sorted set
-	pipe.Expire(ctx, key, rl.window+10*time.Second)
-
-	// Execute pipeline
-	_, err := pipe.Exec(ctx)
-	if err != nil {
-		return fmt.Errorf("redis rate limit error: %w", err)
-	}
+	for {
+		ok, retryAfter, err := rl.TryAcquire(ctx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
 
-	// Check if we've exceeded the limit
-	currentCount := countCmd.Val()
-	if currentCount >= int64(rl.rateLimit) {
-		// Calculate wait time until the oldest request expires
-		oldestCmd := rl.client.ZRangeWithScores(ctx, key, 0, 0)
-		if oldestCmd.Err() == nil && len(oldestCmd.Val()) > 0 {
-			oldestScore := int64(oldestCmd.Val()[0].Score)
-			waitTime := time.Duration(oldestScore-int64(windowStart)) * time.Second
-			if waitTime > 0 {
-				select {
-				case <-ctx.Done():
-					return ctx.Err()
-				case <-time.After(waitTime):
-					// Retry after waiting
-					return rl.Wait(ctx)
-				}
-			}
+		if err := sleepContext(ctx, retryAfter); err != nil {
+			return err
 		}
-		return fmt.Errorf("rate limit exceeded: %d requests in window", currentCount)
 	}
-
-	return nil
 }
 
 // Allow checks if a request is allowed without waiting.
-// Implements RateLimiterInterface.
+// Implements RateLimiterInterface. Redis errors are reported as not allowed.
 func (rl *RedisRateLimiter) Allow(ctx context.Context) bool {
-	key := rl.keyPrefix + "ratelimit:requests"
-	now := time.Now().Unix()
-	windowStart := now - int64(rl.window.Seconds())
-
-	// Remove old entries and count current requests
-	pipe := rl.client.Pipeline()
-	pipe.ZRemRangeByScore(ctx, key, "0", fmt.Sprintf("%d", windowStart))
-	countCmd := pipe.ZCard(ctx, key)
-	pipe.Exec(ctx)
-
-	currentCount := countCmd.Val()
-	if currentCount >= int64(rl.rateLimit) {
-		return false
-	}
+	ok, _, err := rl.TryAcquire(ctx)
+	return err == nil && ok
+}
 
-	// Add current request
-	rl.client.ZAdd(ctx, key, redis.Z{
-		Score:  float64(now),
-		Member: fmt.Sprintf("%d", now),
-	})
-	rl.client.Expire(ctx, key, rl.window+10*time.Second)
+// TryAcquire takes a token if one is available.
+// If not, it returns false and how long to wait until the next token is available.
+func (rl *RedisRateLimiter) TryAcquire(ctx context.Context) (bool, time.Duration, error) {
+	rl.mu.RLock()
+	perSecond := float64(rl.rateLimit) / 60.0
+	burst := rl.burst
+	rl.mu.RUnlock()
+
+	result, err := tokenBucketScript.Run(ctx, rl.client, []string{rl.key()}, perSecond, burst).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("redis rate limit error: %w", err)
+	}
+	if len(result) != 2 {
+		return false, 0, fmt.Errorf("redis rate limit error: unexpected script result %v", result)
+	}
 
-	return true
+	if result[0] == 1 {
+		return true, 0, nil
+	}
+	return false, time.Duration(result[1]) * time.Microsecond, nil
 }
 
-// SetLimit changes the rate limit.
+// SetLimit changes the rate limit (requests per minute).
+// Tokens already in the bucket are kept. Non-positive values are ignored.
 func (rl *RedisRateLimiter) SetLimit(rateLimit int) {
+	if rateLimit <= 0 {
+		return
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
 	rl.rateLimit = rateLimit
 }
 
-// SetBurst changes the burst capacity (not used in Redis implementation, but kept for compatibility).
+// SetBurst changes the burst capacity. Non-positive values are ignored.
 func (rl *RedisRateLimiter) SetBurst(burst int) {
+	if burst <= 0 {
+		return
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
 	rl.burst = burst
 }
 
@@ -171,3 +188,11 @@ func (rl *RedisRateLimiter) Close() error {
 	}
 	return nil
 }
+
+// key returns the Redis key of the shared token bucket.
+//
+// The key differs from the sorted set used by earlier versions,
+// so old and new processes can run side by side without WRONGTYPE errors.
+func (rl *RedisRateLimiter) key() string {
+	return rl.keyPrefix + "ratelimit:bucket"
+}
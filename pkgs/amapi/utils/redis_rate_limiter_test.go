@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestRedis 启动 miniredis 并把服务器时间固定在 now
+func newTestRedis(t *testing.T, now time.Time) *miniredis.Miniredis {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	mr.SetTime(now)
+	return mr
+}
+
+// newTestRedisClient 创建连接到 mr 的 Redis 客户端，模拟一个独立的进程
+func newTestRedisClient(t *testing.T, mr *miniredis.Miniredis) *redis.Client {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// 测试令牌桶遵守 burst 并按速率补充令牌
+func TestRedisRateLimiterTokenBucket(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mr := newTestRedis(t, now)
+
+	// 每分钟 60 个请求，即每秒补充 1 个令牌，突发 5 个
+	limiter := NewRedisRateLimiter(newTestRedisClient(t, mr), "test:", 60, 5)
+
+	for i := 0; i < 5; i++ {
+		if !limiter.Allow(ctx) {
+			t.Fatalf("request %d rejected within burst", i+1)
+		}
+	}
+
+	ok, retryAfter, err := limiter.TryAcquire(ctx)
+	if err != nil {
+		t.Fatalf("TryAcquire() unexpected error: %v", err)
+	}
+	if ok {
+		t.Fatal("TryAcquire() allowed a request beyond burst")
+	}
+	if retryAfter != time.Second {
+		t.Errorf("retryAfter = %v, want 1s", retryAfter)
+	}
+
+	// 被拒绝的请求不消耗令牌：250ms 后只需再等 750ms
+	mr.SetTime(now.Add(250 * time.Millisecond))
+	if _, retryAfter, _ := limiter.TryAcquire(ctx); retryAfter != 750*time.Millisecond {
+		t.Errorf("retryAfter after 250ms = %v, want 750ms", retryAfter)
+	}
+
+	mr.SetTime(now.Add(time.Second))
+	if !limiter.Allow(ctx) {
+		t.Error("request rejected after a token was refilled")
+	}
+	if limiter.Allow(ctx) {
+		t.Error("second request allowed after only one token was refilled")
+	}
+
+	// 长时间空闲后令牌数不超过 burst
+	mr.SetTime(now.Add(time.Hour))
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		if limiter.Allow(ctx) {
+			allowed++
+		}
+	}
+	if allowed != 5 {
+		t.Errorf("allowed %d requests after idling, want burst of 5", allowed)
+	}
+}
+
+// 测试多个进程共享同一个限制
+func TestRedisRateLimiterSharedAcrossProcesses(t *testing.T) {
+	ctx := context.Background()
+	mr := newTestRedis(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	var limiters []*RedisRateLimiter
+	for i := 0; i < 4; i++ {
+		limiters = append(limiters, NewRedisRateLimiter(newTestRedisClient(t, mr), "test:", 60, 10))
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	allowed := 0
+	for _, limiter := range limiters {
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(limiter *RedisRateLimiter) {
+				defer wg.Done()
+				if limiter.Allow(ctx) {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			}(limiter)
+		}
+	}
+	wg.Wait()
+
+	if allowed != 10 {
+		t.Errorf("allowed %d of 40 concurrent requests across 4 processes, want 10", allowed)
+	}
+
+	// 另一个 key 前缀使用独立的令牌桶
+	other := NewRedisRateLimiter(newTestRedisClient(t, mr), "other:", 60, 10)
+	if !other.Allow(ctx) {
+		t.Error("limiter with a different key prefix shares the bucket")
+	}
+}
+
+// 测试 Wait 按 retry-after 等待并响应 context 取消
+func TestRedisRateLimiterWait(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	// 每秒 100 个令牌，突发 1 个
+	limiter := NewRedisRateLimiter(newTestRedisClient(t, mr), "test:", 6000, 1)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait() unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("3 requests with burst 1 at 100/s took %v, want at least ~20ms", elapsed)
+	}
+
+	limiter.SetLimit(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	limiter.Allow(ctx)
+	if err := limiter.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Wait() error = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -19,11 +19,12 @@ import (
 //
 // # 工作原理
 //
-// 1. 每个重试操作生成唯一的 operation ID
-// 2. 尝试获取 Redis 分布式锁（使用 SETNX）
-// 3. 如果获取成功，执行重试操作
-// 4. 如果获取失败，等待一小段时间后检查操作是否已成功
-// 5. 操作完成后释放锁
+// 1. 调用方为每个逻辑操作提供稳定的 operation ID（client 包由操作、资源和请求内容生成）
+// 2. 第一次尝试直接执行，不获取锁
+// 3. 重试前尝试获取 Redis 分布式锁（使用 SETNX）
+// 4. 如果获取成功，执行重试操作，完成后释放锁
+// 5. 如果获取失败，等待一小段时间后再执行一次
+// 6. 每次失败后都检查错误是否可重试，不可重试的错误直接返回
 //
 // # 使用示例
 //
@@ -40,7 +41,8 @@ import (
 //	})
 //	defer handler.Close()
 //
-//	operationID := fmt.Sprintf("operation-%d", time.Now().UnixNano())
+//	// 同一个逻辑操作在所有进程中使用相同的 ID
+//	operationID := "issue_device_command:job-42"
 //	err := handler.Execute(ctx, operationID, func() error {
 //	    // 执行可能失败的操作
 //	    return someOperation()
@@ -85,40 +87,25 @@ func (r *RedisRetryHandler) Execute(ctx context.Context, operationID string, ope
 	var lastErr error
 
 	for attempt := 0; attempt < r.config.MaxAttempts; attempt++ {
-		// Check if another process is already retrying this operation
-		retryKey := fmt.Sprintf("%sretry:lock:%s", r.keyPrefix, operationID)
-
-		// Try to acquire lock to prevent concurrent retries
-		lockAcquired, err := r.client.SetNX(ctx, retryKey, "1", time.Minute).Result()
-		if err != nil {
-			// If we can't acquire lock, proceed anyway (failover to local retry)
-			lockAcquired = true
-		}
-
-		if !lockAcquired {
-			// Another process is handling this, wait a bit and check if it succeeded
-			time.Sleep(500 * time.Millisecond)
-			// Try operation once more
-			err := operation()
-			if err == nil {
-				return nil
-			}
-			lastErr = err
-			continue
+		var err error
+		if attempt == 0 {
+			// 第一次尝试不加锁，相同 operation ID 的并发调用互不影响
+			err = operation()
+		} else {
+			err = r.retryWithLock(ctx, operationID, operation)
 		}
 
-		// Execute operation
-		err = operation()
-
-		// Release lock immediately after operation
-		r.client.Del(ctx, retryKey)
-
 		if err == nil {
 			return nil
 		}
 
 		lastErr = err
 
+		// Stop retrying once the caller has given up
+		if ctx.Err() != nil {
+			return err
+		}
+
 		// Check if error is retryable
 		if apiErr, ok := err.(*types.Error); ok {
 			if !apiErr.IsRetryable() {
@@ -145,11 +132,8 @@ func (r *RedisRetryHandler) Execute(ctx context.Context, operationID string, ope
 		r.client.Expire(ctx, retryCountKey, time.Hour)
 
 		// Wait before next attempt
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(delay):
-			// Continue to next attempt
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
 		}
 	}
 
@@ -163,6 +147,31 @@ func (r *RedisRetryHandler) Execute(ctx context.Context, operationID string, ope
 		"retry attempts exhausted", lastErr)
 }
 
+// retryWithLock runs a retry attempt while holding the retry lock of operationID.
+//
+// 其他进程正在重试同一操作时，等待一小段时间后再执行，结果由 Execute 统一判断是否继续重试。
+func (r *RedisRetryHandler) retryWithLock(ctx context.Context, operationID string, operation func() error) error {
+	retryKey := fmt.Sprintf("%sretry:lock:%s", r.keyPrefix, operationID)
+
+	lockAcquired, err := r.client.SetNX(ctx, retryKey, "1", time.Minute).Result()
+	if err != nil {
+		// If we can't acquire lock, proceed anyway (failover to local retry)
+		lockAcquired = true
+	}
+
+	if !lockAcquired {
+		// Another process is retrying this operation, wait a bit before trying again
+		if err := sleepContext(ctx, 500*time.Millisecond); err != nil {
+			return err
+		}
+		return operation()
+	}
+
+	// Release lock immediately after operation
+	defer r.client.Del(ctx, retryKey)
+	return operation()
+}
+
 // calculateDelay calculates the delay for the given attempt using exponential backoff.
 func (r *RedisRetryHandler) calculateDelay(attempt int) time.Duration {
 	// Exponential backoff: baseDelay * 2^attempt
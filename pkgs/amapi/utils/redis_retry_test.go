@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"amapi-pkg/pkgs/amapi/types"
+)
+
+// newTestRetryHandler 创建连接到 mr 的重试处理器，模拟一个独立的进程
+func newTestRetryHandler(t *testing.T, mr *miniredis.Miniredis) *RedisRetryHandler {
+	t.Helper()
+	return NewRedisRetryHandler(newTestRedisClient(t, mr), "test:", RetryConfig{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    time.Millisecond,
+		EnableRetry: true,
+	})
+}
+
+// 测试两个进程同时执行同一操作时，不可重试的错误不会被重试
+func TestRedisRetryConcurrentSameOperation(t *testing.T) {
+	mr := miniredis.RunT(t)
+	handlers := []*RedisRetryHandler{newTestRetryHandler(t, mr), newTestRetryHandler(t, mr)}
+	badRequest := types.NewError(types.ErrCodeBadRequest, "invalid request")
+
+	var calls [2]atomic.Int32
+	var results [2]error
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i, handler := range handlers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			results[i] = handler.Execute(context.Background(), "get_enterprise:enterprises/e1", func() error {
+				calls[i].Add(1)
+				time.Sleep(10 * time.Millisecond)
+				if i == 1 {
+					return badRequest
+				}
+				return nil
+			})
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if results[0] != nil || calls[0].Load() != 1 {
+		t.Errorf("handler 1: err = %v, calls = %d, want success after 1 call", results[0], calls[0].Load())
+	}
+	if !errors.Is(results[1], badRequest) || calls[1].Load() != 1 {
+		t.Errorf("handler 2: err = %v, calls = %d, want the bad request error after 1 call", results[1], calls[1].Load())
+	}
+}
+
+// 测试其他进程持有重试锁时，重试结果同样检查是否可重试
+func TestRedisRetryLockHeldByOtherProcess(t *testing.T) {
+	mr := miniredis.RunT(t)
+	handler := newTestRetryHandler(t, mr)
+	operationID := "list_devices:enterprises/e1"
+	if err := mr.Set("test:retry:lock:"+operationID, "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := []error{
+		types.NewError(types.ErrCodeServiceUnavailable, "try again"),
+		types.NewError(types.ErrCodeNotFound, "enterprise not found"),
+		nil,
+	}
+	calls := 0
+	err := handler.Execute(context.Background(), operationID, func() error {
+		calls++
+		return errs[calls-1]
+	})
+
+	var apiErr *types.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != types.ErrCodeNotFound || calls != 2 {
+		t.Errorf("Execute() = %v after %d calls, want not found after 2 calls", err, calls)
+	}
+	if !mr.Exists("test:retry:lock:" + operationID) {
+		t.Error("Execute() released a lock held by another process")
+	}
+}
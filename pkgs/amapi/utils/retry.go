@@ -108,6 +108,11 @@ func (r *RetryHandler) Execute(ctx context.Context, operationID string, operatio
 
 		lastErr = err
 
+		// Stop retrying once the caller has given up
+		if ctx.Err() != nil {
+			return err
+		}
+
 		// Check if error is retryable
 		if apiErr, ok := err.(*types.Error); ok {
 			if !apiErr.IsRetryable() {
@@ -125,9 +130,10 @@ func (r *RetryHandler) Execute(ctx context.Context, operationID string, operatio
 			break
 		}
 
-		// Calculate delay
-		delay := r.calculateDelay(attempt)
-		time.Sleep(delay)
+		// Wait before next attempt
+		if err := sleepContext(ctx, r.calculateDelay(attempt)); err != nil {
+			return err
+		}
 	}
 
 	// All attempts failed
@@ -164,6 +170,19 @@ func (r *RetryHandler) calculateDelay(attempt int) time.Duration {
 	return delay
 }
 
+// sleepContext waits for d or until ctx is done, whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 // IsRetryableError checks if an error is retryable.
 func IsRetryableError(err error) bool {
 	if err == nil {